@@ -0,0 +1,205 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/routes"
+	"github.com/opacity/storage-node/utils"
+)
+
+type command struct {
+	usage string
+	run   func(args []string) error
+}
+
+var commands = map[string]command{
+	"migrate-kv": {
+		usage: "migrate-kv --from badger --to sql [--badger-dir /var/lib/badger/prod]",
+		run:   migrateKvStore,
+	},
+	"backup-kv": {
+		usage: "backup-kv --out badger.bak [--since 0] [--badger-dir /var/lib/badger/prod]",
+		run:   backupKvStore,
+	},
+	"restore-kv": {
+		usage: "restore-kv (--in badger.bak | --object-key badger-backups/...) [--replace] [--badger-dir /var/lib/badger/prod]",
+		run:   restoreKvStore,
+	},
+	"metadata-migration-report": {
+		usage: "metadata-migration-report [--badger-dir /var/lib/badger/prod]",
+		run:   metadataMigrationReport,
+	},
+}
+
+/*runCommand runs the subcommand named in args, if there is one.  Returns false if the service should start
+as usual.*/
+func runCommand(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q, available commands:\n", args[0])
+		for _, c := range commands {
+			fmt.Fprintln(os.Stderr, "  "+c.usage)
+		}
+		os.Exit(2)
+	}
+
+	// commands open the stores they need themselves, and badger only lets one process hold its dir
+	utils.CloseKvStore()
+
+	if err := cmd.run(args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "%s failed: %v\n", args[0], err)
+		os.Exit(1)
+	}
+	return true
+}
+
+func migrateKvStore(args []string) error {
+	flags := flag.NewFlagSet("migrate-kv", flag.ContinueOnError)
+	from := flags.String("from", utils.KvStoreBackendBadger, "backend to copy K:V pairs from")
+	to := flags.String("to", utils.KvStoreBackendSQL, "backend to copy K:V pairs to")
+	badgerDir := flags.String("badger-dir", utils.BadgerDirProd, "directory of the badger db")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *from == *to {
+		return errors.New("--from and --to must be different backends")
+	}
+
+	fromStore, err := openKvStoreForCommand(*from, *badgerDir)
+	if err != nil {
+		return err
+	}
+	defer fromStore.Close()
+	toStore, err := openKvStoreForCommand(*to, *badgerDir)
+	if err != nil {
+		return err
+	}
+	defer toStore.Close()
+
+	copied, err := utils.CopyKvStore(fromStore, toStore)
+	fmt.Printf("copied %d K:V pairs from %s to %s\n", copied, *from, *to)
+	return err
+}
+
+func openKvStoreForCommand(backend string, badgerDir string) (utils.KVStore, error) {
+	switch backend {
+	case utils.KvStoreBackendBadger:
+		return utils.NewBadgerKVStore(badgerDir)
+	case utils.KvStoreBackendSQL:
+		if models.DB == nil {
+			models.Connect(utils.Env.DatabaseURL)
+		}
+		return models.NewSQLKVStore(models.DB), nil
+	default:
+		return nil, fmt.Errorf("unsupported backend %q, use %s or %s", backend,
+			utils.KvStoreBackendBadger, utils.KvStoreBackendSQL)
+	}
+}
+
+func backupKvStore(args []string) error {
+	flags := flag.NewFlagSet("backup-kv", flag.ContinueOnError)
+	out := flags.String("out", "", "file to write the backup to")
+	since := flags.Uint64("since", 0, "only back up versions newer than this, 0 for a full backup")
+	badgerDir := flags.String("badger-dir", utils.BadgerDirProd, "directory of the badger db")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *out == "" {
+		return errors.New("--out is required")
+	}
+
+	if err := openBadgerForCommand(*badgerDir); err != nil {
+		return err
+	}
+	defer utils.CloseKvStore()
+
+	file, err := os.OpenFile(*out, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	version, err := utils.BackupBadger(file, *since)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("wrote backup to %s, pass --since %d for the next incremental backup\n", *out, version)
+	return nil
+}
+
+func restoreKvStore(args []string) error {
+	flags := flag.NewFlagSet("restore-kv", flag.ContinueOnError)
+	in := flags.String("in", "", "backup file to restore")
+	objectKey := flags.String("object-key", "", "backup in the bucket to restore")
+	replace := flags.Bool("replace", false, "drop every K:V pair before restoring")
+	badgerDir := flags.String("badger-dir", utils.BadgerDirProd, "directory of the badger db")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if (*in == "") == (*objectKey == "") {
+		return errors.New("exactly one of --in and --object-key is required")
+	}
+
+	if err := openBadgerForCommand(*badgerDir); err != nil {
+		return err
+	}
+	defer utils.CloseKvStore()
+
+	if *in != "" {
+		err := utils.RestoreBadgerFromFile(*in, *replace)
+		if err == nil {
+			fmt.Printf("restored backup from %s\n", *in)
+		}
+		return err
+	}
+	err := utils.RestoreBadgerFromObjectStore(*objectKey, *replace)
+	if err == nil {
+		fmt.Printf("restored backup from %s\n", *objectKey)
+	}
+	return err
+}
+
+func metadataMigrationReport(args []string) error {
+	flags := flag.NewFlagSet("metadata-migration-report", flag.ContinueOnError)
+	badgerDir := flags.String("badger-dir", utils.BadgerDirProd, "directory of the badger db")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	store, err := openKvStoreForCommand(utils.Env.KvStoreBackend, *badgerDir)
+	if err != nil {
+		return err
+	}
+	utils.SetKvStore(store)
+	defer utils.CloseKvStore()
+
+	report, err := routes.CreateMetadataMigrationReport()
+	if err != nil {
+		return err
+	}
+	reportJSON, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(reportJSON))
+	return nil
+}
+
+func openBadgerForCommand(badgerDir string) error {
+	store, err := utils.NewBadgerKVStore(badgerDir)
+	if err != nil {
+		return err
+	}
+	utils.SetKvStore(store)
+	return nil
+}
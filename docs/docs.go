@@ -1,6 +1,6 @@
 // GENERATED BY THE COMMAND ABOVE; DO NOT EDIT
 // This file was generated by swaggo/swag at
-// 2020-11-06 13:46:17.602877915 -0500 EST m=+0.071602225
+// 2026-10-18 21:46:07.94991541 +0000 UTC m=+0.103638583
 
 package docs
 
@@ -74,25 +74,25 @@ var doc = `{
                 }
             }
         },
-        "/api/v1/accounts": {
+        "/api/v1/account/delete": {
             "post": {
-                "description": "create an account\nrequestBody should be a stringified version of (values are just examples):\n{\n\"storageLimit\": 100,\n\"durationInMonths\": 12,\n}",
+                "description": "Returns a code to confirm the deletion with at /api/v1/account/delete/confirm within an hour.\nOnce confirmed the account, its files, uploads in progress, metadata and payments are deleted\nafter delayInHours, and can be cancelled until then.\nrequestBody should be a stringified version of (values are just examples):\n{\n\"delayInHours\": 24,\n\"timestamp\": 1557346389\n}",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "create an account",
+                "summary": "request deleting an account",
                 "parameters": [
                     {
-                        "description": "account creation object",
-                        "name": "accountCreateReq",
+                        "description": "delete account object",
+                        "name": "deleteAccountReq",
                         "in": "body",
                         "required": true,
                         "schema": {
                             "type": "object",
-                            "$ref": "#/definitions/routes.accountCreateReq"
+                            "$ref": "#/definitions/routes.deleteAccountReq"
                         }
                     }
                 ],
@@ -101,7 +101,7 @@ var doc = `{
                         "description": "OK",
                         "schema": {
                             "type": "object",
-                            "$ref": "#/definitions/routes.accountCreateRes"
+                            "$ref": "#/definitions/routes.deleteAccountRes"
                         }
                     },
                     "400": {
@@ -110,8 +110,20 @@ var doc = `{
                             "type": "string"
                         }
                     },
-                    "503": {
-                        "description": "error encrypting private key: (with the error)",
+                    "403": {
+                        "description": "signature did not match",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "no account with that id: (with your accountID)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "some information about the internal error",
                         "schema": {
                             "type": "string"
                         }
@@ -119,25 +131,25 @@ var doc = `{
                 }
             }
         },
-        "/api/v1/delete": {
+        "/api/v1/account/delete/cancel": {
             "post": {
-                "description": "delete a file\nrequestBody should be a stringified version of (values are just examples):\n{\n\"fileID\": \"the handle of the file\",\n}",
+                "description": "requestBody should be a stringified version of (values are just examples):\n{\n\"timestamp\": 1557346389\n}",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "delete a file",
+                "summary": "cancel deleting an account",
                 "parameters": [
                     {
-                        "description": "file deletion object",
-                        "name": "deleteFileReq",
+                        "description": "cancel delete account object",
+                        "name": "deleteAccountTimestampReq",
                         "in": "body",
                         "required": true,
                         "schema": {
                             "type": "object",
-                            "$ref": "#/definitions/routes.deleteFileReq"
+                            "$ref": "#/definitions/routes.deleteAccountTimestampReq"
                         }
                     }
                 ],
@@ -146,7 +158,7 @@ var doc = `{
                         "description": "OK",
                         "schema": {
                             "type": "object",
-                            "$ref": "#/definitions/routes.deleteFileRes"
+                            "$ref": "#/definitions/routes.StatusRes"
                         }
                     },
                     "400": {
@@ -155,6 +167,18 @@ var doc = `{
                             "type": "string"
                         }
                     },
+                    "403": {
+                        "description": "signature did not match",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "no account deletion was requested",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "500": {
                         "description": "some information about the internal error",
                         "schema": {
@@ -164,25 +188,25 @@ var doc = `{
                 }
             }
         },
-        "/api/v1/download": {
+        "/api/v1/account/delete/confirm": {
             "post": {
-                "description": "download a file without cryptographic verification",
+                "description": "If the deletion wasn't delayed the account is deleted right away and the signed receipt is\nreturned.  Otherwise the deletion is scheduled, and the receipt can be fetched from\n/api/v1/account/delete/receipt once it is done.\nrequestBody should be a stringified version of (values are just examples):\n{\n\"confirmationCode\": \"the code returned when the deletion was requested\",\n\"timestamp\": 1557346389\n}",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "download a file without cryptographic verification",
+                "summary": "confirm deleting an account",
                 "parameters": [
                     {
-                        "description": "download object for non-signed requests",
-                        "name": "downloadFileObj",
+                        "description": "confirm delete account object",
+                        "name": "confirmDeleteAccountReq",
                         "in": "body",
                         "required": true,
                         "schema": {
                             "type": "object",
-                            "$ref": "#/definitions/routes.downloadFileObj"
+                            "$ref": "#/definitions/routes.confirmDeleteAccountReq"
                         }
                     }
                 ],
@@ -191,17 +215,23 @@ var doc = `{
                         "description": "OK",
                         "schema": {
                             "type": "object",
-                            "$ref": "#/definitions/routes.downloadFileRes"
+                            "$ref": "#/definitions/routes.deleteAccountReceiptRes"
                         }
                     },
                     "400": {
-                        "description": "bad request, unable to parse request body: (with the error)",
+                        "description": "the confirmation code does not match the deletion request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "signature did not match",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "404": {
-                        "description": "such data does not exist",
+                        "description": "no account deletion was requested",
                         "schema": {
                             "type": "string"
                         }
@@ -215,25 +245,25 @@ var doc = `{
                 }
             }
         },
-        "/api/v1/init-upload": {
+        "/api/v1/account/delete/receipt": {
             "post": {
-                "description": "start an upload\nrequestBody should be a stringified version of (values are just examples):\n{\n\"fileHandle\": \"a deterministically created file handle\",\n\"fileSizeInByte\": \"200000000000006\",\n\"endIndex\": 2\n}",
+                "description": "The receipt is signed by the node's main wallet, at signerAddress, over the keccak256 hash of\nthe accountID, the unix times requestedAt and deletedAt, completedFilesDeleted, uploadsDeleted\nand metadatasDeleted, joined by newlines.\nrequestBody should be a stringified version of (values are just examples):\n{\n\"timestamp\": 1557346389\n}",
                 "consumes": [
-                    "multipart/form-data"
+                    "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "start an upload",
+                "summary": "get the receipt for a deleted account",
                 "parameters": [
                     {
-                        "description": "an object to start a file upload",
-                        "name": "InitFileUploadReq",
+                        "description": "deletion receipt object",
+                        "name": "deleteAccountTimestampReq",
                         "in": "body",
                         "required": true,
                         "schema": {
                             "type": "object",
-                            "$ref": "#/definitions/routes.InitFileUploadReq"
+                            "$ref": "#/definitions/routes.deleteAccountTimestampReq"
                         }
                     }
                 ],
@@ -242,7 +272,7 @@ var doc = `{
                         "description": "OK",
                         "schema": {
                             "type": "object",
-                            "$ref": "#/definitions/routes.StatusRes"
+                            "$ref": "#/definitions/routes.deleteAccountReceiptRes"
                         }
                     },
                     "400": {
@@ -257,6 +287,12 @@ var doc = `{
                             "type": "string"
                         }
                     },
+                    "404": {
+                        "description": "no deletion receipt for that account",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "500": {
                         "description": "some information about the internal error",
                         "schema": {
@@ -266,25 +302,25 @@ var doc = `{
                 }
             }
         },
-        "/api/v1/metadata/create": {
+        "/api/v1/account/ledger": {
             "post": {
-                "description": "requestBody should be a stringified version of (values are just examples):\n{\n\"metadataKey\": \"a 64-char hex string created deterministically, will be a key for the metadata of one of your folders\",\n\"timestamp\": 1557346389\n}",
+                "description": "Returns the account's invoices, payments, credits, refunds and plan changes, newest first.\nEntries are kept after the invoices they came from expire.  Pass nextBeforeID as beforeID to get\nthe next page, until no entries come back.\nrequestBody should be a stringified version of (values are just examples):\n{\n\"beforeID\": 0,\n\"limit\": 100,\n\"timestamp\": 1557346389\n}",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "create a new metadata",
+                "summary": "get an account's invoices and payments",
                 "parameters": [
                     {
-                        "description": "object for endpoints that only need metadataKey and timestamp",
-                        "name": "metadataKeyReq",
+                        "description": "get ledger object",
+                        "name": "getLedgerReq",
                         "in": "body",
                         "required": true,
                         "schema": {
                             "type": "object",
-                            "$ref": "#/definitions/routes.metadataKeyReq"
+                            "$ref": "#/definitions/routes.getLedgerReq"
                         }
                     }
                 ],
@@ -293,7 +329,7 @@ var doc = `{
                         "description": "OK",
                         "schema": {
                             "type": "object",
-                            "$ref": "#/definitions/routes.createMetadataRes"
+                            "$ref": "#/definitions/routes.getLedgerRes"
                         }
                     },
                     "400": {
@@ -303,13 +339,13 @@ var doc = `{
                         }
                     },
                     "403": {
-                        "description": "subscription expired, or the invoice resonse",
+                        "description": "signature did not match",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "404": {
-                        "description": "account not found",
+                        "description": "no account with that id: (with your accountID)",
                         "schema": {
                             "type": "string"
                         }
@@ -323,25 +359,25 @@ var doc = `{
                 }
             }
         },
-        "/api/v1/metadata/delete": {
+        "/api/v1/account/overage": {
             "post": {
-                "description": "requestBody should be a stringified version of (values are just examples):\n{\n\"metadataKey\": \"a 64-char hex string created deterministically, will be a key for the metadata of one of your folders\",\n\"timestamp\": 1557346389\n}",
+                "description": "While enabled, uploads can go up to capInGB over the account's storageLimit instead of failing.\nWhat is stored over the plan is sampled daily and billed per GB-month, added to the renewal invoice\nor paid by card at /api/v1/stripe/overage.  capInGB can be at most the storageLimit.  It can't be\ndisabled or lowered below what the account stores.  It doesn't apply while the account stores in an\norganization's pool.\nrequestBody should be a stringified version of (values are just examples):\n{\n\"capInGB\": 64,\n\"enabled\": true,\n\"timestamp\": 1557346389\n}",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "delete a metadata",
+                "summary": "opt in to or out of storing more than the account's plan",
                 "parameters": [
                     {
-                        "description": "object for endpoints that only need metadataKey and timestamp",
-                        "name": "metadataKeyReq",
+                        "description": "set overage object",
+                        "name": "setOverageReq",
                         "in": "body",
                         "required": true,
                         "schema": {
                             "type": "object",
-                            "$ref": "#/definitions/routes.metadataKeyReq"
+                            "$ref": "#/definitions/routes.setOverageReq"
                         }
                     }
                 ],
@@ -350,7 +386,7 @@ var doc = `{
                         "description": "OK",
                         "schema": {
                             "type": "object",
-                            "$ref": "#/definitions/routes.StatusRes"
+                            "$ref": "#/definitions/routes.setOverageRes"
                         }
                     },
                     "400": {
@@ -360,13 +396,13 @@ var doc = `{
                         }
                     },
                     "403": {
-                        "description": "subscription expired, or the invoice resonse",
+                        "description": "storing more than a plan is not offered",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "404": {
-                        "description": "account not found",
+                        "description": "no account with that id: (with your accountID)",
                         "schema": {
                             "type": "string"
                         }
@@ -380,25 +416,25 @@ var doc = `{
                 }
             }
         },
-        "/api/v1/metadata/get": {
+        "/api/v1/account/usage": {
             "post": {
-                "description": "requestBody should be a stringified version of (values are just examples):\n{\n\"metadataKey\": \"a 64-char hex string created deterministically, will be a key for the metadata of one of your folders\",\n\"timestamp\": 1557346389\n}",
+                "description": "Returns a sample of what the account stored, its file count, folder count and metadata size for each\nday from startDate through endDate, oldest first.  Paid accounts are sampled once a day, in UTC.\nendDate is optional and defaults to today.  The range can be at most 366 days.\nrequestBody should be a stringified version of (values are just examples):\n{\n\"endDate\": \"2019-05-31\",\n\"startDate\": \"2019-05-01\",\n\"timestamp\": 1557346389\n}",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Retrieve account metadata",
+                "summary": "get what an account stored each day",
                 "parameters": [
                     {
-                        "description": "object for endpoints that only need metadataKey and timestamp",
-                        "name": "metadataKeyReq",
+                        "description": "get usage history object",
+                        "name": "getUsageHistoryReq",
                         "in": "body",
                         "required": true,
                         "schema": {
                             "type": "object",
-                            "$ref": "#/definitions/routes.metadataKeyReq"
+                            "$ref": "#/definitions/routes.getUsageHistoryReq"
                         }
                     }
                 ],
@@ -407,17 +443,29 @@ var doc = `{
                         "description": "OK",
                         "schema": {
                             "type": "object",
-                            "$ref": "#/definitions/routes.getMetadataRes"
+                            "$ref": "#/definitions/routes.getUsageHistoryRes"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request, unable to parse request body: (with the error)",
+                        "schema": {
+                            "type": "string"
                         }
                     },
                     "403": {
-                        "description": "subscription expired, or the invoice resonse",
+                        "description": "signature did not match",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "404": {
-                        "description": "no value found for that key, or account not found",
+                        "description": "no account with that id: (with your accountID)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "some information about the internal error",
                         "schema": {
                             "type": "string"
                         }
@@ -425,25 +473,25 @@ var doc = `{
                 }
             }
         },
-        "/api/v1/metadata/history": {
+        "/api/v1/accounts": {
             "post": {
-                "description": "requestBody should be a stringified version of (values are just examples):\n{\n\"metadataKey\": \"a 64-char hex string created deterministically, will be a key for the metadata of one of your folders\",\n\"timestamp\": 1557346389\n}",
+                "description": "create an account\nrequestBody should be a stringified version of (values are just examples):\n{\n\"storageLimit\": 100,\n\"durationInMonths\": 12,\n\"couponCode\": \"SPRING20\"\n}\ncouponCode is optional.  Its discount is taken off the invoice's cost and its extra months are added\nto the subscription.",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Retrieve metadata history",
+                "summary": "create an account",
                 "parameters": [
                     {
-                        "description": "object for endpoints that only need metadataKey and timestamp",
-                        "name": "metadataKeyReq",
+                        "description": "account creation object",
+                        "name": "accountCreateReq",
                         "in": "body",
                         "required": true,
                         "schema": {
                             "type": "object",
-                            "$ref": "#/definitions/routes.metadataKeyReq"
+                            "$ref": "#/definitions/routes.accountCreateReq"
                         }
                     }
                 ],
@@ -452,17 +500,29 @@ var doc = `{
                         "description": "OK",
                         "schema": {
                             "type": "object",
-                            "$ref": "#/definitions/routes.getMetadataHistoryRes"
+                            "$ref": "#/definitions/routes.accountCreateRes"
                         }
                     },
-                    "403": {
-                        "description": "subscription expired, or the invoice resonse",
+                    "400": {
+                        "description": "bad request, unable to parse request body: (with the error)",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "404": {
-                        "description": "no value found for that key, or account not found",
+                        "description": "no coupon with that code",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "too many requests, try again later",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "503": {
+                        "description": "error encrypting private key: (with the error)",
                         "schema": {
                             "type": "string"
                         }
@@ -470,25 +530,25 @@ var doc = `{
                 }
             }
         },
-        "/api/v1/metadata/set": {
+        "/api/v1/delegated-keys/add": {
             "post": {
-                "description": "requestBody should be a stringified version of (values are just examples):\n{\n\"metadataKey\": \"a 64-char hex string created deterministically, will be a key for the metadata of one of your folders\",\n\"metadata\": \"your (updated) account metadata\",\n\"timestamp\": 1557346389\n}",
+                "description": "A delegated key signs requests with its own publicKey and acts for the account that delegated\nit, but only on the account-data, metadata, upload, download and delete endpoints its scopes\nallow.  Scopes can be account:read, metadata:read, metadata:write, files:upload, files:download\nand files:delete.  Adding a key the account already delegated replaces its scopes and expiresAt.\nThis must be signed with the account's own key.\nrequestBody should be a stringified version of (values are just examples):\n{\n\"delegatedPublicKey\": \"a 66-character public key\",\n\"expiresAt\": 1589968389,\n\"scopes\": [\"files:upload\"],\n\"timestamp\": 1557346389\n}",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Update metadata",
+                "summary": "let another key act for the account within some scopes",
                 "parameters": [
                     {
-                        "description": "update metadata object",
-                        "name": "updateMetadataReq",
+                        "description": "add delegated key object",
+                        "name": "addDelegatedKeyReq",
                         "in": "body",
                         "required": true,
                         "schema": {
                             "type": "object",
-                            "$ref": "#/definitions/routes.updateMetadataReq"
+                            "$ref": "#/definitions/routes.addDelegatedKeyReq"
                         }
                     }
                 ],
@@ -497,7 +557,7 @@ var doc = `{
                         "description": "OK",
                         "schema": {
                             "type": "object",
-                            "$ref": "#/definitions/routes.updateMetadataRes"
+                            "$ref": "#/definitions/routes.delegatedKeyRes"
                         }
                     },
                     "400": {
@@ -507,13 +567,13 @@ var doc = `{
                         }
                     },
                     "403": {
-                        "description": "subscription expired, or the invoice response",
+                        "description": "signature did not match",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "404": {
-                        "description": "no value found for that key, or account not found",
+                        "description": "no account with that id: (with your accountID)",
                         "schema": {
                             "type": "string"
                         }
@@ -527,25 +587,25 @@ var doc = `{
                 }
             }
         },
-        "/api/v1/renew": {
+        "/api/v1/delegated-keys/list": {
             "post": {
-                "description": "check the renewal status\nrequestBody should be a stringified version of (values are just examples):\n{\n\"metadataKeys\": \"[\"someKey\", \"someOtherKey]\",\n\"fileHandles\": \"[\"someHandle\", \"someOtherHandle]\",\n}",
+                "description": "This must be signed with the account's own key.\nrequestBody should be a stringified version of (values are just examples):\n{\n\"timestamp\": 1557346389\n}",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "check the renewal status",
+                "summary": "list the keys the account delegated",
                 "parameters": [
                     {
-                        "description": "check renewal status object",
-                        "name": "checkRenewalStatusReq",
+                        "description": "list delegated keys object",
+                        "name": "listDelegatedKeysReq",
                         "in": "body",
                         "required": true,
                         "schema": {
                             "type": "object",
-                            "$ref": "#/definitions/routes.checkRenewalStatusReq"
+                            "$ref": "#/definitions/routes.listDelegatedKeysReq"
                         }
                     }
                 ],
@@ -554,7 +614,7 @@ var doc = `{
                         "description": "OK",
                         "schema": {
                             "type": "object",
-                            "$ref": "#/definitions/routes.StatusRes"
+                            "$ref": "#/definitions/routes.listDelegatedKeysRes"
                         }
                     },
                     "400": {
@@ -563,6 +623,12 @@ var doc = `{
                             "type": "string"
                         }
                     },
+                    "403": {
+                        "description": "signature did not match",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "404": {
                         "description": "no account with that id: (with your accountID)",
                         "schema": {
@@ -578,25 +644,25 @@ var doc = `{
                 }
             }
         },
-        "/api/v1/renew/invoice": {
+        "/api/v1/delegated-keys/remove": {
             "post": {
-                "description": "get an invoice to renew an account\nrequestBody should be a stringified version of (values are just examples):\n{\n}",
+                "description": "This must be signed with the account's own key.\nrequestBody should be a stringified version of (values are just examples):\n{\n\"delegatedPublicKey\": \"a 66-character public key\",\n\"timestamp\": 1557346389\n}",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "get an invoice to renew an account",
+                "summary": "stop a delegated key from acting for the account",
                 "parameters": [
                     {
-                        "description": "get renewal invoice object",
-                        "name": "getRenewalAccountInvoiceReq",
+                        "description": "remove delegated key object",
+                        "name": "removeDelegatedKeyReq",
                         "in": "body",
                         "required": true,
                         "schema": {
                             "type": "object",
-                            "$ref": "#/definitions/routes.getRenewalAccountInvoiceReq"
+                            "$ref": "#/definitions/routes.removeDelegatedKeyReq"
                         }
                     }
                 ],
@@ -605,7 +671,7 @@ var doc = `{
                         "description": "OK",
                         "schema": {
                             "type": "object",
-                            "$ref": "#/definitions/routes.getRenewalAccountInvoiceRes"
+                            "$ref": "#/definitions/routes.StatusRes"
                         }
                     },
                     "400": {
@@ -614,8 +680,14 @@ var doc = `{
                             "type": "string"
                         }
                     },
+                    "403": {
+                        "description": "signature did not match",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "404": {
-                        "description": "no account with that id: (with your accountID)",
+                        "description": "the account has not delegated that key",
                         "schema": {
                             "type": "string"
                         }
@@ -629,25 +701,25 @@ var doc = `{
                 }
             }
         },
-        "/api/v1/stripe/create": {
+        "/api/v1/delete": {
             "post": {
-                "description": "create a stripe payment\nrequestBody should be a stringified version of (values are just examples):\n{\n\"stripeToken\": \"tok_KPte7942xySKBKyrBu11yEpf\",\n}",
+                "description": "delete a file\nrequestBody should be a stringified version of (values are just examples):\n{\n\"fileID\": \"the handle of the file\",\n}",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "create a stripe payment",
+                "summary": "delete a file",
                 "parameters": [
                     {
-                        "description": "stripe payment creation object",
-                        "name": "createStripePaymentReq",
+                        "description": "file deletion object",
+                        "name": "deleteFileReq",
                         "in": "body",
                         "required": true,
                         "schema": {
                             "type": "object",
-                            "$ref": "#/definitions/routes.createStripePaymentReq"
+                            "$ref": "#/definitions/routes.deleteFileReq"
                         }
                     }
                 ],
@@ -656,7 +728,7 @@ var doc = `{
                         "description": "OK",
                         "schema": {
                             "type": "object",
-                            "$ref": "#/definitions/routes.stripeDataRes"
+                            "$ref": "#/definitions/routes.deleteFileRes"
                         }
                     },
                     "400": {
@@ -665,18 +737,6 @@ var doc = `{
                             "type": "string"
                         }
                     },
-                    "403": {
-                        "description": "account is already paid for",
-                        "schema": {
-                            "type": "string"
-                        }
-                    },
-                    "404": {
-                        "description": "no account with that id: (with your accountID)",
-                        "schema": {
-                            "type": "string"
-                        }
-                    },
                     "500": {
                         "description": "some information about the internal error",
                         "schema": {
@@ -686,25 +746,25 @@ var doc = `{
                 }
             }
         },
-        "/api/v1/upgrade": {
+        "/api/v1/downgrade": {
             "post": {
-                "description": "check the upgrade status\nrequestBody should be a stringified version of (values are just examples):\n{\n\"storageLimit\": 100,\n\"durationInMonths\": 12,\n\"metadataKeys\": \"[\"someKey\", \"someOtherKey]\",\n\"fileHandles\": \"[\"someHandle\", \"someOtherHandle]\",\n}",
+                "description": "The account's storage, folders and metadata must fit in the smaller plan.  With applyAt\n\"renewal\" the account moves when it is next renewed, and the renewal is priced at the smaller\nplan.  With applyAt \"immediately\" it moves now, and the rest of the term is credited:  with\ncredit \"time\" it stretches into more months of the smaller plan, with credit \"balance\" the\ndifference in price is taken off the next renewal.\nrequestBody should be a stringified version of (values are just examples):\n{\n\"applyAt\": \"immediately\",\n\"credit\": \"time\",\n\"storageLimit\": 128,\n\"timestamp\": 1557346389\n}",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "check the upgrade status",
+                "summary": "move an account to a smaller plan",
                 "parameters": [
                     {
-                        "description": "check upgrade status object",
-                        "name": "checkUpgradeStatusReq",
+                        "description": "downgrade account object",
+                        "name": "downgradeAccountReq",
                         "in": "body",
                         "required": true,
                         "schema": {
                             "type": "object",
-                            "$ref": "#/definitions/routes.checkUpgradeStatusReq"
+                            "$ref": "#/definitions/routes.downgradeAccountReq"
                         }
                     }
                 ],
@@ -713,7 +773,7 @@ var doc = `{
                         "description": "OK",
                         "schema": {
                             "type": "object",
-                            "$ref": "#/definitions/routes.StatusRes"
+                            "$ref": "#/definitions/routes.downgradeAccountRes"
                         }
                     },
                     "400": {
@@ -722,6 +782,12 @@ var doc = `{
                             "type": "string"
                         }
                     },
+                    "403": {
+                        "description": "signature did not match",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "404": {
                         "description": "no account with that id: (with your accountID)",
                         "schema": {
@@ -737,25 +803,25 @@ var doc = `{
                 }
             }
         },
-        "/api/v1/upgrade/invoice": {
+        "/api/v1/downgrade/cancel": {
             "post": {
-                "description": "get an invoice to upgrade an account\nrequestBody should be a stringified version of (values are just examples):\n{\n\"storageLimit\": 100,\n\"durationInMonths\": 12,\n}",
+                "description": "requestBody should be a stringified version of (values are just examples):\n{\n\"timestamp\": 1557346389\n}",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "get an invoice to upgrade an account",
+                "summary": "cancel a downgrade scheduled for the next renewal",
                 "parameters": [
                     {
-                        "description": "get upgrade invoice object",
-                        "name": "getUpgradeAccountInvoiceReq",
+                        "description": "cancel downgrade object",
+                        "name": "cancelDowngradeReq",
                         "in": "body",
                         "required": true,
                         "schema": {
                             "type": "object",
-                            "$ref": "#/definitions/routes.getUpgradeAccountInvoiceReq"
+                            "$ref": "#/definitions/routes.cancelDowngradeReq"
                         }
                     }
                 ],
@@ -764,7 +830,7 @@ var doc = `{
                         "description": "OK",
                         "schema": {
                             "type": "object",
-                            "$ref": "#/definitions/routes.getUpgradeAccountInvoiceRes"
+                            "$ref": "#/definitions/routes.StatusRes"
                         }
                     },
                     "400": {
@@ -773,8 +839,14 @@ var doc = `{
                             "type": "string"
                         }
                     },
+                    "403": {
+                        "description": "signature did not match",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "404": {
-                        "description": "no account with that id: (with your accountID)",
+                        "description": "no downgrade is scheduled",
                         "schema": {
                             "type": "string"
                         }
@@ -788,25 +860,25 @@ var doc = `{
                 }
             }
         },
-        "/api/v1/upload": {
+        "/api/v1/download": {
             "post": {
-                "description": "upload a chunk of a file. The first partIndex must be 1.\nrequestBody should be a stringified version of (values are just examples):\n{\n\"fileHandle\": \"a deterministically created file handle\",\n\"partIndex\": 1,\n}",
+                "description": "download a file without cryptographic verification",
                 "consumes": [
-                    "multipart/form-data"
+                    "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "upload a chunk of a file",
+                "summary": "download a file without cryptographic verification",
                 "parameters": [
                     {
-                        "description": "an object to upload a chunk of a file",
-                        "name": "UploadFileReq",
+                        "description": "download object for non-signed requests",
+                        "name": "downloadFileObj",
                         "in": "body",
                         "required": true,
                         "schema": {
                             "type": "object",
-                            "$ref": "#/definitions/routes.UploadFileReq"
+                            "$ref": "#/definitions/routes.downloadFileObj"
                         }
                     }
                 ],
@@ -815,7 +887,7 @@ var doc = `{
                         "description": "OK",
                         "schema": {
                             "type": "object",
-                            "$ref": "#/definitions/routes.StatusRes"
+                            "$ref": "#/definitions/routes.downloadFileRes"
                         }
                     },
                     "400": {
@@ -825,14 +897,25 @@ var doc = `{
                         }
                     },
                     "403": {
-                        "description": "Forbidden",
+                        "description": "the account the file belongs to is suspended and must be renewed",
                         "schema": {
-                            "type": "object",
-                            "$ref": "#/definitions/routes.accountCreateRes"
+                            "type": "string"
                         }
                     },
-                    "500": {
-                        "description": "some information about the internal error",
+                    "404": {
+                        "description": "such data does not exist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "too many requests, try again later",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "some information about the internal error",
                         "schema": {
                             "type": "string"
                         }
@@ -840,25 +923,25 @@ var doc = `{
                 }
             }
         },
-        "/api/v1/upload-status": {
+        "/api/v1/init-upload": {
             "post": {
-                "description": "check status of an upload\nrequestBody should be a stringified version of (values are just examples):\n{\n\"fileHandle\": \"a deterministically created file handle\",\n}",
+                "description": "start an upload\nrequestBody should be a stringified version of (values are just examples):\n{\n\"fileHandle\": \"a deterministically created file handle\",\n\"fileSizeInByte\": \"200000000000006\",\n\"endIndex\": 2\n}",
                 "consumes": [
-                    "application/json"
+                    "multipart/form-data"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "check status of an upload",
+                "summary": "start an upload",
                 "parameters": [
                     {
-                        "description": "an object to poll upload status",
-                        "name": "UploadStatusReq",
+                        "description": "an object to start a file upload",
+                        "name": "InitFileUploadReq",
                         "in": "body",
                         "required": true,
                         "schema": {
                             "type": "object",
-                            "$ref": "#/definitions/routes.UploadStatusReq"
+                            "$ref": "#/definitions/routes.InitFileUploadReq"
                         }
                     }
                 ],
@@ -882,8 +965,229 @@ var doc = `{
                             "type": "string"
                         }
                     },
+                    "500": {
+                        "description": "some information about the internal error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/metadata/claim": {
+            "post": {
+                "description": "Metadata created before permission hashes were stored can be read and deleted by anyone with its key.\nClaiming it stores a permission hash for the signing public key, after which only that key can access it.\nClaiming it adds it to the account's folder count and metadata size, and fails if that goes over the plan.\nClaims are accepted until the permission hash cutoff date.\nrequestBody should be a stringified version of (values are just examples):\n{\n\"metadataKey\": \"a 64-char hex string created deterministically, will be a key for the metadata of one of your folders\",\n\"timestamp\": 1557346389\n}",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "claim a metadata that was created without a permission hash",
+                "parameters": [
+                    {
+                        "description": "object for endpoints that only need metadataKey and timestamp",
+                        "name": "metadataKeyReq",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/routes.metadataKeyReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/routes.StatusRes"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request, unable to parse request body: (with the error)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "subscription expired, the invoice response, the metadata already belongs to someone else, the account has no room for it, or the claim deadline has passed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "404": {
-                        "description": "file or account not found",
+                        "description": "no value found for that key, or account not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "some information about the internal error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/metadata/create": {
+            "post": {
+                "description": "requestBody should be a stringified version of (values are just examples):\n{\n\"metadataKey\": \"a 64-char hex string created deterministically, will be a key for the metadata of one of your folders\",\n\"timestamp\": 1557346389\n}",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "create a new metadata",
+                "parameters": [
+                    {
+                        "description": "object for endpoints that only need metadataKey and timestamp",
+                        "name": "metadataKeyReq",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/routes.metadataKeyReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/routes.createMetadataRes"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request, unable to parse request body: (with the error)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "subscription expired, or the invoice resonse",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "account not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "some information about the internal error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/metadata/delete": {
+            "post": {
+                "description": "requestBody should be a stringified version of (values are just examples):\n{\n\"metadataKey\": \"a 64-char hex string created deterministically, will be a key for the metadata of one of your folders\",\n\"timestamp\": 1557346389\n}",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "delete a metadata",
+                "parameters": [
+                    {
+                        "description": "object for endpoints that only need metadataKey and timestamp",
+                        "name": "metadataKeyReq",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/routes.metadataKeyReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/routes.StatusRes"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request, unable to parse request body: (with the error)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "subscription expired, or the invoice resonse",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "no value found for that key, or account not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "some information about the internal error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/metadata/export": {
+            "post": {
+                "description": "Streams every metadata the account owns as newline delimited JSON.  The first line is a header\nwith the archiveVersion and exportedAt, every line after it holds the metadataKey, metadata,\nmetadataHistory and permissionHash of one metadata.\nrequestBody should be a stringified version of (values are just examples):\n{\n\"timestamp\": 1557346389\n}",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/x-ndjson"
+                ],
+                "summary": "export all of an account's metadata",
+                "parameters": [
+                    {
+                        "description": "metadata export object",
+                        "name": "metadataExportReq",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/routes.metadataExportReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "the metadata archive",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request, unable to parse request body: (with the error)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "subscription expired, or the invoice response",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "account not found",
                         "schema": {
                             "type": "string"
                         }
@@ -897,91 +1201,2501 @@ var doc = `{
                 }
             }
         },
-        "/plans": {
-            "get": {
-                "description": "get the plans we sell",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "summary": "get the plans we sell",
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "type": "object",
-                            "$ref": "#/definitions/routes.PlanResponse"
-                        }
-                    }
+        "/api/v1/metadata/get": {
+            "post": {
+                "description": "requestBody should be a stringified version of (values are just examples):\n{\n\"metadataKey\": \"a 64-char hex string created deterministically, will be a key for the metadata of one of your folders\",\n\"timestamp\": 1557346389\n}",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Retrieve account metadata",
+                "parameters": [
+                    {
+                        "description": "object for endpoints that only need metadataKey and timestamp",
+                        "name": "metadataKeyReq",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/routes.metadataKeyReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/routes.getMetadataRes"
+                        }
+                    },
+                    "403": {
+                        "description": "subscription expired, or the invoice resonse",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "no value found for that key, or account not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/metadata/history": {
+            "post": {
+                "description": "requestBody should be a stringified version of (values are just examples):\n{\n\"metadataKey\": \"a 64-char hex string created deterministically, will be a key for the metadata of one of your folders\",\n\"timestamp\": 1557346389\n}",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Retrieve metadata history",
+                "parameters": [
+                    {
+                        "description": "object for endpoints that only need metadataKey and timestamp",
+                        "name": "metadataKeyReq",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/routes.metadataKeyReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/routes.getMetadataHistoryRes"
+                        }
+                    },
+                    "403": {
+                        "description": "subscription expired, or the invoice resonse",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "no value found for that key, or account not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/metadata/import": {
+            "post": {
+                "description": "Imports an archive created by /api/v1/metadata/export.  Metadata is stored with a permission hash\nfor the signing public key.  Metadata that already exists is overwritten if it belongs to the\nsigning public key and reported as a conflict otherwise.\nrequestBody should be a stringified version of (values are just examples):\n{\n\"archiveHash\": \"a 64-char hex keccak256 hash of the archive file\",\n\"timestamp\": 1557346389\n}",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "import a metadata archive into an account",
+                "parameters": [
+                    {
+                        "description": "metadata import object",
+                        "name": "metadataImportReq",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/routes.metadataImportReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/routes.metadataImportRes"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request, unable to parse request body or archive: (with the error)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "subscription expired, the invoice response, or the archive does not fit in the account's plan",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "account not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "some information about the internal error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "503": {
+                        "description": "maintenance in progress, currently rejecting writes",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/metadata/set": {
+            "post": {
+                "description": "requestBody should be a stringified version of (values are just examples):\n{\n\"metadataKey\": \"a 64-char hex string created deterministically, will be a key for the metadata of one of your folders\",\n\"metadata\": \"your (updated) account metadata\",\n\"timestamp\": 1557346389\n}",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Update metadata",
+                "parameters": [
+                    {
+                        "description": "update metadata object",
+                        "name": "updateMetadataReq",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/routes.updateMetadataReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/routes.updateMetadataRes"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request, unable to parse request body: (with the error)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "subscription expired, or the invoice response",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "no value found for that key, or account not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "some information about the internal error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/organization": {
+            "post": {
+                "description": "The owner gets every member it invited and what each stored.  A member only gets its own.\nrequestBody should be a stringified version of (values are just examples):\n{\n\"timestamp\": 1557346389\n}",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "get the organization the account owns or belongs to",
+                "parameters": [
+                    {
+                        "description": "get organization object",
+                        "name": "getOrganizationReq",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/routes.getOrganizationReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/routes.organizationRes"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request, unable to parse request body: (with the error)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "signature did not match",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "the account does not own or belong to an organization",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "some information about the internal error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/organization/cap": {
+            "post": {
+                "description": "A storageCapInGB of 0 lifts the cap.  A cap below what the member already stored only stops it\nstoring more.  This must be signed by the organization's owner.\nrequestBody should be a stringified version of (values are just examples):\n{\n\"memberAccountID\": \"the hash of the member's public key\",\n\"storageCapInGB\": 50,\n\"timestamp\": 1557346389\n}",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "cap what a member can store in the organization",
+                "parameters": [
+                    {
+                        "description": "organization member object",
+                        "name": "organizationMemberReq",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/routes.organizationMemberReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/models.OrganizationMember"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request, unable to parse request body: (with the error)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "only the organization's owner can do that",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "the account is not a member of the organization",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "some information about the internal error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/organization/create": {
+            "post": {
+                "description": "Creates an organization the account owns.  The accounts it invites store in a pool the size of the\nowner's storageLimit, which the owner's own files count towards too.  An account can only own or\nbelong to one organization.\nrequestBody should be a stringified version of (values are just examples):\n{\n\"name\": \"Acme\",\n\"timestamp\": 1557346389\n}",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "share the account's plan with other accounts",
+                "parameters": [
+                    {
+                        "description": "create organization object",
+                        "name": "createOrganizationReq",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/routes.createOrganizationReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/routes.organizationRes"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request, unable to parse request body: (with the error)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "signature did not match",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "no account with that id: (with your accountID)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "some information about the internal error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/organization/invite": {
+            "post": {
+                "description": "The invited account joins at /api/v1/organization/join.  storageCapInGB limits what it can store in\nthe pool, and there is no limit if it is 0.  Inviting an account again changes its cap.\nThis must be signed by the organization's owner.\nrequestBody should be a stringified version of (values are just examples):\n{\n\"memberAccountID\": \"the hash of the member's public key\",\n\"storageCapInGB\": 50,\n\"timestamp\": 1557346389\n}",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "invite an account to the organization",
+                "parameters": [
+                    {
+                        "description": "organization member object",
+                        "name": "organizationMemberReq",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/routes.organizationMemberReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/models.OrganizationMember"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request, unable to parse request body: (with the error)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "only the organization's owner can do that",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "some information about the internal error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/organization/join": {
+            "post": {
+                "description": "Once joined, what the account stores is charged to the organization's pool instead of its own\nstorageLimit.  What it already stored moves to the pool, so it has to fit there.\nrequestBody should be a stringified version of (values are just examples):\n{\n\"organizationID\": 1,\n\"timestamp\": 1557346389\n}",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "join an organization the account was invited to",
+                "parameters": [
+                    {
+                        "description": "join organization object",
+                        "name": "joinOrganizationReq",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/routes.joinOrganizationReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/models.OrganizationMember"
+                        }
+                    },
+                    "400": {
+                        "description": "unable to store more data in the organization's storage",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "signature did not match",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "the account was not invited to that organization",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "some information about the internal error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/organization/remove": {
+            "post": {
+                "description": "The owner can remove any member or invitation, and a member can remove itself to leave.  What the\nmember stored counts against its own storageLimit again.  storageCapInGB is ignored.\nrequestBody should be a stringified version of (values are just examples):\n{\n\"memberAccountID\": \"the hash of the member's public key\",\n\"timestamp\": 1557346389\n}",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "remove a member from the organization",
+                "parameters": [
+                    {
+                        "description": "organization member object",
+                        "name": "organizationMemberReq",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/routes.organizationMemberReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/routes.StatusRes"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request, unable to parse request body: (with the error)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "only the organization's owner can do that",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "the account is not a member of the organization",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "some information about the internal error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/renew": {
+            "post": {
+                "description": "check the renewal status\nrequestBody should be a stringified version of (values are just examples):\n{\n\"metadataKeys\": \"[\"someKey\", \"someOtherKey]\",\n\"fileHandles\": \"[\"someHandle\", \"someOtherHandle]\",\n}",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "check the renewal status",
+                "parameters": [
+                    {
+                        "description": "check renewal status object",
+                        "name": "checkRenewalStatusReq",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/routes.checkRenewalStatusReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/routes.StatusRes"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request, unable to parse request body: (with the error)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "no account with that id: (with your accountID)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "some information about the internal error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/renew/invoice": {
+            "post": {
+                "description": "get an invoice to renew an account\nrequestBody should be a stringified version of (values are just examples):\n{\n\"couponCode\": \"SPRING20\"\n}\ncouponCode is optional.  Its discount is taken off the invoice's cost, after any credit, and its\nextra months are added to the renewal once it is paid.  Overage the account stored over its plan and\nhasn't paid for is added to the cost, after the coupon, and shows up as overage.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "get an invoice to renew an account",
+                "parameters": [
+                    {
+                        "description": "get renewal invoice object",
+                        "name": "getRenewalAccountInvoiceReq",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/routes.getRenewalAccountInvoiceReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/routes.getRenewalAccountInvoiceRes"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request, unable to parse request body: (with the error)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "no account with that id: (with your accountID)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "some information about the internal error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/rotate-key": {
+            "post": {
+                "description": "The request must be signed with both keys:  signature with the current private key, as usual,\nand newKeySignature with the new private key over the same requestBody (and, for v2 signatures,\nthe same method, path and timestamp).  The account moves to the new key right away and the old\nkey stops working.  Metadata and files keep working with the new key while their permission\nhashes are re-derived in the background.\nrequestBody should be a stringified version of (values are just examples):\n{\n\"newPublicKey\": \"a 66-character public key\",\n\"timestamp\": 1557346389\n}",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "move an account to a new key",
+                "parameters": [
+                    {
+                        "description": "rotate key object",
+                        "name": "rotateKeyReq",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/routes.rotateKeyReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/routes.rotateKeyRes"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request, unable to parse request body: (with the error)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "signature did not match",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "no account with that id: (with your accountID)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "some information about the internal error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "503": {
+                        "description": "maintenance in progress, currently rejecting writes",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/session/login": {
+            "post": {
+                "description": "The token can be sent as an Authorization: Bearer header instead of signing each request to the\naccount-data, metadata, upload, download and delete endpoints.  The publicKey still has to be\nsent with those requests, and must be the one the token was issued to.  Scopes can be\naccount:read, metadata:read, metadata:write, files:upload, files:download and files:delete.\nrequestBody should be a stringified version of (values are just examples):\n{\n\"scopes\": [\"metadata:read\", \"files:download\"],\n\"timestamp\": 1557346389\n}",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "log in with a signed request and get a short-lived session token",
+                "parameters": [
+                    {
+                        "description": "session login object",
+                        "name": "sessionLoginReq",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/routes.sessionLoginReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/routes.sessionRes"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request, unable to parse request body: (with the error)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "signature did not match",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "account not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "some information about the internal error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/session/logout": {
+            "post": {
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "revoke a session token",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer \u003ctoken\u003e",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/routes.StatusRes"
+                        }
+                    },
+                    "401": {
+                        "description": "missing, invalid, expired or revoked session token",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "some information about the internal error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/session/refresh": {
+            "post": {
+                "description": "The new token has the same scopes and the old one is revoked.  A session can be refreshed until\nits maximum lifetime after the signed login, after which the client has to log in again.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "swap a session token for a new one",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer \u003ctoken\u003e",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/routes.sessionRes"
+                        }
+                    },
+                    "401": {
+                        "description": "missing, invalid, expired or revoked session token",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "session has reached its maximum lifetime",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "some information about the internal error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/stripe/create": {
+            "post": {
+                "description": "create a stripe payment\nrequestBody should be a stringified version of (values are just examples):\n{\n\"couponCode\": \"SPRING20\",\n\"stripeToken\": \"tok_KPte7942xySKBKyrBu11yEpf\",\n}\ncouponCode is optional, for an account that was created without one.  Its discount in USD is\ntaken off the charge.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "create a stripe payment",
+                "parameters": [
+                    {
+                        "description": "stripe payment creation object",
+                        "name": "createStripePaymentReq",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/routes.createStripePaymentReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/routes.stripeDataRes"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request, unable to parse request body: (with the error)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "account is already paid for",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "no coupon with that code",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "some information about the internal error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/stripe/overage": {
+            "post": {
+                "description": "Charges the card for the overage the account stored and hasn't been billed for.  Overage that is\nalready on an unpaid renewal invoice has to be paid with the renewal.\nrequestBody should be a stringified version of (values are just examples):\n{\n\"stripeToken\": \"tok_KPte7942xySKBKyrBu11yEpf\",\n\"timestamp\": 1557346389\n}",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "pay for overage by card",
+                "parameters": [
+                    {
+                        "description": "pay overage object",
+                        "name": "payOverageReq",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/routes.payOverageReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/routes.stripeDataRes"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request, unable to parse request body: (with the error)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "cannot create stripe charge for less than $0.50",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "no account with that id: (with your accountID)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "some information about the internal error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/upgrade": {
+            "post": {
+                "description": "check the upgrade status\nrequestBody should be a stringified version of (values are just examples):\n{\n\"storageLimit\": 100,\n\"durationInMonths\": 12,\n\"metadataKeys\": \"[\"someKey\", \"someOtherKey]\",\n\"fileHandles\": \"[\"someHandle\", \"someOtherHandle]\",\n}",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "check the upgrade status",
+                "parameters": [
+                    {
+                        "description": "check upgrade status object",
+                        "name": "checkUpgradeStatusReq",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/routes.checkUpgradeStatusReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/routes.StatusRes"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request, unable to parse request body: (with the error)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "no account with that id: (with your accountID)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "some information about the internal error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/upgrade/invoice": {
+            "post": {
+                "description": "get an invoice to upgrade an account\nrequestBody should be a stringified version of (values are just examples):\n{\n\"storageLimit\": 100,\n\"durationInMonths\": 12,\n\"couponCode\": \"SPRING20\"\n}\ncouponCode is optional.  Its discount is taken off the invoice's cost and its extra months are added\nto the subscription once the upgrade is paid.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "get an invoice to upgrade an account",
+                "parameters": [
+                    {
+                        "description": "get upgrade invoice object",
+                        "name": "getUpgradeAccountInvoiceReq",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/routes.getUpgradeAccountInvoiceReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/routes.getUpgradeAccountInvoiceRes"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request, unable to parse request body: (with the error)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "no coupon with that code",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "some information about the internal error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/upload": {
+            "post": {
+                "description": "upload a chunk of a file. The first partIndex must be 1.\nrequestBody should be a stringified version of (values are just examples):\n{\n\"fileHandle\": \"a deterministically created file handle\",\n\"partIndex\": 1,\n}",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "upload a chunk of a file",
+                "parameters": [
+                    {
+                        "description": "an object to upload a chunk of a file",
+                        "name": "UploadFileReq",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/routes.UploadFileReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/routes.StatusRes"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request, unable to parse request body: (with the error)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/routes.accountCreateRes"
+                        }
+                    },
+                    "500": {
+                        "description": "some information about the internal error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/upload-status": {
+            "post": {
+                "description": "check status of an upload\nrequestBody should be a stringified version of (values are just examples):\n{\n\"fileHandle\": \"a deterministically created file handle\",\n}",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "check status of an upload",
+                "parameters": [
+                    {
+                        "description": "an object to poll upload status",
+                        "name": "UploadStatusReq",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/routes.UploadStatusReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/routes.StatusRes"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request, unable to parse request body: (with the error)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "signature did not match",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "file or account not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "some information about the internal error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/plans": {
+            "get": {
+                "description": "get the plans we sell",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "get the plans we sell",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "$ref": "#/definitions/routes.PlanResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "models.AccountDeletionReceipt": {
+            "type": "object",
+            "required": [
+                "accountID",
+                "deletedAt",
+                "requestedAt",
+                "signature",
+                "signerAddress"
+            ],
+            "properties": {
+                "accountID": {
+                    "type": "string"
+                },
+                "completedFilesDeleted": {
+                    "type": "integer"
+                },
+                "deletedAt": {
+                    "type": "string"
+                },
+                "metadatasDeleted": {
+                    "type": "integer"
+                },
+                "requestedAt": {
+                    "type": "string"
+                },
+                "signature": {
+                    "description": "the node's signature over Digest()",
+                    "type": "string"
+                },
+                "signerAddress": {
+                    "type": "string"
+                },
+                "uploadsDeleted": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Invoice": {
+            "type": "object",
+            "required": [
+                "ethAddress"
+            ],
+            "properties": {
+                "cost": {
+                    "type": "number",
+                    "example": 1.56
+                },
+                "discount": {
+                    "description": "already taken off the cost by a coupon",
+                    "type": "number",
+                    "example": 0
+                },
+                "ethAddress": {
+                    "type": "string",
+                    "maxLength": 42,
+                    "minLength": 42,
+                    "example": "a 42-char eth address with 0x prefix"
+                },
+                "overage": {
+                    "description": "already added to the cost for storage over the plan",
+                    "type": "number",
+                    "example": 0
+                }
+            }
+        },
+        "models.LedgerEntry": {
+            "type": "object",
+            "required": [
+                "accountID"
+            ],
+            "properties": {
+                "accountID": {
+                    "type": "string"
+                },
+                "amountInOPCT": {
+                    "description": "negative for credit used up",
+                    "type": "number",
+                    "example": 15
+                },
+                "amountInUSD": {
+                    "type": "number",
+                    "example": 0
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string",
+                    "example": "upgrade to 1024 GB"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "monthsInSubscription": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "planID": {
+                    "type": "integer"
+                },
+                "reference": {
+                    "type": "string",
+                    "example": "the payment address, OPCT tx hash or Stripe charge ID"
+                },
+                "storageLimit": {
+                    "description": "the plan the account is on after the entry",
+                    "type": "integer",
+                    "example": 1024
+                },
+                "type": {
+                    "type": "string",
+                    "example": "payment"
+                }
+            }
+        },
+        "models.Organization": {
+            "type": "object",
+            "required": [
+                "ownerAccountID"
+            ],
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Acme"
+                },
+                "ownerAccountID": {
+                    "type": "string"
+                },
+                "storageUsedInByte": {
+                    "type": "integer",
+                    "example": 30
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.OrganizationMember": {
+            "type": "object",
+            "required": [
+                "accountID",
+                "organizationID"
+            ],
+            "properties": {
+                "accountID": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "organizationID": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "joined"
+                },
+                "storageCapInByte": {
+                    "description": "no cap if 0",
+                    "type": "integer"
+                },
+                "storageUsedInByte": {
+                    "description": "what the member's account has stored",
+                    "type": "integer"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.UsageSample": {
+            "type": "object",
+            "required": [
+                "accountID"
+            ],
+            "properties": {
+                "accountID": {
+                    "type": "string"
+                },
+                "billedAt": {
+                    "description": "nil until the overage is billed",
+                    "type": "string"
+                },
+                "billedReference": {
+                    "description": "the renewal's address or the Stripe charge ID",
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "fileCount": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "folderCount": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "metadataSizeInByte": {
+                    "type": "integer",
+                    "example": 1245765
+                },
+                "overageInByte": {
+                    "description": "how much was stored over the plan",
+                    "type": "integer",
+                    "example": 0
+                },
+                "sampledOn": {
+                    "type": "string"
+                },
+                "storageLimit": {
+                    "description": "the plan the account was on, in GB",
+                    "type": "integer",
+                    "example": 128
+                },
+                "storageUsedInByte": {
+                    "type": "integer",
+                    "example": 30
+                }
+            }
+        },
+        "routes.InitFileUploadObj": {
+            "type": "object",
+            "required": [
+                "endIndex",
+                "fileHandle",
+                "fileSizeInByte"
+            ],
+            "properties": {
+                "endIndex": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "fileHandle": {
+                    "type": "string",
+                    "maxLength": 64,
+                    "minLength": 64,
+                    "example": "a deterministically created file handle"
+                },
+                "fileSizeInByte": {
+                    "type": "integer",
+                    "example": 200000000000006
+                }
+            }
+        },
+        "routes.InitFileUploadReq": {
+            "type": "object",
+            "required": [
+                "metadata",
+                "publicKey",
+                "requestBody"
+            ],
+            "properties": {
+                "initFileUploadObj": {
+                    "type": "object",
+                    "$ref": "#/definitions/routes.InitFileUploadObj"
+                },
+                "metadata": {
+                    "type": "string",
+                    "example": "the metadata of the file you are about to upload, as an array of bytes"
+                },
+                "metadataAsFile": {
+                    "type": "string"
+                },
+                "publicKey": {
+                    "type": "string",
+                    "maxLength": 66,
+                    "minLength": 66,
+                    "example": "a 66-character public key"
+                },
+                "requestBody": {
+                    "type": "string",
+                    "example": "look at description for example"
+                },
+                "signature": {
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nand, for wallet signatures, V: sig[128:129]",
+                    "type": "string",
+                    "maxLength": 130,
+                    "minLength": 128,
+                    "example": "a 128 character string created when you signed the request with your private key or account handle, or a 130 character wallet signature, can be left out when sending a session token"
+                }
+            }
+        },
+        "routes.PlanResponse": {
+            "type": "object"
+        },
+        "routes.StatusRes": {
+            "type": "object",
+            "properties": {
+                "status": {
+                    "type": "string",
+                    "example": "status of the request"
+                }
+            }
+        },
+        "routes.UploadFileObj": {
+            "type": "object",
+            "required": [
+                "fileHandle",
+                "partIndex"
+            ],
+            "properties": {
+                "fileHandle": {
+                    "type": "string",
+                    "maxLength": 64,
+                    "minLength": 64,
+                    "example": "a deterministically created file handle"
+                },
+                "partIndex": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "routes.UploadFileReq": {
+            "type": "object",
+            "required": [
+                "chunkData",
+                "publicKey",
+                "requestBody"
+            ],
+            "properties": {
+                "chunkData": {
+                    "type": "string",
+                    "example": "a binary string of the chunk data"
+                },
+                "publicKey": {
+                    "type": "string",
+                    "maxLength": 66,
+                    "minLength": 66,
+                    "example": "a 66-character public key"
+                },
+                "requestBody": {
+                    "type": "string",
+                    "example": "look at description for example"
+                },
+                "signature": {
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nand, for wallet signatures, V: sig[128:129]",
+                    "type": "string",
+                    "maxLength": 130,
+                    "minLength": 128,
+                    "example": "a 128 character string created when you signed the request with your private key or account handle, or a 130 character wallet signature, can be left out when sending a session token"
+                },
+                "uploadFileObj": {
+                    "type": "object",
+                    "$ref": "#/definitions/routes.UploadFileObj"
+                }
+            }
+        },
+        "routes.UploadStatusObj": {
+            "type": "object",
+            "required": [
+                "fileHandle"
+            ],
+            "properties": {
+                "fileHandle": {
+                    "type": "string",
+                    "maxLength": 64,
+                    "minLength": 64,
+                    "example": "a deterministically created file handle"
+                }
+            }
+        },
+        "routes.UploadStatusReq": {
+            "type": "object",
+            "required": [
+                "publicKey",
+                "requestBody"
+            ],
+            "properties": {
+                "publicKey": {
+                    "type": "string",
+                    "maxLength": 66,
+                    "minLength": 66,
+                    "example": "a 66-character public key"
+                },
+                "requestBody": {
+                    "type": "string",
+                    "example": "look at description for example"
+                },
+                "signature": {
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nand, for wallet signatures, V: sig[128:129]",
+                    "type": "string",
+                    "maxLength": 130,
+                    "minLength": 128,
+                    "example": "a 128 character string created when you signed the request with your private key or account handle, or a 130 character wallet signature, can be left out when sending a session token"
+                },
+                "uploadStatusObj": {
+                    "type": "object",
+                    "$ref": "#/definitions/routes.UploadStatusObj"
+                }
+            }
+        },
+        "routes.accountCreateObj": {
+            "type": "object",
+            "required": [
+                "durationInMonths",
+                "storageLimit"
+            ],
+            "properties": {
+                "couponCode": {
+                    "type": "string",
+                    "maxLength": 64,
+                    "example": "SPRING20"
+                },
+                "durationInMonths": {
+                    "type": "integer",
+                    "minimum": 1,
+                    "example": 12
+                },
+                "storageLimit": {
+                    "type": "integer",
+                    "maximum": 2048,
+                    "minimum": 10,
+                    "example": 100
+                }
+            }
+        },
+        "routes.accountCreateReq": {
+            "type": "object",
+            "required": [
+                "publicKey",
+                "requestBody"
+            ],
+            "properties": {
+                "accountCreateObj": {
+                    "type": "object",
+                    "$ref": "#/definitions/routes.accountCreateObj"
+                },
+                "publicKey": {
+                    "type": "string",
+                    "maxLength": 66,
+                    "minLength": 66,
+                    "example": "a 66-character public key"
+                },
+                "requestBody": {
+                    "type": "string",
+                    "example": "look at description for example"
+                },
+                "signature": {
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nand, for wallet signatures, V: sig[128:129]",
+                    "type": "string",
+                    "maxLength": 130,
+                    "minLength": 128,
+                    "example": "a 128 character string created when you signed the request with your private key or account handle, or a 130 character wallet signature, can be left out when sending a session token"
+                }
+            }
+        },
+        "routes.accountCreateRes": {
+            "type": "object",
+            "required": [
+                "expirationDate"
+            ],
+            "properties": {
+                "expirationDate": {
+                    "type": "string"
+                },
+                "invoice": {
+                    "type": "object",
+                    "$ref": "#/definitions/models.Invoice"
+                }
+            }
+        },
+        "routes.accountDataRes": {
+            "type": "object",
+            "properties": {
+                "account": {
+                    "type": "object",
+                    "$ref": "#/definitions/routes.accountGetObj"
+                },
+                "paymentStatus": {
+                    "type": "string",
+                    "example": "paid"
+                },
+                "stripeData": {
+                    "type": "object",
+                    "$ref": "#/definitions/routes.stripeDataObj"
+                }
+            }
+        },
+        "routes.accountGetObj": {
+            "type": "object",
+            "required": [
+                "apiVersion",
+                "ethAddress",
+                "expirationDate",
+                "monthsInSubscription",
+                "state",
+                "storageLimit"
+            ],
+            "properties": {
+                "apiVersion": {
+                    "type": "integer"
+                },
+                "cost": {
+                    "type": "number",
+                    "example": 2
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "creditInOPCT": {
+                    "description": "taken off their next renewal",
+                    "type": "number",
+                    "example": 0.5
+                },
+                "ethAddress": {
+                    "description": "the eth address they will send payment to",
+                    "type": "string",
+                    "maxLength": 42,
+                    "minLength": 42,
+                    "example": "a 42-char eth address with 0x prefix"
+                },
+                "expirationDate": {
+                    "type": "string"
+                },
+                "maxFolders": {
+                    "type": "integer",
+                    "example": 2000
+                },
+                "maxMetadataSizeInMB": {
+                    "type": "integer",
+                    "example": 200
+                },
+                "monthsInSubscription": {
+                    "description": "number of months in their subscription",
+                    "type": "integer",
+                    "example": 12
+                },
+                "overageCapInGB": {
+                    "description": "how much more than their plan they can store",
+                    "type": "integer",
+                    "example": 0
+                },
+                "overageEnabled": {
+                    "description": "whether they can store more than their plan",
+                    "type": "boolean",
+                    "example": false
+                },
+                "projectedOverage": {
+                    "description": "unpaid overage plus what their usage adds until they expire, in OPCT",
+                    "type": "number",
+                    "example": 0
+                },
+                "projectedOverageInUSD": {
+                    "type": "number",
+                    "example": 0
+                },
+                "state": {
+                    "description": "active, grace (read only), suspended or purged",
+                    "type": "string",
+                    "example": "active"
+                },
+                "storageLimit": {
+                    "description": "how much storage they are allowed, in GB",
+                    "type": "integer",
+                    "example": 100
+                },
+                "storageUsed": {
+                    "description": "how much storage they have used, in GB",
+                    "type": "number",
+                    "example": 30
+                },
+                "totalFolders": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "totalMetadataSizeInMB": {
+                    "type": "number",
+                    "example": 1.245765432
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "routes.accountGetReqObj": {
+            "type": "object",
+            "required": [
+                "timestamp"
+            ],
+            "properties": {
+                "timestamp": {
+                    "type": "integer"
+                }
+            }
+        },
+        "routes.accountUnpaidRes": {
+            "type": "object",
+            "properties": {
+                "account": {
+                    "type": "object",
+                    "$ref": "#/definitions/routes.accountGetObj"
+                },
+                "invoice": {
+                    "type": "object",
+                    "$ref": "#/definitions/models.Invoice"
+                },
+                "paymentStatus": {
+                    "type": "string",
+                    "example": "paid"
+                },
+                "stripeData": {
+                    "type": "object",
+                    "$ref": "#/definitions/routes.stripeDataObj"
+                }
+            }
+        },
+        "routes.addDelegatedKeyObject": {
+            "type": "object",
+            "required": [
+                "delegatedPublicKey",
+                "scopes",
+                "timestamp"
+            ],
+            "properties": {
+                "delegatedPublicKey": {
+                    "type": "string",
+                    "maxLength": 66,
+                    "minLength": 66,
+                    "example": "a 66-character public key"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "an array of scopes",
+                        " like files:upload"
+                    ]
+                },
+                "timestamp": {
+                    "type": "integer"
+                }
+            }
+        },
+        "routes.addDelegatedKeyReq": {
+            "type": "object",
+            "required": [
+                "publicKey",
+                "requestBody"
+            ],
+            "properties": {
+                "addDelegatedKeyObject": {
+                    "type": "object",
+                    "$ref": "#/definitions/routes.addDelegatedKeyObject"
+                },
+                "publicKey": {
+                    "type": "string",
+                    "maxLength": 66,
+                    "minLength": 66,
+                    "example": "a 66-character public key"
+                },
+                "requestBody": {
+                    "type": "string",
+                    "example": "look at description for example"
+                },
+                "signature": {
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nand, for wallet signatures, V: sig[128:129]",
+                    "type": "string",
+                    "maxLength": 130,
+                    "minLength": 128,
+                    "example": "a 128 character string created when you signed the request with your private key or account handle, or a 130 character wallet signature, can be left out when sending a session token"
+                }
+            }
+        },
+        "routes.cancelDowngradeObject": {
+            "type": "object",
+            "required": [
+                "timestamp"
+            ],
+            "properties": {
+                "timestamp": {
+                    "type": "integer"
+                }
+            }
+        },
+        "routes.cancelDowngradeReq": {
+            "type": "object",
+            "required": [
+                "publicKey",
+                "requestBody"
+            ],
+            "properties": {
+                "cancelDowngradeObject": {
+                    "type": "object",
+                    "$ref": "#/definitions/routes.cancelDowngradeObject"
+                },
+                "publicKey": {
+                    "type": "string",
+                    "maxLength": 66,
+                    "minLength": 66,
+                    "example": "a 66-character public key"
+                },
+                "requestBody": {
+                    "type": "string",
+                    "example": "look at description for example"
+                },
+                "signature": {
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nand, for wallet signatures, V: sig[128:129]",
+                    "type": "string",
+                    "maxLength": 130,
+                    "minLength": 128,
+                    "example": "a 128 character string created when you signed the request with your private key or account handle, or a 130 character wallet signature, can be left out when sending a session token"
+                }
+            }
+        },
+        "routes.checkRenewalStatusObject": {
+            "type": "object",
+            "required": [
+                "fileHandles",
+                "metadataKeys"
+            ],
+            "properties": {
+                "fileHandles": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "an array containing all your file handles"
+                    ]
+                },
+                "metadataKeys": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "an array containing all your metadata keys"
+                    ]
+                }
+            }
+        },
+        "routes.checkRenewalStatusReq": {
+            "type": "object",
+            "required": [
+                "publicKey",
+                "requestBody"
+            ],
+            "properties": {
+                "checkRenewalStatusObject": {
+                    "type": "object",
+                    "$ref": "#/definitions/routes.checkRenewalStatusObject"
+                },
+                "publicKey": {
+                    "type": "string",
+                    "maxLength": 66,
+                    "minLength": 66,
+                    "example": "a 66-character public key"
+                },
+                "requestBody": {
+                    "type": "string",
+                    "example": "look at description for example"
+                },
+                "signature": {
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nand, for wallet signatures, V: sig[128:129]",
+                    "type": "string",
+                    "maxLength": 130,
+                    "minLength": 128,
+                    "example": "a 128 character string created when you signed the request with your private key or account handle, or a 130 character wallet signature, can be left out when sending a session token"
+                }
+            }
+        },
+        "routes.checkUpgradeStatusObject": {
+            "type": "object",
+            "required": [
+                "durationInMonths",
+                "fileHandles",
+                "metadataKeys",
+                "storageLimit"
+            ],
+            "properties": {
+                "durationInMonths": {
+                    "type": "integer",
+                    "minimum": 1,
+                    "example": 12
+                },
+                "fileHandles": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "an array containing all your file handles"
+                    ]
+                },
+                "metadataKeys": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "an array containing all your metadata keys"
+                    ]
+                },
+                "storageLimit": {
+                    "type": "integer",
+                    "minimum": 128,
+                    "example": 128
+                }
+            }
+        },
+        "routes.checkUpgradeStatusReq": {
+            "type": "object",
+            "required": [
+                "publicKey",
+                "requestBody"
+            ],
+            "properties": {
+                "checkUpgradeStatusObject": {
+                    "type": "object",
+                    "$ref": "#/definitions/routes.checkUpgradeStatusObject"
+                },
+                "publicKey": {
+                    "type": "string",
+                    "maxLength": 66,
+                    "minLength": 66,
+                    "example": "a 66-character public key"
+                },
+                "requestBody": {
+                    "type": "string",
+                    "example": "look at description for example"
+                },
+                "signature": {
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nand, for wallet signatures, V: sig[128:129]",
+                    "type": "string",
+                    "maxLength": 130,
+                    "minLength": 128,
+                    "example": "a 128 character string created when you signed the request with your private key or account handle, or a 130 character wallet signature, can be left out when sending a session token"
+                }
+            }
+        },
+        "routes.confirmDeleteAccountObject": {
+            "type": "object",
+            "required": [
+                "confirmationCode",
+                "timestamp"
+            ],
+            "properties": {
+                "confirmationCode": {
+                    "type": "string",
+                    "example": "the code returned when the deletion was requested"
+                },
+                "timestamp": {
+                    "type": "integer"
+                }
+            }
+        },
+        "routes.confirmDeleteAccountReq": {
+            "type": "object",
+            "required": [
+                "publicKey",
+                "requestBody"
+            ],
+            "properties": {
+                "confirmDeleteAccountObject": {
+                    "type": "object",
+                    "$ref": "#/definitions/routes.confirmDeleteAccountObject"
+                },
+                "publicKey": {
+                    "type": "string",
+                    "maxLength": 66,
+                    "minLength": 66,
+                    "example": "a 66-character public key"
+                },
+                "requestBody": {
+                    "type": "string",
+                    "example": "look at description for example"
+                },
+                "signature": {
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nand, for wallet signatures, V: sig[128:129]",
+                    "type": "string",
+                    "maxLength": 130,
+                    "minLength": 128,
+                    "example": "a 128 character string created when you signed the request with your private key or account handle, or a 130 character wallet signature, can be left out when sending a session token"
+                }
+            }
+        },
+        "routes.createMetadataRes": {
+            "type": "object",
+            "required": [
+                "expirationDate"
+            ],
+            "properties": {
+                "expirationDate": {
+                    "type": "string"
+                }
+            }
+        },
+        "routes.createOrganizationObject": {
+            "type": "object",
+            "required": [
+                "timestamp"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 64,
+                    "example": "Acme"
+                },
+                "timestamp": {
+                    "type": "integer"
+                }
+            }
+        },
+        "routes.createOrganizationReq": {
+            "type": "object",
+            "required": [
+                "publicKey",
+                "requestBody"
+            ],
+            "properties": {
+                "createOrganizationObject": {
+                    "type": "object",
+                    "$ref": "#/definitions/routes.createOrganizationObject"
+                },
+                "publicKey": {
+                    "type": "string",
+                    "maxLength": 66,
+                    "minLength": 66,
+                    "example": "a 66-character public key"
+                },
+                "requestBody": {
+                    "type": "string",
+                    "example": "look at description for example"
+                },
+                "signature": {
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nand, for wallet signatures, V: sig[128:129]",
+                    "type": "string",
+                    "maxLength": 130,
+                    "minLength": 128,
+                    "example": "a 128 character string created when you signed the request with your private key or account handle, or a 130 character wallet signature, can be left out when sending a session token"
+                }
+            }
+        },
+        "routes.createStripePaymentObject": {
+            "type": "object",
+            "required": [
+                "stripeToken",
+                "timestamp"
+            ],
+            "properties": {
+                "couponCode": {
+                    "type": "string",
+                    "maxLength": 64,
+                    "example": "SPRING20"
+                },
+                "durationInMonths": {
+                    "type": "integer",
+                    "minimum": 1,
+                    "example": 12
+                },
+                "storageLimit": {
+                    "type": "integer",
+                    "minimum": 128,
+                    "example": 128
+                },
+                "stripeToken": {
+                    "type": "string",
+                    "example": "tok_KPte7942xySKBKyrBu11yEpf"
+                },
+                "timestamp": {
+                    "type": "integer"
+                },
+                "upgradeAccount": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "routes.createStripePaymentReq": {
+            "type": "object",
+            "required": [
+                "publicKey",
+                "requestBody"
+            ],
+            "properties": {
+                "createStripePaymentObject": {
+                    "type": "object",
+                    "$ref": "#/definitions/routes.createStripePaymentObject"
+                },
+                "publicKey": {
+                    "type": "string",
+                    "maxLength": 66,
+                    "minLength": 66,
+                    "example": "a 66-character public key"
+                },
+                "requestBody": {
+                    "type": "string",
+                    "example": "look at description for example"
+                },
+                "signature": {
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nand, for wallet signatures, V: sig[128:129]",
+                    "type": "string",
+                    "maxLength": 130,
+                    "minLength": 128,
+                    "example": "a 128 character string created when you signed the request with your private key or account handle, or a 130 character wallet signature, can be left out when sending a session token"
+                }
+            }
+        },
+        "routes.delegatedKeyRes": {
+            "type": "object",
+            "required": [
+                "publicKey",
+                "scopes"
+            ],
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "expiresAt": {
+                    "type": "string"
+                },
+                "publicKey": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "routes.deleteAccountObject": {
+            "type": "object",
+            "required": [
+                "timestamp"
+            ],
+            "properties": {
+                "delayInHours": {
+                    "type": "integer",
+                    "maximum": 720,
+                    "example": 24
+                },
+                "timestamp": {
+                    "type": "integer"
+                }
+            }
+        },
+        "routes.deleteAccountReceiptRes": {
+            "type": "object",
+            "properties": {
+                "receipt": {
+                    "type": "object",
+                    "$ref": "#/definitions/models.AccountDeletionReceipt"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "deleted"
+                }
+            }
+        },
+        "routes.deleteAccountReq": {
+            "type": "object",
+            "required": [
+                "publicKey",
+                "requestBody"
+            ],
+            "properties": {
+                "deleteAccountObject": {
+                    "type": "object",
+                    "$ref": "#/definitions/routes.deleteAccountObject"
+                },
+                "publicKey": {
+                    "type": "string",
+                    "maxLength": 66,
+                    "minLength": 66,
+                    "example": "a 66-character public key"
+                },
+                "requestBody": {
+                    "type": "string",
+                    "example": "look at description for example"
+                },
+                "signature": {
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nand, for wallet signatures, V: sig[128:129]",
+                    "type": "string",
+                    "maxLength": 130,
+                    "minLength": 128,
+                    "example": "a 128 character string created when you signed the request with your private key or account handle, or a 130 character wallet signature, can be left out when sending a session token"
+                }
+            }
+        },
+        "routes.deleteAccountRes": {
+            "type": "object",
+            "properties": {
+                "confirmBy": {
+                    "type": "string"
+                },
+                "confirmationCode": {
+                    "type": "string",
+                    "example": "a code to send to /account/delete/confirm"
+                },
+                "executeAt": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "requested"
+                }
+            }
+        },
+        "routes.deleteAccountTimestampReq": {
+            "type": "object",
+            "required": [
+                "publicKey",
+                "requestBody"
+            ],
+            "properties": {
+                "publicKey": {
+                    "type": "string",
+                    "maxLength": 66,
+                    "minLength": 66,
+                    "example": "a 66-character public key"
+                },
+                "requestBody": {
+                    "type": "string",
+                    "example": "look at description for example"
+                },
+                "signature": {
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nand, for wallet signatures, V: sig[128:129]",
+                    "type": "string",
+                    "maxLength": 130,
+                    "minLength": 128,
+                    "example": "a 128 character string created when you signed the request with your private key or account handle, or a 130 character wallet signature, can be left out when sending a session token"
+                },
+                "timestampObject": {
+                    "type": "object",
+                    "$ref": "#/definitions/routes.timestampObject"
+                }
+            }
+        },
+        "routes.deleteFileObj": {
+            "type": "object",
+            "required": [
+                "fileID"
+            ],
+            "properties": {
+                "fileID": {
+                    "type": "string",
+                    "example": "the handle of the file"
                 }
             }
-        }
-    },
-    "definitions": {
-        "models.Invoice": {
+        },
+        "routes.deleteFileReq": {
             "type": "object",
             "required": [
-                "ethAddress"
+                "publicKey",
+                "requestBody"
             ],
             "properties": {
-                "cost": {
-                    "type": "number",
-                    "example": 1.56
+                "deleteFileObj": {
+                    "type": "object",
+                    "$ref": "#/definitions/routes.deleteFileObj"
                 },
-                "ethAddress": {
+                "publicKey": {
                     "type": "string",
-                    "maxLength": 42,
-                    "minLength": 42,
-                    "example": "a 42-char eth address with 0x prefix"
+                    "maxLength": 66,
+                    "minLength": 66,
+                    "example": "a 66-character public key"
+                },
+                "requestBody": {
+                    "type": "string",
+                    "example": "look at description for example"
+                },
+                "signature": {
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nand, for wallet signatures, V: sig[128:129]",
+                    "type": "string",
+                    "maxLength": 130,
+                    "minLength": 128,
+                    "example": "a 128 character string created when you signed the request with your private key or account handle, or a 130 character wallet signature, can be left out when sending a session token"
                 }
             }
         },
-        "routes.InitFileUploadObj": {
+        "routes.deleteFileRes": {
+            "type": "object"
+        },
+        "routes.downgradeAccountObject": {
             "type": "object",
             "required": [
-                "endIndex",
-                "fileHandle",
-                "fileSizeInByte"
+                "applyAt",
+                "storageLimit",
+                "timestamp"
             ],
             "properties": {
-                "endIndex": {
-                    "type": "integer",
-                    "example": 2
+                "applyAt": {
+                    "type": "string",
+                    "example": "renewal"
                 },
-                "fileHandle": {
+                "credit": {
                     "type": "string",
-                    "maxLength": 64,
-                    "minLength": 64,
-                    "example": "a deterministically created file handle"
+                    "example": "time"
                 },
-                "fileSizeInByte": {
+                "storageLimit": {
                     "type": "integer",
-                    "example": 200000000000006
+                    "minimum": 10,
+                    "example": 128
+                },
+                "timestamp": {
+                    "type": "integer"
                 }
             }
         },
-        "routes.InitFileUploadReq": {
+        "routes.downgradeAccountReq": {
             "type": "object",
             "required": [
-                "metadata",
                 "publicKey",
-                "requestBody",
-                "signature"
+                "requestBody"
             ],
             "properties": {
-                "initFileUploadObj": {
+                "downgradeAccountObject": {
                     "type": "object",
-                    "$ref": "#/definitions/routes.InitFileUploadObj"
+                    "$ref": "#/definitions/routes.downgradeAccountObject"
                 },
-                "metadata": {
+                "publicKey": {
                     "type": "string",
-                    "example": "the metadata of the file you are about to upload, as an array of bytes"
+                    "maxLength": 66,
+                    "minLength": 66,
+                    "example": "a 66-character public key"
                 },
-                "metadataAsFile": {
+                "requestBody": {
+                    "type": "string",
+                    "example": "look at description for example"
+                },
+                "signature": {
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nand, for wallet signatures, V: sig[128:129]",
+                    "type": "string",
+                    "maxLength": 130,
+                    "minLength": 128,
+                    "example": "a 128 character string created when you signed the request with your private key or account handle, or a 130 character wallet signature, can be left out when sending a session token"
+                }
+            }
+        },
+        "routes.downgradeAccountRes": {
+            "type": "object",
+            "properties": {
+                "creditInOPCT": {
+                    "type": "number",
+                    "example": 0.5
+                },
+                "expirationDate": {
                     "type": "string"
                 },
+                "status": {
+                    "type": "string",
+                    "example": "Downgraded"
+                },
+                "storageLimit": {
+                    "type": "integer",
+                    "example": 128
+                }
+            }
+        },
+        "routes.downloadFileObj": {
+            "type": "object",
+            "required": [
+                "fileID"
+            ],
+            "properties": {
+                "fileID": {
+                    "type": "string",
+                    "example": "the handle of the file"
+                }
+            }
+        },
+        "routes.downloadFileRes": {
+            "type": "object",
+            "properties": {
+                "fileDownloadUrl": {
+                    "description": "Url should point to S3, thus client does not need to download it from this node.",
+                    "type": "string",
+                    "example": "a URL to use to download the file"
+                }
+            }
+        },
+        "routes.getAccountDataReq": {
+            "type": "object",
+            "required": [
+                "publicKey",
+                "requestBody"
+            ],
+            "properties": {
+                "accountGetReqObj": {
+                    "type": "object",
+                    "$ref": "#/definitions/routes.accountGetReqObj"
+                },
                 "publicKey": {
                     "type": "string",
                     "maxLength": 66,
@@ -993,58 +3707,125 @@ var doc = `{
                     "example": "look at description for example"
                 },
                 "signature": {
-                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]",
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nand, for wallet signatures, V: sig[128:129]",
                     "type": "string",
-                    "maxLength": 128,
+                    "maxLength": 130,
                     "minLength": 128,
-                    "example": "a 128 character string created when you signed the request with your private key or account handle"
+                    "example": "a 128 character string created when you signed the request with your private key or account handle, or a 130 character wallet signature, can be left out when sending a session token"
                 }
             }
         },
-        "routes.PlanResponse": {
-            "type": "object"
-        },
-        "routes.StatusRes": {
+        "routes.getLedgerObject": {
             "type": "object",
+            "required": [
+                "timestamp"
+            ],
             "properties": {
-                "status": {
-                    "type": "string",
-                    "example": "status of the request"
+                "beforeID": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "limit": {
+                    "type": "integer",
+                    "maximum": 500,
+                    "example": 100
+                },
+                "timestamp": {
+                    "type": "integer"
                 }
             }
         },
-        "routes.UploadFileObj": {
+        "routes.getLedgerReq": {
             "type": "object",
             "required": [
-                "fileHandle",
-                "partIndex"
+                "publicKey",
+                "requestBody"
             ],
             "properties": {
-                "fileHandle": {
+                "getLedgerObject": {
+                    "type": "object",
+                    "$ref": "#/definitions/routes.getLedgerObject"
+                },
+                "publicKey": {
                     "type": "string",
-                    "maxLength": 64,
-                    "minLength": 64,
-                    "example": "a deterministically created file handle"
+                    "maxLength": 66,
+                    "minLength": 66,
+                    "example": "a 66-character public key"
                 },
-                "partIndex": {
+                "requestBody": {
+                    "type": "string",
+                    "example": "look at description for example"
+                },
+                "signature": {
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nand, for wallet signatures, V: sig[128:129]",
+                    "type": "string",
+                    "maxLength": 130,
+                    "minLength": 128,
+                    "example": "a 128 character string created when you signed the request with your private key or account handle, or a 130 character wallet signature, can be left out when sending a session token"
+                }
+            }
+        },
+        "routes.getLedgerRes": {
+            "type": "object",
+            "properties": {
+                "entries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.LedgerEntry"
+                    }
+                },
+                "nextBeforeID": {
                     "type": "integer",
-                    "example": 1
+                    "example": 0
                 }
             }
         },
-        "routes.UploadFileReq": {
+        "routes.getMetadataHistoryRes": {
             "type": "object",
             "required": [
-                "chunkData",
-                "publicKey",
-                "requestBody",
-                "signature"
+                "expirationDate"
             ],
             "properties": {
-                "chunkData": {
+                "expirationDate": {
+                    "type": "string"
+                },
+                "metadata": {
                     "type": "string",
-                    "example": "a binary string of the chunk data"
+                    "example": "your account metadata"
+                },
+                "metadataHistory": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "your account metadata"
+                    ]
+                }
+            }
+        },
+        "routes.getMetadataRes": {
+            "type": "object",
+            "required": [
+                "expirationDate"
+            ],
+            "properties": {
+                "expirationDate": {
+                    "type": "string"
                 },
+                "metadata": {
+                    "type": "string",
+                    "example": "your account metadata"
+                }
+            }
+        },
+        "routes.getOrganizationReq": {
+            "type": "object",
+            "required": [
+                "publicKey",
+                "requestBody"
+            ],
+            "properties": {
                 "publicKey": {
                     "type": "string",
                     "maxLength": 66,
@@ -1056,40 +3837,39 @@ var doc = `{
                     "example": "look at description for example"
                 },
                 "signature": {
-                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]",
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nand, for wallet signatures, V: sig[128:129]",
                     "type": "string",
-                    "maxLength": 128,
+                    "maxLength": 130,
                     "minLength": 128,
-                    "example": "a 128 character string created when you signed the request with your private key or account handle"
+                    "example": "a 128 character string created when you signed the request with your private key or account handle, or a 130 character wallet signature, can be left out when sending a session token"
                 },
-                "uploadFileObj": {
+                "timestampObject": {
                     "type": "object",
-                    "$ref": "#/definitions/routes.UploadFileObj"
+                    "$ref": "#/definitions/routes.timestampObject"
                 }
             }
         },
-        "routes.UploadStatusObj": {
+        "routes.getRenewalAccountInvoiceObject": {
             "type": "object",
-            "required": [
-                "fileHandle"
-            ],
             "properties": {
-                "fileHandle": {
+                "couponCode": {
                     "type": "string",
                     "maxLength": 64,
-                    "minLength": 64,
-                    "example": "a deterministically created file handle"
+                    "example": "SPRING20"
                 }
             }
         },
-        "routes.UploadStatusReq": {
+        "routes.getRenewalAccountInvoiceReq": {
             "type": "object",
             "required": [
                 "publicKey",
-                "requestBody",
-                "signature"
+                "requestBody"
             ],
             "properties": {
+                "getRenewalAccountInvoiceObject": {
+                    "type": "object",
+                    "$ref": "#/definitions/routes.getRenewalAccountInvoiceObject"
+                },
                 "publicKey": {
                     "type": "string",
                     "maxLength": 66,
@@ -1101,25 +3881,35 @@ var doc = `{
                     "example": "look at description for example"
                 },
                 "signature": {
-                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]",
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nand, for wallet signatures, V: sig[128:129]",
                     "type": "string",
-                    "maxLength": 128,
+                    "maxLength": 130,
                     "minLength": 128,
-                    "example": "a 128 character string created when you signed the request with your private key or account handle"
-                },
-                "uploadStatusObj": {
+                    "example": "a 128 character string created when you signed the request with your private key or account handle, or a 130 character wallet signature, can be left out when sending a session token"
+                }
+            }
+        },
+        "routes.getRenewalAccountInvoiceRes": {
+            "type": "object",
+            "properties": {
+                "opctInvoice": {
                     "type": "object",
-                    "$ref": "#/definitions/routes.UploadStatusObj"
+                    "$ref": "#/definitions/models.Invoice"
                 }
             }
         },
-        "routes.accountCreateObj": {
+        "routes.getUpgradeAccountInvoiceObject": {
             "type": "object",
             "required": [
                 "durationInMonths",
                 "storageLimit"
             ],
             "properties": {
+                "couponCode": {
+                    "type": "string",
+                    "maxLength": 64,
+                    "example": "SPRING20"
+                },
                 "durationInMonths": {
                     "type": "integer",
                     "minimum": 1,
@@ -1127,23 +3917,21 @@ var doc = `{
                 },
                 "storageLimit": {
                     "type": "integer",
-                    "maximum": 2048,
-                    "minimum": 10,
-                    "example": 100
+                    "minimum": 128,
+                    "example": 128
                 }
             }
         },
-        "routes.accountCreateReq": {
+        "routes.getUpgradeAccountInvoiceReq": {
             "type": "object",
             "required": [
                 "publicKey",
-                "requestBody",
-                "signature"
+                "requestBody"
             ],
             "properties": {
-                "accountCreateObj": {
+                "getUpgradeAccountInvoiceObject": {
                     "type": "object",
-                    "$ref": "#/definitions/routes.accountCreateObj"
+                    "$ref": "#/definitions/routes.getUpgradeAccountInvoiceObject"
                 },
                 "publicKey": {
                     "type": "string",
@@ -1156,113 +3944,131 @@ var doc = `{
                     "example": "look at description for example"
                 },
                 "signature": {
-                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]",
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nand, for wallet signatures, V: sig[128:129]",
                     "type": "string",
-                    "maxLength": 128,
+                    "maxLength": 130,
                     "minLength": 128,
-                    "example": "a 128 character string created when you signed the request with your private key or account handle"
+                    "example": "a 128 character string created when you signed the request with your private key or account handle, or a 130 character wallet signature, can be left out when sending a session token"
                 }
             }
         },
-        "routes.accountCreateRes": {
+        "routes.getUpgradeAccountInvoiceRes": {
             "type": "object",
-            "required": [
-                "expirationDate"
-            ],
             "properties": {
-                "expirationDate": {
-                    "type": "string"
-                },
-                "invoice": {
+                "opctInvoice": {
                     "type": "object",
                     "$ref": "#/definitions/models.Invoice"
                 }
             }
         },
-        "routes.accountDataRes": {
+        "routes.getUsageHistoryObject": {
             "type": "object",
+            "required": [
+                "startDate",
+                "timestamp"
+            ],
             "properties": {
-                "account": {
-                    "type": "object",
-                    "$ref": "#/definitions/routes.accountGetObj"
+                "endDate": {
+                    "type": "string",
+                    "example": "2019-05-31"
                 },
-                "paymentStatus": {
+                "startDate": {
                     "type": "string",
-                    "example": "paid"
+                    "example": "2019-05-01"
                 },
-                "stripeData": {
-                    "type": "object",
-                    "$ref": "#/definitions/routes.stripeDataObj"
+                "timestamp": {
+                    "type": "integer"
                 }
             }
         },
-        "routes.accountGetObj": {
+        "routes.getUsageHistoryReq": {
             "type": "object",
             "required": [
-                "apiVersion",
-                "ethAddress",
-                "expirationDate",
-                "monthsInSubscription",
-                "storageLimit"
+                "publicKey",
+                "requestBody"
             ],
             "properties": {
-                "apiVersion": {
-                    "type": "integer"
-                },
-                "cost": {
-                    "type": "number",
-                    "example": 2
-                },
-                "createdAt": {
-                    "type": "string"
+                "getUsageHistoryObject": {
+                    "type": "object",
+                    "$ref": "#/definitions/routes.getUsageHistoryObject"
                 },
-                "ethAddress": {
-                    "description": "the eth address they will send payment to",
+                "publicKey": {
                     "type": "string",
-                    "maxLength": 42,
-                    "minLength": 42,
-                    "example": "a 42-char eth address with 0x prefix"
-                },
-                "expirationDate": {
-                    "type": "string"
-                },
-                "maxFolders": {
-                    "type": "integer",
-                    "example": 2000
-                },
-                "maxMetadataSizeInMB": {
-                    "type": "integer",
-                    "example": 200
+                    "maxLength": 66,
+                    "minLength": 66,
+                    "example": "a 66-character public key"
                 },
-                "monthsInSubscription": {
-                    "description": "number of months in their subscription",
-                    "type": "integer",
-                    "example": 12
+                "requestBody": {
+                    "type": "string",
+                    "example": "look at description for example"
                 },
-                "storageLimit": {
-                    "description": "how much storage they are allowed, in GB",
+                "signature": {
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nand, for wallet signatures, V: sig[128:129]",
+                    "type": "string",
+                    "maxLength": 130,
+                    "minLength": 128,
+                    "example": "a 128 character string created when you signed the request with your private key or account handle, or a 130 character wallet signature, can be left out when sending a session token"
+                }
+            }
+        },
+        "routes.getUsageHistoryRes": {
+            "type": "object",
+            "properties": {
+                "samples": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.UsageSample"
+                    }
+                }
+            }
+        },
+        "routes.joinOrganizationObject": {
+            "type": "object",
+            "required": [
+                "organizationID",
+                "timestamp"
+            ],
+            "properties": {
+                "organizationID": {
                     "type": "integer",
-                    "example": 100
+                    "example": 1
                 },
-                "storageUsed": {
-                    "description": "how much storage they have used, in GB",
-                    "type": "number",
-                    "example": 30
+                "timestamp": {
+                    "type": "integer"
+                }
+            }
+        },
+        "routes.joinOrganizationReq": {
+            "type": "object",
+            "required": [
+                "publicKey",
+                "requestBody"
+            ],
+            "properties": {
+                "joinOrganizationObject": {
+                    "type": "object",
+                    "$ref": "#/definitions/routes.joinOrganizationObject"
                 },
-                "totalFolders": {
-                    "type": "integer",
-                    "example": 2
+                "publicKey": {
+                    "type": "string",
+                    "maxLength": 66,
+                    "minLength": 66,
+                    "example": "a 66-character public key"
                 },
-                "totalMetadataSizeInMB": {
-                    "type": "number",
-                    "example": 1.245765432
+                "requestBody": {
+                    "type": "string",
+                    "example": "look at description for example"
                 },
-                "updatedAt": {
-                    "type": "string"
+                "signature": {
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nand, for wallet signatures, V: sig[128:129]",
+                    "type": "string",
+                    "maxLength": 130,
+                    "minLength": 128,
+                    "example": "a 128 character string created when you signed the request with your private key or account handle, or a 130 character wallet signature, can be left out when sending a session token"
                 }
             }
         },
-        "routes.accountGetReqObj": {
+        "routes.listDelegatedKeysObject": {
             "type": "object",
             "required": [
                 "timestamp"
@@ -1273,65 +4079,68 @@ var doc = `{
                 }
             }
         },
-        "routes.accountUnpaidRes": {
+        "routes.listDelegatedKeysReq": {
             "type": "object",
+            "required": [
+                "publicKey",
+                "requestBody"
+            ],
             "properties": {
-                "account": {
+                "listDelegatedKeysObject": {
                     "type": "object",
-                    "$ref": "#/definitions/routes.accountGetObj"
+                    "$ref": "#/definitions/routes.listDelegatedKeysObject"
                 },
-                "invoice": {
-                    "type": "object",
-                    "$ref": "#/definitions/models.Invoice"
+                "publicKey": {
+                    "type": "string",
+                    "maxLength": 66,
+                    "minLength": 66,
+                    "example": "a 66-character public key"
                 },
-                "paymentStatus": {
+                "requestBody": {
                     "type": "string",
-                    "example": "paid"
+                    "example": "look at description for example"
                 },
-                "stripeData": {
-                    "type": "object",
-                    "$ref": "#/definitions/routes.stripeDataObj"
+                "signature": {
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nand, for wallet signatures, V: sig[128:129]",
+                    "type": "string",
+                    "maxLength": 130,
+                    "minLength": 128,
+                    "example": "a 128 character string created when you signed the request with your private key or account handle, or a 130 character wallet signature, can be left out when sending a session token"
                 }
             }
         },
-        "routes.checkRenewalStatusObject": {
+        "routes.listDelegatedKeysRes": {
             "type": "object",
-            "required": [
-                "fileHandles",
-                "metadataKeys"
-            ],
             "properties": {
-                "fileHandles": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    },
-                    "example": [
-                        "an array containing all your file handles"
-                    ]
-                },
-                "metadataKeys": {
+                "delegatedKeys": {
                     "type": "array",
                     "items": {
-                        "type": "string"
-                    },
-                    "example": [
-                        "an array containing all your metadata keys"
-                    ]
+                        "$ref": "#/definitions/routes.delegatedKeyRes"
+                    }
                 }
             }
         },
-        "routes.checkRenewalStatusReq": {
+        "routes.metadataExportObject": {
+            "type": "object",
+            "required": [
+                "timestamp"
+            ],
+            "properties": {
+                "timestamp": {
+                    "type": "integer"
+                }
+            }
+        },
+        "routes.metadataExportReq": {
             "type": "object",
             "required": [
                 "publicKey",
-                "requestBody",
-                "signature"
+                "requestBody"
             ],
             "properties": {
-                "checkRenewalStatusObject": {
+                "metadataExportObject": {
                     "type": "object",
-                    "$ref": "#/definitions/routes.checkRenewalStatusObject"
+                    "$ref": "#/definitions/routes.metadataExportObject"
                 },
                 "publicKey": {
                     "type": "string",
@@ -1344,64 +4153,45 @@ var doc = `{
                     "example": "look at description for example"
                 },
                 "signature": {
-                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]",
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nand, for wallet signatures, V: sig[128:129]",
                     "type": "string",
-                    "maxLength": 128,
+                    "maxLength": 130,
                     "minLength": 128,
-                    "example": "a 128 character string created when you signed the request with your private key or account handle"
+                    "example": "a 128 character string created when you signed the request with your private key or account handle, or a 130 character wallet signature, can be left out when sending a session token"
                 }
             }
         },
-        "routes.checkUpgradeStatusObject": {
+        "routes.metadataImportObject": {
             "type": "object",
             "required": [
-                "durationInMonths",
-                "fileHandles",
-                "metadataKeys",
-                "storageLimit"
+                "archiveHash",
+                "timestamp"
             ],
             "properties": {
-                "durationInMonths": {
-                    "type": "integer",
-                    "minimum": 1,
-                    "example": 12
-                },
-                "fileHandles": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    },
-                    "example": [
-                        "an array containing all your file handles"
-                    ]
-                },
-                "metadataKeys": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    },
-                    "example": [
-                        "an array containing all your metadata keys"
-                    ]
+                "archiveHash": {
+                    "type": "string",
+                    "example": "a 64-char hex keccak256 hash of the archive file"
                 },
-                "storageLimit": {
-                    "type": "integer",
-                    "minimum": 128,
-                    "example": 128
+                "timestamp": {
+                    "type": "integer"
                 }
             }
         },
-        "routes.checkUpgradeStatusReq": {
+        "routes.metadataImportReq": {
             "type": "object",
             "required": [
+                "archive",
                 "publicKey",
-                "requestBody",
-                "signature"
+                "requestBody"
             ],
             "properties": {
-                "checkUpgradeStatusObject": {
+                "archive": {
+                    "type": "string",
+                    "example": "an archive file returned by /api/v1/metadata/export"
+                },
+                "metadataImportObject": {
                     "type": "object",
-                    "$ref": "#/definitions/routes.checkUpgradeStatusObject"
+                    "$ref": "#/definitions/routes.metadataImportObject"
                 },
                 "publicKey": {
                     "type": "string",
@@ -1414,65 +4204,65 @@ var doc = `{
                     "example": "look at description for example"
                 },
                 "signature": {
-                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]",
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nand, for wallet signatures, V: sig[128:129]",
                     "type": "string",
-                    "maxLength": 128,
+                    "maxLength": 130,
                     "minLength": 128,
-                    "example": "a 128 character string created when you signed the request with your private key or account handle"
+                    "example": "a 128 character string created when you signed the request with your private key or account handle, or a 130 character wallet signature, can be left out when sending a session token"
                 }
             }
         },
-        "routes.createMetadataRes": {
+        "routes.metadataImportRes": {
             "type": "object",
-            "required": [
-                "expirationDate"
-            ],
             "properties": {
+                "conflicts": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "metadata keys that already belong to someone else and were skipped"
+                    ]
+                },
                 "expirationDate": {
                     "type": "string"
+                },
+                "imported": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "overwritten": {
+                    "type": "integer",
+                    "example": 2
                 }
             }
         },
-        "routes.createStripePaymentObject": {
+        "routes.metadataKeyObject": {
             "type": "object",
             "required": [
-                "stripeToken",
+                "metadataKey",
                 "timestamp"
             ],
             "properties": {
-                "durationInMonths": {
-                    "type": "integer",
-                    "minimum": 1,
-                    "example": 12
-                },
-                "storageLimit": {
-                    "type": "integer",
-                    "minimum": 128,
-                    "example": 128
-                },
-                "stripeToken": {
+                "metadataKey": {
                     "type": "string",
-                    "example": "tok_KPte7942xySKBKyrBu11yEpf"
+                    "example": "a 64-char hex string created deterministically, will be a key for the metadata of one of your folders"
                 },
                 "timestamp": {
                     "type": "integer"
-                },
-                "upgradeAccount": {
-                    "type": "boolean"
                 }
             }
         },
-        "routes.createStripePaymentReq": {
+        "routes.metadataKeyReq": {
             "type": "object",
             "required": [
                 "publicKey",
-                "requestBody",
-                "signature"
+                "requestBody"
             ],
             "properties": {
-                "createStripePaymentObject": {
+                "metadataKeyObject": {
                     "type": "object",
-                    "$ref": "#/definitions/routes.createStripePaymentObject"
+                    "$ref": "#/definitions/routes.metadataKeyObject"
                 },
                 "publicKey": {
                     "type": "string",
@@ -1485,37 +4275,46 @@ var doc = `{
                     "example": "look at description for example"
                 },
                 "signature": {
-                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]",
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nand, for wallet signatures, V: sig[128:129]",
                     "type": "string",
-                    "maxLength": 128,
+                    "maxLength": 130,
                     "minLength": 128,
-                    "example": "a 128 character string created when you signed the request with your private key or account handle"
+                    "example": "a 128 character string created when you signed the request with your private key or account handle, or a 130 character wallet signature, can be left out when sending a session token"
                 }
             }
         },
-        "routes.deleteFileObj": {
+        "routes.organizationMemberObject": {
             "type": "object",
             "required": [
-                "fileID"
+                "memberAccountID",
+                "timestamp"
             ],
             "properties": {
-                "fileID": {
+                "memberAccountID": {
                     "type": "string",
-                    "example": "the handle of the file"
+                    "maxLength": 64,
+                    "minLength": 64,
+                    "example": "the hash of the member's public key"
+                },
+                "storageCapInGB": {
+                    "type": "number",
+                    "example": 50
+                },
+                "timestamp": {
+                    "type": "integer"
                 }
             }
         },
-        "routes.deleteFileReq": {
+        "routes.organizationMemberReq": {
             "type": "object",
             "required": [
                 "publicKey",
-                "requestBody",
-                "signature"
+                "requestBody"
             ],
             "properties": {
-                "deleteFileObj": {
+                "organizationMemberObject": {
                     "type": "object",
-                    "$ref": "#/definitions/routes.deleteFileObj"
+                    "$ref": "#/definitions/routes.organizationMemberObject"
                 },
                 "publicKey": {
                     "type": "string",
@@ -1528,50 +4327,61 @@ var doc = `{
                     "example": "look at description for example"
                 },
                 "signature": {
-                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]",
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nand, for wallet signatures, V: sig[128:129]",
                     "type": "string",
-                    "maxLength": 128,
+                    "maxLength": 130,
                     "minLength": 128,
-                    "example": "a 128 character string created when you signed the request with your private key or account handle"
+                    "example": "a 128 character string created when you signed the request with your private key or account handle, or a 130 character wallet signature, can be left out when sending a session token"
                 }
             }
         },
-        "routes.deleteFileRes": {
-            "type": "object"
-        },
-        "routes.downloadFileObj": {
+        "routes.organizationRes": {
             "type": "object",
-            "required": [
-                "fileID"
-            ],
             "properties": {
-                "fileID": {
-                    "type": "string",
-                    "example": "the handle of the file"
+                "members": {
+                    "description": "only the requester's own, for a member",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.OrganizationMember"
+                    }
+                },
+                "organization": {
+                    "type": "object",
+                    "$ref": "#/definitions/models.Organization"
+                },
+                "storageLimit": {
+                    "description": "the pool everyone stores in, in GB",
+                    "type": "integer",
+                    "example": 1024
                 }
             }
         },
-        "routes.downloadFileRes": {
+        "routes.payOverageObject": {
             "type": "object",
+            "required": [
+                "stripeToken",
+                "timestamp"
+            ],
             "properties": {
-                "fileDownloadUrl": {
-                    "description": "Url should point to S3, thus client does not need to download it from this node.",
+                "stripeToken": {
                     "type": "string",
-                    "example": "a URL to use to download the file"
+                    "example": "tok_KPte7942xySKBKyrBu11yEpf"
+                },
+                "timestamp": {
+                    "type": "integer"
                 }
             }
         },
-        "routes.getAccountDataReq": {
+        "routes.payOverageReq": {
             "type": "object",
             "required": [
                 "publicKey",
-                "requestBody",
-                "signature"
+                "requestBody"
             ],
             "properties": {
-                "accountGetReqObj": {
+                "payOverageObject": {
                     "type": "object",
-                    "$ref": "#/definitions/routes.accountGetReqObj"
+                    "$ref": "#/definitions/routes.payOverageObject"
                 },
                 "publicKey": {
                     "type": "string",
@@ -1584,67 +4394,93 @@ var doc = `{
                     "example": "look at description for example"
                 },
                 "signature": {
-                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]",
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nand, for wallet signatures, V: sig[128:129]",
                     "type": "string",
-                    "maxLength": 128,
+                    "maxLength": 130,
                     "minLength": 128,
-                    "example": "a 128 character string created when you signed the request with your private key or account handle"
+                    "example": "a 128 character string created when you signed the request with your private key or account handle, or a 130 character wallet signature, can be left out when sending a session token"
                 }
             }
         },
-        "routes.getMetadataHistoryRes": {
+        "routes.removeDelegatedKeyObject": {
             "type": "object",
             "required": [
-                "expirationDate"
+                "delegatedPublicKey",
+                "timestamp"
             ],
             "properties": {
-                "expirationDate": {
-                    "type": "string"
-                },
-                "metadata": {
+                "delegatedPublicKey": {
                     "type": "string",
-                    "example": "your account metadata"
+                    "maxLength": 66,
+                    "minLength": 66,
+                    "example": "a 66-character public key"
                 },
-                "metadataHistory": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    },
-                    "example": [
-                        "your account metadata"
-                    ]
+                "timestamp": {
+                    "type": "integer"
                 }
             }
         },
-        "routes.getMetadataRes": {
+        "routes.removeDelegatedKeyReq": {
             "type": "object",
             "required": [
-                "expirationDate"
+                "publicKey",
+                "requestBody"
             ],
             "properties": {
-                "expirationDate": {
-                    "type": "string"
+                "publicKey": {
+                    "type": "string",
+                    "maxLength": 66,
+                    "minLength": 66,
+                    "example": "a 66-character public key"
                 },
-                "metadata": {
+                "removeDelegatedKeyObject": {
+                    "type": "object",
+                    "$ref": "#/definitions/routes.removeDelegatedKeyObject"
+                },
+                "requestBody": {
                     "type": "string",
-                    "example": "your account metadata"
+                    "example": "look at description for example"
+                },
+                "signature": {
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nand, for wallet signatures, V: sig[128:129]",
+                    "type": "string",
+                    "maxLength": 130,
+                    "minLength": 128,
+                    "example": "a 128 character string created when you signed the request with your private key or account handle, or a 130 character wallet signature, can be left out when sending a session token"
                 }
             }
         },
-        "routes.getRenewalAccountInvoiceObject": {
-            "type": "object"
+        "routes.rotateKeyObject": {
+            "type": "object",
+            "required": [
+                "newPublicKey",
+                "timestamp"
+            ],
+            "properties": {
+                "newPublicKey": {
+                    "type": "string",
+                    "maxLength": 66,
+                    "minLength": 66,
+                    "example": "a 66-character public key"
+                },
+                "timestamp": {
+                    "type": "integer"
+                }
+            }
         },
-        "routes.getRenewalAccountInvoiceReq": {
+        "routes.rotateKeyReq": {
             "type": "object",
             "required": [
+                "newKeySignature",
                 "publicKey",
-                "requestBody",
-                "signature"
+                "requestBody"
             ],
             "properties": {
-                "getRenewalAccountInvoiceObject": {
-                    "type": "object",
-                    "$ref": "#/definitions/routes.getRenewalAccountInvoiceObject"
+                "newKeySignature": {
+                    "type": "string",
+                    "maxLength": 130,
+                    "minLength": 128,
+                    "example": "the same request signed with the new private key"
                 },
                 "publicKey": {
                     "type": "string",
@@ -1656,55 +4492,62 @@ var doc = `{
                     "type": "string",
                     "example": "look at description for example"
                 },
+                "rotateKeyObject": {
+                    "type": "object",
+                    "$ref": "#/definitions/routes.rotateKeyObject"
+                },
                 "signature": {
-                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]",
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nand, for wallet signatures, V: sig[128:129]",
                     "type": "string",
-                    "maxLength": 128,
+                    "maxLength": 130,
                     "minLength": 128,
-                    "example": "a 128 character string created when you signed the request with your private key or account handle"
+                    "example": "a 128 character string created when you signed the request with your private key or account handle, or a 130 character wallet signature, can be left out when sending a session token"
                 }
             }
         },
-        "routes.getRenewalAccountInvoiceRes": {
+        "routes.rotateKeyRes": {
             "type": "object",
+            "required": [
+                "newAccountID"
+            ],
             "properties": {
-                "opctInvoice": {
-                    "type": "object",
-                    "$ref": "#/definitions/models.Invoice"
+                "newAccountID": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "RekeyingMetadatas"
                 }
             }
         },
-        "routes.getUpgradeAccountInvoiceObject": {
+        "routes.sessionLoginObject": {
             "type": "object",
             "required": [
-                "durationInMonths",
-                "storageLimit"
+                "timestamp"
             ],
             "properties": {
-                "durationInMonths": {
-                    "type": "integer",
-                    "minimum": 1,
-                    "example": 12
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "an optional array of scopes to limit the session to",
+                        " like metadata:read"
+                    ]
                 },
-                "storageLimit": {
-                    "type": "integer",
-                    "minimum": 128,
-                    "example": 128
+                "timestamp": {
+                    "type": "integer"
                 }
             }
         },
-        "routes.getUpgradeAccountInvoiceReq": {
+        "routes.sessionLoginReq": {
             "type": "object",
             "required": [
                 "publicKey",
-                "requestBody",
-                "signature"
+                "requestBody"
             ],
             "properties": {
-                "getUpgradeAccountInvoiceObject": {
-                    "type": "object",
-                    "$ref": "#/definitions/routes.getUpgradeAccountInvoiceObject"
-                },
                 "publicKey": {
                     "type": "string",
                     "maxLength": 66,
@@ -1715,52 +4558,70 @@ var doc = `{
                     "type": "string",
                     "example": "look at description for example"
                 },
+                "sessionLoginObject": {
+                    "type": "object",
+                    "$ref": "#/definitions/routes.sessionLoginObject"
+                },
                 "signature": {
-                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]",
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nand, for wallet signatures, V: sig[128:129]",
                     "type": "string",
-                    "maxLength": 128,
+                    "maxLength": 130,
                     "minLength": 128,
-                    "example": "a 128 character string created when you signed the request with your private key or account handle"
+                    "example": "a 128 character string created when you signed the request with your private key or account handle, or a 130 character wallet signature, can be left out when sending a session token"
                 }
             }
         },
-        "routes.getUpgradeAccountInvoiceRes": {
+        "routes.sessionRes": {
             "type": "object",
+            "required": [
+                "expiresAt",
+                "token"
+            ],
             "properties": {
-                "opctInvoice": {
-                    "type": "object",
-                    "$ref": "#/definitions/models.Invoice"
+                "expiresAt": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "the scopes the token is limited to",
+                        " empty if it is not limited"
+                    ]
+                },
+                "token": {
+                    "type": "string",
+                    "example": "a bearer token to send as Authorization: Bearer \u003ctoken\u003e"
                 }
             }
         },
-        "routes.metadataKeyObject": {
+        "routes.setOverageObject": {
             "type": "object",
             "required": [
-                "metadataKey",
                 "timestamp"
             ],
             "properties": {
-                "metadataKey": {
-                    "type": "string",
-                    "example": "a 64-char hex string created deterministically, will be a key for the metadata of one of your folders"
+                "capInGB": {
+                    "type": "integer",
+                    "example": 64
+                },
+                "enabled": {
+                    "type": "boolean"
                 },
                 "timestamp": {
                     "type": "integer"
                 }
             }
         },
-        "routes.metadataKeyReq": {
+        "routes.setOverageReq": {
             "type": "object",
             "required": [
                 "publicKey",
-                "requestBody",
-                "signature"
+                "requestBody"
             ],
             "properties": {
-                "metadataKeyObject": {
-                    "type": "object",
-                    "$ref": "#/definitions/routes.metadataKeyObject"
-                },
                 "publicKey": {
                     "type": "string",
                     "maxLength": 66,
@@ -1771,12 +4632,29 @@ var doc = `{
                     "type": "string",
                     "example": "look at description for example"
                 },
+                "setOverageObject": {
+                    "type": "object",
+                    "$ref": "#/definitions/routes.setOverageObject"
+                },
                 "signature": {
-                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]",
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nand, for wallet signatures, V: sig[128:129]",
                     "type": "string",
-                    "maxLength": 128,
+                    "maxLength": 130,
                     "minLength": 128,
-                    "example": "a 128 character string created when you signed the request with your private key or account handle"
+                    "example": "a 128 character string created when you signed the request with your private key or account handle, or a 130 character wallet signature, can be left out when sending a session token"
+                }
+            }
+        },
+        "routes.setOverageRes": {
+            "type": "object",
+            "properties": {
+                "overageCapInGB": {
+                    "type": "integer",
+                    "example": 64
+                },
+                "overageEnabled": {
+                    "type": "boolean",
+                    "example": true
                 }
             }
         },
@@ -1830,6 +4708,14 @@ var doc = `{
                 }
             }
         },
+        "routes.timestampObject": {
+            "type": "object",
+            "properties": {
+                "timestamp": {
+                    "type": "integer"
+                }
+            }
+        },
         "routes.updateMetadataObject": {
             "type": "object",
             "required": [
@@ -1855,8 +4741,7 @@ var doc = `{
             "type": "object",
             "required": [
                 "publicKey",
-                "requestBody",
-                "signature"
+                "requestBody"
             ],
             "properties": {
                 "publicKey": {
@@ -1870,11 +4755,11 @@ var doc = `{
                     "example": "look at description for example"
                 },
                 "signature": {
-                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]",
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nand, for wallet signatures, V: sig[128:129]",
                     "type": "string",
-                    "maxLength": 128,
+                    "maxLength": 130,
                     "minLength": 128,
-                    "example": "a 128 character string created when you signed the request with your private key or account handle"
+                    "example": "a 128 character string created when you signed the request with your private key or account handle, or a 130 character wallet signature, can be left out when sending a session token"
                 },
                 "updateMetadataObject": {
                     "type": "object",
@@ -1926,6 +4811,10 @@ var doc = `{
                 "maxMetadataSizeInMB": {
                     "type": "integer"
                 },
+                "minMonthsInSubscription": {
+                    "description": "the shortest subscription the plan can be bought for, MIN_MONTHS_IN_SUBSCRIPTION if not set",
+                    "type": "integer"
+                },
                 "name": {
                     "type": "string"
                 },
@@ -1959,6 +4848,10 @@ var doc = `{
                     "maxMetadataSizeInMB": {
                         "type": "integer"
                     },
+                    "minMonthsInSubscription": {
+                        "description": "the shortest subscription the plan can be bought for, MIN_MONTHS_IN_SUBSCRIPTION if not set",
+                        "type": "integer"
+                    },
                     "name": {
                         "type": "string"
                     },
@@ -0,0 +1,43 @@
+package jobs
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/utils"
+)
+
+type accountDeleter struct{}
+
+func (a accountDeleter) Name() string {
+	return "accountDeleter"
+}
+
+func (a accountDeleter) ScheduleInterval() string {
+	return "@every 10m"
+}
+
+func (a accountDeleter) Run() {
+	deletions, err := models.GetAccountDeletionsDue(time.Now())
+	if err != nil {
+		utils.LogIfError(err, nil)
+		return
+	}
+
+	for _, deletion := range deletions {
+		receipt, err := deletion.Execute()
+		if err != nil {
+			utils.LogIfError(err, map[string]interface{}{"accountID": deletion.AccountID})
+			continue
+		}
+		utils.SlackLog(fmt.Sprintf("deleted account %s at its owner's request:  %d completed files, %d uploads and %d metadatas",
+			receipt.AccountID, receipt.CompletedFilesDeleted, receipt.UploadsDeleted, receipt.MetadatasDeleted))
+	}
+
+	utils.LogIfError(models.DeleteExpiredAccountDeletionRequests(time.Now()), nil)
+}
+
+func (a accountDeleter) Runnable() bool {
+	return models.DB != nil
+}
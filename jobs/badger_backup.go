@@ -0,0 +1,36 @@
+package jobs
+
+import (
+	"fmt"
+
+	"github.com/opacity/storage-node/utils"
+)
+
+type badgerBackup struct{}
+
+func (b badgerBackup) Name() string {
+	return "badgerBackup"
+}
+
+func (b badgerBackup) ScheduleInterval() string {
+	return "@every 6h"
+}
+
+func (b badgerBackup) Run() {
+	utils.SlackLog("running " + b.Name())
+
+	// chains are no longer than the retention count, so pruning never breaks the newest one
+	backup, err := utils.CreateIncrementalBadgerBackup(utils.Env.BadgerBackupDir,
+		utils.Env.BadgerBackupRetentionCount)
+	if err != nil {
+		utils.LogIfError(err, map[string]interface{}{"path": backup.Path})
+		return
+	}
+
+	utils.SlackLog(fmt.Sprintf("%s wrote %s since version %d up to version %d", b.Name(), backup.Path,
+		backup.Since, backup.Version))
+}
+
+func (b badgerBackup) Runnable() bool {
+	return utils.GetBadgerDb() != nil
+}
@@ -0,0 +1,37 @@
+package jobs
+
+import (
+	"fmt"
+
+	"github.com/opacity/storage-node/utils"
+)
+
+type badgerMaintenance struct{}
+
+func (b badgerMaintenance) Name() string {
+	return "badgerMaintenance"
+}
+
+func (b badgerMaintenance) ScheduleInterval() string {
+	return "@every 1h"
+}
+
+func (b badgerMaintenance) Run() {
+	utils.SlackLog("running " + b.Name())
+
+	rewrites, err := utils.RunBadgerValueLogGC(utils.Env.BadgerGCDiscardRatio)
+	utils.LogIfError(err, map[string]interface{}{"discardRatio": utils.Env.BadgerGCDiscardRatio})
+
+	flattened, err := utils.FlattenBadgerIfNeeded(utils.Env.BadgerFlattenLevelThreshold)
+	utils.LogIfError(err, nil)
+
+	stats, err := utils.GetBadgerStats()
+	utils.LogIfError(err, nil)
+
+	utils.SlackLog(fmt.Sprintf("%s rewrote %d value log files, flattened: %t, LSM size: %d, value log size: %d",
+		b.Name(), rewrites, flattened, stats.LSMSize, stats.VlogSize))
+}
+
+func (b badgerMaintenance) Runnable() bool {
+	return utils.GetBadgerDb() != nil
+}
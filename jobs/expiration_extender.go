@@ -0,0 +1,79 @@
+package jobs
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/utils"
+)
+
+// how many metadatas or files each step of an expiration extension handles
+const expirationExtensionBatchSize = 500
+
+// how long finished expiration extensions are kept around for progress reporting
+const completedExpirationExtensionRetention = 30 * 24 * time.Hour
+
+// only metadata in an account's index is extended, so metadata nobody has touched since the index was added is
+// skipped and keeps its old TTL
+type expirationExtender struct{}
+
+func (e expirationExtender) Name() string {
+	return "expirationExtender"
+}
+
+func (e expirationExtender) ScheduleInterval() string {
+	return "@every 1m"
+}
+
+func (e expirationExtender) Run() {
+	extensions, err := models.GetIncompleteExpirationExtensions()
+	if err != nil {
+		utils.LogIfError(err, nil)
+		return
+	}
+	utils.Metrics_Pending_Expiration_Extensions.Set(float64(len(extensions)))
+
+	for _, extension := range extensions {
+		utils.LogIfError(runExpirationExtension(extension), map[string]interface{}{"accountID": extension.AccountID})
+	}
+
+	err = models.DeleteCompletedExpirationExtensionsOlderThan(time.Now().Add(-completedExpirationExtensionRetention))
+	utils.LogIfError(err, nil)
+
+	if extensions, err = models.GetIncompleteExpirationExtensions(); err == nil {
+		utils.Metrics_Pending_Expiration_Extensions.Set(float64(len(extensions)))
+	}
+}
+
+func (e expirationExtender) Runnable() bool {
+	return models.DB != nil
+}
+
+/*runExpirationExtension runs an expiration extension to the end.  Progress is saved after every batch, so if
+the node stops partway the next run picks up from the last batch.*/
+func runExpirationExtension(extension models.ExpirationExtension) error {
+	metadatasExtended := extension.MetadatasExtended
+	filesExtended := extension.FilesExtended
+	for {
+		done, err := extension.Run(expirationExtensionBatchSize)
+		if extension.MetadatasExtended < metadatasExtended || extension.FilesExtended < filesExtended {
+			// queued again with a new date, which starts the counts over
+			metadatasExtended, filesExtended = 0, 0
+		}
+		utils.Metrics_Expiration_Extension_Items_Counter.WithLabelValues("metadata").
+			Add(float64(extension.MetadatasExtended - metadatasExtended))
+		utils.Metrics_Expiration_Extension_Items_Counter.WithLabelValues("file").
+			Add(float64(extension.FilesExtended - filesExtended))
+		metadatasExtended, filesExtended = extension.MetadatasExtended, extension.FilesExtended
+
+		if err != nil {
+			return err
+		}
+		if done {
+			utils.SlackLog(fmt.Sprintf("extended expiration of account %s to %s: %d metadatas and %d files",
+				extension.AccountID, extension.ExpiredAt, metadatasExtended, filesExtended))
+			return nil
+		}
+	}
+}
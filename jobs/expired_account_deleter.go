@@ -21,7 +21,8 @@ func (e expiredAccountDeleter) ScheduleInterval() string {
 func (e expiredAccountDeleter) Run() {
 	utils.SlackLog("running " + e.Name())
 
-	models.DeleteExpiredAccounts(time.Now().Add(-24 * time.Hour * 60))
+	// accounts are purged once their grace period and suspension are over
+	models.DeleteExpiredAccounts(models.AccountPurgeCutoff(time.Now()))
 }
 
 func (e expiredAccountDeleter) Runnable() bool {
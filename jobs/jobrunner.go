@@ -37,7 +37,9 @@ func StartupJobs() {
 		}
 	}
 
-	// Run metric collector immediately upon startup so we don't have to wait 24 hours everytime we deploy
+	// Sample usage and run metric collector immediately upon startup so we don't have to wait 24 hours everytime
+	// we deploy
+	usageSampler{}.Run()
 	// TODO:  change BackgroundRunnable job's Run() methods to also return an error, so that we can have jobs
 	// that we run both at startup and on a schedule
 	metricCollector{}.Run()
@@ -57,6 +59,13 @@ func ScheduleBackgroundJobs() {
 		upgradeDeleter{},
 		renewalDeleter{},
 		expiredAccountDeleter{},
+		accountDeleter{},
+		kvPairCleaner{},
+		badgerBackup{},
+		badgerMaintenance{},
+		expirationExtender{},
+		keyRotator{},
+		usageSampler{},
 	}
 
 	for _, s := range jobs {
@@ -0,0 +1,58 @@
+package jobs
+
+import (
+	"fmt"
+
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/utils"
+)
+
+// how many metadatas or files each step of a key rotation handles
+const keyRotationBatchSize = 500
+
+type keyRotator struct{}
+
+func (e keyRotator) Name() string {
+	return "keyRotator"
+}
+
+func (e keyRotator) ScheduleInterval() string {
+	return "@every 1m"
+}
+
+func (e keyRotator) Run() {
+	rotations, err := models.GetIncompleteKeyRotations()
+	if err != nil {
+		utils.LogIfError(err, nil)
+		return
+	}
+	utils.Metrics_Pending_Key_Rotations.Set(float64(len(rotations)))
+
+	for _, rotation := range rotations {
+		utils.LogIfError(runKeyRotation(rotation), map[string]interface{}{"newAccountID": rotation.NewAccountID})
+	}
+
+	if rotations, err = models.GetIncompleteKeyRotations(); err == nil {
+		utils.Metrics_Pending_Key_Rotations.Set(float64(len(rotations)))
+	}
+}
+
+func (e keyRotator) Runnable() bool {
+	return models.DB != nil
+}
+
+/*runKeyRotation re-keys an account's metadatas and files to the end.  Progress is saved after every batch, so
+if the node stops partway the next run picks up from the last batch.*/
+func runKeyRotation(rotation models.KeyRotation) error {
+	for {
+		done, err := rotation.Run(keyRotationBatchSize)
+		if err != nil {
+			return err
+		}
+		if done {
+			utils.SlackLog(fmt.Sprintf("rotated account %s to %s: %d metadatas and %d files", rotation.OldAccountID,
+				rotation.NewAccountID, rotation.MetadatasRekeyed, rotation.FilesRekeyed))
+			return nil
+		}
+	}
+}
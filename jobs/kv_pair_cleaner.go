@@ -0,0 +1,28 @@
+package jobs
+
+import (
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/utils"
+)
+
+type kvPairCleaner struct{}
+
+func (k kvPairCleaner) Name() string {
+	return "kvPairCleaner"
+}
+
+func (k kvPairCleaner) ScheduleInterval() string {
+	return "@midnight"
+}
+
+func (k kvPairCleaner) Run() {
+	utils.SlackLog("running " + k.Name())
+
+	err := models.PurgeExpiredKVPairs()
+
+	utils.LogIfError(err, nil)
+}
+
+func (k kvPairCleaner) Runnable() bool {
+	return models.DB != nil && utils.Env.KvStoreBackend == utils.KvStoreBackendSQL
+}
@@ -18,22 +18,28 @@ func (m metricCollector) ScheduleInterval() string {
 func (m metricCollector) Run() {
 	utils.SlackLog("running " + m.Name())
 
-	m.spaceUsageMetrics()
+	// space and file metrics come from the last day accounts were sampled, rather than adding up the accounts and
+	// files tables every hour
+	total, byPlan, err := models.GetLatestUsageReports()
+	utils.LogIfError(err, nil)
+	if err == nil {
+		m.spaceUsageMetrics(total, byPlan)
+		m.fileMetrics(total)
+	}
 	m.accountsMetrics()
-	m.fileMetrics()
 }
 
 func (m metricCollector) Runnable() bool {
 	return models.DB != nil
 }
 
-func (m metricCollector) spaceUsageMetrics() {
-	spaceReport := models.CreateSpaceUsedReport()
+func (m metricCollector) spaceUsageMetrics(total models.UsageReport,
+	byPlan map[models.StorageLimitType]models.UsageReport) {
+	utils.Metrics_Percent_Of_Space_Used_Map[utils.TotalLbl].Set(models.CalculatePercentSpaceUsed(total.SpaceReport))
 
-	utils.Metrics_Percent_Of_Space_Used_Map[utils.TotalLbl].Set(models.CalculatePercentSpaceUsed(spaceReport))
-
-	for _, plan := range utils.Env.Plans {
-		spaceReport := models.CreateSpaceUsedReportForPlanType(models.StorageLimitType(plan.StorageInGB))
+	for _, plan := range models.GetAllPlans() {
+		utils.AddPlanMetrics(plan.Name)
+		spaceReport := byPlan[models.StorageLimitType(plan.StorageInGB)].SpaceReport
 
 		utils.Metrics_Percent_Of_Space_Used_Map[plan.Name].Set(models.CalculatePercentSpaceUsed(spaceReport))
 	}
@@ -79,8 +85,9 @@ func (m metricCollector) accountsMetrics() {
 		utils.Metrics_Percent_Of_Paid_Accounts_Collected.Set(float64(percentOfPaidAccountsCollected))
 	}
 
-	for _, plan := range utils.Env.Plans {
+	for _, plan := range models.GetAllPlans() {
 		name := plan.Name
+		utils.AddPlanMetrics(name)
 		accountCount, err := models.CountPaidAccountsByPlanType(models.StorageLimitType(plan.StorageInGB))
 		if err == nil {
 			utils.Metrics_Total_Paid_Accounts_Map[name].Set(float64(accountCount))
@@ -95,17 +102,7 @@ func (m metricCollector) accountsMetrics() {
 	}
 }
 
-func (m metricCollector) fileMetrics() {
-	completedFileInSQLCount := 0
-	err := models.DB.Model(&models.CompletedFile{}).Count(&completedFileInSQLCount).Error
-	utils.LogIfError(err, nil)
-	if err == nil {
-		utils.Metrics_Completed_Files_Count_SQL.Set(float64(completedFileInSQLCount))
-	}
-
-	fileSizeInByteInSQL, err := models.GetTotalFileSizeInByte()
-	utils.LogIfError(err, nil)
-	if err == nil {
-		utils.Metrics_Uploaded_File_Size_MB_SQL.Set(float64(fileSizeInByteInSQL) / 1000000.0)
-	}
+func (m metricCollector) fileMetrics(total models.UsageReport) {
+	utils.Metrics_Completed_Files_Count_SQL.Set(float64(total.FileCount))
+	utils.Metrics_Uploaded_File_Size_MB_SQL.Set(total.SpaceUsedSum / 1000000.0)
 }
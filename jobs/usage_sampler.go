@@ -0,0 +1,32 @@
+package jobs
+
+import (
+	"time"
+
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/utils"
+)
+
+type usageSampler struct{}
+
+func (u usageSampler) Name() string {
+	return "usageSampler"
+}
+
+// each day is only sampled once, and a run on a day that's already sampled returns before loading any accounts,
+// so running hourly just makes sure a restart around midnight doesn't skip a day
+func (u usageSampler) ScheduleInterval() string {
+	return "@every 1h"
+}
+
+func (u usageSampler) Run() {
+	utils.SlackLog("running " + u.Name())
+
+	err := models.SampleUsage(time.Now())
+
+	utils.LogIfError(err, nil)
+}
+
+func (u usageSampler) Runnable() bool {
+	return models.DB != nil
+}
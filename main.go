@@ -3,6 +3,7 @@ package main
 import (
 	"bytes"
 	"fmt"
+	"os"
 	"runtime/debug"
 	"strings"
 
@@ -18,6 +19,10 @@ func main() {
 	defer models.Close()
 
 	utils.SetLive()
+	if runCommand(os.Args[1:]) {
+		return
+	}
+
 	services.SetWallet()
 	err := services.InitStripe()
 	utils.PanicOnError(err)
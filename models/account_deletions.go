@@ -0,0 +1,309 @@
+package models
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/opacity/storage-node/services"
+	"github.com/opacity/storage-node/utils"
+)
+
+/*AccountDeletion is an owner's request to delete their account and everything it stores.  It only goes ahead
+once the owner confirms it with the code they got when requesting it, and then at ExecuteAt, so a delayed
+deletion can still be cancelled.*/
+type AccountDeletion struct {
+	AccountID        string                    `gorm:"primary_key;type:varchar(64)" json:"accountID" binding:"required,len=64"`
+	CreatedAt        time.Time                 `json:"createdAt"`
+	UpdatedAt        time.Time                 `json:"updatedAt"`
+	ConfirmationHash string                    `json:"-" binding:"required,len=64"` // hash of the code the owner confirms with
+	ConfirmBy        time.Time                 `json:"confirmBy" binding:"required"`
+	ExecuteAt        time.Time                 `json:"executeAt" binding:"required"`
+	Status           AccountDeletionStatusType `json:"status" binding:"required,gte=1"`
+}
+
+/*AccountDeletionStatusType defines a type for the account deletion statuses*/
+type AccountDeletionStatusType int
+
+const (
+	/*AccountDeletionRequested - the owner asked to delete the account but hasn't confirmed it yet*/
+	AccountDeletionRequested AccountDeletionStatusType = iota + 1
+
+	/*AccountDeletionConfirmed - the owner confirmed, and the account is deleted at ExecuteAt*/
+	AccountDeletionConfirmed
+)
+
+/*AccountDeletionStatusMap is for pretty printing the AccountDeletionStatus*/
+var AccountDeletionStatusMap = map[AccountDeletionStatusType]string{
+	AccountDeletionRequested: "requested",
+	AccountDeletionConfirmed: "confirmed",
+}
+
+/*AccountDeletionReceipt records that an account was deleted, signed by the node's main wallet so the owner can
+prove it.  It is kept after the account is gone.*/
+type AccountDeletionReceipt struct {
+	AccountID             string    `gorm:"primary_key;type:varchar(64)" json:"accountID" binding:"required,len=64"`
+	RequestedAt           time.Time `json:"requestedAt" binding:"required"`
+	DeletedAt             time.Time `json:"deletedAt" binding:"required"`
+	CompletedFilesDeleted int       `json:"completedFilesDeleted" binding:"omitempty,gte=0"`
+	UploadsDeleted        int       `json:"uploadsDeleted" binding:"omitempty,gte=0"`
+	MetadatasDeleted      int       `json:"metadatasDeleted" binding:"omitempty,gte=0"`
+	SignerAddress         string    `json:"signerAddress" binding:"required,len=42"`
+	Signature             string    `json:"signature" binding:"required,len=130"` // the node's signature over Digest()
+}
+
+// how long the owner has to confirm a deletion, and the longest a confirmed deletion can be put off
+const (
+	AccountDeletionConfirmationWindow = time.Hour
+	MaxAccountDeletionDelay           = 30 * 24 * time.Hour
+)
+
+const accountDeletionCodeLength = 16
+
+/*ErrAccountDeletionCodeInvalid is returned when confirming a deletion with the wrong code*/
+var ErrAccountDeletionCodeInvalid = errors.New("the confirmation code does not match the deletion request")
+
+/*ErrAccountDeletionConfirmationExpired is returned when confirming a deletion after its ConfirmBy*/
+var ErrAccountDeletionConfirmationExpired = errors.New("the deletion request expired, request it again")
+
+/*ErrAccountDeletionDelay is returned for a delay longer than MaxAccountDeletionDelay*/
+var ErrAccountDeletionDelay = fmt.Errorf("a deletion can be delayed by at most %v", MaxAccountDeletionDelay)
+
+/*BeforeCreate - callback called before the row is created*/
+func (deletion *AccountDeletion) BeforeCreate(scope *gorm.Scope) error {
+	return utils.Validator.Struct(deletion)
+}
+
+/*BeforeUpdate - callback called before the row is updated*/
+func (deletion *AccountDeletion) BeforeUpdate(scope *gorm.Scope) error {
+	return utils.Validator.Struct(deletion)
+}
+
+/*BeforeCreate - callback called before the row is created*/
+func (receipt *AccountDeletionReceipt) BeforeCreate(scope *gorm.Scope) error {
+	return utils.Validator.Struct(receipt)
+}
+
+/*RequestAccountDeletion records a request to delete the account delay after it is confirmed, replacing a request
+the owner already made.  It returns the code the owner must confirm the request with.*/
+func RequestAccountDeletion(accountID string, delay time.Duration) (AccountDeletion, string, error) {
+	if delay < 0 || delay > MaxAccountDeletionDelay {
+		return AccountDeletion{}, "", ErrAccountDeletionDelay
+	}
+	code := utils.RandHexString(accountDeletionCodeLength)
+	deletion := AccountDeletion{
+		AccountID:        accountID,
+		ConfirmationHash: accountDeletionCodeHash(code),
+		ConfirmBy:        time.Now().Add(AccountDeletionConfirmationWindow),
+		// until the owner confirms this only records the delay
+		ExecuteAt: time.Now().Add(delay),
+		Status:    AccountDeletionRequested,
+	}
+
+	if err := DB.Where("account_id = ?", accountID).Delete(&AccountDeletion{}).Error; err != nil {
+		return deletion, "", err
+	}
+	return deletion, code, DB.Create(&deletion).Error
+}
+
+/*ConfirmAccountDeletion confirms the owner's deletion request with code.  The delay the owner asked for starts
+now.  It returns gorm.ErrRecordNotFound if the owner didn't request a deletion.*/
+func ConfirmAccountDeletion(accountID string, code string) (AccountDeletion, error) {
+	deletion, err := GetAccountDeletion(accountID)
+	if err != nil {
+		return deletion, err
+	}
+	if deletion.ConfirmationHash != accountDeletionCodeHash(code) {
+		return deletion, ErrAccountDeletionCodeInvalid
+	}
+	if deletion.Status == AccountDeletionConfirmed {
+		return deletion, nil
+	}
+	if time.Now().After(deletion.ConfirmBy) {
+		return deletion, ErrAccountDeletionConfirmationExpired
+	}
+
+	delay := deletion.ExecuteAt.Sub(deletion.CreatedAt)
+	if delay < 0 {
+		delay = 0
+	}
+	deletion.ExecuteAt = time.Now().Add(delay)
+	deletion.Status = AccountDeletionConfirmed
+	return deletion, DB.Save(&deletion).Error
+}
+
+/*GetAccountDeletion returns the deletion the owner requested*/
+func GetAccountDeletion(accountID string) (AccountDeletion, error) {
+	deletion := AccountDeletion{}
+	err := DB.Where("account_id = ?", accountID).First(&deletion).Error
+	return deletion, err
+}
+
+/*CancelAccountDeletion cancels the deletion the owner requested.  It returns gorm.ErrRecordNotFound if there
+was none.*/
+func CancelAccountDeletion(accountID string) error {
+	db := DB.Where("account_id = ?", accountID).Delete(&AccountDeletion{})
+	if db.Error != nil {
+		return db.Error
+	}
+	if db.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+/*GetAccountDeletionsDue returns the confirmed deletions whose ExecuteAt has passed at t*/
+func GetAccountDeletionsDue(t time.Time) ([]AccountDeletion, error) {
+	var deletions []AccountDeletion
+	err := DB.Where("status = ? AND execute_at <= ?", AccountDeletionConfirmed, t).Find(&deletions).Error
+	return deletions, err
+}
+
+/*DeleteExpiredAccountDeletionRequests deletes the requests no one confirmed in time*/
+func DeleteExpiredAccountDeletionRequests(t time.Time) error {
+	return DB.Where("status = ? AND confirm_by < ?", AccountDeletionRequested, t).
+		Delete(&AccountDeletion{}).Error
+}
+
+/*GetAccountDeletionReceipt returns the receipt for a deleted account*/
+func GetAccountDeletionReceipt(accountID string) (AccountDeletionReceipt, error) {
+	receipt := AccountDeletionReceipt{}
+	err := DB.Where("account_id = ?", accountID).First(&receipt).Error
+	return receipt, err
+}
+
+/*Execute deletes the account along with its completed files, uploads in progress, metadata with its history and
+permission hashes, upgrades, renewals, stripe payments and usage history, and returns the signed receipt.
+Completed files uploaded before they recorded their account can't be found, and expire with the account as before.
+Neither can metadata that hasn't been set or read since the per account index was added, which isn't counted in
+the receipt's MetadatasDeleted either.*/
+func (deletion *AccountDeletion) Execute() (AccountDeletionReceipt, error) {
+	receipt := AccountDeletionReceipt{AccountID: deletion.AccountID, RequestedAt: deletion.CreatedAt}
+	if services.MainWalletPrivateKey == nil {
+		return receipt, errors.New("the node's main wallet is needed to sign deletion receipts")
+	}
+	account, err := GetAccountById(deletion.AccountID)
+	if err != nil {
+		return receipt, err
+	}
+
+	if receipt.CompletedFilesDeleted, err = deleteAccountCompletedFiles(account.AccountID); err != nil {
+		return receipt, err
+	}
+	if receipt.UploadsDeleted, err = deleteAccountUploads(account.AccountID); err != nil {
+		return receipt, err
+	}
+	if receipt.MetadatasDeleted, err = deleteAccountMetadatas(account.AccountID); err != nil {
+		return receipt, err
+	}
+	if err := utils.ReturnFirstError([]error{
+		releaseUnpaidCouponRedemptions(&Upgrade{}, "account_id = ?", account.AccountID),
+		releaseUnpaidCouponRedemptions(&Renewal{}, "account_id = ?", account.AccountID),
+		leaveOrganization(account.AccountID),
+		DB.Where("account_id = ?", account.AccountID).Delete(&Upgrade{}).Error,
+		DB.Where("account_id = ?", account.AccountID).Delete(&Renewal{}).Error,
+		DB.Where("account_id = ?", account.AccountID).Delete(&StripePayment{}).Error,
+		DB.Where("account_id = ?", account.AccountID).Delete(&ExpirationExtension{}).Error,
+		DB.Where("account_id = ?", account.AccountID).Delete(&UsageSample{}).Error,
+	}); err != nil {
+		return receipt, err
+	}
+	if err := DB.Delete(&account).Error; err != nil {
+		return receipt, err
+	}
+	if account.PaymentStatus == InitialPaymentInProgress {
+		ReleaseCouponRedemption(account.CouponID)
+	}
+
+	receipt.DeletedAt = time.Now()
+	if err := receipt.sign(); err != nil {
+		return receipt, err
+	}
+	if err := DB.Save(&receipt).Error; err != nil {
+		return receipt, err
+	}
+	return receipt, DB.Delete(deletion).Error
+}
+
+/*Digest returns what the receipt's signature covers:  the keccak256 hash of the account ID, the unix times it was
+requested and deleted at and the number of completed files, uploads and metadatas deleted, joined by newlines*/
+func (receipt AccountDeletionReceipt) Digest() []byte {
+	return utils.Hash([]byte(fmt.Sprintf("%s\n%d\n%d\n%d\n%d\n%d", receipt.AccountID, receipt.RequestedAt.Unix(),
+		receipt.DeletedAt.Unix(), receipt.CompletedFilesDeleted, receipt.UploadsDeleted, receipt.MetadatasDeleted)))
+}
+
+func (receipt *AccountDeletionReceipt) sign() error {
+	// the database keeps whole seconds, so the receipt is signed as it will be read back
+	receipt.RequestedAt = receipt.RequestedAt.Truncate(time.Second)
+	receipt.DeletedAt = receipt.DeletedAt.Truncate(time.Second)
+	signature, err := utils.Sign(receipt.Digest(), services.MainWalletPrivateKey)
+	if err != nil {
+		return err
+	}
+	receipt.SignerAddress = services.MainWalletAddress.Hex()
+	receipt.Signature = hex.EncodeToString(signature)
+	return nil
+}
+
+func deleteAccountCompletedFiles(accountID string) (int, error) {
+	var fileIDs []string
+	if err := DB.Model(&CompletedFile{}).Where("account_id = ?", accountID).Pluck("file_id", &fileIDs).Error; err != nil {
+		return 0, err
+	}
+	for _, fileID := range fileIDs {
+		if err := utils.DeleteDefaultBucketObjectKeys(fileID); err != nil {
+			return 0, err
+		}
+	}
+	if len(fileIDs) == 0 {
+		return 0, nil
+	}
+	return len(fileIDs), DeleteAllCompletedFiles(fileIDs)
+}
+
+func deleteAccountUploads(accountID string) (int, error) {
+	var files []File
+	if err := DB.Where("account_id = ?", accountID).Find(&files).Error; err != nil {
+		return 0, err
+	}
+	for _, file := range files {
+		if file.AwsObjectKey != nil && file.AwsUploadID != nil {
+			utils.LogIfError(utils.AbortMultiPartUpload(*file.AwsObjectKey, *file.AwsUploadID),
+				map[string]interface{}{"fileID": file.FileID})
+		}
+		if err := DB.Where("file_id = ?", file.FileID).Delete(&CompletedUploadIndex{}).Error; err != nil {
+			return 0, err
+		}
+		if err := DB.Delete(&file).Error; err != nil {
+			return 0, err
+		}
+	}
+	return len(files), nil
+}
+
+func deleteAccountMetadatas(accountID string) (int, error) {
+	metadataKeys, err := GetMetadataKeysByAccountID(accountID)
+	if err != nil {
+		return 0, err
+	}
+	var kvKeys utils.KVKeys
+	for _, metadataKey := range metadataKeys {
+		kvKeys = append(kvKeys, metadataKey, metadataKey+metadataPermissionHashKeySuffix)
+		for i := 0; i < numMetadataVersionKeys; i++ {
+			kvKeys = append(kvKeys, metadataKey+"_"+strconv.Itoa(i))
+		}
+	}
+	if len(kvKeys) > 0 {
+		if err := utils.BatchDelete(&kvKeys); err != nil {
+			return 0, err
+		}
+	}
+	return len(metadataKeys), DeleteAccountMetadataKeys(accountID)
+}
+
+func accountDeletionCodeHash(code string) string {
+	return hex.EncodeToString(utils.Hash([]byte(code)))
+}
@@ -0,0 +1,151 @@
+package models
+
+import (
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/opacity/storage-node/services"
+	"github.com/opacity/storage-node/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Init_Account_Deletions(t *testing.T) {
+	utils.SetTesting("../.env")
+	Connect(utils.Env.TestDatabaseURL)
+}
+
+func setMainWalletForDeletionTest(t *testing.T) func() {
+	oldKey, oldAddress := services.MainWalletPrivateKey, services.MainWalletAddress
+	privateKey, err := utils.GenerateKey()
+	assert.Nil(t, err)
+	services.MainWalletPrivateKey = privateKey
+	services.MainWalletAddress = utils.PubkeyToAddress(privateKey.PublicKey)
+	return func() {
+		services.MainWalletPrivateKey, services.MainWalletAddress = oldKey, oldAddress
+	}
+}
+
+func Test_AccountDeletionReceipt_Signature_Recovers_Signer(t *testing.T) {
+	defer setMainWalletForDeletionTest(t)()
+
+	receipt := AccountDeletionReceipt{
+		AccountID:             utils.GenerateFileHandle(),
+		RequestedAt:           time.Now().Add(-time.Hour),
+		DeletedAt:             time.Now(),
+		CompletedFilesDeleted: 3,
+		UploadsDeleted:        1,
+		MetadatasDeleted:      2,
+	}
+	assert.Nil(t, receipt.sign())
+	assert.Equal(t, services.MainWalletAddress.Hex(), receipt.SignerAddress)
+
+	signature, err := hex.DecodeString(receipt.Signature)
+	assert.Nil(t, err)
+	publicKey, err := utils.Recover(receipt.Digest(), signature)
+	assert.Nil(t, err)
+	assert.Equal(t, services.MainWalletAddress, utils.PubkeyToAddress(*publicKey))
+
+	receipt.MetadatasDeleted++
+	publicKey, err = utils.Recover(receipt.Digest(), signature)
+	assert.Nil(t, err)
+	assert.NotEqual(t, services.MainWalletAddress, utils.PubkeyToAddress(*publicKey))
+}
+
+func Test_RequestAccountDeletion_Rejects_Long_Delay(t *testing.T) {
+	_, _, err := RequestAccountDeletion(utils.GenerateFileHandle(), MaxAccountDeletionDelay+time.Hour)
+	assert.Equal(t, ErrAccountDeletionDelay, err)
+}
+
+func Test_ConfirmAccountDeletion(t *testing.T) {
+	DeleteAccountDeletionsForTest(t)
+	accountID := utils.GenerateFileHandle()
+
+	_, code, err := RequestAccountDeletion(accountID, 24*time.Hour)
+	assert.Nil(t, err)
+
+	_, err = ConfirmAccountDeletion(accountID, "wrong")
+	assert.Equal(t, ErrAccountDeletionCodeInvalid, err)
+
+	deletion, err := ConfirmAccountDeletion(accountID, code)
+	assert.Nil(t, err)
+	assert.Equal(t, AccountDeletionConfirmed, deletion.Status)
+	assert.True(t, deletion.ExecuteAt.After(time.Now().Add(23*time.Hour)))
+
+	due, err := GetAccountDeletionsDue(time.Now())
+	assert.Nil(t, err)
+	assert.Len(t, due, 0)
+	due, err = GetAccountDeletionsDue(time.Now().Add(25 * time.Hour))
+	assert.Nil(t, err)
+	assert.Len(t, due, 1)
+}
+
+func Test_ConfirmAccountDeletion_After_ConfirmBy(t *testing.T) {
+	DeleteAccountDeletionsForTest(t)
+	accountID := utils.GenerateFileHandle()
+
+	deletion, code, err := RequestAccountDeletion(accountID, 0)
+	assert.Nil(t, err)
+	deletion.ConfirmBy = time.Now().Add(-time.Minute)
+	assert.Nil(t, DB.Save(&deletion).Error)
+
+	_, err = ConfirmAccountDeletion(accountID, code)
+	assert.Equal(t, ErrAccountDeletionConfirmationExpired, err)
+
+	assert.Nil(t, DeleteExpiredAccountDeletionRequests(time.Now()))
+	_, err = GetAccountDeletion(accountID)
+	assert.True(t, gorm.IsRecordNotFoundError(err))
+}
+
+func Test_CancelAccountDeletion(t *testing.T) {
+	DeleteAccountDeletionsForTest(t)
+	accountID := utils.GenerateFileHandle()
+
+	assert.True(t, gorm.IsRecordNotFoundError(CancelAccountDeletion(accountID)))
+
+	_, _, err := RequestAccountDeletion(accountID, 0)
+	assert.Nil(t, err)
+	assert.Nil(t, CancelAccountDeletion(accountID))
+
+	_, err = GetAccountDeletion(accountID)
+	assert.True(t, gorm.IsRecordNotFoundError(err))
+}
+
+func Test_AccountDeletion_Execute(t *testing.T) {
+	defer setMainWalletForDeletionTest(t)()
+	DeleteAccountsForTest(t)
+	DeleteAccountDeletionsForTest(t)
+	DeleteUpgradesForTest(t)
+	DeleteRenewalsForTest(t)
+	DeleteUsageSamplesForTest(t)
+
+	upgrade, account := returnValidUpgrade()
+	assert.Nil(t, DB.Create(&upgrade).Error)
+	assert.Nil(t, DB.Create(&UsageSample{AccountID: account.AccountID, SampledOn: time.Now()}).Error)
+
+	_, code, err := RequestAccountDeletion(account.AccountID, 0)
+	assert.Nil(t, err)
+	deletion, err := ConfirmAccountDeletion(account.AccountID, code)
+	assert.Nil(t, err)
+
+	receipt, err := deletion.Execute()
+	assert.Nil(t, err)
+	assert.Equal(t, account.AccountID, receipt.AccountID)
+
+	_, err = GetAccountById(account.AccountID)
+	assert.True(t, gorm.IsRecordNotFoundError(err))
+	_, err = GetAccountDeletion(account.AccountID)
+	assert.True(t, gorm.IsRecordNotFoundError(err))
+	upgrades := []Upgrade{}
+	assert.Nil(t, DB.Where("account_id = ?", account.AccountID).Find(&upgrades).Error)
+	assert.Len(t, upgrades, 0)
+	samples, err := GetUsageSamples(account.AccountID, time.Now().AddDate(-1, 0, 0), time.Now())
+	assert.Nil(t, err)
+	assert.Len(t, samples, 0)
+
+	stored, err := GetAccountDeletionReceipt(account.AccountID)
+	assert.Nil(t, err)
+	assert.Equal(t, receipt.Signature, stored.Signature)
+	assert.Equal(t, receipt.Digest(), stored.Digest())
+}
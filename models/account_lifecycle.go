@@ -0,0 +1,83 @@
+package models
+
+import (
+	"time"
+
+	"github.com/opacity/storage-node/utils"
+)
+
+/*AccountState is where an account is in its lifecycle.  Each state allows less than the one before it.*/
+type AccountState int
+
+const (
+	/*AccountStateActive is an account whose subscription hasn't run out, which can do everything*/
+	AccountStateActive AccountState = iota + 1
+
+	/*AccountStateGrace is an expired account that is read only:  its metadata and files can be read but not
+	changed, and it can still be renewed*/
+	AccountStateGrace
+
+	/*AccountStateSuspended is an expired account past its grace period, which can only be renewed*/
+	AccountStateSuspended
+
+	/*AccountStatePurged is an expired account past its suspension, which the expired account deleter deletes
+	along with its files and metadata*/
+	AccountStatePurged
+)
+
+/*AccountStateMap is the name of each state, as account-data returns it*/
+var AccountStateMap = make(map[AccountState]string)
+
+func init() {
+	AccountStateMap[AccountStateActive] = "active"
+	AccountStateMap[AccountStateGrace] = "grace"
+	AccountStateMap[AccountStateSuspended] = "suspended"
+	AccountStateMap[AccountStatePurged] = "purged"
+}
+
+/*AccountGracePeriod returns how long an expired account stays read only*/
+func AccountGracePeriod() time.Duration {
+	return lifecycleDays(utils.Env.AccountGracePeriodDays)
+}
+
+/*AccountSuspensionPeriod returns how long an account is suspended after its grace period, before it is purged*/
+func AccountSuspensionPeriod() time.Duration {
+	return lifecycleDays(utils.Env.AccountSuspensionDays)
+}
+
+/*AccountPurgeCutoff returns the expiration date before which accounts are purged at t*/
+func AccountPurgeCutoff(t time.Time) time.Time {
+	return t.Add(-AccountGracePeriod() - AccountSuspensionPeriod())
+}
+
+/*State returns where the account is in its lifecycle now*/
+func (account *Account) State() AccountState {
+	return account.StateAt(time.Now())
+}
+
+/*StateAt returns where the account is in its lifecycle at t.  Accounts on the free plan never expire.*/
+func (account *Account) StateAt(t time.Time) AccountState {
+	if account.Plan().Name == "Free" {
+		return AccountStateActive
+	}
+
+	// the same date ExpirationDate returns, without saving it
+	expiredAt := account.CreatedAt.AddDate(0, account.MonthsInSubscription, 0)
+	graceEndsAt := expiredAt.Add(AccountGracePeriod())
+	switch {
+	case t.Before(expiredAt):
+		return AccountStateActive
+	case t.Before(graceEndsAt):
+		return AccountStateGrace
+	case t.Before(graceEndsAt.Add(AccountSuspensionPeriod())):
+		return AccountStateSuspended
+	}
+	return AccountStatePurged
+}
+
+func lifecycleDays(days int) time.Duration {
+	if days < 0 {
+		days = 0
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/opacity/storage-node/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Init_Account_Lifecycle(t *testing.T) {
+	utils.SetTesting("../.env")
+	Connect(utils.Env.TestDatabaseURL)
+}
+
+func Test_Account_StateAt(t *testing.T) {
+	account := returnValidAccount()
+	account.CreatedAt = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	expiredAt := account.CreatedAt.AddDate(0, account.MonthsInSubscription, 0)
+	day := 24 * time.Hour
+
+	tests := []struct {
+		name  string
+		at    time.Time
+		state AccountState
+	}{
+		{"before it expires", expiredAt.Add(-time.Second), AccountStateActive},
+		{"when it expires", expiredAt, AccountStateGrace},
+		{"at the end of the grace period", expiredAt.Add(30*day - time.Second), AccountStateGrace},
+		{"after the grace period", expiredAt.Add(30 * day), AccountStateSuspended},
+		{"at the end of the suspension", expiredAt.Add(60*day - time.Second), AccountStateSuspended},
+		{"after the suspension", expiredAt.Add(60 * day), AccountStatePurged},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.state, account.StateAt(tt.at), tt.name)
+	}
+}
+
+func Test_Account_StateAt_Uses_Configured_Periods(t *testing.T) {
+	defer func() {
+		utils.Env.AccountGracePeriodDays = 30
+		utils.Env.AccountSuspensionDays = 30
+	}()
+	utils.Env.AccountGracePeriodDays = 0
+	utils.Env.AccountSuspensionDays = 7
+
+	account := returnValidAccount()
+	account.CreatedAt = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	expiredAt := account.CreatedAt.AddDate(0, account.MonthsInSubscription, 0)
+
+	assert.Equal(t, AccountStateSuspended, account.StateAt(expiredAt))
+	assert.Equal(t, AccountStatePurged, account.StateAt(expiredAt.Add(7*24*time.Hour)))
+	assert.Equal(t, expiredAt, AccountPurgeCutoff(expiredAt.Add(7*24*time.Hour)))
+}
+
+func Test_Free_Account_Stays_Active(t *testing.T) {
+	account := returnValidAccount()
+	account.StorageLimit = 10
+	account.CreatedAt = time.Now().AddDate(-5, 0, 0)
+
+	assert.Equal(t, AccountStateActive, account.State())
+}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/opacity/storage-node/utils"
+)
+
+/*AccountMetadataKey indexes which metadata keys in the K:V store belong to which account, since the keys
+themselves are not namespaced by account*/
+type AccountMetadataKey struct {
+	AccountID   string    `gorm:"primary_key;type:varchar(64)" json:"accountID" binding:"required,len=64"`
+	MetadataKey string    `gorm:"primary_key;type:varchar(64)" json:"metadataKey" binding:"required,len=64"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+/*BeforeCreate - callback called before the row is created*/
+func (accountMetadataKey *AccountMetadataKey) BeforeCreate(scope *gorm.Scope) error {
+	return utils.Validator.Struct(accountMetadataKey)
+}
+
+/*AddAccountMetadataKey indexes a metadata key under an account.  Adding a key that is already indexed is
+not an error.*/
+func AddAccountMetadataKey(accountID string, metadataKey string) error {
+	accountMetadataKey := AccountMetadataKey{AccountID: accountID, MetadataKey: metadataKey}
+	if err := utils.Validator.Struct(accountMetadataKey); err != nil {
+		return err
+	}
+	return DB.Exec("INSERT IGNORE INTO account_metadata_keys (account_id, metadata_key, created_at) VALUES (?, ?, ?)",
+		accountID, metadataKey, time.Now()).Error
+}
+
+/*RemoveAccountMetadataKey removes a metadata key from an account's index*/
+func RemoveAccountMetadataKey(accountID string, metadataKey string) error {
+	return DB.Where("account_id = ? AND metadata_key = ?", accountID, metadataKey).
+		Delete(&AccountMetadataKey{}).Error
+}
+
+/*GetMetadataKeysByAccountID returns every metadata key indexed under an account, oldest first*/
+func GetMetadataKeysByAccountID(accountID string) ([]string, error) {
+	var metadataKeys []string
+	err := DB.Model(&AccountMetadataKey{}).Where("account_id = ?", accountID).
+		Order("created_at asc, metadata_key asc").Pluck("metadata_key", &metadataKeys).Error
+	return metadataKeys, err
+}
+
+/*DeleteAccountMetadataKeys removes an account's whole index*/
+func DeleteAccountMetadataKeys(accountID string) error {
+	return DB.Where("account_id = ?", accountID).Delete(&AccountMetadataKey{}).Error
+}
+
+/*CountMetadataKeysByAccountID returns how many metadata keys are indexed under an account*/
+func CountMetadataKeysByAccountID(accountID string) (int, error) {
+	count := 0
+	err := DB.Model(&AccountMetadataKey{}).Where("account_id = ?", accountID).Count(&count).Error
+	return count, err
+}
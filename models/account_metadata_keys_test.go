@@ -0,0 +1,53 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/opacity/storage-node/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Init_Account_Metadata_Keys(t *testing.T) {
+	utils.SetTesting("../.env")
+	Connect(utils.Env.TestDatabaseURL)
+}
+
+func Test_AddAccountMetadataKey_GetMetadataKeysByAccountID(t *testing.T) {
+	DeleteAccountMetadataKeysForTest(t)
+	accountID := utils.RandSeqFromRunes(AccountIDLength, []rune("abcdef01234567890"))
+	metadataKey1 := utils.RandSeqFromRunes(64, []rune("abcdef01234567890"))
+	metadataKey2 := utils.RandSeqFromRunes(64, []rune("abcdef01234567890"))
+
+	assert.Nil(t, AddAccountMetadataKey(accountID, metadataKey1))
+	assert.Nil(t, AddAccountMetadataKey(accountID, metadataKey2))
+	// adding it again is a no-op
+	assert.Nil(t, AddAccountMetadataKey(accountID, metadataKey1))
+
+	metadataKeys, err := GetMetadataKeysByAccountID(accountID)
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{metadataKey1, metadataKey2}, metadataKeys)
+
+	assert.Nil(t, RemoveAccountMetadataKey(accountID, metadataKey1))
+	metadataKeys, err = GetMetadataKeysByAccountID(accountID)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{metadataKey2}, metadataKeys)
+}
+
+func Test_AddAccountMetadataKey_Invalid_Key_Fails(t *testing.T) {
+	accountID := utils.RandSeqFromRunes(AccountIDLength, []rune("abcdef01234567890"))
+
+	assert.NotNil(t, AddAccountMetadataKey(accountID, "tooShort"))
+}
+
+func Test_Deleting_Account_Deletes_Its_Metadata_Keys(t *testing.T) {
+	DeleteAccountMetadataKeysForTest(t)
+	account := returnValidAccount()
+	assert.Nil(t, DB.Create(&account).Error)
+	assert.Nil(t, AddAccountMetadataKey(account.AccountID, utils.RandSeqFromRunes(64, []rune("abcdef01234567890"))))
+
+	assert.Nil(t, DB.Delete(&account).Error)
+
+	metadataKeys, err := GetMetadataKeysByAccountID(account.AccountID)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(metadataKeys))
+}
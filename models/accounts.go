@@ -39,6 +39,13 @@ type Account struct {
 	TotalFolders             int               `json:"totalFolders" binding:"omitempty,gte=0" gorm:"default:0"`
 	TotalMetadataSizeInBytes int64             `json:"totalMetadataSizeInBytes" binding:"omitempty,gte=0" gorm:"default:0"`
 	PaymentMethod            PaymentMethodType `json:"paymentMethod" gorm:"default:0"`
+	PlanID                   uint              `json:"planID" gorm:"index"`                                       // the version of the plan they bought
+	CreditInOPCT             float64           `json:"creditInOPCT" binding:"omitempty,gte=0" gorm:"default:0"`   // balance taken off their next renewal
+	CouponID                 uint              `json:"couponID" gorm:"default:0"`                                 // the coupon applied to their first invoice
+	DiscountInOPCT           float64           `json:"discountInOPCT" binding:"omitempty,gte=0" gorm:"default:0"` // taken off the cost of their subscription
+	DiscountInUSD            float64           `json:"discountInUSD" binding:"omitempty,gte=0" gorm:"default:0"`
+	OverageEnabled           bool              `json:"overageEnabled" gorm:"default:false"`                       // whether they opted in to storing more than their plan
+	OverageCapInGB           int               `json:"overageCapInGB" binding:"omitempty,gte=0" gorm:"default:0"` // how much more than their plan they can store
 	Upgrades                 []Upgrade         `gorm:"foreignkey:AccountID;association_foreignkey:AccountID"`
 	ExpiredAt                time.Time         `json:"expiredAt"`
 }
@@ -53,6 +60,8 @@ type SpaceReport struct {
 type Invoice struct {
 	Cost       float64 `json:"cost" binding:"omitempty,gte=0" example:"1.56"`
 	EthAddress string  `json:"ethAddress" binding:"required,len=42" minLength:"42" maxLength:"42" example:"a 42-char eth address with 0x prefix"`
+	Discount   float64 `json:"discount" binding:"omitempty,gte=0" example:"0"` // already taken off the cost by a coupon
+	Overage    float64 `json:"overage" binding:"omitempty,gte=0" example:"0"`  // already added to the cost for storage over the plan
 }
 
 /*StorageLimitType defines a type for the storage limits*/
@@ -136,13 +145,30 @@ func (account *Account) BeforeCreate(scope *gorm.Scope) error {
 	if account.PaymentStatus < InitialPaymentInProgress {
 		account.PaymentStatus = InitialPaymentInProgress
 	}
-	if utils.FreeModeEnabled() || utils.Env.Plans[int(account.StorageLimit)].Name == "Free" {
+	if account.PlanID == 0 {
+		if plan, ok := GetPurchasablePlan(int(account.StorageLimit)); ok {
+			account.PlanID = plan.ID
+		}
+	}
+	if utils.FreeModeEnabled() || account.Plan().Name == "Free" {
+		account.PaymentStatus = PaymentRetrievalComplete
+	}
+	if cost, _ := account.Cost(); account.CouponID != 0 && cost == 0 {
+		// a coupon took the whole cost off, so there is nothing to pay or collect
 		account.PaymentStatus = PaymentRetrievalComplete
 	}
 	account.ExpiredAt = time.Now().AddDate(0, account.MonthsInSubscription, 0)
 	return utils.Validator.Struct(account)
 }
 
+/*AfterCreate - callback called after the row is created*/
+func (account *Account) AfterCreate(scope *gorm.Scope) error {
+	if account.PaymentStatus >= InitialPaymentReceived {
+		account.RecordCouponRedemption()
+	}
+	return nil
+}
+
 /*BeforeUpdate - callback called before the row is updated*/
 func (account *Account) BeforeUpdate(scope *gorm.Scope) error {
 	account.ExpiredAt = time.Now().AddDate(0, account.MonthsInSubscription, 0)
@@ -152,6 +178,14 @@ func (account *Account) BeforeUpdate(scope *gorm.Scope) error {
 /*BeforeDelete - callback called before the row is deleted*/
 func (account *Account) BeforeDelete(scope *gorm.Scope) error {
 	DeleteStripePaymentIfExists(account.AccountID)
+	utils.LogIfError(DeleteAccountMetadataKeys(account.AccountID), map[string]interface{}{"accountID": account.AccountID})
+	utils.LogIfError(DeleteDelegatedKeys(account.AccountID), map[string]interface{}{"accountID": account.AccountID})
+	if err := CancelDowngrade(account.AccountID); err != nil && !gorm.IsRecordNotFoundError(err) {
+		utils.LogIfError(err, map[string]interface{}{"accountID": account.AccountID})
+	}
+	if err := CancelAccountDeletion(account.AccountID); err != nil && !gorm.IsRecordNotFoundError(err) {
+		utils.LogIfError(err, map[string]interface{}{"accountID": account.AccountID})
+	}
 	return nil
 }
 
@@ -163,16 +197,40 @@ func (account *Account) ExpirationDate() time.Time {
 	return account.CreatedAt.AddDate(0, account.MonthsInSubscription, 0)
 }
 
-/*Cost returns the expected price of the subscription*/
+/*Plan returns the version of the plan the account bought, or the newest version for its storage limit if it
+doesn't reference one*/
+func (account *Account) Plan() utils.PlanInfo {
+	if plan, err := GetPlan(account.PlanID); err == nil && plan.StorageInGB == int(account.StorageLimit) {
+		return plan.Info()
+	}
+	plan, _ := latestPlan(int(account.StorageLimit), false)
+	return plan.Info()
+}
+
+/*Cost returns the expected price of the subscription, less the discount of a coupon it was created with*/
 func (account *Account) Cost() (float64, error) {
-	return utils.Env.Plans[int(account.StorageLimit)].Cost *
-		float64(account.MonthsInSubscription/DefaultMonthsPerSubscription), nil
+	cost := utils.SubscriptionCost(account.Plan(), account.MonthsInSubscription)
+	return utils.RoundCost(math.Max(0, cost-account.DiscountInOPCT)), nil
+}
+
+/*CostInUSD returns the expected price of the subscription in USD, less the discount of a coupon it was created
+with*/
+func (account *Account) CostInUSD() (float64, error) {
+	cost := utils.SubscriptionCostInUSD(account.Plan(), account.MonthsInSubscription)
+	return math.Max(0, cost-account.DiscountInUSD), nil
+}
+
+/*RenewalCost returns the price of renewing the subscription for another DefaultMonthsPerSubscription months, on
+the plan of a downgrade scheduled for the renewal if there is one*/
+func (account *Account) RenewalCost() (float64, error) {
+	plan, _ := account.renewalPlan()
+	return utils.SubscriptionCost(plan, DefaultMonthsPerSubscription), nil
 }
 
 /*UpgradeCostInOPCT returns the cost to upgrade in OPCT*/
 func (account *Account) UpgradeCostInOPCT(upgradeStorageLimit int, monthsForNewPlan int) (float64, error) {
-	baseCostOfHigherPlan := utils.Env.Plans[int(upgradeStorageLimit)].Cost *
-		float64(monthsForNewPlan/DefaultMonthsPerSubscription)
+	higherPlan, _ := GetPurchasablePlan(upgradeStorageLimit)
+	baseCostOfHigherPlan := utils.SubscriptionCost(higherPlan.Info(), monthsForNewPlan)
 	costOfCurrentPlan, _ := account.Cost()
 
 	if account.ExpirationDate().Before(time.Now()) {
@@ -184,10 +242,9 @@ func (account *Account) UpgradeCostInOPCT(upgradeStorageLimit int, monthsForNewP
 
 /*UpgradeCostInUSD returns the cost to upgrade in USD*/
 func (account *Account) UpgradeCostInUSD(upgradeStorageLimit int, monthsForNewPlan int) (float64, error) {
-	baseCostOfHigherPlan := utils.Env.Plans[int(upgradeStorageLimit)].CostInUSD *
-		float64(monthsForNewPlan/DefaultMonthsPerSubscription)
-	costOfCurrentPlan := utils.Env.Plans[int(account.StorageLimit)].CostInUSD *
-		float64(account.MonthsInSubscription/DefaultMonthsPerSubscription)
+	higherPlan, _ := GetPurchasablePlan(upgradeStorageLimit)
+	baseCostOfHigherPlan := utils.SubscriptionCostInUSD(higherPlan.Info(), monthsForNewPlan)
+	costOfCurrentPlan, _ := account.CostInUSD()
 
 	if account.ExpirationDate().Before(time.Now()) {
 		return baseCostOfHigherPlan, nil
@@ -223,6 +280,7 @@ func (account *Account) CheckIfPaid() (bool, error) {
 		costInWei)
 	if paid {
 		SetAccountsToNextPaymentStatus([]Account{*(account)})
+		account.RecordCouponRedemption()
 	}
 	return paid, err
 }
@@ -232,7 +290,8 @@ func (account *Account) CheckIfPending() (bool, error) {
 	return BackendManager.CheckIfPending(services.StringToAddress(account.EthAddress))
 }
 
-/*UseStorageSpaceInByte updates the account's StorageUsedInByte value*/
+/*UseStorageSpaceInByte updates the account's StorageUsedInByte value.  An account in an organization is charged
+to the organization's pool, in the same transaction, instead of its own StorageLimit.*/
 func (account *Account) UseStorageSpaceInByte(planToUsedInByte int64) error {
 	paid, err := account.CheckIfPaid()
 	if err != nil {
@@ -259,9 +318,16 @@ func (account *Account) UseStorageSpaceInByte(planToUsedInByte int64) error {
 		return err
 	}
 
+	organization, member, err := getAccountOrganization(tx, account.AccountID)
+	inOrganization := err == nil
+	if err != nil && !gorm.IsRecordNotFoundError(err) {
+		tx.Rollback()
+		return err
+	}
+
 	plannedInGB := (float64(planToUsedInByte) + float64(accountFromDB.StorageUsedInByte)) / 1e9
 
-	if plannedInGB > float64(accountFromDB.StorageLimit) {
+	if !inOrganization && plannedInGB > float64(accountFromDB.StorageLimitWithOverage()) {
 		return errors.New("unable to store more data")
 	}
 
@@ -279,7 +345,13 @@ func (account *Account) UseStorageSpaceInByte(planToUsedInByte int64) error {
 		tx.Rollback()
 		return errors.New(StorageUsedTooLow)
 	}
-	if (accountFromDB.StorageUsedInByte / 1e9) > int64(accountFromDB.StorageLimit) {
+	if inOrganization {
+		if err := organization.chargeStorage(tx, member, accountFromDB.StorageUsedInByte,
+			planToUsedInByte); err != nil {
+			tx.Rollback()
+			return err
+		}
+	} else if (accountFromDB.StorageUsedInByte / 1e9) > int64(accountFromDB.StorageLimitWithOverage()) {
 		tx.Rollback()
 		return errors.New("unable to store more data")
 	}
@@ -287,15 +359,46 @@ func (account *Account) UseStorageSpaceInByte(planToUsedInByte int64) error {
 	return tx.Commit().Error
 }
 
+/*StorageLimitWithOverage returns how much the account can store, in GB, which is more than its plan if it opted in
+to overage*/
+func (account *Account) StorageLimitWithOverage() int {
+	if account.OverageEnabled && utils.OverageOffered() {
+		return int(account.StorageLimit) + account.OverageCapInGB
+	}
+	return int(account.StorageLimit)
+}
+
+/*SetOverage opts the account in to storing up to capInGB more than its plan, or out of it*/
+func (account *Account) SetOverage(enabled bool, capInGB int) error {
+	return DB.Model(account).Updates(map[string]interface{}{
+		"overage_enabled":   enabled,
+		"overage_cap_in_gb": capInGB,
+	}).Error
+}
+
+/*HasStorageSpaceFor returns whether the account can store fileSizeInByte more, in its organization's pool if it is
+in one*/
+func (account *Account) HasStorageSpaceFor(fileSizeInByte int64) (bool, error) {
+	organization, member, err := GetAccountOrganization(account.AccountID)
+	if gorm.IsRecordNotFoundError(err) {
+		plannedInGB := (float64(fileSizeInByte) + float64(account.StorageUsedInByte)) / 1e9
+		return plannedInGB <= float64(account.StorageLimitWithOverage()), nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return organization.hasStorageSpaceFor(member, account.StorageUsedInByte, fileSizeInByte)
+}
+
 /*MaxAllowedMetadataSizeInBytes returns the maximum possible metadata size for an account based on its plan*/
 func (account *Account) MaxAllowedMetadataSizeInBytes() int64 {
-	maxAllowedMetadataSizeInMB := utils.Env.Plans[int(account.StorageLimit)].MaxMetadataSizeInMB
+	maxAllowedMetadataSizeInMB := account.Plan().MaxMetadataSizeInMB
 	return maxAllowedMetadataSizeInMB * 1e6
 }
 
 /*MaxAllowedMetadatas returns the maximum possible number of metadatas for an account based on its plan*/
 func (account *Account) MaxAllowedMetadatas() int {
-	return utils.Env.Plans[int(account.StorageLimit)].MaxFolders
+	return account.Plan().MaxFolders
 }
 
 /*CanAddNewMetadata checks if an account can have another metadata*/
@@ -369,8 +472,26 @@ func (account *Account) RemoveMetadata(oldMetadataSizeInBytes int64) error {
 	return err
 }
 
+/*AddImportedMetadatas adds metadatas imported from an archive to the account's metadata count and size in one
+go, or returns an error if that would go over the plan's limits*/
+func (account *Account) AddImportedMetadatas(newMetadatas int, oldMetadataSizeInBytes, newMetadataSizeInBytes int64) error {
+	if account.TotalFolders+newMetadatas > account.MaxAllowedMetadatas() || account.TotalFolders+newMetadatas < 0 {
+		return errors.New("cannot exceed allowed metadatas")
+	}
+	if !account.CanUpdateMetadata(oldMetadataSizeInBytes, newMetadataSizeInBytes) {
+		return errors.New("metadata size is too large for this account")
+	}
+
+	account.TotalFolders += newMetadatas
+	account.TotalMetadataSizeInBytes = account.TotalMetadataSizeInBytes - oldMetadataSizeInBytes + newMetadataSizeInBytes
+	return DB.Model(account).Updates(map[string]interface{}{
+		"total_folders":                account.TotalFolders,
+		"total_metadata_size_in_bytes": account.TotalMetadataSizeInBytes,
+	}).Error
+}
+
 func (account *Account) UpgradeAccount(upgradeStorageLimit int, monthsForNewPlan int) error {
-	_, ok := utils.Env.Plans[upgradeStorageLimit]
+	plan, ok := GetPurchasablePlan(upgradeStorageLimit)
 	if !ok {
 		return InvalidStorageLimitError
 	}
@@ -380,21 +501,75 @@ func (account *Account) UpgradeAccount(upgradeStorageLimit int, monthsForNewPlan
 	}
 	monthsSinceCreation := differenceInMonths(account.CreatedAt, time.Now())
 	account.StorageLimit = StorageLimitType(upgradeStorageLimit)
+	account.PlanID = plan.ID
 	account.MonthsInSubscription = monthsSinceCreation + monthsForNewPlan
-	return DB.Model(account).Updates(map[string]interface{}{
+	expiredAt := account.CreatedAt.AddDate(0, account.MonthsInSubscription, 0)
+	if err := DB.Model(account).Updates(map[string]interface{}{
 		"months_in_subscription": account.MonthsInSubscription,
 		"storage_limit":          account.StorageLimit,
-		"expired_at":             account.CreatedAt.AddDate(0, account.MonthsInSubscription, 0),
+		"plan_id":                account.PlanID,
+		"expired_at":             expiredAt,
 		"updated_at":             time.Now(),
-	}).Error
+	}).Error; err != nil {
+		return err
+	}
+	account.logLedgerEntry(LedgerPlanChange, 0, "", upgradeLedgerDescription(account.StorageLimit))
+	account.queueExpirationExtension(expiredAt)
+	return nil
 }
 
 func (account *Account) RenewAccount() error {
-	return DB.Model(account).Updates(map[string]interface{}{
-		"months_in_subscription": account.MonthsInSubscription + 12,
-		"expired_at":             account.CreatedAt.AddDate(0, account.MonthsInSubscription+12, 0),
+	return account.RenewAccountWithExtraMonths(0)
+}
+
+/*RenewAccountWithExtraMonths renews the account for another DefaultMonthsPerSubscription months plus the extra
+months a coupon gave the renewal*/
+func (account *Account) RenewAccountWithExtraMonths(extraMonths int) error {
+	months := account.MonthsInSubscription + DefaultMonthsPerSubscription + extraMonths
+	expiredAt := account.CreatedAt.AddDate(0, months, 0)
+	updates := map[string]interface{}{
+		"months_in_subscription": months,
+		"expired_at":             expiredAt,
 		"updated_at":             time.Now(),
-	}).Error
+	}
+	_, downgrade := account.renewalPlan()
+	if downgrade != nil {
+		updates["storage_limit"] = downgrade.NewStorageLimit
+		updates["plan_id"] = downgrade.PlanID
+	}
+	if err := DB.Model(account).Updates(updates).Error; err != nil {
+		return err
+	}
+	description := renewalLedgerDescription
+	if downgrade != nil {
+		account.StorageLimit = downgrade.NewStorageLimit
+		account.PlanID = downgrade.PlanID
+		utils.LogIfError(CancelDowngrade(account.AccountID), map[string]interface{}{"accountID": account.AccountID})
+		description += ", " + downgradeLedgerDescription(downgrade.NewStorageLimit)
+	}
+	account.logLedgerEntry(LedgerPlanChange, 0, "", description)
+	account.queueExpirationExtension(expiredAt)
+	return nil
+}
+
+/*UseCredit takes amount off the account's credit balance, without letting it go below 0*/
+func (account *Account) UseCredit(amount float64) error {
+	if amount <= 0 {
+		return nil
+	}
+	err := DB.Model(account).UpdateColumn("credit_in_opct", gorm.Expr("GREATEST(credit_in_opct - ?, 0)", amount)).Error
+	if err == nil {
+		account.CreditInOPCT = math.Max(0, account.CreditInOPCT-amount)
+		account.logLedgerEntry(LedgerCredit, -amount, "", renewalLedgerDescription)
+	}
+	return err
+}
+
+/*queueExpirationExtension queues extending the account's metadata and files to its new expiration date.  The
+account change already went through, so failing to queue it is logged rather than returned.*/
+func (account *Account) queueExpirationExtension(expiredAt time.Time) {
+	err := QueueExpirationExtension(account.AccountID, expiredAt)
+	utils.LogIfError(err, map[string]interface{}{"accountID": account.AccountID, "expiredAt": expiredAt})
 }
 
 func differenceInMonths(a, b time.Time) int {
@@ -452,7 +627,8 @@ func CalculatePercentSpaceUsed(spaceReport SpaceReport) float64 {
 	return (spaceUsedInGB / float64(spaceReport.SpaceAllottedSum)) * float64(100)
 }
 
-/*PurgeOldUnpaidAccounts deletes accounts past a certain age which have not been paid for*/
+/*PurgeOldUnpaidAccounts deletes accounts past a certain age which have not been paid for, releasing the redemption
+of a coupon they were created with*/
 func PurgeOldUnpaidAccounts(daysToRetainUnpaidAccounts int) error {
 	accounts := []Account{}
 	err := DB.Where("created_at < ? AND payment_status = ? AND storage_used_in_byte = ?",
@@ -461,7 +637,9 @@ func PurgeOldUnpaidAccounts(daysToRetainUnpaidAccounts int) error {
 		int64(0)).Find(&accounts).Error
 	for _, account := range accounts {
 		if paid, _ := CheckForPaidStripePayment(account.AccountID); !paid {
-			err = DB.Delete(&account).Error
+			if err = DB.Delete(&account).Error; err == nil {
+				ReleaseCouponRedemption(account.CouponID)
+			}
 		}
 	}
 	return err
@@ -584,13 +762,14 @@ func handleAccountReadyForCollection(account Account) error {
 		return errors.New("got negative balance for ethBalance")
 	}
 
-	success, _, _ := EthWrapper.TransferToken(
+	success, txHash, _ := EthWrapper.TransferToken(
 		services.StringToAddress(account.EthAddress),
 		privateKey,
 		services.MainWalletAddress,
 		*tokenBalance,
 		services.SlowGasPrice)
 	if success {
+		logOPCTCollection(account.AccountID, tokenBalance, txHash, accountLedgerDescription)
 		SetAccountsToNextPaymentStatus([]Account{account})
 		return nil
 	}
@@ -637,6 +816,8 @@ func DeleteExpiredAccounts(expiredTime time.Time) error {
 		}
 		err := DB.Create(&s).Error
 		utils.LogIfError(err, nil)
+		err = leaveOrganization(account.AccountID)
+		utils.LogIfError(err, map[string]interface{}{"accountID": account.AccountID})
 		err = DB.Delete(&account).Error
 		utils.LogIfError(err, nil)
 	}
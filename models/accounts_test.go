@@ -186,6 +186,44 @@ func Test_Cost_Returns_Cost(t *testing.T) {
 	assert.Equal(t, BasicSubscriptionDefaultCost, cost)
 }
 
+func Test_Cost_Is_Prorated_By_Month(t *testing.T) {
+	tests := []struct {
+		months       int
+		storageLimit StorageLimitType
+		cost         float64
+		costInUSD    float64
+	}{
+		{1, BasicStorageLimit, 0.166667, 3.33},
+		{6, BasicStorageLimit, 1, 20},
+		{12, BasicStorageLimit, 2, 39.99},
+		{18, BasicStorageLimit, 3, 59.99},
+		{24, ProfessionalStorageLimit, 32, 199.98},
+		{6, 10, 0, 0},
+	}
+
+	for _, tt := range tests {
+		account := returnValidAccount()
+		account.StorageLimit = tt.storageLimit
+		account.MonthsInSubscription = tt.months
+
+		cost, err := account.Cost()
+		assert.Nil(t, err)
+		assert.Equal(t, tt.cost, cost, "%d months of %d GB", tt.months, tt.storageLimit)
+		costInUSD, err := account.CostInUSD()
+		assert.Nil(t, err)
+		assert.Equal(t, tt.costInUSD, costInUSD, "%d months of %d GB", tt.months, tt.storageLimit)
+	}
+}
+
+func Test_RenewalCost_Is_For_A_Default_Term(t *testing.T) {
+	account := returnValidAccount()
+	account.MonthsInSubscription = 6
+
+	cost, err := account.RenewalCost()
+	assert.Nil(t, err)
+	assert.Equal(t, BasicSubscriptionDefaultCost, cost)
+}
+
 func Test_UpgradeCostInOPCT_Basic_To_Professional_None_Of_Subscription_Has_Passed(t *testing.T) {
 	account := returnValidAccount()
 
@@ -721,6 +759,28 @@ func Test_UpdateMetadataSizeInBytes(t *testing.T) {
 	assert.NotNil(t, account.UpdateMetadataSizeInBytes(200e6, 300e6))
 }
 
+func Test_AddImportedMetadatas(t *testing.T) {
+	account := returnValidAccount()
+	account.TotalMetadataSizeInBytes = 100
+	account.TotalFolders = 1
+	if err := DB.Create(&account).Error; err != nil {
+		t.Fatalf("should have created account but didn't: " + err.Error())
+	}
+
+	assert.Nil(t, account.AddImportedMetadatas(2, 50, 80))
+
+	accountFromDB, _ := GetAccountById(account.AccountID)
+	assert.Equal(t, 3, accountFromDB.TotalFolders)
+	assert.Equal(t, int64(130), accountFromDB.TotalMetadataSizeInBytes)
+
+	assert.NotNil(t, account.AddImportedMetadatas(account.MaxAllowedMetadatas(), 0, 0))
+	assert.NotNil(t, account.AddImportedMetadatas(0, 0, account.MaxAllowedMetadataSizeInBytes()))
+
+	accountFromDB, _ = GetAccountById(account.AccountID)
+	assert.Equal(t, 3, accountFromDB.TotalFolders)
+	assert.Equal(t, int64(130), accountFromDB.TotalMetadataSizeInBytes)
+}
+
 func Test_RemoveMetadata(t *testing.T) {
 	// This test relies upon TestFileStoragePerMetadataInMB
 	// and TestMaxPerMetadataSizeInMB defined in utils/env.go.
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/opacity/storage-node/utils"
+)
+
+/*AdminAuditLog records an action an admin took.  Rows are only ever added.*/
+type AdminAuditLog struct {
+	ID         uint      `gorm:"primary_key" json:"id"`
+	Actor      string    `gorm:"type:varchar(64);index" json:"actor" binding:"required"`
+	Role       string    `json:"role" binding:"required"`
+	Action     string    `json:"action" binding:"required"`
+	Target     string    `json:"target"`
+	Parameters string    `gorm:"type:text" json:"parameters"`
+	Status     int       `json:"status" binding:"required"`
+	Result     string    `gorm:"type:text" json:"result"`
+	CreatedAt  time.Time `gorm:"index" json:"createdAt"`
+}
+
+/*ErrAdminAuditLogAppendOnly is returned when something tries to change or remove an audit log entry*/
+var ErrAdminAuditLogAppendOnly = errors.New("the admin audit log is append-only")
+
+// the most entries a page of the audit log shows
+const maxAdminAuditLogPageSize = 500
+
+/*BeforeCreate - callback called before the row is created*/
+func (auditLog *AdminAuditLog) BeforeCreate(scope *gorm.Scope) error {
+	return utils.Validator.Struct(auditLog)
+}
+
+/*BeforeUpdate - callback called before the row is updated*/
+func (auditLog *AdminAuditLog) BeforeUpdate(scope *gorm.Scope) error {
+	return ErrAdminAuditLogAppendOnly
+}
+
+/*BeforeDelete - callback called before the row is deleted*/
+func (auditLog *AdminAuditLog) BeforeDelete(scope *gorm.Scope) error {
+	return ErrAdminAuditLogAppendOnly
+}
+
+/*CreateAdminAuditLog records an admin action*/
+func CreateAdminAuditLog(auditLog AdminAuditLog) (AdminAuditLog, error) {
+	err := DB.Create(&auditLog).Error
+	return auditLog, err
+}
+
+/*GetAdminAuditLogs returns up to limit entries older than beforeID, newest first.  A beforeID of 0 starts from
+the newest entry, and an empty actor returns every actor's entries.*/
+func GetAdminAuditLogs(actor string, beforeID uint, limit int) ([]AdminAuditLog, error) {
+	if limit <= 0 || limit > maxAdminAuditLogPageSize {
+		limit = maxAdminAuditLogPageSize
+	}
+	query := DB.Order("id desc").Limit(limit)
+	if actor != "" {
+		query = query.Where("actor = ?", actor)
+	}
+	if beforeID != 0 {
+		query = query.Where("id < ?", beforeID)
+	}
+
+	var auditLogs []AdminAuditLog
+	err := query.Find(&auditLogs).Error
+	return auditLogs, err
+}
@@ -0,0 +1,127 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/opacity/storage-node/utils"
+	"golang.org/x/crypto/bcrypt"
+)
+
+/*AdminUser is someone who can sign in to the admin pages.  Their role decides which admin routes they can use.*/
+type AdminUser struct {
+	Username     string        `gorm:"primary_key;type:varchar(64)" json:"username" binding:"required,max=64"`
+	PasswordHash string        `json:"-" binding:"required"`
+	Role         AdminRoleType `json:"role" binding:"required,min=1,max=3"`
+	CreatedAt    time.Time     `json:"createdAt"`
+	UpdatedAt    time.Time     `json:"updatedAt"`
+}
+
+/*AdminRoleType defines a type for the admin roles.  Each role can do everything the roles before it can.*/
+type AdminRoleType int
+
+const (
+	/*AdminRoleViewer - can look at reports and metrics*/
+	AdminRoleViewer AdminRoleType = iota + 1
+
+	/*AdminRoleOperator - can also delete files and take backups*/
+	AdminRoleOperator
+
+	/*AdminRoleSuperuser - can also download and restore the K:V store, manage admin users and read the audit log*/
+	AdminRoleSuperuser
+)
+
+/*AdminRoleMap is for pretty printing the AdminRole*/
+var AdminRoleMap = map[AdminRoleType]string{
+	AdminRoleViewer:    "viewer",
+	AdminRoleOperator:  "operator",
+	AdminRoleSuperuser: "superuser",
+}
+
+/*ErrInvalidAdminCredentials is returned when the username or password is wrong*/
+var ErrInvalidAdminCredentials = errors.New("invalid admin username or password")
+
+/*BeforeCreate - callback called before the row is created*/
+func (adminUser *AdminUser) BeforeCreate(scope *gorm.Scope) error {
+	return utils.Validator.Struct(adminUser)
+}
+
+/*BeforeUpdate - callback called before the row is updated*/
+func (adminUser *AdminUser) BeforeUpdate(scope *gorm.Scope) error {
+	return utils.Validator.Struct(adminUser)
+}
+
+/*AdminRoleFromName returns the role with a name from the AdminRoleMap*/
+func AdminRoleFromName(name string) (AdminRoleType, bool) {
+	for role, roleName := range AdminRoleMap {
+		if roleName == name {
+			return role, true
+		}
+	}
+	return 0, false
+}
+
+/*SetAdminUser creates an admin user, or changes the password and role of an existing one*/
+func SetAdminUser(username, password string, role AdminRoleType) (AdminUser, error) {
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return AdminUser{}, err
+	}
+	adminUser := AdminUser{
+		Username:     username,
+		PasswordHash: string(passwordHash),
+		Role:         role,
+	}
+
+	existing, err := GetAdminUser(username)
+	if gorm.IsRecordNotFoundError(err) {
+		return adminUser, DB.Create(&adminUser).Error
+	}
+	if err != nil {
+		return adminUser, err
+	}
+	adminUser.CreatedAt = existing.CreatedAt
+	return adminUser, DB.Save(&adminUser).Error
+}
+
+/*GetAdminUser returns the admin user with a username*/
+func GetAdminUser(username string) (AdminUser, error) {
+	adminUser := AdminUser{}
+	err := DB.Where("username = ?", username).First(&adminUser).Error
+	return adminUser, err
+}
+
+/*GetAdminUsers returns every admin user*/
+func GetAdminUsers() ([]AdminUser, error) {
+	var adminUsers []AdminUser
+	err := DB.Order("username asc").Find(&adminUsers).Error
+	return adminUsers, err
+}
+
+/*AuthenticateAdminUser returns the admin user if the password is theirs*/
+func AuthenticateAdminUser(username, password string) (AdminUser, error) {
+	adminUser, err := GetAdminUser(username)
+	if gorm.IsRecordNotFoundError(err) {
+		return adminUser, ErrInvalidAdminCredentials
+	}
+	if err != nil {
+		return adminUser, err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(adminUser.PasswordHash), []byte(password)) != nil {
+		return adminUser, ErrInvalidAdminCredentials
+	}
+	return adminUser, nil
+}
+
+/*DeleteAdminUser removes an admin user.  It returns gorm.ErrRecordNotFound if there is none with the username.*/
+func DeleteAdminUser(username string) error {
+	db := DB.Where("username = ?", username).Delete(&AdminUser{})
+	if db.Error != nil {
+		return db.Error
+	}
+	if db.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	"github.com/opacity/storage-node/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Init_Admin_Users(t *testing.T) {
+	utils.SetTesting("../.env")
+	Connect(utils.Env.TestDatabaseURL)
+}
+
+func Test_SetAdminUser_And_Authenticate(t *testing.T) {
+	DeleteAdminUsersForTest(t)
+
+	adminUser, err := SetAdminUser("operator", "correct horse battery", AdminRoleOperator)
+	assert.Nil(t, err)
+	assert.NotEqual(t, "correct horse battery", adminUser.PasswordHash)
+
+	authenticated, err := AuthenticateAdminUser("operator", "correct horse battery")
+	assert.Nil(t, err)
+	assert.Equal(t, AdminRoleOperator, authenticated.Role)
+
+	_, err = AuthenticateAdminUser("operator", "wrong password")
+	assert.Equal(t, ErrInvalidAdminCredentials, err)
+	_, err = AuthenticateAdminUser("nobody", "correct horse battery")
+	assert.Equal(t, ErrInvalidAdminCredentials, err)
+
+	_, err = SetAdminUser("operator", "a new password", AdminRoleViewer)
+	assert.Nil(t, err)
+	adminUsers, err := GetAdminUsers()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(adminUsers))
+	assert.Equal(t, AdminRoleViewer, adminUsers[0].Role)
+}
+
+func Test_DeleteAdminUser(t *testing.T) {
+	DeleteAdminUsersForTest(t)
+
+	assert.True(t, gorm.IsRecordNotFoundError(DeleteAdminUser("viewer")))
+	_, err := SetAdminUser("viewer", "correct horse battery", AdminRoleViewer)
+	assert.Nil(t, err)
+	assert.Nil(t, DeleteAdminUser("viewer"))
+	_, err = GetAdminUser("viewer")
+	assert.True(t, gorm.IsRecordNotFoundError(err))
+}
+
+func Test_AdminRoleFromName(t *testing.T) {
+	role, ok := AdminRoleFromName("superuser")
+	assert.True(t, ok)
+	assert.Equal(t, AdminRoleSuperuser, role)
+	_, ok = AdminRoleFromName("root")
+	assert.False(t, ok)
+}
+
+func Test_AdminAuditLogs_Are_Append_Only(t *testing.T) {
+	DeleteAdminAuditLogsForTest(t)
+
+	for _, action := range []string{"POST /admin/delete", "POST /admin/badger/backup"} {
+		_, err := CreateAdminAuditLog(AdminAuditLog{Actor: "operator", Role: "operator", Action: action,
+			Status: 200, Result: "ok"})
+		assert.Nil(t, err)
+	}
+
+	auditLogs, err := GetAdminAuditLogs("operator", 0, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(auditLogs))
+	assert.Equal(t, "POST /admin/badger/backup", auditLogs[0].Action)
+
+	auditLogs[0].Result = "changed"
+	assert.Equal(t, ErrAdminAuditLogAppendOnly, DB.Save(&auditLogs[0]).Error)
+	assert.Equal(t, ErrAdminAuditLogAppendOnly, DB.Delete(&auditLogs[0]).Error)
+
+	auditLogs, err = GetAdminAuditLogs("", auditLogs[0].ID, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(auditLogs))
+}
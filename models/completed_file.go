@@ -16,6 +16,7 @@ type CompletedFile struct {
 	FileSizeInByte int64     `json:"fileSizeInByte"`
 	ModifierHash   string    `json:"modifierHash" binding:"required,len=64" minLength:"64" maxLength:"64"`
 	ApiVersion     int       `json:"apiVersion" binding:"omitempty,gte=1" gorm:"default:1"`
+	AccountID      string    `json:"accountID" binding:"omitempty,len=64" gorm:"type:varchar(64);index"`
 }
 
 /*BeforeCreate - callback called before the row is created*/
@@ -75,9 +76,26 @@ func UpdateExpiredAt(fileHandles []string, key string, newExpiredAtTime time.Tim
 	if err != nil {
 		return err
 	}
+	// files whose modifier hashes a key rotation hasn't re-derived yet still have the old key's hashes
+	rotatedKeys, err := GetRotatedPublicKeys(key)
+	if err != nil {
+		return err
+	}
+	for _, rotatedKey := range rotatedKeys {
+		rotatedModifierHashes, err := CreateModifierHashes(fileHandles, rotatedKey)
+		if err != nil {
+			return err
+		}
+		modifierHashes = append(modifierHashes, rotatedModifierHashes...)
+	}
+	// files completed before completed files were tied to an account get tied to it here
+	accountID, err := utils.HashString(key)
+	if err != nil {
+		return err
+	}
 	db := DB.Table("completed_files").Where("file_id IN (?) AND modifier_hash IN (?)",
 		fileHandles, modifierHashes).Updates(map[string]interface{}{"expired_at": newExpiredAtTime,
-		"updated_at": time.Now()})
+		"updated_at": time.Now(), "account_id": accountID})
 	if db.Error != nil {
 		return db.Error
 	}
@@ -99,3 +117,10 @@ func CreateModifierHashes(fileHandles []string, key string) ([]string, error) {
 	}
 	return modifierHashes, nil
 }
+
+/*CountCompletedFilesByAccountID returns how many completed files are tied to an account*/
+func CountCompletedFilesByAccountID(accountID string) (int, error) {
+	count := 0
+	err := DB.Model(&CompletedFile{}).Where("account_id = ?", accountID).Count(&count).Error
+	return count, err
+}
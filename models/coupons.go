@@ -0,0 +1,372 @@
+package models
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/opacity/storage-node/utils"
+)
+
+/*Coupon is a promo code for a discount or extra months on a new account, an upgrade or a renewal.  A coupon can be
+redeemed once per account.*/
+type Coupon struct {
+	ID              uint       `gorm:"primary_key" json:"id"`
+	Code            string     `gorm:"type:varchar(64);unique_index" json:"code" binding:"required,max=64" example:"SPRING20"`
+	PercentOff      float64    `json:"percentOff" binding:"omitempty,gte=0,lte=100" example:"20"`
+	AmountOffInOPCT float64    `json:"amountOffInOPCT" binding:"omitempty,gte=0" example:"0"`
+	AmountOffInUSD  float64    `json:"amountOffInUSD" binding:"omitempty,gte=0" example:"0"`
+	ExtraMonths     int        `json:"extraMonths" binding:"omitempty,gte=0" example:"0"`
+	StorageLimits   string     `json:"storageLimits" example:"128,1024"`                       // the plans it is for, any plan if empty
+	MaxRedemptions  int        `json:"maxRedemptions" binding:"omitempty,gte=0"`               // unlimited if 0
+	Redemptions     int        `json:"redemptions" binding:"omitempty,gte=0" gorm:"default:0"` // including unpaid invoices it is on
+	ExpiresAt       *time.Time `json:"expiresAt"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	UpdatedAt       time.Time  `json:"updatedAt"`
+}
+
+/*CouponRedemption records the discount a coupon gave an account once the invoice it was used on was paid*/
+type CouponRedemption struct {
+	ID             uint       `gorm:"primary_key" json:"id"`
+	CouponID       uint       `gorm:"unique_index:idx_coupon_redemption_account" json:"couponID" binding:"required"`
+	AccountID      string     `gorm:"type:varchar(64);unique_index:idx_coupon_redemption_account" json:"accountID" binding:"required,len=64"`
+	Flow           CouponFlow `json:"flow" binding:"required,gte=1"`
+	DiscountInOPCT float64    `json:"discountInOPCT" binding:"omitempty,gte=0"`
+	DiscountInUSD  float64    `json:"discountInUSD" binding:"omitempty,gte=0"`
+	ExtraMonths    int        `json:"extraMonths" binding:"omitempty,gte=0"`
+	CreatedAt      time.Time  `json:"createdAt"`
+}
+
+/*CouponFlow defines a type for what a coupon was redeemed on*/
+type CouponFlow int
+
+const (
+	/*CouponFlowAccount - a new account, paid in OPCT or by card*/
+	CouponFlowAccount CouponFlow = iota + 1
+
+	/*CouponFlowUpgrade - an upgrade to a bigger plan*/
+	CouponFlowUpgrade
+
+	/*CouponFlowRenewal - a renewal*/
+	CouponFlowRenewal
+)
+
+/*CouponFlowMap is for pretty printing the CouponFlow*/
+var CouponFlowMap = map[CouponFlow]string{
+	CouponFlowAccount: "account",
+	CouponFlowUpgrade: "upgrade",
+	CouponFlowRenewal: "renewal",
+}
+
+var (
+	/*ErrCouponExpired is returned for a coupon past its ExpiresAt*/
+	ErrCouponExpired = errors.New("the coupon has expired")
+
+	/*ErrCouponRedeemedOut is returned for a coupon redeemed MaxRedemptions times*/
+	ErrCouponRedeemedOut = errors.New("the coupon has been redeemed as many times as it can be")
+
+	/*ErrCouponWrongPlan is returned for a coupon that isn't for the plan being bought*/
+	ErrCouponWrongPlan = errors.New("the coupon is not for that plan")
+
+	/*ErrCouponAlreadyRedeemed is returned when an account uses a coupon it already redeemed*/
+	ErrCouponAlreadyRedeemed = errors.New("the account already redeemed the coupon")
+
+	/*ErrCouponAlreadyApplied is returned when a coupon was already applied to the invoice*/
+	ErrCouponAlreadyApplied = errors.New("a coupon was already applied to the account's invoice")
+
+	/*ErrCouponStorageLimits is returned for a coupon whose storageLimits aren't a list of numbers*/
+	ErrCouponStorageLimits = errors.New("storageLimits must be a comma separated list of storage limits in GB")
+)
+
+/*BeforeCreate - callback called before the row is created*/
+func (coupon *Coupon) BeforeCreate(scope *gorm.Scope) error {
+	return coupon.validate()
+}
+
+/*BeforeUpdate - callback called before the row is updated*/
+func (coupon *Coupon) BeforeUpdate(scope *gorm.Scope) error {
+	return coupon.validate()
+}
+
+func (coupon *Coupon) validate() error {
+	for _, storageLimit := range coupon.storageLimits() {
+		if _, err := strconv.Atoi(storageLimit); err != nil {
+			return ErrCouponStorageLimits
+		}
+	}
+	return utils.Validator.Struct(coupon)
+}
+
+/*BeforeCreate - callback called before the row is created*/
+func (redemption *CouponRedemption) BeforeCreate(scope *gorm.Scope) error {
+	return utils.Validator.Struct(redemption)
+}
+
+func (coupon *Coupon) storageLimits() []string {
+	if strings.TrimSpace(coupon.StorageLimits) == "" {
+		return nil
+	}
+	storageLimits := strings.Split(coupon.StorageLimits, ",")
+	for i := range storageLimits {
+		storageLimits[i] = strings.TrimSpace(storageLimits[i])
+	}
+	return storageLimits
+}
+
+/*CheckRedeemable returns why the coupon can't be used on the plan for storageLimit, if it can't*/
+func (coupon *Coupon) CheckRedeemable(storageLimit int) error {
+	if coupon.ExpiresAt != nil && !time.Now().Before(*coupon.ExpiresAt) {
+		return ErrCouponExpired
+	}
+	if coupon.MaxRedemptions > 0 && coupon.Redemptions >= coupon.MaxRedemptions {
+		return ErrCouponRedeemedOut
+	}
+	storageLimits := coupon.storageLimits()
+	if len(storageLimits) == 0 {
+		return nil
+	}
+	for _, allowed := range storageLimits {
+		if allowed == strconv.Itoa(storageLimit) {
+			return nil
+		}
+	}
+	return ErrCouponWrongPlan
+}
+
+/*DiscountInOPCT returns how much the coupon takes off costInOPCT*/
+func (coupon *Coupon) DiscountInOPCT(costInOPCT float64) float64 {
+	return couponDiscount(costInOPCT, coupon.PercentOff, coupon.AmountOffInOPCT)
+}
+
+/*DiscountInUSD returns how much the coupon takes off costInUSD*/
+func (coupon *Coupon) DiscountInUSD(costInUSD float64) float64 {
+	return couponDiscount(costInUSD, coupon.PercentOff, coupon.AmountOffInUSD)
+}
+
+func couponDiscount(cost, percentOff, amountOff float64) float64 {
+	return utils.RoundCost(math.Min(cost, cost*percentOff/100+amountOff))
+}
+
+/*GetRedeemableCoupon returns the coupon for code if accountID can use it on the plan for storageLimit*/
+func GetRedeemableCoupon(code string, accountID string, storageLimit int) (Coupon, error) {
+	coupon, err := GetCouponByCode(code)
+	if err != nil {
+		return coupon, err
+	}
+	if err := coupon.CheckRedeemable(storageLimit); err != nil {
+		return coupon, err
+	}
+	redeemed, err := CouponRedeemedBy(coupon.ID, accountID)
+	if err != nil {
+		return coupon, err
+	}
+	if redeemed {
+		return coupon, ErrCouponAlreadyRedeemed
+	}
+	return coupon, nil
+}
+
+/*GetCouponByCode returns the coupon for code*/
+func GetCouponByCode(code string) (Coupon, error) {
+	coupon := Coupon{}
+	err := DB.Where("code = ?", code).First(&coupon).Error
+	return coupon, err
+}
+
+/*GetCoupon returns the coupon with id*/
+func GetCoupon(id uint) (Coupon, error) {
+	coupon := Coupon{}
+	err := DB.Where("id = ?", id).First(&coupon).Error
+	return coupon, err
+}
+
+/*GetCoupons returns every coupon, newest first*/
+func GetCoupons() ([]Coupon, error) {
+	coupons := []Coupon{}
+	err := DB.Order("id desc").Find(&coupons).Error
+	return coupons, err
+}
+
+/*ExpireCoupon stops the coupon from being used on new invoices.  Invoices it was already applied to keep it.*/
+func ExpireCoupon(id uint) (Coupon, error) {
+	coupon, err := GetCoupon(id)
+	if err != nil {
+		return coupon, err
+	}
+	now := time.Now()
+	coupon.ExpiresAt = &now
+	return coupon, DB.Save(&coupon).Error
+}
+
+/*CouponRedeemedBy returns whether the account redeemed the coupon*/
+func CouponRedeemedBy(couponID uint, accountID string) (bool, error) {
+	count := 0
+	err := DB.Model(&CouponRedemption{}).Where("coupon_id = ? AND account_id = ?", couponID, accountID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+/*GetCouponRedemptions returns the coupons the account redeemed*/
+func GetCouponRedemptions(accountID string) ([]CouponRedemption, error) {
+	redemptions := []CouponRedemption{}
+	err := DB.Where("account_id = ?", accountID).Order("id").Find(&redemptions).Error
+	return redemptions, err
+}
+
+/*ReserveCouponRedemption counts a redemption of the coupon for the invoice it is being applied to, so no more unpaid
+invoices can hold the coupon than it has redemptions left.  It returns ErrCouponRedeemedOut if it has none left.
+The reservation becomes the redemption once the invoice is paid, and is released if the invoice is dropped.*/
+func ReserveCouponRedemption(couponID uint) error {
+	if couponID == 0 {
+		return nil
+	}
+	db := DB.Model(&Coupon{}).Where("id = ? AND (max_redemptions = 0 OR redemptions < max_redemptions)", couponID).
+		UpdateColumn("redemptions", gorm.Expr("redemptions + 1"))
+	if db.Error != nil {
+		return db.Error
+	}
+	if db.RowsAffected == 0 {
+		return ErrCouponRedeemedOut
+	}
+	return nil
+}
+
+/*ReleaseCouponRedemption gives back the redemption reserved for an invoice that was dropped without being paid.
+The invoice is gone either way, so failing to is logged rather than returned.*/
+func ReleaseCouponRedemption(couponID uint) {
+	if couponID == 0 {
+		return
+	}
+	err := DB.Model(&Coupon{}).Where("id = ? AND redemptions > 0", couponID).
+		UpdateColumn("redemptions", gorm.Expr("redemptions - 1")).Error
+	utils.LogIfError(err, map[string]interface{}{"couponID": couponID})
+}
+
+/*releaseUnpaidCouponRedemptions releases the redemptions reserved by the unpaid invoices of model that match query,
+before they are deleted*/
+func releaseUnpaidCouponRedemptions(model interface{}, query string, args ...interface{}) error {
+	var couponIDs []uint
+	if err := DB.Model(model).Where(query, args...).Where("payment_status = ? AND coupon_id != 0",
+		InitialPaymentInProgress).Pluck("coupon_id", &couponIDs).Error; err != nil {
+		return err
+	}
+	for _, couponID := range couponIDs {
+		ReleaseCouponRedemption(couponID)
+	}
+	return nil
+}
+
+/*logCouponRedemption records the coupon an invoice used once the invoice is paid.  The redemption was counted when
+the coupon was applied.  The payment already went through, so failing to record it is logged rather than
+returned.*/
+func logCouponRedemption(redemption CouponRedemption) {
+	if redemption.CouponID == 0 {
+		return
+	}
+	redeemed, err := CouponRedeemedBy(redemption.CouponID, redemption.AccountID)
+	if err == nil && !redeemed {
+		err = DB.Create(&redemption).Error
+	}
+	utils.LogIfError(err, map[string]interface{}{"accountID": redemption.AccountID, "couponID": redemption.CouponID})
+}
+
+/*ApplyCoupon applies the coupon to a new account's invoice.  The extra months are added to the subscription, and
+the account pays its cost for the months it asked for, less the coupon's discount.*/
+func (account *Account) ApplyCoupon(coupon Coupon) error {
+	if account.CouponID != 0 {
+		return ErrCouponAlreadyApplied
+	}
+	if err := coupon.CheckRedeemable(int(account.StorageLimit)); err != nil {
+		return err
+	}
+	costInOPCT := utils.SubscriptionCost(account.Plan(), account.MonthsInSubscription)
+	costInUSD := utils.SubscriptionCostInUSD(account.Plan(), account.MonthsInSubscription)
+	costInOPCT -= coupon.DiscountInOPCT(costInOPCT)
+	costInUSD -= coupon.DiscountInUSD(costInUSD)
+
+	account.CouponID = coupon.ID
+	account.MonthsInSubscription += coupon.ExtraMonths
+	account.ExpiredAt = account.ExpiredAt.AddDate(0, coupon.ExtraMonths, 0)
+	account.DiscountInOPCT = utils.RoundCost(math.Max(0,
+		utils.SubscriptionCost(account.Plan(), account.MonthsInSubscription)-costInOPCT))
+	account.DiscountInUSD = utils.RoundCost(math.Max(0,
+		utils.SubscriptionCostInUSD(account.Plan(), account.MonthsInSubscription)-costInUSD))
+	return nil
+}
+
+/*SaveCoupon saves the coupon ApplyCoupon applied to the invoice of an account that was already created*/
+func (account *Account) SaveCoupon() error {
+	return DB.Model(account).Updates(map[string]interface{}{
+		"coupon_id":              account.CouponID,
+		"months_in_subscription": account.MonthsInSubscription,
+		"expired_at":             account.CreatedAt.AddDate(0, account.MonthsInSubscription, 0),
+		"discount_in_opct":       account.DiscountInOPCT,
+		"discount_in_usd":        account.DiscountInUSD,
+	}).Error
+}
+
+/*RecordCouponRedemption records the coupon the account was created with once it is paid for*/
+func (account *Account) RecordCouponRedemption() {
+	if account.CouponID == 0 {
+		return
+	}
+	coupon, _ := GetCoupon(account.CouponID)
+	logCouponRedemption(CouponRedemption{
+		CouponID:       account.CouponID,
+		AccountID:      account.AccountID,
+		Flow:           CouponFlowAccount,
+		DiscountInOPCT: account.DiscountInOPCT,
+		DiscountInUSD:  account.DiscountInUSD,
+		ExtraMonths:    coupon.ExtraMonths,
+	})
+}
+
+/*ApplyCoupon takes the coupon's discount off the upgrade and adds its extra months*/
+func (upgrade *Upgrade) ApplyCoupon(coupon Coupon) error {
+	if err := coupon.CheckRedeemable(int(upgrade.NewStorageLimit)); err != nil {
+		return err
+	}
+	upgrade.CouponID = coupon.ID
+	upgrade.DiscountInOPCT = coupon.DiscountInOPCT(upgrade.OpctCost)
+	upgrade.OpctCost = utils.RoundCost(upgrade.OpctCost - upgrade.DiscountInOPCT)
+	upgrade.ExtraMonths = coupon.ExtraMonths
+	return nil
+}
+
+/*RecordCouponRedemption records the coupon the upgrade used once it is paid for*/
+func (upgrade *Upgrade) RecordCouponRedemption() {
+	logCouponRedemption(CouponRedemption{
+		CouponID:       upgrade.CouponID,
+		AccountID:      upgrade.AccountID,
+		Flow:           CouponFlowUpgrade,
+		DiscountInOPCT: upgrade.DiscountInOPCT,
+		ExtraMonths:    upgrade.ExtraMonths,
+	})
+}
+
+/*ApplyCoupon takes the coupon's discount off the account's renewal, after any credit, and adds its extra months.
+The coupon must be for the plan the account renews on.*/
+func (renewal *Renewal) ApplyCoupon(coupon Coupon, account Account) error {
+	if err := coupon.CheckRedeemable(account.RenewalStorageLimit()); err != nil {
+		return err
+	}
+	renewal.CouponID = coupon.ID
+	renewal.DiscountInOPCT = coupon.DiscountInOPCT(renewal.OpctCost)
+	renewal.OpctCost = utils.RoundCost(renewal.OpctCost - renewal.DiscountInOPCT)
+	renewal.ExtraMonths = coupon.ExtraMonths
+	return nil
+}
+
+/*RecordCouponRedemption records the coupon the renewal used once it is paid for*/
+func (renewal *Renewal) RecordCouponRedemption() {
+	logCouponRedemption(CouponRedemption{
+		CouponID:       renewal.CouponID,
+		AccountID:      renewal.AccountID,
+		Flow:           CouponFlowRenewal,
+		DiscountInOPCT: renewal.DiscountInOPCT,
+		ExtraMonths:    renewal.ExtraMonths,
+	})
+}
@@ -0,0 +1,165 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/opacity/storage-node/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func returnValidCouponForTest() Coupon {
+	return Coupon{
+		Code:       utils.RandHexString(16),
+		PercentOff: 20,
+	}
+}
+
+func Test_Init_Coupons(t *testing.T) {
+	utils.SetTesting("../.env")
+	Connect(utils.Env.TestDatabaseURL)
+}
+
+func Test_Coupon_CheckRedeemable(t *testing.T) {
+	coupon := returnValidCouponForTest()
+	assert.Nil(t, coupon.CheckRedeemable(int(BasicStorageLimit)))
+
+	coupon.StorageLimits = "1024, 2048"
+	assert.Equal(t, ErrCouponWrongPlan, coupon.CheckRedeemable(int(BasicStorageLimit)))
+	assert.Nil(t, coupon.CheckRedeemable(int(ProfessionalStorageLimit)))
+
+	coupon.MaxRedemptions = 2
+	coupon.Redemptions = 2
+	assert.Equal(t, ErrCouponRedeemedOut, coupon.CheckRedeemable(int(ProfessionalStorageLimit)))
+
+	expiredAt := time.Now().Add(-time.Minute)
+	coupon.ExpiresAt = &expiredAt
+	assert.Equal(t, ErrCouponExpired, coupon.CheckRedeemable(int(ProfessionalStorageLimit)))
+}
+
+func Test_Coupon_Discount_Is_At_Most_The_Cost(t *testing.T) {
+	coupon := Coupon{PercentOff: 50, AmountOffInOPCT: 1}
+	assert.Equal(t, 6.0, coupon.DiscountInOPCT(10))
+	assert.Equal(t, 1.0, coupon.DiscountInOPCT(1))
+	assert.Equal(t, 0.0, coupon.DiscountInUSD(0))
+}
+
+func Test_Account_ApplyCoupon_Adds_Extra_Months_At_The_Discounted_Price(t *testing.T) {
+	account := returnValidAccount()
+	costForTwelveMonths, _ := account.Cost()
+	coupon := returnValidCouponForTest()
+	coupon.ID = 1
+	coupon.ExtraMonths = 3
+
+	assert.Nil(t, account.ApplyCoupon(coupon))
+	assert.Equal(t, DefaultMonthsPerSubscription+3, account.MonthsInSubscription)
+	cost, _ := account.Cost()
+	assert.Equal(t, utils.RoundCost(costForTwelveMonths*0.8), cost)
+
+	assert.Equal(t, ErrCouponAlreadyApplied, account.ApplyCoupon(coupon))
+}
+
+func Test_Upgrade_ApplyCoupon(t *testing.T) {
+	upgrade := Upgrade{NewStorageLimit: ProfessionalStorageLimit, OpctCost: 10}
+	coupon := returnValidCouponForTest()
+	coupon.ID = 1
+	coupon.ExtraMonths = 1
+
+	assert.Nil(t, upgrade.ApplyCoupon(coupon))
+	assert.Equal(t, 8.0, upgrade.OpctCost)
+	assert.Equal(t, 2.0, upgrade.DiscountInOPCT)
+	assert.Equal(t, 1, upgrade.ExtraMonths)
+}
+
+func Test_Coupon_Redemption_Is_Counted_Once_Per_Account(t *testing.T) {
+	DeleteAccountsForTest(t)
+	DeleteCouponsForTest(t)
+	coupon := returnValidCouponForTest()
+	coupon.MaxRedemptions = 1
+	assert.Nil(t, DB.Create(&coupon).Error)
+
+	account := returnValidAccount()
+	assert.Nil(t, account.ApplyCoupon(coupon))
+	assert.Nil(t, ReserveCouponRedemption(coupon.ID))
+	assert.Nil(t, DB.Create(&account).Error)
+	account.RecordCouponRedemption()
+	account.RecordCouponRedemption()
+
+	coupon, err := GetCoupon(coupon.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, coupon.Redemptions)
+	redemptions, err := GetCouponRedemptions(account.AccountID)
+	assert.Nil(t, err)
+	assert.Len(t, redemptions, 1)
+	assert.Equal(t, CouponFlowAccount, redemptions[0].Flow)
+	assert.Equal(t, account.DiscountInOPCT, redemptions[0].DiscountInOPCT)
+
+	_, err = GetRedeemableCoupon(coupon.Code, account.AccountID, int(BasicStorageLimit))
+	assert.Equal(t, ErrCouponRedeemedOut, err)
+}
+
+func Test_Fully_Discounted_Account_Is_Paid_When_Created(t *testing.T) {
+	DeleteAccountsForTest(t)
+	DeleteCouponsForTest(t)
+	coupon := Coupon{Code: utils.RandHexString(16), PercentOff: 100}
+	assert.Nil(t, DB.Create(&coupon).Error)
+
+	account := returnValidAccount()
+	assert.Nil(t, account.ApplyCoupon(coupon))
+	assert.Nil(t, DB.Create(&account).Error)
+
+	paid, err := account.CheckIfPaid()
+	assert.Nil(t, err)
+	assert.True(t, paid)
+	redeemed, err := CouponRedeemedBy(coupon.ID, account.AccountID)
+	assert.Nil(t, err)
+	assert.True(t, redeemed)
+}
+
+func Test_Coupon_StorageLimits_Must_Be_Numbers(t *testing.T) {
+	DeleteCouponsForTest(t)
+	coupon := returnValidCouponForTest()
+	coupon.StorageLimits = "basic"
+
+	assert.Equal(t, ErrCouponStorageLimits, DB.Create(&coupon).Error)
+}
+
+func Test_Coupon_Redemptions_Are_Reserved_Up_To_MaxRedemptions(t *testing.T) {
+	DeleteCouponsForTest(t)
+	coupon := returnValidCouponForTest()
+	coupon.MaxRedemptions = 1
+	assert.Nil(t, DB.Create(&coupon).Error)
+
+	assert.Nil(t, ReserveCouponRedemption(coupon.ID))
+	assert.Equal(t, ErrCouponRedeemedOut, ReserveCouponRedemption(coupon.ID))
+
+	ReleaseCouponRedemption(coupon.ID)
+	assert.Nil(t, ReserveCouponRedemption(coupon.ID))
+
+	coupon, err := GetCoupon(coupon.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, coupon.Redemptions)
+}
+
+func Test_Purged_Upgrade_Releases_Its_Coupon_Redemption(t *testing.T) {
+	DeleteUpgradesForTest(t)
+	DeleteCouponsForTest(t)
+	coupon := returnValidCouponForTest()
+	coupon.MaxRedemptions = 1
+	assert.Nil(t, DB.Create(&coupon).Error)
+
+	upgrade, _ := returnValidUpgrade()
+	assert.Nil(t, upgrade.ApplyCoupon(coupon))
+	_, err := GetOrCreateUpgrade(upgrade)
+	assert.Nil(t, err)
+	coupon, err = GetCoupon(coupon.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, coupon.Redemptions)
+
+	assert.Nil(t, DB.Model(&Upgrade{}).Where("account_id = ?", upgrade.AccountID).
+		UpdateColumn("updated_at", time.Now().Add(-2*time.Hour)).Error)
+	assert.Nil(t, PurgeOldUpgrades(1))
+	coupon, err = GetCoupon(coupon.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, coupon.Redemptions)
+}
@@ -0,0 +1,117 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/opacity/storage-node/utils"
+)
+
+/*DelegatedKey is an extra public key an account owner registered, which can act for the account within its
+scopes until it expires.  ParentPublicKey is the owner's key, which the account's permission hashes and
+modifier hashes are derived from.*/
+type DelegatedKey struct {
+	PublicKey       string     `gorm:"primary_key;type:varchar(66)" json:"publicKey" binding:"required,len=66"`
+	AccountID       string     `gorm:"type:varchar(64);index" json:"accountID" binding:"required,len=64"`
+	ParentPublicKey string     `gorm:"type:varchar(66)" json:"-" binding:"required,len=66"`
+	Scopes          string     `json:"-" binding:"required"` // comma separated
+	ExpiresAt       *time.Time `json:"expiresAt"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	UpdatedAt       time.Time  `json:"updatedAt"`
+}
+
+/*ErrDelegatedKeyTaken is returned when delegating a key that another account already delegated to*/
+var ErrDelegatedKeyTaken = errors.New("the key is already delegated by another account")
+
+/*BeforeCreate - callback called before the row is created*/
+func (delegatedKey *DelegatedKey) BeforeCreate(scope *gorm.Scope) error {
+	return utils.Validator.Struct(delegatedKey)
+}
+
+/*BeforeUpdate - callback called before the row is updated*/
+func (delegatedKey *DelegatedKey) BeforeUpdate(scope *gorm.Scope) error {
+	return utils.Validator.Struct(delegatedKey)
+}
+
+/*ScopeList returns the scopes of the key*/
+func (delegatedKey DelegatedKey) ScopeList() []string {
+	return strings.Split(delegatedKey.Scopes, ",")
+}
+
+/*HasScope returns whether the key allows scope*/
+func (delegatedKey DelegatedKey) HasScope(scope string) bool {
+	for _, s := range delegatedKey.ScopeList() {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+/*Expired returns whether the key has passed its expiration.  Keys without one never expire.*/
+func (delegatedKey DelegatedKey) Expired() bool {
+	return delegatedKey.ExpiresAt != nil && !time.Now().Before(*delegatedKey.ExpiresAt)
+}
+
+/*SetDelegatedKey delegates publicKey to the account of parentPublicKey, or changes the scopes and expiration
+of a key the account already delegated*/
+func SetDelegatedKey(parentPublicKey, publicKey string, scopes []string, expiresAt *time.Time) (DelegatedKey, error) {
+	accountID, err := utils.HashString(parentPublicKey)
+	if err != nil {
+		return DelegatedKey{}, err
+	}
+	delegatedKey := DelegatedKey{
+		PublicKey:       publicKey,
+		AccountID:       accountID,
+		ParentPublicKey: parentPublicKey,
+		Scopes:          strings.Join(scopes, ","),
+		ExpiresAt:       expiresAt,
+	}
+
+	existing, err := GetDelegatedKey(publicKey)
+	if gorm.IsRecordNotFoundError(err) {
+		return delegatedKey, DB.Create(&delegatedKey).Error
+	}
+	if err != nil {
+		return delegatedKey, err
+	}
+	if existing.AccountID != accountID {
+		return delegatedKey, ErrDelegatedKeyTaken
+	}
+	delegatedKey.CreatedAt = existing.CreatedAt
+	return delegatedKey, DB.Save(&delegatedKey).Error
+}
+
+/*GetDelegatedKey returns the delegated key for a public key*/
+func GetDelegatedKey(publicKey string) (DelegatedKey, error) {
+	delegatedKey := DelegatedKey{}
+	err := DB.Where("public_key = ?", publicKey).First(&delegatedKey).Error
+	return delegatedKey, err
+}
+
+/*GetDelegatedKeysByAccountID returns every key an account delegated*/
+func GetDelegatedKeysByAccountID(accountID string) ([]DelegatedKey, error) {
+	var delegatedKeys []DelegatedKey
+	err := DB.Where("account_id = ?", accountID).Order("created_at asc").Find(&delegatedKeys).Error
+	return delegatedKeys, err
+}
+
+/*RemoveDelegatedKey revokes a key an account delegated.  It returns gorm.ErrRecordNotFound if the account
+didn't delegate the key.*/
+func RemoveDelegatedKey(accountID, publicKey string) error {
+	db := DB.Where("account_id = ? AND public_key = ?", accountID, publicKey).Delete(&DelegatedKey{})
+	if db.Error != nil {
+		return db.Error
+	}
+	if db.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+/*DeleteDelegatedKeys revokes every key an account delegated*/
+func DeleteDelegatedKeys(accountID string) error {
+	return DB.Where("account_id = ?", accountID).Delete(&DelegatedKey{}).Error
+}
@@ -0,0 +1,74 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/opacity/storage-node/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Init_Delegated_Keys(t *testing.T) {
+	utils.SetTesting("../.env")
+	Connect(utils.Env.TestDatabaseURL)
+}
+
+func Test_SetDelegatedKey_Creates_And_Updates(t *testing.T) {
+	DeleteDelegatedKeysForTest(t)
+	parentPublicKey := returnPublicKeyForTest(t)
+	publicKey := returnPublicKeyForTest(t)
+
+	delegatedKey, err := SetDelegatedKey(parentPublicKey, publicKey, []string{"metadata:read"}, nil)
+	assert.Nil(t, err)
+	accountID, _ := utils.HashString(parentPublicKey)
+	assert.Equal(t, accountID, delegatedKey.AccountID)
+	assert.True(t, delegatedKey.HasScope("metadata:read"))
+	assert.False(t, delegatedKey.HasScope("files:delete"))
+	assert.False(t, delegatedKey.Expired())
+
+	expiresAt := time.Now().Add(time.Hour)
+	_, err = SetDelegatedKey(parentPublicKey, publicKey, []string{"files:upload", "files:delete"}, &expiresAt)
+	assert.Nil(t, err)
+
+	delegatedKeys, err := GetDelegatedKeysByAccountID(accountID)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(delegatedKeys))
+	assert.Equal(t, []string{"files:upload", "files:delete"}, delegatedKeys[0].ScopeList())
+	assert.NotNil(t, delegatedKeys[0].ExpiresAt)
+}
+
+func Test_SetDelegatedKey_Refuses_Key_Of_Another_Account(t *testing.T) {
+	DeleteDelegatedKeysForTest(t)
+	publicKey := returnPublicKeyForTest(t)
+
+	_, err := SetDelegatedKey(returnPublicKeyForTest(t), publicKey, []string{"metadata:read"}, nil)
+	assert.Nil(t, err)
+	_, err = SetDelegatedKey(returnPublicKeyForTest(t), publicKey, []string{"metadata:read"}, nil)
+	assert.Equal(t, ErrDelegatedKeyTaken, err)
+}
+
+func Test_DelegatedKey_Expired(t *testing.T) {
+	expiresAt := time.Now().Add(-time.Minute)
+	assert.True(t, DelegatedKey{ExpiresAt: &expiresAt}.Expired())
+	expiresAt = time.Now().Add(time.Minute)
+	assert.False(t, DelegatedKey{ExpiresAt: &expiresAt}.Expired())
+}
+
+func Test_RemoveDelegatedKey(t *testing.T) {
+	DeleteDelegatedKeysForTest(t)
+	parentPublicKey := returnPublicKeyForTest(t)
+	publicKey := returnPublicKeyForTest(t)
+	accountID, _ := utils.HashString(parentPublicKey)
+
+	assert.True(t, gorm.IsRecordNotFoundError(RemoveDelegatedKey(accountID, publicKey)))
+
+	_, err := SetDelegatedKey(parentPublicKey, publicKey, []string{"metadata:read"}, nil)
+	assert.Nil(t, err)
+	otherAccountID, _ := utils.HashString(returnPublicKeyForTest(t))
+	assert.True(t, gorm.IsRecordNotFoundError(RemoveDelegatedKey(otherAccountID, publicKey)))
+	assert.Nil(t, RemoveDelegatedKey(accountID, publicKey))
+
+	_, err = GetDelegatedKey(publicKey)
+	assert.True(t, gorm.IsRecordNotFoundError(err))
+}
@@ -0,0 +1,223 @@
+package models
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/opacity/storage-node/utils"
+)
+
+/*Downgrade is a move to a smaller plan that takes effect when the account is next renewed.  The renewal is
+priced at the plan version the downgrade was scheduled for.*/
+type Downgrade struct {
+	AccountID       string           `gorm:"primary_key" json:"accountID" binding:"required,len=64"`
+	OldStorageLimit StorageLimitType `json:"oldStorageLimit" binding:"required,gte=10" example:"1024"`
+	NewStorageLimit StorageLimitType `json:"newStorageLimit" binding:"required,gte=10" example:"128"`
+	PlanID          uint             `json:"planID"`
+	CreatedAt       time.Time        `json:"createdAt"`
+	UpdatedAt       time.Time        `json:"updatedAt"`
+}
+
+/*DowngradeCredit is what is left of the account's current term when it downgrades right away, either as the
+months it extends the subscription to or as a balance off future invoices*/
+type DowngradeCredit struct {
+	MonthsInSubscription int
+	CreditInOPCT         float64
+}
+
+var (
+	/*ErrDowngradeStorageUsed is returned when the account uses more storage than the smaller plan has*/
+	ErrDowngradeStorageUsed = errors.New("the account uses more storage than the smaller plan allows")
+
+	/*ErrDowngradeTooManyFolders is returned when the account has more folders than the smaller plan allows*/
+	ErrDowngradeTooManyFolders = errors.New("the account has more folders than the smaller plan allows")
+
+	/*ErrDowngradeMetadataTooLarge is returned when the account's metadata is larger than the smaller plan allows*/
+	ErrDowngradeMetadataTooLarge = errors.New("the account has more metadata than the smaller plan allows")
+
+	/*ErrDowngradeCreditAsTime is returned when taking credit as time on a plan that costs nothing*/
+	ErrDowngradeCreditAsTime = errors.New("credit for downgrading to a free plan can only be taken as a balance")
+)
+
+/*BeforeCreate - callback called before the row is created*/
+func (downgrade *Downgrade) BeforeCreate(scope *gorm.Scope) error {
+	return utils.Validator.Struct(downgrade)
+}
+
+/*BeforeUpdate - callback called before the row is updated*/
+func (downgrade *Downgrade) BeforeUpdate(scope *gorm.Scope) error {
+	return utils.Validator.Struct(downgrade)
+}
+
+/*CheckDowngradeFits returns why the account's storage, folders or metadata don't fit in plan, or nil if they do.
+The storage of an organization's owner is everything its members stored too.*/
+func (account *Account) CheckDowngradeFits(plan utils.PlanInfo) error {
+	storageUsedInByte := account.StorageUsedInByte
+	if DB != nil {
+		if organization, err := GetOwnedOrganization(account.AccountID); err == nil {
+			storageUsedInByte = organization.StorageUsedInByte
+		}
+	}
+	if storageUsedInByte > int64(plan.StorageInGB)*1e9 {
+		return ErrDowngradeStorageUsed
+	}
+	if account.TotalFolders > plan.MaxFolders {
+		return ErrDowngradeTooManyFolders
+	}
+	if account.TotalMetadataSizeInBytes > plan.MaxMetadataSizeInMB*1e6 {
+		return ErrDowngradeMetadataTooLarge
+	}
+	return nil
+}
+
+/*DowngradeCredit works out the credit for the rest of the account's term if it moved to plan now.  The months
+left are worth more on the cheaper plan, so they either stretch into more months of it or the difference in
+price becomes a balance.*/
+func (account *Account) DowngradeCredit(plan utils.PlanInfo) DowngradeCredit {
+	monthsSinceCreation := differenceInMonths(account.CreatedAt, time.Now())
+	monthsRemaining := account.MonthsInSubscription - monthsSinceCreation
+	credit := DowngradeCredit{MonthsInSubscription: account.MonthsInSubscription}
+	if monthsRemaining <= 0 {
+		return credit
+	}
+
+	// the months left are only worth what was paid for them, so a coupon's discount is left out of the credit
+	costOfCurrentPlan, _ := account.Cost()
+	costOfNewPlan := utils.SubscriptionCost(plan, account.MonthsInSubscription)
+	if costOfNewPlan > 0 {
+		monthsOnNewPlan := math.Floor(float64(monthsRemaining) * costOfCurrentPlan / costOfNewPlan)
+		credit.MonthsInSubscription = monthsSinceCreation + int(monthsOnNewPlan)
+	}
+	credit.CreditInOPCT = utils.RoundCost(math.Max(0,
+		(costOfCurrentPlan-costOfNewPlan)*float64(monthsRemaining)/float64(account.MonthsInSubscription)))
+	return credit
+}
+
+/*DowngradeAccount moves the account to the plan on sale for downgradeStorageLimit right away, crediting the rest
+of its term as more months if creditAsTime is set or as a balance if it isn't*/
+func (account *Account) DowngradeAccount(downgradeStorageLimit int, creditAsTime bool) (DowngradeCredit, error) {
+	plan, ok := GetPurchasablePlan(downgradeStorageLimit)
+	if !ok {
+		return DowngradeCredit{}, InvalidStorageLimitError
+	}
+	if err := account.CheckDowngradeFits(plan.Info()); err != nil {
+		return DowngradeCredit{}, err
+	}
+	credit := account.DowngradeCredit(plan.Info())
+	if creditAsTime && plan.Cost == 0 {
+		return credit, ErrDowngradeCreditAsTime
+	}
+
+	updates := map[string]interface{}{
+		"storage_limit": StorageLimitType(downgradeStorageLimit),
+		"plan_id":       plan.ID,
+		"updated_at":    time.Now(),
+	}
+	if creditAsTime {
+		credit.CreditInOPCT = 0
+		updates["months_in_subscription"] = credit.MonthsInSubscription
+		updates["expired_at"] = account.CreatedAt.AddDate(0, credit.MonthsInSubscription, 0)
+	} else {
+		credit.MonthsInSubscription = account.MonthsInSubscription
+		updates["credit_in_opct"] = gorm.Expr("credit_in_opct + ?", credit.CreditInOPCT)
+	}
+	if err := DB.Model(account).Updates(updates).Error; err != nil {
+		return credit, err
+	}
+	account.StorageLimit = StorageLimitType(downgradeStorageLimit)
+	account.PlanID = plan.ID
+	account.MonthsInSubscription = credit.MonthsInSubscription
+	if !creditAsTime {
+		account.CreditInOPCT += credit.CreditInOPCT
+	}
+
+	if err := CancelDowngrade(account.AccountID); err != nil && !gorm.IsRecordNotFoundError(err) {
+		return credit, err
+	}
+	account.logLedgerEntry(LedgerPlanChange, 0, "", downgradeLedgerDescription(account.StorageLimit))
+	if credit.CreditInOPCT > 0 {
+		account.logLedgerEntry(LedgerCredit, credit.CreditInOPCT, "", downgradeLedgerDescription(account.StorageLimit))
+	}
+	if creditAsTime {
+		account.queueExpirationExtension(account.CreatedAt.AddDate(0, credit.MonthsInSubscription, 0))
+	}
+	return credit, nil
+}
+
+/*ScheduleDowngrade moves the account to the plan on sale for downgradeStorageLimit when it is next renewed.  It
+replaces a downgrade the account already scheduled.*/
+func (account *Account) ScheduleDowngrade(downgradeStorageLimit int) (Downgrade, error) {
+	plan, ok := GetPurchasablePlan(downgradeStorageLimit)
+	if !ok {
+		return Downgrade{}, InvalidStorageLimitError
+	}
+	if err := account.CheckDowngradeFits(plan.Info()); err != nil {
+		return Downgrade{}, err
+	}
+
+	downgrade := Downgrade{
+		AccountID:       account.AccountID,
+		OldStorageLimit: account.StorageLimit,
+		NewStorageLimit: StorageLimitType(downgradeStorageLimit),
+		PlanID:          plan.ID,
+	}
+	existing, err := GetScheduledDowngrade(account.AccountID)
+	if gorm.IsRecordNotFoundError(err) {
+		return downgrade, DB.Create(&downgrade).Error
+	}
+	if err != nil {
+		return downgrade, err
+	}
+	downgrade.CreatedAt = existing.CreatedAt
+	return downgrade, DB.Save(&downgrade).Error
+}
+
+/*GetScheduledDowngrade returns the downgrade an account scheduled for its next renewal*/
+func GetScheduledDowngrade(accountID string) (Downgrade, error) {
+	downgrade := Downgrade{}
+	err := DB.Where("account_id = ?", accountID).First(&downgrade).Error
+	return downgrade, err
+}
+
+/*CancelDowngrade cancels the downgrade an account scheduled.  It returns gorm.ErrRecordNotFound if there was
+none.*/
+func CancelDowngrade(accountID string) error {
+	db := DB.Where("account_id = ?", accountID).Delete(&Downgrade{})
+	if db.Error != nil {
+		return db.Error
+	}
+	if db.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+/*renewalPlan returns the plan the account renews on, which is the plan of a scheduled downgrade if there is one*/
+func (account *Account) renewalPlan() (utils.PlanInfo, *Downgrade) {
+	if DB == nil {
+		return account.Plan(), nil
+	}
+	downgrade, err := GetScheduledDowngrade(account.AccountID)
+	if err != nil {
+		return account.Plan(), nil
+	}
+	return downgrade.Plan(), &downgrade
+}
+
+/*Plan returns the plan version the downgrade was scheduled for, or the newest version for its storage limit if
+that one is gone*/
+func (downgrade Downgrade) Plan() utils.PlanInfo {
+	if plan, err := GetPlan(downgrade.PlanID); err == nil {
+		return plan.Info()
+	}
+	plan, _ := latestPlan(int(downgrade.NewStorageLimit), false)
+	return plan.Info()
+}
+
+/*RenewalStorageLimit returns the storage limit, in GB, of the plan the account renews on*/
+func (account *Account) RenewalStorageLimit() int {
+	plan, _ := account.renewalPlan()
+	return plan.StorageInGB
+}
@@ -0,0 +1,202 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/opacity/storage-node/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Init_Downgrades(t *testing.T) {
+	utils.SetTesting("../.env")
+	Connect(utils.Env.TestDatabaseURL)
+}
+
+func returnProfessionalAccountForDowngradeTest() Account {
+	account := returnValidAccount()
+	account.StorageLimit = ProfessionalStorageLimit
+	account.PaymentStatus = PaymentRetrievalComplete
+	account.CreatedAt = time.Now().AddDate(0, -6, 0)
+	account.ExpiredAt = account.CreatedAt.AddDate(0, account.MonthsInSubscription, 0)
+	return account
+}
+
+func Test_CheckDowngradeFits(t *testing.T) {
+	basic := utils.Env.Plans[int(BasicStorageLimit)]
+
+	tests := []struct {
+		name         string
+		storageUsed  int64
+		folders      int
+		metadataSize int64
+		err          error
+	}{
+		{"fits", 100 * 1e9, 100, 1e6, nil},
+		{"fits exactly", 128 * 1e9, 2000, 200 * 1e6, nil},
+		{"too much storage", 129 * 1e9, 100, 1e6, ErrDowngradeStorageUsed},
+		{"too many folders", 100 * 1e9, 2001, 1e6, ErrDowngradeTooManyFolders},
+		{"too much metadata", 100 * 1e9, 100, 201 * 1e6, ErrDowngradeMetadataTooLarge},
+	}
+
+	for _, tt := range tests {
+		account := returnProfessionalAccountForDowngradeTest()
+		account.StorageUsedInByte = tt.storageUsed
+		account.TotalFolders = tt.folders
+		account.TotalMetadataSizeInBytes = tt.metadataSize
+
+		assert.Equal(t, tt.err, account.CheckDowngradeFits(basic), tt.name)
+	}
+}
+
+func Test_DowngradeCredit(t *testing.T) {
+	tests := []struct {
+		name         string
+		storageLimit StorageLimitType
+		months       int
+		creditInOPCT float64
+	}{
+		{"half a year of professional stretches into four years of basic", BasicStorageLimit, 54, 7},
+		{"credit for a free plan is only a balance", 10, 12, 8},
+	}
+
+	for _, tt := range tests {
+		account := returnProfessionalAccountForDowngradeTest()
+
+		credit := account.DowngradeCredit(utils.Env.Plans[int(tt.storageLimit)])
+		assert.Equal(t, tt.months, credit.MonthsInSubscription, tt.name)
+		assert.Equal(t, tt.creditInOPCT, credit.CreditInOPCT, tt.name)
+	}
+}
+
+func Test_DowngradeCredit_Leaves_Out_Coupon_Discount(t *testing.T) {
+	tests := []struct {
+		name           string
+		discountInOPCT float64
+		months         int
+		creditInOPCT   float64
+	}{
+		{"half off stretches into half as much basic", 8, 30, 3},
+		{"a free subscription has nothing to credit", 16, 6, 0},
+	}
+
+	for _, tt := range tests {
+		account := returnProfessionalAccountForDowngradeTest()
+		account.DiscountInOPCT = tt.discountInOPCT
+
+		credit := account.DowngradeCredit(utils.Env.Plans[int(BasicStorageLimit)])
+		assert.Equal(t, tt.months, credit.MonthsInSubscription, tt.name)
+		assert.Equal(t, tt.creditInOPCT, credit.CreditInOPCT, tt.name)
+	}
+}
+
+func Test_DowngradeCredit_Expired_Term_Has_No_Credit(t *testing.T) {
+	account := returnProfessionalAccountForDowngradeTest()
+	account.CreatedAt = time.Now().AddDate(-2, 0, 0)
+
+	credit := account.DowngradeCredit(utils.Env.Plans[int(BasicStorageLimit)])
+	assert.Equal(t, account.MonthsInSubscription, credit.MonthsInSubscription)
+	assert.Equal(t, 0.0, credit.CreditInOPCT)
+}
+
+func Test_DowngradeAccount_Credit_As_Time(t *testing.T) {
+	DeleteAccountsForTest(t)
+	DeleteDowngradesForTest(t)
+
+	account := returnProfessionalAccountForDowngradeTest()
+	assert.Nil(t, DB.Create(&account).Error)
+
+	credit, err := account.DowngradeAccount(int(BasicStorageLimit), true)
+	assert.Nil(t, err)
+	assert.Equal(t, 54, credit.MonthsInSubscription)
+	assert.Equal(t, 0.0, credit.CreditInOPCT)
+
+	accountFromDB, _ := GetAccountById(account.AccountID)
+	assert.Equal(t, BasicStorageLimit, accountFromDB.StorageLimit)
+	assert.Equal(t, 54, accountFromDB.MonthsInSubscription)
+	assert.Equal(t, 0.0, accountFromDB.CreditInOPCT)
+}
+
+func Test_DowngradeAccount_Credit_As_Balance(t *testing.T) {
+	DeleteAccountsForTest(t)
+	DeleteDowngradesForTest(t)
+
+	account := returnProfessionalAccountForDowngradeTest()
+	assert.Nil(t, DB.Create(&account).Error)
+
+	credit, err := account.DowngradeAccount(int(BasicStorageLimit), false)
+	assert.Nil(t, err)
+	assert.Equal(t, 7.0, credit.CreditInOPCT)
+
+	accountFromDB, _ := GetAccountById(account.AccountID)
+	assert.Equal(t, BasicStorageLimit, accountFromDB.StorageLimit)
+	assert.Equal(t, DefaultMonthsPerSubscription, accountFromDB.MonthsInSubscription)
+	assert.Equal(t, 7.0, accountFromDB.CreditInOPCT)
+
+	assert.Nil(t, accountFromDB.UseCredit(5))
+	accountFromDB, _ = GetAccountById(account.AccountID)
+	assert.Equal(t, 2.0, accountFromDB.CreditInOPCT)
+}
+
+func Test_DowngradeAccount_Too_Much_Storage_Used(t *testing.T) {
+	DeleteAccountsForTest(t)
+	DeleteDowngradesForTest(t)
+
+	account := returnProfessionalAccountForDowngradeTest()
+	account.StorageUsedInByte = 500 * 1e9
+	assert.Nil(t, DB.Create(&account).Error)
+
+	_, err := account.DowngradeAccount(int(BasicStorageLimit), true)
+	assert.Equal(t, ErrDowngradeStorageUsed, err)
+
+	accountFromDB, _ := GetAccountById(account.AccountID)
+	assert.Equal(t, ProfessionalStorageLimit, accountFromDB.StorageLimit)
+}
+
+func Test_ScheduleDowngrade_Applies_At_Renewal(t *testing.T) {
+	DeleteAccountsForTest(t)
+	DeleteDowngradesForTest(t)
+
+	account := returnProfessionalAccountForDowngradeTest()
+	assert.Nil(t, DB.Create(&account).Error)
+
+	_, err := account.ScheduleDowngrade(int(BasicStorageLimit))
+	assert.Nil(t, err)
+
+	renewalCost, _ := account.RenewalCost()
+	assert.Equal(t, utils.Env.Plans[int(BasicStorageLimit)].Cost, renewalCost)
+
+	assert.Nil(t, account.RenewAccount())
+	accountFromDB, _ := GetAccountById(account.AccountID)
+	assert.Equal(t, BasicStorageLimit, accountFromDB.StorageLimit)
+	assert.Equal(t, DefaultMonthsPerSubscription+12, accountFromDB.MonthsInSubscription)
+
+	_, err = GetScheduledDowngrade(account.AccountID)
+	assert.True(t, gorm.IsRecordNotFoundError(err))
+}
+
+func Test_Downgrade_Plan_Falls_Back_To_Latest_Version(t *testing.T) {
+	downgrade := Downgrade{OldStorageLimit: ProfessionalStorageLimit, NewStorageLimit: BasicStorageLimit,
+		PlanID: 1e6}
+
+	plan, _ := latestPlan(int(BasicStorageLimit), false)
+	assert.Equal(t, plan.Info(), downgrade.Plan())
+}
+
+func Test_CancelDowngrade(t *testing.T) {
+	DeleteAccountsForTest(t)
+	DeleteDowngradesForTest(t)
+
+	account := returnProfessionalAccountForDowngradeTest()
+	assert.Nil(t, DB.Create(&account).Error)
+
+	assert.True(t, gorm.IsRecordNotFoundError(CancelDowngrade(account.AccountID)))
+
+	_, err := account.ScheduleDowngrade(int(BasicStorageLimit))
+	assert.Nil(t, err)
+	assert.Nil(t, CancelDowngrade(account.AccountID))
+
+	renewalCost, _ := account.RenewalCost()
+	assert.Equal(t, utils.Env.Plans[int(ProfessionalStorageLimit)].Cost, renewalCost)
+}
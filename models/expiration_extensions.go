@@ -0,0 +1,198 @@
+package models
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/opacity/storage-node/utils"
+)
+
+/*MetadataExpirationGracePeriod returns how long metadata is kept past the account's expiration date, which is
+until the account is purged, so it can still be read while the account is in its grace period*/
+func MetadataExpirationGracePeriod() time.Duration {
+	return AccountGracePeriod() + AccountSuspensionPeriod()
+}
+
+// these must match getPermissionHashKeyForBadger, getVersionKeyForBadger and numMetadatasToRetain in routes
+const metadataPermissionHashKeySuffix = "_permissionHash"
+const numMetadataVersionKeys = 5
+
+/*ExpirationExtension tracks extending the TTLs of an account's metadata and the ExpiredAt of its completed
+files to a new account expiration date.  The cursors let a run pick up where the last one stopped.  Only metadata
+in the account's AccountMetadataKey index is extended.  Metadata that hasn't been set or read since the index was
+added isn't in it, and keeps its old TTL.*/
+type ExpirationExtension struct {
+	AccountID         string                        `gorm:"primary_key;type:varchar(64)" json:"accountID" binding:"required,len=64"`
+	CreatedAt         time.Time                     `json:"createdAt"`
+	UpdatedAt         time.Time                     `json:"updatedAt"`
+	ExpiredAt         time.Time                     `json:"expiredAt" binding:"required"`
+	Status            ExpirationExtensionStatusType `json:"status" binding:"required,gte=1"`
+	LastMetadataKey   string                        `json:"lastMetadataKey"`
+	LastFileID        string                        `json:"lastFileID"`
+	MetadatasExtended int                           `json:"metadatasExtended" binding:"omitempty,gte=0" gorm:"default:0"`
+	FilesExtended     int                           `json:"filesExtended" binding:"omitempty,gte=0" gorm:"default:0"`
+}
+
+/*ExpirationExtensionStatusType defines a type for the expiration extension statuses*/
+type ExpirationExtensionStatusType int
+
+const (
+	/*ExtendingMetadatas - the metadata TTLs are being extended*/
+	ExtendingMetadatas ExpirationExtensionStatusType = iota + 1
+
+	/*ExtendingFiles - the metadata is done and the completed files are being extended*/
+	ExtendingFiles
+
+	/*ExpirationExtensionComplete - everything has been extended*/
+	ExpirationExtensionComplete
+)
+
+/*ExpirationExtensionStatusMap is for pretty printing the ExpirationExtensionStatus*/
+var ExpirationExtensionStatusMap = map[ExpirationExtensionStatusType]string{
+	ExtendingMetadatas:          "ExtendingMetadatas",
+	ExtendingFiles:              "ExtendingFiles",
+	ExpirationExtensionComplete: "ExpirationExtensionComplete",
+}
+
+/*BeforeCreate - callback called before the row is created*/
+func (extension *ExpirationExtension) BeforeCreate(scope *gorm.Scope) error {
+	return utils.Validator.Struct(extension)
+}
+
+/*BeforeUpdate - callback called before the row is updated*/
+func (extension *ExpirationExtension) BeforeUpdate(scope *gorm.Scope) error {
+	return utils.Validator.Struct(extension)
+}
+
+/*QueueExpirationExtension starts extending an account's metadata and files to expiredAt.  If an extension is
+already underway for the account it starts over with the new date, since everything it already extended
+needs the new date too.*/
+func QueueExpirationExtension(accountID string, expiredAt time.Time) error {
+	extension := ExpirationExtension{
+		AccountID: accountID,
+		ExpiredAt: expiredAt,
+		Status:    ExtendingMetadatas,
+	}
+	if err := utils.Validator.Struct(extension); err != nil {
+		return err
+	}
+	return DB.Exec("INSERT INTO expiration_extensions (account_id, created_at, updated_at, expired_at, status, "+
+		"last_metadata_key, last_file_id, metadatas_extended, files_extended) VALUES (?, ?, ?, ?, ?, '', '', 0, 0) "+
+		"ON DUPLICATE KEY UPDATE updated_at = VALUES(updated_at), expired_at = VALUES(expired_at), "+
+		"status = VALUES(status), last_metadata_key = '', last_file_id = '', metadatas_extended = 0, "+
+		"files_extended = 0", accountID, time.Now(), time.Now(), expiredAt, ExtendingMetadatas).Error
+}
+
+/*GetExpirationExtension returns the expiration extension of an account*/
+func GetExpirationExtension(accountID string) (ExpirationExtension, error) {
+	extension := ExpirationExtension{}
+	err := DB.Where("account_id = ?", accountID).First(&extension).Error
+	return extension, err
+}
+
+/*GetIncompleteExpirationExtensions returns every expiration extension that still has work left, oldest first*/
+func GetIncompleteExpirationExtensions() ([]ExpirationExtension, error) {
+	var extensions []ExpirationExtension
+	err := DB.Where("status < ?", ExpirationExtensionComplete).Order("updated_at asc").Find(&extensions).Error
+	return extensions, err
+}
+
+/*DeleteCompletedExpirationExtensionsOlderThan deletes finished expiration extensions last updated before
+updatedTime*/
+func DeleteCompletedExpirationExtensionsOlderThan(updatedTime time.Time) error {
+	return DB.Where("status = ? AND updated_at < ?", ExpirationExtensionComplete, updatedTime).
+		Delete(&ExpirationExtension{}).Error
+}
+
+/*Run extends the next batchSize metadatas or files and saves the cursor, returning true once everything
+has been extended.  Call it until it returns true.*/
+func (extension *ExpirationExtension) Run(batchSize int) (bool, error) {
+	switch extension.Status {
+	case ExtendingMetadatas:
+		return false, extension.extendNextMetadatas(batchSize)
+	case ExtendingFiles:
+		return false, extension.extendNextFiles(batchSize)
+	}
+	return true, nil
+}
+
+func (extension *ExpirationExtension) extendNextMetadatas(batchSize int) error {
+	var metadataKeys []string
+	if err := DB.Model(&AccountMetadataKey{}).Where("account_id = ? AND metadata_key > ?",
+		extension.AccountID, extension.LastMetadataKey).Order("metadata_key asc").Limit(batchSize).
+		Pluck("metadata_key", &metadataKeys).Error; err != nil {
+		return err
+	}
+
+	if len(metadataKeys) == 0 {
+		return extension.saveProgress(map[string]interface{}{"status": ExtendingFiles})
+	}
+
+	var kvKeys utils.KVKeys
+	for _, metadataKey := range metadataKeys {
+		kvKeys = append(kvKeys, metadataKey, metadataKey+metadataPermissionHashKeySuffix)
+		for i := 0; i < numMetadataVersionKeys; i++ {
+			kvKeys = append(kvKeys, metadataKey+"_"+strconv.Itoa(i))
+		}
+	}
+	kvs, err := utils.BatchGet(&kvKeys)
+	if err != nil {
+		return err
+	}
+	// each pair is only set again if it still holds the value just read, so a metadata set or deleted in the
+	// meantime keeps its new value or stays deleted
+	ttl := time.Until(extension.ExpiredAt.Add(MetadataExpirationGracePeriod()))
+	for key, value := range *kvs {
+		if _, err := utils.CompareAndSwap(key, value, value, ttl); err != nil {
+			return err
+		}
+	}
+
+	return extension.saveProgress(map[string]interface{}{
+		"last_metadata_key":  metadataKeys[len(metadataKeys)-1],
+		"metadatas_extended": extension.MetadatasExtended + len(metadataKeys),
+	})
+}
+
+func (extension *ExpirationExtension) extendNextFiles(batchSize int) error {
+	var fileIDs []string
+	if err := DB.Model(&CompletedFile{}).Where("account_id = ? AND file_id > ?",
+		extension.AccountID, extension.LastFileID).Order("file_id asc").Limit(batchSize).
+		Pluck("file_id", &fileIDs).Error; err != nil {
+		return err
+	}
+
+	if len(fileIDs) == 0 {
+		return extension.saveProgress(map[string]interface{}{"status": ExpirationExtensionComplete})
+	}
+
+	if err := DB.Table("completed_files").Where("file_id IN (?)", fileIDs).Updates(map[string]interface{}{
+		"expired_at": extension.ExpiredAt,
+		"updated_at": time.Now(),
+	}).Error; err != nil {
+		return err
+	}
+
+	return extension.saveProgress(map[string]interface{}{
+		"last_file_id":   fileIDs[len(fileIDs)-1],
+		"files_extended": extension.FilesExtended + len(fileIDs),
+	})
+}
+
+/*saveProgress saves the cursor and reloads the extension.  If the extension was queued again with a new date
+since it was loaded nothing is saved, and the reload starts the run over with the new date.*/
+func (extension *ExpirationExtension) saveProgress(progress map[string]interface{}) error {
+	progress["updated_at"] = time.Now()
+	if err := DB.Model(&ExpirationExtension{}).Where("account_id = ? AND expired_at = ? AND status = ?",
+		extension.AccountID, extension.ExpiredAt, extension.Status).UpdateColumns(progress).Error; err != nil {
+		return err
+	}
+
+	reloaded, err := GetExpirationExtension(extension.AccountID)
+	if err != nil {
+		return err
+	}
+	*extension = reloaded
+	return nil
+}
@@ -0,0 +1,110 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/opacity/storage-node/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Init_Expiration_Extensions(t *testing.T) {
+	utils.SetTesting("../.env")
+	Connect(utils.Env.TestDatabaseURL)
+}
+
+func runExpirationExtensionForTest(t *testing.T, accountID string) ExpirationExtension {
+	extension, err := GetExpirationExtension(accountID)
+	assert.Nil(t, err)
+	for i := 0; i < 10; i++ {
+		done, err := extension.Run(1)
+		assert.Nil(t, err)
+		if done {
+			return extension
+		}
+	}
+	t.Fatalf("expiration extension should have finished")
+	return extension
+}
+
+func Test_ExpirationExtension_Extends_Metadatas_And_Files(t *testing.T) {
+	DeleteExpirationExtensionsForTest(t)
+	accountID := utils.RandSeqFromRunes(AccountIDLength, []rune("abcdef01234567890"))
+	expiredAt := time.Now().Add(365 * 24 * time.Hour).Truncate(time.Second)
+
+	metadataKey := utils.RandSeqFromRunes(64, []rune("abcdef01234567890"))
+	assert.Nil(t, utils.BatchSet(&utils.KVPairs{
+		metadataKey: "metadata",
+		metadataKey + metadataPermissionHashKeySuffix: "permissionHash",
+	}, time.Hour))
+	assert.Nil(t, AddAccountMetadataKey(accountID, metadataKey))
+	// an indexed key that was deleted must not come back
+	deletedMetadataKey := utils.RandSeqFromRunes(64, []rune("abcdef01234567890"))
+	assert.Nil(t, AddAccountMetadataKey(accountID, deletedMetadataKey))
+
+	completedFile := CompletedFile{
+		FileID:       utils.GenerateFileHandle(),
+		ModifierHash: utils.GenerateFileHandle(),
+		ExpiredAt:    time.Now(),
+		AccountID:    accountID,
+	}
+	assert.Nil(t, DB.Create(&completedFile).Error)
+
+	assert.Nil(t, QueueExpirationExtension(accountID, expiredAt))
+	extension := runExpirationExtensionForTest(t, accountID)
+
+	assert.Equal(t, ExpirationExtensionComplete, extension.Status)
+	assert.Equal(t, 2, extension.MetadatasExtended)
+	assert.Equal(t, 1, extension.FilesExtended)
+
+	_, metadataExpiration, err := utils.GetValueFromKV(metadataKey)
+	assert.Nil(t, err)
+	assert.True(t, metadataExpiration.After(expiredAt.Add(MetadataExpirationGracePeriod()).Add(-time.Minute)))
+	_, _, err = utils.GetValueFromKV(deletedMetadataKey)
+	assert.Equal(t, utils.ErrKeyNotFound, err)
+
+	completedFile, err = GetCompletedFileByFileID(completedFile.FileID)
+	assert.Nil(t, err)
+	assert.Equal(t, expiredAt.Unix(), completedFile.ExpiredAt.Unix())
+
+	incomplete, err := GetIncompleteExpirationExtensions()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(incomplete))
+}
+
+func Test_QueueExpirationExtension_Starts_Over(t *testing.T) {
+	DeleteExpirationExtensionsForTest(t)
+	accountID := utils.RandSeqFromRunes(AccountIDLength, []rune("abcdef01234567890"))
+	assert.Nil(t, AddAccountMetadataKey(accountID, utils.RandSeqFromRunes(64, []rune("abcdef01234567890"))))
+
+	assert.Nil(t, QueueExpirationExtension(accountID, time.Now().Add(time.Hour)))
+	extension := runExpirationExtensionForTest(t, accountID)
+	assert.Equal(t, ExpirationExtensionComplete, extension.Status)
+
+	newExpiredAt := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+	assert.Nil(t, QueueExpirationExtension(accountID, newExpiredAt))
+
+	extension, err := GetExpirationExtension(accountID)
+	assert.Nil(t, err)
+	assert.Equal(t, ExtendingMetadatas, extension.Status)
+	assert.Equal(t, "", extension.LastMetadataKey)
+	assert.Equal(t, 0, extension.MetadatasExtended)
+	assert.Equal(t, newExpiredAt.Unix(), extension.ExpiredAt.Unix())
+
+	incomplete, err := GetIncompleteExpirationExtensions()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(incomplete))
+}
+
+func Test_RenewAccount_Queues_Expiration_Extension(t *testing.T) {
+	DeleteExpirationExtensionsForTest(t)
+	account := returnValidAccount()
+	assert.Nil(t, DB.Create(&account).Error)
+
+	assert.Nil(t, account.RenewAccount())
+
+	extension, err := GetExpirationExtension(account.AccountID)
+	assert.Nil(t, err)
+	assert.Equal(t, ExtendingMetadatas, extension.Status)
+	assert.Equal(t, account.CreatedAt.AddDate(0, account.MonthsInSubscription, 0).Unix(), extension.ExpiredAt.Unix())
+}
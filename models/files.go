@@ -26,6 +26,7 @@ type File struct {
 	CompletedIndexes *string   `json:"completedIndexes" gorm:"type:mediumtext"`
 	ModifierHash     string    `json:"modifierHash" binding:"required,len=64" minLength:"64" maxLength:"64"`
 	ApiVersion       int       `json:"apiVersion" binding:"omitempty,gte=1" gorm:"default:1"`
+	AccountID        string    `json:"accountID" binding:"omitempty,len=64" gorm:"type:varchar(64)"`
 }
 
 type IndexMap map[int64]*s3.CompletedPart
@@ -244,6 +245,7 @@ func (file *File) FinishUpload() (CompletedFile, error) {
 		ExpiredAt:      file.ExpiredAt,
 		FileSizeInByte: objectSize,
 		ModifierHash:   file.ModifierHash,
+		AccountID:      file.AccountID,
 	}
 	if err := DB.Save(&completedFile).Error; err != nil {
 		return CompletedFile{}, err
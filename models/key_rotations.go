@@ -0,0 +1,407 @@
+package models
+
+import (
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/opacity/storage-node/utils"
+)
+
+/*KeyRotation tracks moving an account from one public key to another.  The account row and everything that
+refers to it by account ID move in one transaction when the rotation starts.  The metadata permission hashes
+and completed file modifier hashes are re-derived afterwards in batches, and the cursors let a run pick up
+where the last one stopped.*/
+type KeyRotation struct {
+	OldAccountID     string                `gorm:"primary_key;type:varchar(64)" json:"oldAccountID" binding:"required,len=64"`
+	NewAccountID     string                `gorm:"type:varchar(64);unique_index" json:"newAccountID" binding:"required,len=64"`
+	OldPublicKey     string                `gorm:"type:varchar(66)" json:"oldPublicKey" binding:"required,len=66"`
+	NewPublicKey     string                `gorm:"type:varchar(66);unique_index" json:"newPublicKey" binding:"required,len=66"`
+	CreatedAt        time.Time             `json:"createdAt"`
+	UpdatedAt        time.Time             `json:"updatedAt"`
+	Status           KeyRotationStatusType `json:"status" binding:"required,gte=1"`
+	LastMetadataKey  string                `json:"lastMetadataKey"`
+	LastFileID       string                `json:"lastFileID"`
+	MetadatasRekeyed int                   `json:"metadatasRekeyed" binding:"omitempty,gte=0" gorm:"default:0"`
+	FilesRekeyed     int                   `json:"filesRekeyed" binding:"omitempty,gte=0" gorm:"default:0"`
+}
+
+/*KeyRotationStatusType defines a type for the key rotation statuses*/
+type KeyRotationStatusType int
+
+const (
+	/*RekeyingMetadatas - the account has moved and the metadata permission hashes are being re-derived*/
+	RekeyingMetadatas KeyRotationStatusType = iota + 1
+
+	/*RekeyingFiles - the metadata is done and the completed file modifier hashes are being re-derived*/
+	RekeyingFiles
+
+	/*KeyRotationComplete - everything has been re-keyed*/
+	KeyRotationComplete
+)
+
+/*KeyRotationStatusMap is for pretty printing the KeyRotationStatus*/
+var KeyRotationStatusMap = map[KeyRotationStatusType]string{
+	RekeyingMetadatas:   "RekeyingMetadatas",
+	RekeyingFiles:       "RekeyingFiles",
+	KeyRotationComplete: "KeyRotationComplete",
+}
+
+/*ErrKeyRotationTargetExists is returned when rotating to a public key that already has an account*/
+var ErrKeyRotationTargetExists = errors.New("an account already exists for the new public key")
+
+/*ErrKeyRotatedAway is returned when rotating to a public key that an account has already rotated away from*/
+var ErrKeyRotatedAway = errors.New("the new public key was rotated away from and cannot be used again")
+
+/*ErrKeyRotationInProgress is returned when rotating an account whose last rotation hasn't finished*/
+var ErrKeyRotationInProgress = errors.New("the account's last key rotation has not finished yet")
+
+// how many rotations back permissions are looked up for things the batches haven't re-keyed
+const maxKeyRotationChainLength = 10
+
+/*BeforeCreate - callback called before the row is created*/
+func (rotation *KeyRotation) BeforeCreate(scope *gorm.Scope) error {
+	return utils.Validator.Struct(rotation)
+}
+
+/*BeforeUpdate - callback called before the row is updated*/
+func (rotation *KeyRotation) BeforeUpdate(scope *gorm.Scope) error {
+	return utils.Validator.Struct(rotation)
+}
+
+/*StartKeyRotation moves the account of oldPublicKey to newPublicKey.  In one transaction it re-keys the account
+row, re-encrypts the eth private keys of the account and its pending upgrades and renewals (which use the
+account ID as the nonce), moves every row that refers to the account ID, re-derives the modifier hashes of
+uploads in progress, and records the rotation for the batches that re-key metadata and completed files.
+Either all of it happens or none of it does.*/
+func StartKeyRotation(oldPublicKey, newPublicKey string) (KeyRotation, error) {
+	oldAccountID, err := utils.HashString(oldPublicKey)
+	if err != nil {
+		return KeyRotation{}, err
+	}
+	newAccountID, err := utils.HashString(newPublicKey)
+	if err != nil {
+		return KeyRotation{}, err
+	}
+	rotation := KeyRotation{
+		OldAccountID: oldAccountID,
+		NewAccountID: newAccountID,
+		OldPublicKey: oldPublicKey,
+		NewPublicKey: newPublicKey,
+		Status:       RekeyingMetadatas,
+	}
+
+	tx := DB.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+	if err := tx.Error; err != nil {
+		return rotation, err
+	}
+
+	if err := rekeyAccount(tx, rotation); err != nil {
+		tx.Rollback()
+		return rotation, err
+	}
+	if err := tx.Create(&rotation).Error; err != nil {
+		tx.Rollback()
+		return rotation, err
+	}
+	return rotation, tx.Commit().Error
+}
+
+func rekeyAccount(tx *gorm.DB, rotation KeyRotation) error {
+	account := Account{}
+	if err := tx.Set("gorm:query_option", "FOR UPDATE").Where("account_id = ?", rotation.OldAccountID).
+		First(&account).Error; err != nil {
+		return err
+	}
+
+	count := 0
+	if err := tx.Model(&Account{}).Where("account_id = ?", rotation.NewAccountID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrKeyRotationTargetExists
+	}
+	if err := tx.Model(&KeyRotation{}).Where("old_account_id = ?", rotation.NewAccountID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrKeyRotatedAway
+	}
+	if err := tx.Model(&KeyRotation{}).Where("new_account_id = ? AND status < ?", rotation.OldAccountID,
+		KeyRotationComplete).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrKeyRotationInProgress
+	}
+
+	ethPrivateKey, err := reencryptEthPrivateKey(account.EthPrivateKey, rotation)
+	if err != nil {
+		return err
+	}
+	// UpdateColumns skips BeforeUpdate, which would move the expiration date
+	if err := tx.Model(&Account{}).Where("account_id = ?", rotation.OldAccountID).UpdateColumns(
+		map[string]interface{}{"account_id": rotation.NewAccountID, "eth_private_key": ethPrivateKey}).Error; err != nil {
+		return err
+	}
+
+	var upgrades []Upgrade
+	if err := tx.Where("account_id = ?", rotation.OldAccountID).Find(&upgrades).Error; err != nil {
+		return err
+	}
+	for _, upgrade := range upgrades {
+		if ethPrivateKey, err = reencryptEthPrivateKey(upgrade.EthPrivateKey, rotation); err != nil {
+			return err
+		}
+		if err := tx.Model(&Upgrade{}).Where("account_id = ? AND new_storage_limit = ?", rotation.OldAccountID,
+			upgrade.NewStorageLimit).UpdateColumns(map[string]interface{}{"account_id": rotation.NewAccountID,
+			"eth_private_key": ethPrivateKey}).Error; err != nil {
+			return err
+		}
+	}
+
+	var renewals []Renewal
+	if err := tx.Where("account_id = ?", rotation.OldAccountID).Find(&renewals).Error; err != nil {
+		return err
+	}
+	for _, renewal := range renewals {
+		if ethPrivateKey, err = reencryptEthPrivateKey(renewal.EthPrivateKey, rotation); err != nil {
+			return err
+		}
+		if err := tx.Model(&Renewal{}).Where("account_id = ? AND eth_address = ?", rotation.OldAccountID,
+			renewal.EthAddress).UpdateColumns(map[string]interface{}{"account_id": rotation.NewAccountID,
+			"eth_private_key": ethPrivateKey}).Error; err != nil {
+			return err
+		}
+	}
+
+	var files []File
+	if err := tx.Where("account_id = ?", rotation.OldAccountID).Find(&files).Error; err != nil {
+		return err
+	}
+	for _, file := range files {
+		modifierHash, err := rotatedModifierHash(file.FileID, file.ModifierHash, rotation)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&File{}).Where("file_id = ?", file.FileID).UpdateColumns(map[string]interface{}{
+			"account_id": rotation.NewAccountID, "modifier_hash": modifierHash}).Error; err != nil {
+			return err
+		}
+	}
+
+	// delegated keys act with the owner's permissions, so they follow the owner to the new key
+	if err := tx.Model(&DelegatedKey{}).Where("account_id = ?", rotation.OldAccountID).UpdateColumns(
+		map[string]interface{}{"account_id": rotation.NewAccountID, "parent_public_key": rotation.NewPublicKey}).
+		Error; err != nil {
+		return err
+	}
+
+	// an extension or deletion left over from an account deleted under the new ID would block moving this one
+	if err := tx.Where("account_id = ?", rotation.NewAccountID).Delete(&ExpirationExtension{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("account_id = ?", rotation.NewAccountID).Delete(&AccountDeletion{}).Error; err != nil {
+		return err
+	}
+	// UpdateColumn skips the ledger's append-only BeforeUpdate, so an account's history follows it to the new key
+	for _, table := range []string{"stripe_payments", "account_metadata_keys", "completed_files", "expiration_extensions",
+		"ledger_entries", "organization_members", "coupon_redemptions", "usage_samples",
+		"downgrades", "account_deletions"} {
+		if err := tx.Table(table).Where("account_id = ?", rotation.OldAccountID).
+			UpdateColumn("account_id", rotation.NewAccountID).Error; err != nil {
+			return err
+		}
+	}
+	// the owner can only ask for a receipt with the new key.  There is one receipt per account ID, so one for an
+	// account deleted under the new key is kept instead.
+	if err := tx.Model(&AccountDeletionReceipt{}).Where("account_id = ?", rotation.NewAccountID).Count(&count).
+		Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		if err := tx.Model(&AccountDeletionReceipt{}).Where("account_id = ?", rotation.OldAccountID).
+			UpdateColumn("account_id", rotation.NewAccountID).Error; err != nil {
+			return err
+		}
+	}
+	// an owner keeps its organization, so its members keep storing in its pool
+	if err := tx.Model(&Organization{}).Where("owner_account_id = ?", rotation.OldAccountID).
+		UpdateColumn("owner_account_id", rotation.NewAccountID).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func reencryptEthPrivateKey(encryptedKey string, rotation KeyRotation) (string, error) {
+	keyInBytes, err := utils.DecryptWithErrorReturn(utils.Env.EncryptionKey, encryptedKey, rotation.OldAccountID)
+	if err != nil {
+		return "", err
+	}
+	encryptedKeyInBytes, err := utils.EncryptWithErrorReturn(utils.Env.EncryptionKey, hex.EncodeToString(keyInBytes),
+		rotation.NewAccountID)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(encryptedKeyInBytes), nil
+}
+
+/*rotatedModifierHash returns the modifier hash for the new key if modifierHash belongs to the old key, and
+leaves any other hash alone*/
+func rotatedModifierHash(fileID, modifierHash string, rotation KeyRotation) (string, error) {
+	oldModifierHash, err := utils.HashString(rotation.OldPublicKey + fileID)
+	if err != nil || oldModifierHash != modifierHash {
+		return modifierHash, err
+	}
+	return utils.HashString(rotation.NewPublicKey + fileID)
+}
+
+/*GetKeyRotation returns the key rotation an account ID was rotated from or to*/
+func GetKeyRotation(accountID string) (KeyRotation, error) {
+	rotation := KeyRotation{}
+	err := DB.Where("old_account_id = ? OR new_account_id = ?", accountID, accountID).
+		Order("created_at desc").First(&rotation).Error
+	return rotation, err
+}
+
+/*GetIncompleteKeyRotations returns every key rotation that still has work left, oldest first*/
+func GetIncompleteKeyRotations() ([]KeyRotation, error) {
+	var rotations []KeyRotation
+	err := DB.Where("status < ?", KeyRotationComplete).Order("updated_at asc").Find(&rotations).Error
+	return rotations, err
+}
+
+/*GetRotatedPublicKeys returns the public keys an account was rotated from to get to publicKey, newest first.
+Permission hashes the batches haven't re-derived yet, or that the account's metadata key index doesn't know
+about, can still be checked against these.*/
+func GetRotatedPublicKeys(publicKey string) ([]string, error) {
+	var publicKeys []string
+	for len(publicKeys) < maxKeyRotationChainLength {
+		rotation := KeyRotation{}
+		err := DB.Where("new_public_key = ?", publicKey).First(&rotation).Error
+		if gorm.IsRecordNotFoundError(err) {
+			break
+		}
+		if err != nil {
+			return publicKeys, err
+		}
+		publicKeys = append(publicKeys, rotation.OldPublicKey)
+		publicKey = rotation.OldPublicKey
+	}
+	return publicKeys, nil
+}
+
+/*KeyWasRotatedAway returns whether an account has rotated away from accountID, so it must not be used again*/
+func KeyWasRotatedAway(accountID string) (bool, error) {
+	count := 0
+	err := DB.Model(&KeyRotation{}).Where("old_account_id = ?", accountID).Count(&count).Error
+	return count > 0, err
+}
+
+/*Run re-keys the next batchSize metadatas or files and saves the cursor, returning true once everything has
+been re-keyed.  Call it until it returns true.  Every batch only changes hashes that still belong to the old
+key, so running one again after a crash is harmless.*/
+func (rotation *KeyRotation) Run(batchSize int) (bool, error) {
+	switch rotation.Status {
+	case RekeyingMetadatas:
+		return false, rotation.rekeyNextMetadatas(batchSize)
+	case RekeyingFiles:
+		return false, rotation.rekeyNextFiles(batchSize)
+	}
+	return true, nil
+}
+
+func (rotation *KeyRotation) rekeyNextMetadatas(batchSize int) error {
+	var metadataKeys []string
+	if err := DB.Model(&AccountMetadataKey{}).Where("account_id = ? AND metadata_key > ?",
+		rotation.NewAccountID, rotation.LastMetadataKey).Order("metadata_key asc").Limit(batchSize).
+		Pluck("metadata_key", &metadataKeys).Error; err != nil {
+		return err
+	}
+
+	if len(metadataKeys) == 0 {
+		return rotation.saveProgress(map[string]interface{}{"status": RekeyingFiles})
+	}
+
+	for _, metadataKey := range metadataKeys {
+		permissionHashKey := metadataKey + metadataPermissionHashKeySuffix
+		permissionHash, expirationTime, err := utils.GetValueFromKV(permissionHashKey)
+		if err == utils.ErrKeyNotFound {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		oldPermissionHash, err := utils.HashString(rotation.OldPublicKey + metadataKey)
+		if err != nil {
+			return err
+		}
+		if permissionHash != oldPermissionHash {
+			continue
+		}
+		newPermissionHash, err := utils.HashString(rotation.NewPublicKey + metadataKey)
+		if err != nil {
+			return err
+		}
+		// keep the TTL the permission hash already had, where zero means it never expires
+		ttl := time.Duration(0)
+		if expirationTime.Unix() != 0 {
+			ttl = time.Until(expirationTime)
+		}
+		// only swapped if it is still the old hash, so a metadata deleted in the meantime stays deleted
+		if _, err := utils.CompareAndSwap(permissionHashKey, oldPermissionHash, newPermissionHash, ttl); err != nil {
+			return err
+		}
+	}
+
+	return rotation.saveProgress(map[string]interface{}{
+		"last_metadata_key": metadataKeys[len(metadataKeys)-1],
+		"metadatas_rekeyed": rotation.MetadatasRekeyed + len(metadataKeys),
+	})
+}
+
+func (rotation *KeyRotation) rekeyNextFiles(batchSize int) error {
+	var completedFiles []CompletedFile
+	if err := DB.Where("account_id = ? AND file_id > ?", rotation.NewAccountID, rotation.LastFileID).
+		Order("file_id asc").Limit(batchSize).Find(&completedFiles).Error; err != nil {
+		return err
+	}
+
+	if len(completedFiles) == 0 {
+		return rotation.saveProgress(map[string]interface{}{"status": KeyRotationComplete})
+	}
+
+	for _, completedFile := range completedFiles {
+		modifierHash, err := rotatedModifierHash(completedFile.FileID, completedFile.ModifierHash, *rotation)
+		if err != nil {
+			return err
+		}
+		if modifierHash == completedFile.ModifierHash {
+			continue
+		}
+		if err := DB.Model(&CompletedFile{}).Where("file_id = ? AND modifier_hash = ?", completedFile.FileID,
+			completedFile.ModifierHash).UpdateColumn("modifier_hash", modifierHash).Error; err != nil {
+			return err
+		}
+	}
+
+	return rotation.saveProgress(map[string]interface{}{
+		"last_file_id":  completedFiles[len(completedFiles)-1].FileID,
+		"files_rekeyed": rotation.FilesRekeyed + len(completedFiles),
+	})
+}
+
+func (rotation *KeyRotation) saveProgress(progress map[string]interface{}) error {
+	progress["updated_at"] = time.Now()
+	if err := DB.Model(&KeyRotation{}).Where("old_account_id = ?", rotation.OldAccountID).
+		UpdateColumns(progress).Error; err != nil {
+		return err
+	}
+	return DB.Where("old_account_id = ?", rotation.OldAccountID).First(rotation).Error
+}
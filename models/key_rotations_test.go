@@ -0,0 +1,329 @@
+package models
+
+import (
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/opacity/storage-node/services"
+	"github.com/opacity/storage-node/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Init_Key_Rotations(t *testing.T) {
+	utils.SetTesting("../.env")
+	Connect(utils.Env.TestDatabaseURL)
+}
+
+func returnPublicKeyForTest(t *testing.T) string {
+	privateKey, err := utils.GenerateKey()
+	assert.Nil(t, err)
+	return utils.PubkeyCompressedToHex(privateKey.PublicKey)
+}
+
+func createAccountForPublicKeyForTest(t *testing.T, publicKey string) Account {
+	accountID, err := utils.HashString(publicKey)
+	assert.Nil(t, err)
+	account := returnValidAccount()
+	account.AccountID = accountID
+	_, privateKey, _ := services.EthWrapper.GenerateWallet()
+	account.EthPrivateKey = hex.EncodeToString(utils.Encrypt(utils.Env.EncryptionKey, privateKey, accountID))
+	assert.Nil(t, DB.Create(&account).Error)
+	return account
+}
+
+func runKeyRotationForTest(t *testing.T, rotation KeyRotation) KeyRotation {
+	for i := 0; i < 10; i++ {
+		done, err := rotation.Run(1)
+		assert.Nil(t, err)
+		if done {
+			return rotation
+		}
+	}
+	t.Fatalf("key rotation should have finished")
+	return rotation
+}
+
+func Test_KeyRotation_Rekeys_Account_Metadatas_And_Files(t *testing.T) {
+	DeleteKeyRotationsForTest(t)
+	oldPublicKey := returnPublicKeyForTest(t)
+	newPublicKey := returnPublicKeyForTest(t)
+	account := createAccountForPublicKeyForTest(t, oldPublicKey)
+	expiredAt := account.ExpiredAt
+
+	metadataKey := utils.RandSeqFromRunes(64, []rune("abcdef01234567890"))
+	oldPermissionHash, _ := utils.HashString(oldPublicKey + metadataKey)
+	assert.Nil(t, utils.BatchSet(&utils.KVPairs{
+		metadataKey: "metadata",
+		metadataKey + metadataPermissionHashKeySuffix: oldPermissionHash,
+	}, time.Hour))
+	assert.Nil(t, AddAccountMetadataKey(account.AccountID, metadataKey))
+
+	fileID := utils.GenerateFileHandle()
+	oldModifierHash, _ := utils.HashString(oldPublicKey + fileID)
+	assert.Nil(t, DB.Create(&CompletedFile{
+		FileID:       fileID,
+		ModifierHash: oldModifierHash,
+		ExpiredAt:    time.Now(),
+		AccountID:    account.AccountID,
+	}).Error)
+
+	rotation, err := StartKeyRotation(oldPublicKey, newPublicKey)
+	assert.Nil(t, err)
+	assert.Equal(t, RekeyingMetadatas, rotation.Status)
+
+	_, err = GetAccountById(rotation.OldAccountID)
+	assert.NotNil(t, err)
+	rotated, err := GetAccountById(rotation.NewAccountID)
+	assert.Nil(t, err)
+	assert.Equal(t, expiredAt.Unix(), rotated.ExpiredAt.Unix())
+	oldEthPrivateKey, err := utils.DecryptWithErrorReturn(utils.Env.EncryptionKey, account.EthPrivateKey,
+		rotation.OldAccountID)
+	assert.Nil(t, err)
+	newEthPrivateKey, err := utils.DecryptWithErrorReturn(utils.Env.EncryptionKey, rotated.EthPrivateKey,
+		rotation.NewAccountID)
+	assert.Nil(t, err)
+	assert.Equal(t, oldEthPrivateKey, newEthPrivateKey)
+
+	metadataKeys, err := GetMetadataKeysByAccountID(rotation.NewAccountID)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{metadataKey}, metadataKeys)
+
+	rotation = runKeyRotationForTest(t, rotation)
+	assert.Equal(t, KeyRotationComplete, rotation.Status)
+	assert.Equal(t, 1, rotation.MetadatasRekeyed)
+	assert.Equal(t, 1, rotation.FilesRekeyed)
+
+	permissionHash, _, err := utils.GetValueFromKV(metadataKey + metadataPermissionHashKeySuffix)
+	assert.Nil(t, err)
+	newPermissionHash, _ := utils.HashString(newPublicKey + metadataKey)
+	assert.Equal(t, newPermissionHash, permissionHash)
+
+	completedFile, err := GetCompletedFileByFileID(fileID)
+	assert.Nil(t, err)
+	newModifierHash, _ := utils.HashString(newPublicKey + fileID)
+	assert.Equal(t, newModifierHash, completedFile.ModifierHash)
+	assert.Equal(t, rotation.NewAccountID, completedFile.AccountID)
+
+	rotatedPublicKeys, err := GetRotatedPublicKeys(newPublicKey)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{oldPublicKey}, rotatedPublicKeys)
+}
+
+func Test_KeyRotation_Rekeys_Pending_Upgrades(t *testing.T) {
+	DeleteKeyRotationsForTest(t)
+	oldPublicKey := returnPublicKeyForTest(t)
+	newPublicKey := returnPublicKeyForTest(t)
+	account := createAccountForPublicKeyForTest(t, oldPublicKey)
+
+	upgrade, _ := returnValidUpgrade()
+	upgrade.AccountID = account.AccountID
+	_, privateKey, _ := services.EthWrapper.GenerateWallet()
+	upgrade.EthPrivateKey = hex.EncodeToString(utils.Encrypt(utils.Env.EncryptionKey, privateKey, account.AccountID))
+	assert.Nil(t, DB.Create(&upgrade).Error)
+
+	rotation, err := StartKeyRotation(oldPublicKey, newPublicKey)
+	assert.Nil(t, err)
+
+	rotatedUpgrade, err := GetUpgradeFromAccountIDAndStorageLimits(rotation.NewAccountID,
+		int(upgrade.NewStorageLimit), int(upgrade.OldStorageLimit))
+	assert.Nil(t, err)
+	ethPrivateKey, err := utils.DecryptWithErrorReturn(utils.Env.EncryptionKey, rotatedUpgrade.EthPrivateKey,
+		rotation.NewAccountID)
+	assert.Nil(t, err)
+	assert.Equal(t, privateKey, hex.EncodeToString(ethPrivateKey))
+}
+
+func Test_KeyRotation_Leaves_Other_Keys_Hashes_Alone(t *testing.T) {
+	DeleteKeyRotationsForTest(t)
+	oldPublicKey := returnPublicKeyForTest(t)
+	account := createAccountForPublicKeyForTest(t, oldPublicKey)
+
+	// claimed by someone else since it was indexed
+	metadataKey := utils.RandSeqFromRunes(64, []rune("abcdef01234567890"))
+	assert.Nil(t, utils.BatchSet(&utils.KVPairs{
+		metadataKey + metadataPermissionHashKeySuffix: "someOtherPermissionHash",
+	}, time.Hour))
+	assert.Nil(t, AddAccountMetadataKey(account.AccountID, metadataKey))
+
+	rotation, err := StartKeyRotation(oldPublicKey, returnPublicKeyForTest(t))
+	assert.Nil(t, err)
+	runKeyRotationForTest(t, rotation)
+
+	permissionHash, _, err := utils.GetValueFromKV(metadataKey + metadataPermissionHashKeySuffix)
+	assert.Nil(t, err)
+	assert.Equal(t, "someOtherPermissionHash", permissionHash)
+}
+
+func Test_KeyRotation_Refuses_Existing_And_Rotated_Away_Keys(t *testing.T) {
+	DeleteKeyRotationsForTest(t)
+	oldPublicKey := returnPublicKeyForTest(t)
+	existingPublicKey := returnPublicKeyForTest(t)
+	createAccountForPublicKeyForTest(t, oldPublicKey)
+	createAccountForPublicKeyForTest(t, existingPublicKey)
+
+	_, err := StartKeyRotation(oldPublicKey, existingPublicKey)
+	assert.Equal(t, ErrKeyRotationTargetExists, err)
+	// nothing moved
+	oldAccountID, _ := utils.HashString(oldPublicKey)
+	_, err = GetAccountById(oldAccountID)
+	assert.Nil(t, err)
+
+	newPublicKey := returnPublicKeyForTest(t)
+	rotation, err := StartKeyRotation(oldPublicKey, newPublicKey)
+	assert.Nil(t, err)
+
+	_, err = StartKeyRotation(newPublicKey, returnPublicKeyForTest(t))
+	assert.Equal(t, ErrKeyRotationInProgress, err)
+
+	runKeyRotationForTest(t, rotation)
+	_, err = StartKeyRotation(existingPublicKey, oldPublicKey)
+	assert.Equal(t, ErrKeyRotatedAway, err)
+
+	rotatedAway, err := KeyWasRotatedAway(oldAccountID)
+	assert.Nil(t, err)
+	assert.True(t, rotatedAway)
+}
+
+func Test_KeyRotation_Moves_The_Ledger(t *testing.T) {
+	DeleteKeyRotationsForTest(t)
+	DeleteLedgerEntriesForTest(t)
+	oldPublicKey := returnPublicKeyForTest(t)
+	account := createAccountForPublicKeyForTest(t, oldPublicKey)
+	assert.Nil(t, account.RecordInvoice())
+
+	rotation, err := StartKeyRotation(oldPublicKey, returnPublicKeyForTest(t))
+	assert.Nil(t, err)
+
+	entries, err := GetLedgerEntries(rotation.NewAccountID, 0, 0)
+	assert.Nil(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, account.EthAddress, entries[0].Reference)
+	entries, err = GetLedgerEntries(rotation.OldAccountID, 0, 0)
+	assert.Nil(t, err)
+	assert.Len(t, entries, 0)
+}
+
+func Test_KeyRotation_Keeps_Organizations(t *testing.T) {
+	DeleteKeyRotationsForTest(t)
+	DeleteOrganizationsForTest(t)
+	ownerPublicKey := returnPublicKeyForTest(t)
+	memberPublicKey := returnPublicKeyForTest(t)
+	owner := createAccountForPublicKeyForTest(t, ownerPublicKey)
+	member := createAccountForPublicKeyForTest(t, memberPublicKey)
+	organization, err := CreateOrganization(owner, "Acme")
+	assert.Nil(t, err)
+	_, err = organization.InviteMember(member.AccountID, 0)
+	assert.Nil(t, err)
+	_, err = organization.Join(member)
+	assert.Nil(t, err)
+
+	ownerRotation, err := StartKeyRotation(ownerPublicKey, returnPublicKeyForTest(t))
+	assert.Nil(t, err)
+	memberRotation, err := StartKeyRotation(memberPublicKey, returnPublicKeyForTest(t))
+	assert.Nil(t, err)
+
+	owned, err := GetOwnedOrganization(ownerRotation.NewAccountID)
+	assert.Nil(t, err)
+	assert.Equal(t, organization.ID, owned.ID)
+	joined, joinedMember, err := GetAccountOrganization(memberRotation.NewAccountID)
+	assert.Nil(t, err)
+	assert.Equal(t, organization.ID, joined.ID)
+	assert.Equal(t, OrganizationMemberJoined, joinedMember.Status)
+	_, _, err = GetAccountOrganization(memberRotation.OldAccountID)
+	assert.NotNil(t, err)
+}
+
+func Test_KeyRotation_Moves_Coupon_Redemptions(t *testing.T) {
+	DeleteKeyRotationsForTest(t)
+	DeleteCouponsForTest(t)
+	oldPublicKey := returnPublicKeyForTest(t)
+	account := createAccountForPublicKeyForTest(t, oldPublicKey)
+	coupon := Coupon{Code: utils.RandHexString(16), PercentOff: 10}
+	assert.Nil(t, DB.Create(&coupon).Error)
+	assert.Nil(t, DB.Create(&CouponRedemption{CouponID: coupon.ID, AccountID: account.AccountID,
+		Flow: CouponFlowAccount}).Error)
+
+	rotation, err := StartKeyRotation(oldPublicKey, returnPublicKeyForTest(t))
+	assert.Nil(t, err)
+
+	redeemed, err := CouponRedeemedBy(coupon.ID, rotation.NewAccountID)
+	assert.Nil(t, err)
+	assert.True(t, redeemed)
+}
+
+func Test_KeyRotation_Moves_Usage_Samples(t *testing.T) {
+	DeleteKeyRotationsForTest(t)
+	DeleteUsageSamplesForTest(t)
+	oldPublicKey := returnPublicKeyForTest(t)
+	account := createAccountForPublicKeyForTest(t, oldPublicKey)
+	assert.Nil(t, DB.Create(&UsageSample{AccountID: account.AccountID, SampledOn: time.Now()}).Error)
+
+	rotation, err := StartKeyRotation(oldPublicKey, returnPublicKeyForTest(t))
+	assert.Nil(t, err)
+
+	samples, err := GetUsageSamples(rotation.NewAccountID, time.Now(), time.Now())
+	assert.Nil(t, err)
+	assert.Len(t, samples, 1)
+	samples, err = GetUsageSamples(rotation.OldAccountID, time.Now(), time.Now())
+	assert.Nil(t, err)
+	assert.Len(t, samples, 0)
+}
+
+func Test_KeyRotation_Keeps_Scheduled_Downgrade(t *testing.T) {
+	DeleteKeyRotationsForTest(t)
+	DeleteDowngradesForTest(t)
+	oldPublicKey := returnPublicKeyForTest(t)
+	account := createAccountForPublicKeyForTest(t, oldPublicKey)
+	assert.Nil(t, DB.Create(&Downgrade{
+		AccountID:       account.AccountID,
+		OldStorageLimit: ProfessionalStorageLimit,
+		NewStorageLimit: BasicStorageLimit,
+	}).Error)
+
+	rotation, err := StartKeyRotation(oldPublicKey, returnPublicKeyForTest(t))
+	assert.Nil(t, err)
+
+	downgrade, err := GetScheduledDowngrade(rotation.NewAccountID)
+	assert.Nil(t, err)
+	assert.Equal(t, BasicStorageLimit, downgrade.NewStorageLimit)
+	_, err = GetScheduledDowngrade(rotation.OldAccountID)
+	assert.True(t, gorm.IsRecordNotFoundError(err))
+}
+
+func Test_KeyRotation_Moves_Pending_Account_Deletion(t *testing.T) {
+	defer setMainWalletForDeletionTest(t)()
+	DeleteKeyRotationsForTest(t)
+	DeleteAccountDeletionsForTest(t)
+	oldPublicKey := returnPublicKeyForTest(t)
+	account := createAccountForPublicKeyForTest(t, oldPublicKey)
+
+	// a receipt for an earlier account under the old key
+	oldReceipt := AccountDeletionReceipt{AccountID: account.AccountID, RequestedAt: time.Now(), DeletedAt: time.Now()}
+	assert.Nil(t, oldReceipt.sign())
+	assert.Nil(t, DB.Save(&oldReceipt).Error)
+
+	_, code, err := RequestAccountDeletion(account.AccountID, 0)
+	assert.Nil(t, err)
+
+	rotation, err := StartKeyRotation(oldPublicKey, returnPublicKeyForTest(t))
+	assert.Nil(t, err)
+
+	_, err = GetAccountDeletion(rotation.OldAccountID)
+	assert.True(t, gorm.IsRecordNotFoundError(err))
+	receipt, err := GetAccountDeletionReceipt(rotation.NewAccountID)
+	assert.Nil(t, err)
+	assert.Equal(t, oldReceipt.Signature, receipt.Signature)
+
+	// the owner confirms under the new key, and the deletion goes ahead on the rotated account
+	deletion, err := ConfirmAccountDeletion(rotation.NewAccountID, code)
+	assert.Nil(t, err)
+	_, err = deletion.Execute()
+	assert.Nil(t, err)
+
+	_, err = GetAccountById(rotation.NewAccountID)
+	assert.True(t, gorm.IsRecordNotFoundError(err))
+	_, err = GetAccountDeletion(rotation.NewAccountID)
+	assert.True(t, gorm.IsRecordNotFoundError(err))
+}
@@ -0,0 +1,210 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/opacity/storage-node/utils"
+)
+
+/*KVPair defines a model for a K:V pair when the K:V store is backed by the SQL database.  Keys and values are
+binary so they compare byte for byte, as they do in badger, rather than ignoring case and trailing spaces.*/
+type KVPair struct {
+	Key       string     `gorm:"primary_key;column:kv_key;type:varbinary(255)" json:"key" binding:"required"`
+	Value     string     `gorm:"column:kv_value;type:longblob" json:"value"`
+	ExpiredAt *time.Time `gorm:"index" json:"expiredAt"` // nil if the pair never expires
+}
+
+// keep the IN (...) lists MySQL has to parse reasonably small
+const kvPairQueryBatchSize = 1000
+
+type sqlKVStore struct {
+	db *gorm.DB
+}
+
+/*NewSQLKVStore returns a K:V store backed by the kv_pairs table*/
+func NewSQLKVStore(db *gorm.DB) utils.KVStore {
+	return &sqlKVStore{db: db}
+}
+
+/*BeforeCreate - callback called before the row is created*/
+func (kvPair *KVPair) BeforeCreate(scope *gorm.Scope) error {
+	return utils.Validator.Struct(kvPair)
+}
+
+/*PurgeExpiredKVPairs deletes K:V pairs whose ttl has run out*/
+func PurgeExpiredKVPairs() error {
+	return DB.Where("expired_at IS NOT NULL AND expired_at <= ?", time.Now()).Delete(&KVPair{}).Error
+}
+
+func (s *sqlKVStore) Get(key string) (string, time.Time, error) {
+	kvPair := KVPair{}
+	err := s.unexpired().Where("kv_key = ?", key).First(&kvPair).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return "", time.Now(), utils.ErrKeyNotFound
+	}
+	if err != nil {
+		return "", time.Now(), err
+	}
+	return kvPair.Value, expirationTimeOf(kvPair), nil
+}
+
+func (s *sqlKVStore) BatchGet(ks *utils.KVKeys) (*utils.KVPairs, error) {
+	kvs := &utils.KVPairs{}
+	for start := 0; start < len(*ks); start += kvPairQueryBatchSize {
+		end := minInt(start+kvPairQueryBatchSize, len(*ks))
+		var kvPairs []KVPair
+		if err := s.unexpired().Where("kv_key IN (?)", []string((*ks)[start:end])).Find(&kvPairs).Error; err != nil {
+			return kvs, err
+		}
+		for _, kvPair := range kvPairs {
+			(*kvs)[kvPair.Key] = kvPair.Value
+		}
+	}
+	return kvs, nil
+}
+
+func (s *sqlKVStore) BatchSet(kvs *utils.KVPairs, ttl time.Duration) error {
+	expiredAt := expiredAtFromTTL(ttl)
+
+	tx := s.db.Begin()
+	if err := tx.Error; err != nil {
+		return err
+	}
+
+	var placeholders []string
+	var args []interface{}
+	flush := func() error {
+		if len(placeholders) == 0 {
+			return nil
+		}
+		err := tx.Exec("INSERT INTO kv_pairs (kv_key, kv_value, expired_at) VALUES "+strings.Join(placeholders, ",")+
+			" ON DUPLICATE KEY UPDATE kv_value = VALUES(kv_value), expired_at = VALUES(expired_at)", args...).Error
+		placeholders = nil
+		args = nil
+		return err
+	}
+
+	for k, v := range *kvs {
+		placeholders = append(placeholders, "(?, ?, ?)")
+		args = append(args, k, v, expiredAt)
+		if len(placeholders) >= kvPairQueryBatchSize {
+			if err := flush(); err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+func (s *sqlKVStore) BatchDelete(ks *utils.KVKeys) error {
+	for start := 0; start < len(*ks); start += kvPairQueryBatchSize {
+		end := minInt(start+kvPairQueryBatchSize, len(*ks))
+		if err := s.db.Where("kv_key IN (?)", []string((*ks)[start:end])).Delete(&KVPair{}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *sqlKVStore) SetIfNotExists(key string, value string, ttl time.Duration) (bool, error) {
+	tx := s.db.Begin()
+	if err := tx.Error; err != nil {
+		return false, err
+	}
+
+	// an expired pair doesn't count as existing
+	if err := tx.Where("kv_key = ? AND expired_at IS NOT NULL AND expired_at <= ?", key, time.Now()).
+		Delete(&KVPair{}).Error; err != nil {
+		tx.Rollback()
+		return false, err
+	}
+
+	result := tx.Exec("INSERT IGNORE INTO kv_pairs (kv_key, kv_value, expired_at) VALUES (?, ?, ?)",
+		key, value, expiredAtFromTTL(ttl))
+	if result.Error != nil {
+		tx.Rollback()
+		return false, result.Error
+	}
+
+	return result.RowsAffected == 1, tx.Commit().Error
+}
+
+func (s *sqlKVStore) CompareAndSwap(key string, oldValue string, newValue string, ttl time.Duration) (bool, error) {
+	result := s.unexpired().Model(&KVPair{}).Where("kv_key = ? AND kv_value = ?", key, oldValue).
+		UpdateColumns(map[string]interface{}{"kv_value": newValue, "expired_at": expiredAtFromTTL(ttl)})
+	if result.Error != nil || result.RowsAffected == 1 || oldValue != newValue {
+		return result.RowsAffected == 1, result.Error
+	}
+
+	// MySQL doesn't count a matched row as affected when the update leaves it unchanged
+	count := 0
+	err := s.unexpired().Model(&KVPair{}).Where("kv_key = ? AND kv_value = ?", key, oldValue).Count(&count).Error
+	return count == 1, err
+}
+
+func (s *sqlKVStore) Iterate(fn func(key string, value string, expirationTime time.Time) error) error {
+	lastKey := ""
+	for {
+		var kvPairs []KVPair
+		if err := s.unexpired().Where("kv_key > ?", lastKey).Order("kv_key asc").
+			Limit(kvPairQueryBatchSize).Find(&kvPairs).Error; err != nil {
+			return err
+		}
+		for _, kvPair := range kvPairs {
+			expirationTime := time.Time{}
+			if kvPair.ExpiredAt != nil {
+				expirationTime = *kvPair.ExpiredAt
+			}
+			if err := fn(kvPair.Key, kvPair.Value, expirationTime); err != nil {
+				return err
+			}
+		}
+		if len(kvPairs) < kvPairQueryBatchSize {
+			return nil
+		}
+		lastKey = kvPairs[len(kvPairs)-1].Key
+	}
+}
+
+func (s *sqlKVStore) DropAll() error {
+	return s.db.Delete(&KVPair{}).Error
+}
+
+func (s *sqlKVStore) Close() error {
+	// the connection is shared with the rest of the models and closed by models.Close
+	return nil
+}
+
+func (s *sqlKVStore) unexpired() *gorm.DB {
+	return s.db.Where("expired_at IS NULL OR expired_at > ?", time.Now())
+}
+
+func expiredAtFromTTL(ttl time.Duration) *time.Time {
+	if ttl == 0 {
+		return nil
+	}
+	expiredAt := time.Now().Add(ttl)
+	return &expiredAt
+}
+
+func expirationTimeOf(kvPair KVPair) time.Time {
+	if kvPair.ExpiredAt == nil {
+		return time.Unix(0, 0)
+	}
+	return *kvPair.ExpiredAt
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,132 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/opacity/storage-node/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Init_KV_Pairs(t *testing.T) {
+	utils.SetTesting("../.env")
+	Connect(utils.Env.TestDatabaseURL)
+}
+
+func Test_SQLKVStore_BatchSet_BatchGet_BatchDelete(t *testing.T) {
+	DeleteKVPairsForTest(t)
+	store := NewSQLKVStore(DB)
+
+	kvs := utils.KVPairs{"key1": "value1", "key2": "value2"}
+	assert.Nil(t, store.BatchSet(&kvs, time.Minute))
+
+	// overwrite an existing key
+	kvs = utils.KVPairs{"key1": "value3"}
+	assert.Nil(t, store.BatchSet(&kvs, time.Minute))
+
+	result, err := store.BatchGet(&utils.KVKeys{"key1", "key2", "key3"})
+	assert.Nil(t, err)
+	assert.Equal(t, utils.KVPairs{"key1": "value3", "key2": "value2"}, *result)
+
+	assert.Nil(t, store.BatchDelete(&utils.KVKeys{"key1"}))
+	_, _, err = store.Get("key1")
+	assert.Equal(t, utils.ErrKeyNotFound, err)
+
+	value, _, err := store.Get("key2")
+	assert.Nil(t, err)
+	assert.Equal(t, "value2", value)
+}
+
+func Test_SQLKVStore_ExpiredPairs(t *testing.T) {
+	DeleteKVPairsForTest(t)
+	store := NewSQLKVStore(DB)
+
+	kvs := utils.KVPairs{"expired": "value"}
+	assert.Nil(t, store.BatchSet(&kvs, -time.Minute))
+
+	_, _, err := store.Get("expired")
+	assert.Equal(t, utils.ErrKeyNotFound, err)
+
+	// an expired pair can be set again
+	set, err := store.SetIfNotExists("expired", "newValue", time.Minute)
+	assert.Nil(t, err)
+	assert.True(t, set)
+
+	set, err = store.SetIfNotExists("expired", "otherValue", time.Minute)
+	assert.Nil(t, err)
+	assert.False(t, set)
+
+	value, _, err := store.Get("expired")
+	assert.Nil(t, err)
+	assert.Equal(t, "newValue", value)
+}
+
+func Test_SQLKVStore_CompareAndSwap(t *testing.T) {
+	DeleteKVPairsForTest(t)
+	store := NewSQLKVStore(DB)
+
+	expired := utils.KVPairs{"expired": "value"}
+	assert.Nil(t, store.BatchSet(&expired, -time.Minute))
+	kvs := utils.KVPairs{"key": "value"}
+	assert.Nil(t, store.BatchSet(&kvs, time.Minute))
+
+	swapped, err := store.CompareAndSwap("expired", "value", "newValue", time.Minute)
+	assert.Nil(t, err)
+	assert.False(t, swapped)
+
+	swapped, err = store.CompareAndSwap("key", "otherValue", "newValue", time.Minute)
+	assert.Nil(t, err)
+	assert.False(t, swapped)
+
+	swapped, err = store.CompareAndSwap("key", "value", "newValue", time.Minute)
+	assert.Nil(t, err)
+	assert.True(t, swapped)
+
+	// swapping a value for itself leaves the row unchanged, but still counts as a swap
+	swapped, err = store.CompareAndSwap("key", "newValue", "newValue", time.Minute)
+	assert.Nil(t, err)
+	assert.True(t, swapped)
+
+	value, _, err := store.Get("key")
+	assert.Nil(t, err)
+	assert.Equal(t, "newValue", value)
+}
+
+func Test_SQLKVStore_Compares_Keys_And_Values_Byte_For_Byte(t *testing.T) {
+	DeleteKVPairsForTest(t)
+	store := NewSQLKVStore(DB)
+
+	kvs := utils.KVPairs{"abcdef": "value", "ABCDEF": "other value"}
+	assert.Nil(t, store.BatchSet(&kvs, time.Minute))
+
+	result, err := store.BatchGet(&utils.KVKeys{"abcdef", "ABCDEF", "abcdef "})
+	assert.Nil(t, err)
+	assert.Equal(t, kvs, *result)
+
+	swapped, err := store.CompareAndSwap("abcdef", "VALUE", "new value", time.Minute)
+	assert.Nil(t, err)
+	assert.False(t, swapped)
+	swapped, err = store.CompareAndSwap("abcdef", "value ", "new value", time.Minute)
+	assert.Nil(t, err)
+	assert.False(t, swapped)
+
+	value, _, err := store.Get("abcdef")
+	assert.Nil(t, err)
+	assert.Equal(t, "value", value)
+}
+
+func Test_PurgeExpiredKVPairs(t *testing.T) {
+	DeleteKVPairsForTest(t)
+	store := NewSQLKVStore(DB)
+
+	expired := utils.KVPairs{"expired": "value"}
+	assert.Nil(t, store.BatchSet(&expired, -time.Minute))
+	neverExpires := utils.KVPairs{"neverExpires": "value"}
+	assert.Nil(t, store.BatchSet(&neverExpires, 0))
+
+	assert.Nil(t, PurgeExpiredKVPairs())
+
+	count := 0
+	DB.Model(&KVPair{}).Count(&count)
+	assert.Equal(t, 1, count)
+}
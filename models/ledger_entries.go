@@ -0,0 +1,217 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/opacity/storage-node/utils"
+)
+
+/*LedgerEntry records an invoice, payment, credit, refund or plan change on an account.  Rows are only ever added,
+and are kept after the upgrades, renewals, stripe payments and even the account they came from are deleted.*/
+type LedgerEntry struct {
+	ID                   uint             `gorm:"primary_key" json:"id"`
+	AccountID            string           `gorm:"type:varchar(64);index" json:"accountID" binding:"required,len=64"`
+	Type                 LedgerEntryType  `json:"-" binding:"required,gte=1"`
+	TypeName             string           `gorm:"-" json:"type" example:"payment"`
+	AmountInOPCT         float64          `json:"amountInOPCT" example:"15"` // negative for credit used up
+	AmountInUSD          float64          `json:"amountInUSD" example:"0"`
+	Reference            string           `gorm:"index" json:"reference" example:"the payment address, OPCT tx hash or Stripe charge ID"`
+	Description          string           `json:"description" example:"upgrade to 1024 GB"`
+	StorageLimit         StorageLimitType `json:"storageLimit" example:"1024"` // the plan the account is on after the entry
+	PlanID               uint             `json:"planID"`
+	MonthsInSubscription int              `json:"monthsInSubscription" example:"12"`
+	CreatedAt            time.Time        `gorm:"index" json:"createdAt"`
+}
+
+/*LedgerEntryType defines a type for the kinds of ledger entries*/
+type LedgerEntryType int
+
+const (
+	/*LedgerInvoice - the account was asked to pay, Reference is the address to pay to*/
+	LedgerInvoice LedgerEntryType = iota + 1
+
+	/*LedgerPayment - a payment was received, Reference is the OPCT collection tx hash or the Stripe charge ID*/
+	LedgerPayment
+
+	/*LedgerCredit - credit was added to the account's balance, or taken off it when negative*/
+	LedgerCredit
+
+	/*LedgerRefund - money was given back to the owner*/
+	LedgerRefund
+
+	/*LedgerPlanChange - the account's plan or term changed*/
+	LedgerPlanChange
+)
+
+/*LedgerEntryTypeMap is for pretty printing the LedgerEntryType*/
+var LedgerEntryTypeMap = map[LedgerEntryType]string{
+	LedgerInvoice:    "invoice",
+	LedgerPayment:    "payment",
+	LedgerCredit:     "credit",
+	LedgerRefund:     "refund",
+	LedgerPlanChange: "planChange",
+}
+
+/*ErrLedgerAppendOnly is returned when something tries to change or remove a ledger entry*/
+var ErrLedgerAppendOnly = errors.New("the payments ledger is append-only")
+
+// the most entries a page of an account's ledger shows
+const maxLedgerPageSize = 500
+
+/*BeforeCreate - callback called before the row is created*/
+func (entry *LedgerEntry) BeforeCreate(scope *gorm.Scope) error {
+	return utils.Validator.Struct(entry)
+}
+
+/*BeforeUpdate - callback called before the row is updated*/
+func (entry *LedgerEntry) BeforeUpdate(scope *gorm.Scope) error {
+	return ErrLedgerAppendOnly
+}
+
+/*BeforeDelete - callback called before the row is deleted*/
+func (entry *LedgerEntry) BeforeDelete(scope *gorm.Scope) error {
+	return ErrLedgerAppendOnly
+}
+
+/*AfterFind - callback called after the row is read*/
+func (entry *LedgerEntry) AfterFind() error {
+	entry.TypeName = LedgerEntryTypeMap[entry.Type]
+	return nil
+}
+
+/*RecordLedgerEntry adds entry to the ledger.  Entries with a Reference are only recorded once, so the checks
+that notice an invoice was paid can run as often as they are called.*/
+func RecordLedgerEntry(entry LedgerEntry) (LedgerEntry, error) {
+	entry.TypeName = LedgerEntryTypeMap[entry.Type]
+	if entry.Reference == "" {
+		return entry, DB.Create(&entry).Error
+	}
+	err := DB.Where(LedgerEntry{AccountID: entry.AccountID, Type: entry.Type, Reference: entry.Reference}).
+		FirstOrCreate(&entry).Error
+	return entry, err
+}
+
+/*GetLedgerEntries returns up to limit of the account's entries older than beforeID, newest first.  A beforeID
+of 0 starts from the newest entry.*/
+func GetLedgerEntries(accountID string, beforeID uint, limit int) ([]LedgerEntry, error) {
+	if limit <= 0 || limit > maxLedgerPageSize {
+		limit = maxLedgerPageSize
+	}
+	query := DB.Where("account_id = ?", accountID).Order("id desc").Limit(limit)
+	if beforeID != 0 {
+		query = query.Where("id < ?", beforeID)
+	}
+
+	entries := []LedgerEntry{}
+	err := query.Find(&entries).Error
+	return entries, err
+}
+
+/*ledgerEntry returns an entry for the account with the plan it is on now*/
+func (account *Account) ledgerEntry(entryType LedgerEntryType, amountInOPCT float64, reference,
+	description string) LedgerEntry {
+	return LedgerEntry{
+		AccountID:            account.AccountID,
+		Type:                 entryType,
+		AmountInOPCT:         amountInOPCT,
+		Reference:            reference,
+		Description:          description,
+		StorageLimit:         account.StorageLimit,
+		PlanID:               account.PlanID,
+		MonthsInSubscription: account.MonthsInSubscription,
+	}
+}
+
+/*RecordInvoice records the invoice for creating the account*/
+func (account *Account) RecordInvoice() error {
+	cost, err := account.Cost()
+	if err != nil {
+		return err
+	}
+	_, err = RecordLedgerEntry(account.ledgerEntry(LedgerInvoice, cost, account.EthAddress, accountLedgerDescription))
+	return err
+}
+
+/*RecordStripePayment records paying for the account by card, referenced by the Stripe charge ID*/
+func (account *Account) RecordStripePayment(amountInUSD float64, chargeID string) error {
+	entry := account.ledgerEntry(LedgerPayment, 0, chargeID, accountLedgerDescription)
+	entry.AmountInUSD = amountInUSD
+	_, err := RecordLedgerEntry(entry)
+	return err
+}
+
+/*RecordOverageStripePayment records billing and paying for overage by card, referenced by the Stripe charge ID*/
+func (account *Account) RecordOverageStripePayment(amountInUSD float64, chargeID string) error {
+	invoice := account.ledgerEntry(LedgerInvoice, 0, chargeID, overageLedgerDescription)
+	invoice.AmountInUSD = amountInUSD
+	payment := account.ledgerEntry(LedgerPayment, 0, chargeID, overageLedgerDescription)
+	payment.AmountInUSD = amountInUSD
+
+	_, invoiceErr := RecordLedgerEntry(invoice)
+	_, paymentErr := RecordLedgerEntry(payment)
+	return utils.CollectErrors([]error{invoiceErr, paymentErr})
+}
+
+/*RecordRefund records money given back to the owner, referenced by whatever the refund was made with*/
+func (account *Account) RecordRefund(amountInOPCT, amountInUSD float64, reference, description string) (LedgerEntry, error) {
+	entry := account.ledgerEntry(LedgerRefund, amountInOPCT, reference, description)
+	entry.AmountInUSD = amountInUSD
+	return RecordLedgerEntry(entry)
+}
+
+/*RecordInvoice records the invoice for the upgrade*/
+func (upgrade *Upgrade) RecordInvoice(account Account) error {
+	_, err := RecordLedgerEntry(account.ledgerEntry(LedgerInvoice, upgrade.OpctCost, upgrade.EthAddress,
+		upgradeLedgerDescription(upgrade.NewStorageLimit)))
+	return err
+}
+
+/*RecordInvoice records the invoice for the renewal, after any credit came off it*/
+func (renewal *Renewal) RecordInvoice(account Account) error {
+	_, err := RecordLedgerEntry(account.ledgerEntry(LedgerInvoice, renewal.OpctCost, renewal.EthAddress,
+		renewalLedgerDescription))
+	return err
+}
+
+/*logLedgerEntry records an entry for a change to the account that already went through, so failing to record it
+is logged rather than returned*/
+func (account *Account) logLedgerEntry(entryType LedgerEntryType, amountInOPCT float64, reference,
+	description string) {
+	_, err := RecordLedgerEntry(account.ledgerEntry(entryType, amountInOPCT, reference, description))
+	utils.LogIfError(err, map[string]interface{}{"accountID": account.AccountID, "ledgerEntry": description})
+}
+
+/*logOPCTCollection records the OPCT collected from a payment address as a payment, referenced by the
+collection's tx hash.  Accounts paid for by card were sent that OPCT by the node itself, and the card payment is
+already in the ledger.*/
+func logOPCTCollection(accountID string, tokenBalance *big.Int, txHash string, description string) {
+	account, err := GetAccountById(accountID)
+	if err != nil {
+		utils.LogIfError(err, map[string]interface{}{"accountID": accountID, "txHash": txHash})
+		return
+	}
+	if account.PaymentMethod == PaymentMethodWithCreditCard && description == accountLedgerDescription {
+		return
+	}
+	amountInOPCT, _ := utils.ConvertFromWeiUnit(tokenBalance).Float64()
+	account.logLedgerEntry(LedgerPayment, amountInOPCT, txHash, description)
+}
+
+// descriptions for the entries the node records by itself
+const (
+	accountLedgerDescription = "account"
+	renewalLedgerDescription = "renewal"
+	overageLedgerDescription = "overage"
+)
+
+func upgradeLedgerDescription(storageLimit StorageLimitType) string {
+	return fmt.Sprintf("upgrade to %d GB", storageLimit)
+}
+
+func downgradeLedgerDescription(storageLimit StorageLimitType) string {
+	return fmt.Sprintf("downgrade to %d GB", storageLimit)
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/opacity/storage-node/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Init_Ledger_Entries(t *testing.T) {
+	utils.SetTesting("../.env")
+	Connect(utils.Env.TestDatabaseURL)
+}
+
+func Test_RecordLedgerEntry_Records_A_Reference_Once(t *testing.T) {
+	DeleteLedgerEntriesForTest(t)
+	account := returnValidAccount()
+
+	assert.Nil(t, account.RecordInvoice())
+	assert.Nil(t, account.RecordInvoice())
+
+	entries, err := GetLedgerEntries(account.AccountID, 0, 0)
+	assert.Nil(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, LedgerInvoice, entries[0].Type)
+	assert.Equal(t, "invoice", entries[0].TypeName)
+	assert.Equal(t, account.EthAddress, entries[0].Reference)
+}
+
+func Test_LedgerEntry_Is_Append_Only(t *testing.T) {
+	DeleteLedgerEntriesForTest(t)
+	account := returnValidAccount()
+
+	entry, err := account.RecordRefund(10, 0, "refund-1", "refund for an outage")
+	assert.Nil(t, err)
+
+	entry.AmountInOPCT = 20
+	assert.Equal(t, ErrLedgerAppendOnly, DB.Save(&entry).Error)
+	assert.Equal(t, ErrLedgerAppendOnly, DB.Delete(&entry).Error)
+
+	entries, err := GetLedgerEntries(account.AccountID, 0, 0)
+	assert.Nil(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, 10.0, entries[0].AmountInOPCT)
+}
+
+func Test_GetLedgerEntries_Pages_Newest_First(t *testing.T) {
+	DeleteLedgerEntriesForTest(t)
+	account := returnValidAccount()
+	for i := 0; i < 3; i++ {
+		account.logLedgerEntry(LedgerCredit, float64(i+1), "", "credit")
+	}
+
+	entries, err := GetLedgerEntries(account.AccountID, 0, 2)
+	assert.Nil(t, err)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, 3.0, entries[0].AmountInOPCT)
+	assert.Equal(t, 2.0, entries[1].AmountInOPCT)
+
+	entries, err = GetLedgerEntries(account.AccountID, entries[1].ID, 2)
+	assert.Nil(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, 1.0, entries[0].AmountInOPCT)
+}
+
+func Test_Plan_Changes_Are_Recorded(t *testing.T) {
+	DeleteAccountsForTest(t)
+	DeleteLedgerEntriesForTest(t)
+	account := returnValidAccount()
+	assert.Nil(t, DB.Create(&account).Error)
+
+	assert.Nil(t, account.UpgradeAccount(int(ProfessionalStorageLimit), account.MonthsInSubscription))
+	assert.Nil(t, account.RenewAccount())
+
+	entries, err := GetLedgerEntries(account.AccountID, 0, 0)
+	assert.Nil(t, err)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, LedgerPlanChange, entries[0].Type)
+	assert.Equal(t, "renewal", entries[0].Description)
+	assert.Equal(t, account.MonthsInSubscription, entries[0].MonthsInSubscription)
+	assert.Equal(t, "upgrade to 1024 GB", entries[1].Description)
+	assert.Equal(t, ProfessionalStorageLimit, entries[1].StorageLimit)
+}
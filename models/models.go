@@ -44,9 +44,34 @@ func Connect(dbURL string) {
 	DB.AutoMigrate(&Upgrade{})
 	DB.AutoMigrate(&Renewal{})
 	DB.AutoMigrate(&ExpiredAccount{})
+	DB.AutoMigrate(&KVPair{})
+	DB.AutoMigrate(&AccountMetadataKey{})
+	DB.AutoMigrate(&ExpirationExtension{})
+	DB.AutoMigrate(&KeyRotation{})
+	DB.AutoMigrate(&DelegatedKey{})
+	DB.AutoMigrate(&AdminUser{})
+	DB.AutoMigrate(&AdminAuditLog{})
+	DB.AutoMigrate(&Plan{})
+	DB.AutoMigrate(&Downgrade{})
+	DB.AutoMigrate(&AccountDeletion{})
+	DB.AutoMigrate(&AccountDeletionReceipt{})
+	DB.AutoMigrate(&LedgerEntry{})
+	DB.AutoMigrate(&Coupon{})
+	DB.AutoMigrate(&CouponRedemption{})
+	DB.AutoMigrate(&Organization{})
+	DB.AutoMigrate(&OrganizationMember{})
+	DB.AutoMigrate(&UsageSample{})
+
+	utils.LogIfError(SeedPlans(), nil)
+
+	if utils.Env.KvStoreBackend == utils.KvStoreBackendSQL {
+		utils.SetKvStore(NewSQLKVStore(DB))
+	}
 }
 
 /*Close a database connection*/
 func Close() {
-	DB.Close()
+	if DB != nil {
+		DB.Close()
+	}
 }
@@ -69,3 +69,120 @@ func DeleteStripePaymentsForTest(t *testing.T) {
 		DB.Exec("DELETE from stripe_payments;")
 	}
 }
+
+func DeleteKVPairsForTest(t *testing.T) {
+	if utils.Env.DatabaseURL != utils.Env.TestDatabaseURL {
+		t.Fatalf("should only be calling DeleteKVPairsForTest method on test database")
+	} else {
+		DB.Exec("DELETE from kv_pairs;")
+	}
+}
+
+func DeleteAccountMetadataKeysForTest(t *testing.T) {
+	if utils.Env.DatabaseURL != utils.Env.TestDatabaseURL {
+		t.Fatalf("should only be calling DeleteAccountMetadataKeysForTest method on test database")
+	} else {
+		DB.Exec("DELETE from account_metadata_keys;")
+	}
+}
+
+func DeleteExpirationExtensionsForTest(t *testing.T) {
+	if utils.Env.DatabaseURL != utils.Env.TestDatabaseURL {
+		t.Fatalf("should only be calling DeleteExpirationExtensionsForTest method on test database")
+	} else {
+		DB.Exec("DELETE from expiration_extensions;")
+	}
+}
+
+func DeleteKeyRotationsForTest(t *testing.T) {
+	if utils.Env.DatabaseURL != utils.Env.TestDatabaseURL {
+		t.Fatalf("should only be calling DeleteKeyRotationsForTest method on test database")
+	} else {
+		DB.Exec("DELETE from key_rotations;")
+	}
+}
+
+func DeleteDelegatedKeysForTest(t *testing.T) {
+	if utils.Env.DatabaseURL != utils.Env.TestDatabaseURL {
+		t.Fatalf("should only be calling DeleteDelegatedKeysForTest method on test database")
+	} else {
+		DB.Exec("DELETE from delegated_keys;")
+	}
+}
+
+func DeleteAdminUsersForTest(t *testing.T) {
+	if utils.Env.DatabaseURL != utils.Env.TestDatabaseURL {
+		t.Fatalf("should only be calling DeleteAdminUsersForTest method on test database")
+	} else {
+		DB.Exec("DELETE from admin_users;")
+	}
+}
+
+func DeleteAdminAuditLogsForTest(t *testing.T) {
+	if utils.Env.DatabaseURL != utils.Env.TestDatabaseURL {
+		t.Fatalf("should only be calling DeleteAdminAuditLogsForTest method on test database")
+	} else {
+		DB.Exec("DELETE from admin_audit_logs;")
+	}
+}
+
+func DeleteDowngradesForTest(t *testing.T) {
+	if utils.Env.DatabaseURL != utils.Env.TestDatabaseURL {
+		t.Fatalf("should only be calling DeleteDowngradesForTest method on test database")
+	} else {
+		DB.Exec("DELETE from downgrades;")
+	}
+}
+
+func DeleteAccountDeletionsForTest(t *testing.T) {
+	if utils.Env.DatabaseURL != utils.Env.TestDatabaseURL {
+		t.Fatalf("should only be calling DeleteAccountDeletionsForTest method on test database")
+	} else {
+		DB.Exec("DELETE from account_deletions;")
+		DB.Exec("DELETE from account_deletion_receipts;")
+	}
+}
+
+func ResetPlansForTest(t *testing.T) {
+	if utils.Env.DatabaseURL != utils.Env.TestDatabaseURL {
+		t.Fatalf("should only be calling ResetPlansForTest method on test database")
+	} else {
+		DB.Exec("UPDATE accounts SET plan_id = 0;")
+		DB.Exec("DELETE from plans;")
+		SeedPlans()
+	}
+}
+
+func DeleteLedgerEntriesForTest(t *testing.T) {
+	if utils.Env.DatabaseURL != utils.Env.TestDatabaseURL {
+		t.Fatalf("should only be calling DeleteLedgerEntriesForTest method on test database")
+	} else {
+		DB.Exec("DELETE from ledger_entries;")
+	}
+}
+
+func DeleteCouponsForTest(t *testing.T) {
+	if utils.Env.DatabaseURL != utils.Env.TestDatabaseURL {
+		t.Fatalf("should only be calling DeleteCouponsForTest method on test database")
+	} else {
+		DB.Exec("DELETE from coupons;")
+		DB.Exec("DELETE from coupon_redemptions;")
+	}
+}
+
+func DeleteOrganizationsForTest(t *testing.T) {
+	if utils.Env.DatabaseURL != utils.Env.TestDatabaseURL {
+		t.Fatalf("should only be calling DeleteOrganizationsForTest method on test database")
+	} else {
+		DB.Exec("DELETE from organizations;")
+		DB.Exec("DELETE from organization_members;")
+	}
+}
+
+func DeleteUsageSamplesForTest(t *testing.T) {
+	if utils.Env.DatabaseURL != utils.Env.TestDatabaseURL {
+		t.Fatalf("should only be calling DeleteUsageSamplesForTest method on test database")
+	} else {
+		DB.Exec("DELETE from usage_samples;")
+	}
+}
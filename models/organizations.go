@@ -0,0 +1,344 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/opacity/storage-node/utils"
+)
+
+/*Organization lets an owner account share the storage of its plan with member accounts.  The pool is the owner's
+StorageLimit, and StorageUsedInByte counts what the owner and every member that joined have stored.*/
+type Organization struct {
+	ID                uint      `gorm:"primary_key" json:"id"`
+	OwnerAccountID    string    `gorm:"type:varchar(64);unique_index" json:"ownerAccountID" binding:"required,len=64"`
+	Name              string    `json:"name" binding:"omitempty,max=64" example:"Acme"`
+	StorageUsedInByte int64     `json:"storageUsedInByte" binding:"exists,gte=0" gorm:"default:0" example:"30"`
+	CreatedAt         time.Time `json:"createdAt"`
+	UpdatedAt         time.Time `json:"updatedAt"`
+}
+
+/*OrganizationMember is an account invited to an organization.  Once it joins, what it stores is charged to the
+organization's pool instead of its own StorageLimit, up to StorageCapInByte if the owner set one.*/
+type OrganizationMember struct {
+	ID                uint                     `gorm:"primary_key" json:"-"`
+	OrganizationID    uint                     `gorm:"unique_index:idx_organization_member_account" json:"organizationID" binding:"required"`
+	AccountID         string                   `gorm:"type:varchar(64);unique_index:idx_organization_member_account;index" json:"accountID" binding:"required,len=64"`
+	Status            OrganizationMemberStatus `json:"-" binding:"required,gte=1"`
+	StatusName        string                   `gorm:"-" json:"status" example:"joined"`
+	StorageCapInByte  int64                    `json:"storageCapInByte" binding:"omitempty,gte=0" gorm:"default:0"` // no cap if 0
+	StorageUsedInByte int64                    `gorm:"-" json:"storageUsedInByte"`                                  // what the member's account has stored
+	CreatedAt         time.Time                `json:"createdAt"`
+	UpdatedAt         time.Time                `json:"updatedAt"`
+}
+
+/*OrganizationMemberStatus defines a type for where a member is in joining an organization*/
+type OrganizationMemberStatus int
+
+const (
+	/*OrganizationMemberInvited - the owner invited the account and it hasn't joined yet*/
+	OrganizationMemberInvited OrganizationMemberStatus = iota + 1
+
+	/*OrganizationMemberJoined - the account joined and stores in the organization's pool*/
+	OrganizationMemberJoined
+)
+
+/*OrganizationMemberStatusMap is for pretty printing the OrganizationMemberStatus*/
+var OrganizationMemberStatusMap = map[OrganizationMemberStatus]string{
+	OrganizationMemberInvited: "invited",
+	OrganizationMemberJoined:  "joined",
+}
+
+var (
+	/*ErrAlreadyInOrganization is returned when an account that owns or joined an organization creates or joins
+	another*/
+	ErrAlreadyInOrganization = errors.New("the account already owns or belongs to an organization")
+
+	/*ErrInviteOrganizationOwner is returned when the owner invites itself*/
+	ErrInviteOrganizationOwner = errors.New("the owner is already in the organization")
+
+	/*ErrOrganizationStorageFull is returned when storing would use more than the organization's pool*/
+	ErrOrganizationStorageFull = errors.New("unable to store more data in the organization's storage")
+
+	/*ErrOrganizationMemberCap is returned when storing would put a member over the cap its owner set*/
+	ErrOrganizationMemberCap = errors.New("unable to store more data than the organization allows the member")
+
+	/*ErrOrganizationExpired is returned when a member stores more after the owner's subscription ran out*/
+	ErrOrganizationExpired = errors.New("the organization owner's subscription has expired")
+)
+
+/*BeforeCreate - callback called before the row is created*/
+func (organization *Organization) BeforeCreate(scope *gorm.Scope) error {
+	return utils.Validator.Struct(organization)
+}
+
+/*BeforeUpdate - callback called before the row is updated*/
+func (organization *Organization) BeforeUpdate(scope *gorm.Scope) error {
+	return utils.Validator.Struct(organization)
+}
+
+/*BeforeCreate - callback called before the row is created*/
+func (member *OrganizationMember) BeforeCreate(scope *gorm.Scope) error {
+	return utils.Validator.Struct(member)
+}
+
+/*BeforeUpdate - callback called before the row is updated*/
+func (member *OrganizationMember) BeforeUpdate(scope *gorm.Scope) error {
+	return utils.Validator.Struct(member)
+}
+
+/*AfterFind - callback called after the row is read*/
+func (member *OrganizationMember) AfterFind() error {
+	member.StatusName = OrganizationMemberStatusMap[member.Status]
+	return nil
+}
+
+/*CreateOrganization creates an organization sharing the owner's plan.  What the owner already stored is the
+first thing in its pool.*/
+func CreateOrganization(owner Account, name string) (Organization, error) {
+	if _, _, err := GetAccountOrganization(owner.AccountID); err == nil {
+		return Organization{}, ErrAlreadyInOrganization
+	} else if !gorm.IsRecordNotFoundError(err) {
+		return Organization{}, err
+	}
+	organization := Organization{
+		OwnerAccountID:    owner.AccountID,
+		Name:              name,
+		StorageUsedInByte: owner.StorageUsedInByte,
+	}
+	return organization, DB.Create(&organization).Error
+}
+
+/*GetOrganization returns the organization with id*/
+func GetOrganization(id uint) (Organization, error) {
+	organization := Organization{}
+	err := DB.Where("id = ?", id).First(&organization).Error
+	return organization, err
+}
+
+/*GetOwnedOrganization returns the organization the account owns*/
+func GetOwnedOrganization(ownerAccountID string) (Organization, error) {
+	organization := Organization{}
+	err := DB.Where("owner_account_id = ?", ownerAccountID).First(&organization).Error
+	return organization, err
+}
+
+/*GetAccountOrganization returns the organization the account owns or joined, and its membership if it isn't the
+owner.  It returns gorm.ErrRecordNotFound if the account is in no organization.*/
+func GetAccountOrganization(accountID string) (Organization, *OrganizationMember, error) {
+	return getAccountOrganization(DB, accountID)
+}
+
+func getAccountOrganization(db *gorm.DB, accountID string) (Organization, *OrganizationMember, error) {
+	organization := Organization{}
+	err := db.Where("owner_account_id = ?", accountID).First(&organization).Error
+	if !gorm.IsRecordNotFoundError(err) {
+		return organization, nil, err
+	}
+	member := OrganizationMember{}
+	if err := db.Where("account_id = ? AND status = ?", accountID, OrganizationMemberJoined).
+		First(&member).Error; err != nil {
+		return organization, nil, err
+	}
+	err = db.Where("id = ?", member.OrganizationID).First(&organization).Error
+	return organization, &member, err
+}
+
+/*Members returns everyone the owner invited, with what each joined member has stored*/
+func (organization Organization) Members() ([]OrganizationMember, error) {
+	members := []OrganizationMember{}
+	if err := DB.Where("organization_id = ?", organization.ID).Order("id").Find(&members).Error; err != nil {
+		return members, err
+	}
+	for i := range members {
+		if members[i].Status != OrganizationMemberJoined {
+			continue
+		}
+		account, err := GetAccountById(members[i].AccountID)
+		if err != nil && !gorm.IsRecordNotFoundError(err) {
+			return members, err
+		}
+		members[i].StorageUsedInByte = account.StorageUsedInByte
+	}
+	return members, nil
+}
+
+/*Member returns the organization's membership of the account*/
+func (organization Organization) Member(accountID string) (OrganizationMember, error) {
+	member := OrganizationMember{}
+	err := DB.Where("organization_id = ? AND account_id = ?", organization.ID, accountID).First(&member).Error
+	return member, err
+}
+
+/*InviteMember invites the account to the organization, or changes the cap of an account already invited*/
+func (organization Organization) InviteMember(accountID string, storageCapInByte int64) (OrganizationMember, error) {
+	if accountID == organization.OwnerAccountID {
+		return OrganizationMember{}, ErrInviteOrganizationOwner
+	}
+	member, err := organization.Member(accountID)
+	if gorm.IsRecordNotFoundError(err) {
+		member = OrganizationMember{
+			OrganizationID:   organization.ID,
+			AccountID:        accountID,
+			Status:           OrganizationMemberInvited,
+			StorageCapInByte: storageCapInByte,
+		}
+		err = DB.Create(&member).Error
+		member.StatusName = OrganizationMemberStatusMap[member.Status]
+		return member, err
+	}
+	if err != nil {
+		return member, err
+	}
+	return organization.SetMemberStorageCap(accountID, storageCapInByte)
+}
+
+/*SetMemberStorageCap caps what the member can store in the organization's pool, or lifts the cap if
+storageCapInByte is 0.  A cap below what the member already stored only stops it storing more.*/
+func (organization Organization) SetMemberStorageCap(accountID string, storageCapInByte int64) (OrganizationMember,
+	error) {
+	member, err := organization.Member(accountID)
+	if err != nil {
+		return member, err
+	}
+	member.StorageCapInByte = storageCapInByte
+	return member, DB.Model(&member).Update("storage_cap_in_byte", storageCapInByte).Error
+}
+
+/*Join makes the account a member of the organization that invited it.  What the account already stored moves to
+the organization's pool, so it has to fit in the pool and under the member's cap.*/
+func (organization Organization) Join(account Account) (OrganizationMember, error) {
+	member, err := organization.Member(account.AccountID)
+	if err != nil {
+		return member, err
+	}
+	if member.Status == OrganizationMemberJoined {
+		return member, nil
+	}
+	if _, _, err := GetAccountOrganization(account.AccountID); err == nil {
+		return member, ErrAlreadyInOrganization
+	} else if !gorm.IsRecordNotFoundError(err) {
+		return member, err
+	}
+
+	tx := DB.Begin()
+	if err := tx.Error; err != nil {
+		return member, err
+	}
+	if err := organization.chargeStorage(tx, &member, account.StorageUsedInByte,
+		account.StorageUsedInByte); err != nil {
+		tx.Rollback()
+		return member, err
+	}
+	if err := tx.Model(&member).Update("status", OrganizationMemberJoined).Error; err != nil {
+		tx.Rollback()
+		return member, err
+	}
+	member.StatusName = OrganizationMemberStatusMap[member.Status]
+	member.StorageUsedInByte = account.StorageUsedInByte
+	return member, tx.Commit().Error
+}
+
+/*RemoveMember takes the account out of the organization, or withdraws its invitation.  What a joined member
+stored leaves the organization's pool and counts against the account's own StorageLimit again.  It returns
+gorm.ErrRecordNotFound if the account wasn't invited.*/
+func (organization Organization) RemoveMember(accountID string) error {
+	member, err := organization.Member(accountID)
+	if err != nil {
+		return err
+	}
+
+	tx := DB.Begin()
+	if err := tx.Error; err != nil {
+		return err
+	}
+	if member.Status == OrganizationMemberJoined {
+		account := Account{}
+		err := tx.Where("account_id = ?", accountID).First(&account).Error
+		if err != nil && !gorm.IsRecordNotFoundError(err) {
+			tx.Rollback()
+			return err
+		}
+		if err := organization.chargeStorage(tx, &member, 0, -account.StorageUsedInByte); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if err := tx.Delete(&member).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+/*chargeStorage adds planToUsedInByte to the organization's pool in tx.  storageUsedInByte is what the member will
+have stored after it.  Freeing space always works, while storing more needs the owner's subscription to be active
+and the pool and the member's cap to have room, which the update itself checks so concurrent uploads can't
+overfill the pool.*/
+func (organization Organization) chargeStorage(tx *gorm.DB, member *OrganizationMember, storageUsedInByte int64,
+	planToUsedInByte int64) error {
+	if planToUsedInByte <= 0 {
+		return tx.Model(&Organization{}).Where("id = ?", organization.ID).UpdateColumn("storage_used_in_byte",
+			gorm.Expr("GREATEST(storage_used_in_byte + ?, 0)", planToUsedInByte)).Error
+	}
+
+	owner := Account{}
+	if err := tx.Where("account_id = ?", organization.OwnerAccountID).First(&owner).Error; err != nil {
+		return err
+	}
+	if owner.State() != AccountStateActive {
+		return ErrOrganizationExpired
+	}
+	if member != nil && member.StorageCapInByte > 0 && storageUsedInByte > member.StorageCapInByte {
+		return ErrOrganizationMemberCap
+	}
+
+	db := tx.Model(&Organization{}).
+		Where("id = ? AND storage_used_in_byte + ? <= ?", organization.ID, planToUsedInByte,
+			int64(owner.StorageLimit)*1e9).
+		UpdateColumn("storage_used_in_byte", gorm.Expr("storage_used_in_byte + ?", planToUsedInByte))
+	if db.Error != nil {
+		return db.Error
+	}
+	if db.RowsAffected == 0 {
+		return ErrOrganizationStorageFull
+	}
+	return nil
+}
+
+/*hasStorageSpaceFor returns whether a member that stored storageUsedInByte, or the owner if member is nil, can
+store fileSizeInByte more in the organization*/
+func (organization Organization) hasStorageSpaceFor(member *OrganizationMember, storageUsedInByte int64,
+	fileSizeInByte int64) (bool, error) {
+	owner, err := GetAccountById(organization.OwnerAccountID)
+	if err != nil {
+		return false, err
+	}
+	if member != nil && member.StorageCapInByte > 0 && storageUsedInByte+fileSizeInByte > member.StorageCapInByte {
+		return false, nil
+	}
+	return organization.StorageUsedInByte+fileSizeInByte <= int64(owner.StorageLimit)*1e9, nil
+}
+
+/*leaveOrganization takes an account that is being deleted out of its organization.  An owner's organization is
+dissolved, and every member goes back to its own StorageLimit.*/
+func leaveOrganization(accountID string) error {
+	organization, member, err := GetAccountOrganization(accountID)
+	if gorm.IsRecordNotFoundError(err) {
+		return DB.Where("account_id = ?", accountID).Delete(&OrganizationMember{}).Error
+	}
+	if err != nil {
+		return err
+	}
+	if member != nil {
+		return utils.ReturnFirstError([]error{
+			organization.RemoveMember(accountID),
+			DB.Where("account_id = ?", accountID).Delete(&OrganizationMember{}).Error,
+		})
+	}
+	return utils.ReturnFirstError([]error{
+		DB.Where("organization_id = ?", organization.ID).Delete(&OrganizationMember{}).Error,
+		DB.Delete(&organization).Error,
+	})
+}
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/opacity/storage-node/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func createOrganizationForTest(t *testing.T) (Organization, Account, Account) {
+	owner := returnValidAccount()
+	owner.StorageLimit = ProfessionalStorageLimit
+	owner.StorageUsedInByte = 0
+	owner.PaymentStatus = PaymentRetrievalComplete
+	assert.Nil(t, DB.Create(&owner).Error)
+
+	member := returnValidAccount()
+	member.StorageUsedInByte = 0
+	member.PaymentStatus = PaymentRetrievalComplete
+	assert.Nil(t, DB.Create(&member).Error)
+
+	organization, err := CreateOrganization(owner, "Acme")
+	assert.Nil(t, err)
+	_, err = organization.InviteMember(member.AccountID, 0)
+	assert.Nil(t, err)
+	_, err = organization.Join(member)
+	assert.Nil(t, err)
+	return organization, owner, member
+}
+
+func Test_Init_Organizations(t *testing.T) {
+	utils.SetTesting("../.env")
+	Connect(utils.Env.TestDatabaseURL)
+}
+
+func Test_Member_Storage_Is_Charged_To_The_Pool(t *testing.T) {
+	DeleteAccountsForTest(t)
+	DeleteOrganizationsForTest(t)
+	organization, owner, member := createOrganizationForTest(t)
+
+	// more than the member's own plan, but within the owner's
+	assert.Nil(t, member.UseStorageSpaceInByte(200*1e9))
+	assert.Nil(t, owner.UseStorageSpaceInByte(100*1e9))
+
+	organization, err := GetOrganization(organization.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(300*1e9), organization.StorageUsedInByte)
+
+	assert.Equal(t, ErrOrganizationStorageFull, member.UseStorageSpaceInByte(800*1e9))
+	memberFromDB, _ := GetAccountById(member.AccountID)
+	assert.Equal(t, int64(200*1e9), memberFromDB.StorageUsedInByte)
+
+	assert.Nil(t, member.UseStorageSpaceInByte(-50*1e9))
+	organization, _ = GetOrganization(organization.ID)
+	assert.Equal(t, int64(250*1e9), organization.StorageUsedInByte)
+}
+
+func Test_Member_Storage_Cap(t *testing.T) {
+	DeleteAccountsForTest(t)
+	DeleteOrganizationsForTest(t)
+	organization, _, member := createOrganizationForTest(t)
+
+	_, err := organization.SetMemberStorageCap(member.AccountID, 50*1e9)
+	assert.Nil(t, err)
+
+	hasSpace, err := member.HasStorageSpaceFor(60 * 1e9)
+	assert.Nil(t, err)
+	assert.False(t, hasSpace)
+	assert.Equal(t, ErrOrganizationMemberCap, member.UseStorageSpaceInByte(60*1e9))
+	assert.Nil(t, member.UseStorageSpaceInByte(40*1e9))
+}
+
+func Test_Removing_A_Member_Frees_The_Pool(t *testing.T) {
+	DeleteAccountsForTest(t)
+	DeleteOrganizationsForTest(t)
+	organization, _, member := createOrganizationForTest(t)
+	assert.Nil(t, member.UseStorageSpaceInByte(5*1e9))
+
+	assert.Nil(t, organization.RemoveMember(member.AccountID))
+
+	organization, _ = GetOrganization(organization.ID)
+	assert.Equal(t, int64(0), organization.StorageUsedInByte)
+	_, _, err := GetAccountOrganization(member.AccountID)
+	assert.True(t, err != nil)
+}
+
+func Test_An_Account_Can_Only_Be_In_One_Organization(t *testing.T) {
+	DeleteAccountsForTest(t)
+	DeleteOrganizationsForTest(t)
+	_, _, member := createOrganizationForTest(t)
+
+	_, err := CreateOrganization(member, "")
+	assert.Equal(t, ErrAlreadyInOrganization, err)
+}
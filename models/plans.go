@@ -0,0 +1,286 @@
+package models
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/opacity/storage-node/utils"
+)
+
+/*Plan is one version of a plan we sell.  A version's prices and limits never change once accounts can reference
+it, so changing a plan adds a new version, and accounts keep the version they bought until they upgrade.*/
+type Plan struct {
+	ID                      uint       `gorm:"primary_key" json:"id"`
+	StorageInGB             int        `gorm:"unique_index:idx_plan_storage_version" json:"storageInGB" binding:"required,gt=0" example:"128"`
+	Version                 int        `gorm:"unique_index:idx_plan_storage_version" json:"version" binding:"required,gte=1" example:"1"`
+	Name                    string     `gorm:"type:varchar(64)" json:"name" binding:"required,max=64" example:"Basic"`
+	Cost                    float64    `json:"cost" binding:"gte=0" example:"2"`
+	CostInUSD               float64    `json:"costInUSD" binding:"gte=0" example:"39.99"`
+	MaxFolders              int        `json:"maxFolders" binding:"required,gt=0" example:"2000"`
+	MaxMetadataSizeInMB     int64      `json:"maxMetadataSizeInMB" binding:"required,gt=0" example:"200"`
+	MinMonthsInSubscription int        `json:"minMonthsInSubscription" binding:"omitempty,gte=1" example:"1"`
+	EffectiveFrom           time.Time  `json:"effectiveFrom"`  // when the version goes on sale
+	EffectiveUntil          *time.Time `json:"effectiveUntil"` // when the version stops being sold, if it does
+	Grandfathered           bool       `json:"grandfathered"`  // no longer sold, but kept by the accounts that bought it
+	CreatedAt               time.Time  `json:"createdAt"`
+	UpdatedAt               time.Time  `json:"updatedAt"`
+}
+
+/*ErrPlanInUse is returned when deleting a plan version that accounts or their scheduled downgrades still
+reference.  Grandfather it instead.*/
+var ErrPlanInUse = errors.New("accounts are on or moving to this plan version, so it can only be grandfathered")
+
+/*ErrPlanEffectiveUntil is returned for a plan version that would stop being sold before it goes on sale*/
+var ErrPlanEffectiveUntil = errors.New("effectiveUntil must be after effectiveFrom")
+
+// how long nodes use the plans they loaded before loading them again, so admin changes on one node reach the rest
+const planCatalogTTL = time.Minute
+
+type planCatalog struct {
+	mutex    sync.RWMutex
+	plans    []Plan
+	loadedAt time.Time
+}
+
+var catalog planCatalog
+
+/*BeforeCreate - callback called before the row is created*/
+func (plan *Plan) BeforeCreate(scope *gorm.Scope) error {
+	return plan.validate()
+}
+
+/*BeforeUpdate - callback called before the row is updated*/
+func (plan *Plan) BeforeUpdate(scope *gorm.Scope) error {
+	return plan.validate()
+}
+
+func (plan *Plan) validate() error {
+	if plan.EffectiveUntil != nil && !plan.EffectiveUntil.After(plan.EffectiveFrom) {
+		return ErrPlanEffectiveUntil
+	}
+	return utils.Validator.Struct(plan)
+}
+
+/*Info returns the version in the shape PLANS_JSON and /plans use*/
+func (plan Plan) Info() utils.PlanInfo {
+	return utils.PlanInfo{
+		Name:                    plan.Name,
+		Cost:                    plan.Cost,
+		CostInUSD:               plan.CostInUSD,
+		StorageInGB:             plan.StorageInGB,
+		MaxFolders:              plan.MaxFolders,
+		MaxMetadataSizeInMB:     plan.MaxMetadataSizeInMB,
+		MinMonthsInSubscription: plan.MinMonthsInSubscription,
+	}
+}
+
+/*Purchasable returns whether new accounts and upgrades can buy the version at t*/
+func (plan Plan) Purchasable(t time.Time) bool {
+	return !plan.Grandfathered && !t.Before(plan.EffectiveFrom) &&
+		(plan.EffectiveUntil == nil || t.Before(*plan.EffectiveUntil))
+}
+
+/*SeedPlans adds the plans in PLANS_JSON as the first version of each plan when there are no plans yet, and puts
+accounts that don't reference a plan version on the first version for their storage limit*/
+func SeedPlans() error {
+	count := 0
+	if err := DB.Model(&Plan{}).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		for _, plan := range plansFromEnv() {
+			plan.EffectiveFrom = time.Now()
+			if err := DB.Create(&plan).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	var firstVersions []Plan
+	if err := DB.Where("version = ?", 1).Find(&firstVersions).Error; err != nil {
+		return err
+	}
+	for _, plan := range firstVersions {
+		// UpdateColumn skips the account hooks, which would move the expiration date
+		err := DB.Model(&Account{}).Where("(plan_id = ? OR plan_id IS NULL) AND storage_limit = ?", 0, plan.StorageInGB).
+			UpdateColumn("plan_id", plan.ID).Error
+		if err != nil {
+			return err
+		}
+	}
+	return ReloadPlans()
+}
+
+/*ReloadPlans loads the plan versions from the database again*/
+func ReloadPlans() error {
+	var loaded []Plan
+	if err := DB.Order("storage_in_gb asc, version asc").Find(&loaded).Error; err != nil {
+		return err
+	}
+
+	catalog.mutex.Lock()
+	defer catalog.mutex.Unlock()
+	catalog.plans = loaded
+	catalog.loadedAt = time.Now()
+	return nil
+}
+
+/*GetPlans returns every plan version, including those no longer sold*/
+func GetPlans() ([]Plan, error) {
+	var allPlans []Plan
+	err := DB.Order("storage_in_gb asc, version asc").Find(&allPlans).Error
+	return allPlans, err
+}
+
+/*GetPlan returns a plan version by its id*/
+func GetPlan(id uint) (Plan, error) {
+	for _, plan := range cachedPlans() {
+		if plan.ID == id {
+			return plan, nil
+		}
+	}
+	return Plan{}, gorm.ErrRecordNotFound
+}
+
+/*GetPurchasablePlan returns the newest version of the plan for storageInGB that is on sale now*/
+func GetPurchasablePlan(storageInGB int) (Plan, bool) {
+	return latestPlan(storageInGB, true)
+}
+
+/*GetPurchasablePlans returns the plans on sale now, keyed by storage in GB*/
+func GetPurchasablePlans() utils.PlanResponseType {
+	purchasable := make(utils.PlanResponseType)
+	for _, plan := range cachedPlans() {
+		if current, ok := GetPurchasablePlan(plan.StorageInGB); ok && current.ID == plan.ID {
+			purchasable[plan.StorageInGB] = plan.Info()
+		}
+	}
+	return purchasable
+}
+
+/*GetAllPlans returns the newest version of every plan, including plans no longer sold, keyed by storage in GB*/
+func GetAllPlans() utils.PlanResponseType {
+	allPlans := make(utils.PlanResponseType)
+	for _, plan := range cachedPlans() {
+		if latest, ok := latestPlan(plan.StorageInGB, false); ok && latest.ID == plan.ID {
+			allPlans[plan.StorageInGB] = plan.Info()
+		}
+	}
+	return allPlans
+}
+
+/*CreatePlanVersion adds plan as the next version of the plan for its storage size*/
+func CreatePlanVersion(plan Plan) (Plan, error) {
+	latest := Plan{}
+	err := DB.Where("storage_in_gb = ?", plan.StorageInGB).Order("version desc").First(&latest).Error
+	if err != nil && !gorm.IsRecordNotFoundError(err) {
+		return plan, err
+	}
+	plan.ID = 0
+	plan.Version = latest.Version + 1
+	if plan.EffectiveFrom.IsZero() {
+		plan.EffectiveFrom = time.Now()
+	}
+	if err := DB.Create(&plan).Error; err != nil {
+		return plan, err
+	}
+	return plan, ReloadPlans()
+}
+
+/*SetPlanAvailability changes when a plan version stops being sold and whether it is grandfathered.  The rest of
+a version can't change, since accounts may have bought it.*/
+func SetPlanAvailability(id uint, effectiveUntil *time.Time, grandfathered bool) (Plan, error) {
+	plan := Plan{}
+	if err := DB.Where("id = ?", id).First(&plan).Error; err != nil {
+		return plan, err
+	}
+	plan.EffectiveUntil = effectiveUntil
+	plan.Grandfathered = grandfathered
+	if err := DB.Save(&plan).Error; err != nil {
+		return plan, err
+	}
+	return plan, ReloadPlans()
+}
+
+/*DeletePlan deletes a plan version no account or scheduled downgrade references.  It returns
+gorm.ErrRecordNotFound if there is no such version.*/
+func DeletePlan(id uint) error {
+	accountCount := 0
+	if err := DB.Model(&Account{}).Where("plan_id = ?", id).Count(&accountCount).Error; err != nil {
+		return err
+	}
+	downgradeCount := 0
+	if err := DB.Model(&Downgrade{}).Where("plan_id = ?", id).Count(&downgradeCount).Error; err != nil {
+		return err
+	}
+	if accountCount > 0 || downgradeCount > 0 {
+		return ErrPlanInUse
+	}
+	db := DB.Where("id = ?", id).Delete(&Plan{})
+	if db.Error != nil {
+		return db.Error
+	}
+	if db.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return ReloadPlans()
+}
+
+/*latestPlan returns the newest version of the plan for storageInGB, only looking at versions on sale now if
+purchasable is set*/
+func latestPlan(storageInGB int, purchasable bool) (Plan, bool) {
+	now := time.Now()
+	latest, found := Plan{}, false
+	for _, plan := range cachedPlans() {
+		if plan.StorageInGB != storageInGB || (purchasable && !plan.Purchasable(now)) {
+			continue
+		}
+		if !found || plan.Version > latest.Version {
+			latest, found = plan, true
+		}
+	}
+	return latest, found
+}
+
+/*cachedPlans returns the loaded plan versions, loading them again once they are older than planCatalogTTL.
+Without a database they are the plans in PLANS_JSON.*/
+func cachedPlans() []Plan {
+	catalog.mutex.RLock()
+	loaded, loadedAt := catalog.plans, catalog.loadedAt
+	catalog.mutex.RUnlock()
+
+	if DB != nil && time.Since(loadedAt) > planCatalogTTL {
+		if err := ReloadPlans(); err == nil {
+			catalog.mutex.RLock()
+			loaded = catalog.plans
+			catalog.mutex.RUnlock()
+		} else {
+			utils.LogIfError(err, nil)
+		}
+	}
+	if len(loaded) == 0 {
+		return plansFromEnv()
+	}
+	return loaded
+}
+
+func plansFromEnv() []Plan {
+	var envPlans []Plan
+	for storageInGB, info := range utils.Env.Plans {
+		envPlans = append(envPlans, Plan{
+			StorageInGB:             storageInGB,
+			Version:                 1,
+			Name:                    info.Name,
+			Cost:                    info.Cost,
+			CostInUSD:               info.CostInUSD,
+			MaxFolders:              info.MaxFolders,
+			MaxMetadataSizeInMB:     info.MaxMetadataSizeInMB,
+			MinMonthsInSubscription: info.MinMonthsInSubscription,
+		})
+	}
+	sort.Slice(envPlans, func(i, j int) bool { return envPlans[i].StorageInGB < envPlans[j].StorageInGB })
+	return envPlans
+}
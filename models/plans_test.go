@@ -0,0 +1,130 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/opacity/storage-node/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Init_Plans(t *testing.T) {
+	utils.SetTesting("../.env")
+	Connect(utils.Env.TestDatabaseURL)
+}
+
+func returnValidPlanForTest(storageInGB int, cost float64) Plan {
+	return Plan{
+		StorageInGB:         storageInGB,
+		Name:                "Basic",
+		Cost:                cost,
+		CostInUSD:           cost * 20,
+		MaxFolders:          2000,
+		MaxMetadataSizeInMB: 200,
+	}
+}
+
+func Test_Plan_Purchasable(t *testing.T) {
+	now := time.Now()
+	later := now.Add(time.Hour)
+	earlier := now.Add(-time.Hour)
+
+	tests := []struct {
+		name        string
+		plan        Plan
+		purchasable bool
+	}{
+		{"on sale", Plan{EffectiveFrom: earlier}, true},
+		{"not on sale yet", Plan{EffectiveFrom: later}, false},
+		{"on sale until later", Plan{EffectiveFrom: earlier, EffectiveUntil: &later}, true},
+		{"no longer on sale", Plan{EffectiveFrom: earlier, EffectiveUntil: &now}, false},
+		{"grandfathered", Plan{EffectiveFrom: earlier, Grandfathered: true}, false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.purchasable, tt.plan.Purchasable(now), tt.name)
+	}
+}
+
+func Test_SeedPlans_Adds_The_Env_Plans(t *testing.T) {
+	ResetPlansForTest(t)
+
+	plans, err := GetPlans()
+	assert.Nil(t, err)
+	assert.Equal(t, len(utils.Env.Plans), len(plans))
+	assert.Equal(t, utils.Env.Plans, GetPurchasablePlans())
+}
+
+func Test_CreatePlanVersion_Replaces_The_Plan_On_Sale(t *testing.T) {
+	ResetPlansForTest(t)
+	account := returnValidAccount()
+	assert.Nil(t, DB.Create(&account).Error)
+	firstVersion, ok := GetPurchasablePlan(int(BasicStorageLimit))
+	assert.True(t, ok)
+	assert.Equal(t, firstVersion.ID, account.PlanID)
+
+	secondVersion, err := CreatePlanVersion(returnValidPlanForTest(int(BasicStorageLimit), 3))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, secondVersion.Version)
+
+	current, ok := GetPurchasablePlan(int(BasicStorageLimit))
+	assert.True(t, ok)
+	assert.Equal(t, secondVersion.ID, current.ID)
+	assert.Equal(t, float64(3), GetPurchasablePlans()[int(BasicStorageLimit)].Cost)
+
+	// the account keeps the price it bought
+	cost, _ := account.Cost()
+	assert.Equal(t, BasicSubscriptionDefaultCost, cost)
+}
+
+func Test_CreatePlanVersion_In_The_Future_Is_Not_On_Sale_Yet(t *testing.T) {
+	ResetPlansForTest(t)
+	firstVersion, _ := GetPurchasablePlan(int(BasicStorageLimit))
+
+	plan := returnValidPlanForTest(int(BasicStorageLimit), 3)
+	plan.EffectiveFrom = time.Now().Add(24 * time.Hour)
+	_, err := CreatePlanVersion(plan)
+	assert.Nil(t, err)
+
+	current, _ := GetPurchasablePlan(int(BasicStorageLimit))
+	assert.Equal(t, firstVersion.ID, current.ID)
+}
+
+func Test_SetPlanAvailability_Grandfathers_A_Plan(t *testing.T) {
+	ResetPlansForTest(t)
+	account := returnValidAccount()
+	assert.Nil(t, DB.Create(&account).Error)
+
+	_, err := SetPlanAvailability(account.PlanID, nil, true)
+	assert.Nil(t, err)
+
+	_, ok := GetPurchasablePlan(int(BasicStorageLimit))
+	assert.False(t, ok)
+	assert.Equal(t, utils.Env.Plans[int(BasicStorageLimit)].MaxFolders, account.MaxAllowedMetadatas())
+	assert.Equal(t, utils.Env.Plans[int(BasicStorageLimit)], GetAllPlans()[int(BasicStorageLimit)])
+
+	effectiveUntil := time.Time{}
+	_, err = SetPlanAvailability(account.PlanID, &effectiveUntil, false)
+	assert.Equal(t, ErrPlanEffectiveUntil, err)
+}
+
+func Test_DeletePlan_Refuses_Plans_In_Use(t *testing.T) {
+	ResetPlansForTest(t)
+	account := returnValidAccount()
+	assert.Nil(t, DB.Create(&account).Error)
+
+	assert.Equal(t, ErrPlanInUse, DeletePlan(account.PlanID))
+
+	plan, err := CreatePlanVersion(returnValidPlanForTest(int(BasicStorageLimit), 3))
+	assert.Nil(t, err)
+	DeleteDowngradesForTest(t)
+	downgrade := Downgrade{AccountID: account.AccountID, OldStorageLimit: ProfessionalStorageLimit,
+		NewStorageLimit: BasicStorageLimit, PlanID: plan.ID}
+	assert.Nil(t, DB.Create(&downgrade).Error)
+	assert.Equal(t, ErrPlanInUse, DeletePlan(plan.ID))
+
+	assert.Nil(t, CancelDowngrade(account.AccountID))
+	assert.Nil(t, DeletePlan(plan.ID))
+	assert.True(t, gorm.IsRecordNotFoundError(DeletePlan(plan.ID)))
+}
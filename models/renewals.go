@@ -21,8 +21,13 @@ type Renewal struct {
 	ApiVersion    int               `json:"apiVersion" binding:"omitempty,gte=1" gorm:"default:1"`
 	PaymentMethod PaymentMethodType `json:"paymentMethod" gorm:"default:0"`
 	OpctCost      float64           `json:"opctCost" binding:"omitempty,gte=0" example:"1.56"`
+	CreditInOPCT  float64           `json:"creditInOPCT" binding:"omitempty,gte=0" example:"0.5"` // account credit taken off the renewal
 	//UsdCost          float64           `json:"usdcost" binding:"omitempty,gte=0" example:"39.99"`
-	DurationInMonths int `json:"durationInMonths" gorm:"default:12" binding:"required,gte=1" minimum:"1" example:"12"`
+	DurationInMonths int     `json:"durationInMonths" gorm:"default:12" binding:"required,gte=1" minimum:"1" example:"12"`
+	CouponID         uint    `json:"couponID" gorm:"default:0"`
+	DiscountInOPCT   float64 `json:"discountInOPCT" binding:"omitempty,gte=0" gorm:"default:0"` // taken off OpctCost by the coupon
+	ExtraMonths      int     `json:"extraMonths" binding:"omitempty,gte=0" gorm:"default:0"`    // added to the subscription by the coupon
+	OverageInOPCT    float64 `json:"overageInOPCT" binding:"omitempty,gte=0" gorm:"default:0"`  // unbilled overage added to OpctCost
 }
 
 /*RenewalCollectionFunctions maps a PaymentStatus to the method that should be run
@@ -58,15 +63,40 @@ func (renewal *Renewal) BeforeDelete(scope *gorm.Scope) error {
 	return nil
 }
 
-/*GetOrCreateRenewal will either get or create an renewal.  If the renewal already existed it will update the OpctCost
-but will not update the EthAddress and EthPrivateKey*/
+/*GetOrCreateRenewal will either get or create an renewal.  If the renewal already existed it will only apply a
+coupon it didn't have, and will not update the EthAddress and EthPrivateKey.  A redemption is reserved for a coupon
+the renewal is saved with.*/
 func GetOrCreateRenewal(renewal Renewal) (*Renewal, error) {
 	renewalsFromDB, err := GetRenewalsFromAccountID(renewal.AccountID)
 	if err != nil {
 		return &Renewal{}, err
 	}
 	if len(renewalsFromDB) == 0 {
-		err = DB.Create(&renewal).Error
+		if err := ReserveCouponRedemption(renewal.CouponID); err != nil {
+			return &renewal, err
+		}
+		if err = DB.Create(&renewal).Error; err != nil {
+			ReleaseCouponRedemption(renewal.CouponID)
+		}
+	} else if renewal.CouponID != 0 && renewalsFromDB[0].CouponID == 0 &&
+		renewalsFromDB[0].PaymentStatus == InitialPaymentInProgress {
+		if err := ReserveCouponRedemption(renewal.CouponID); err != nil {
+			return &renewalsFromDB[0], err
+		}
+		// a coupon sent after the invoice was made still applies until it is paid, and the overage stays what
+		// the invoice was made with
+		couponRenewal := renewal
+		renewal = renewalsFromDB[0]
+		err = DB.Model(&renewal).Updates(map[string]interface{}{
+			"opct_cost":        utils.RoundCost(couponRenewal.OpctCost - couponRenewal.OverageInOPCT + renewal.OverageInOPCT),
+			"credit_in_opct":   couponRenewal.CreditInOPCT,
+			"coupon_id":        couponRenewal.CouponID,
+			"discount_in_opct": couponRenewal.DiscountInOPCT,
+			"extra_months":     couponRenewal.ExtraMonths,
+		}).Error
+		if err != nil {
+			ReleaseCouponRedemption(couponRenewal.CouponID)
+		}
 	} else {
 		renewal = renewalsFromDB[0]
 	}
@@ -184,13 +214,14 @@ func handleRenewalReadyForCollection(renewal Renewal) error {
 		return errors.New("got negative balance for ethBalance")
 	}
 
-	success, _, _ := EthWrapper.TransferToken(
+	success, txHash, _ := EthWrapper.TransferToken(
 		services.StringToAddress(renewal.EthAddress),
 		privateKey,
 		services.MainWalletAddress,
 		*tokenBalance,
 		services.SlowGasPrice)
 	if success {
+		logOPCTCollection(renewal.AccountID, tokenBalance, txHash, renewalLedgerDescription)
 		SetRenewalsToNextPaymentStatus([]Renewal{renewal})
 		return nil
 	}
@@ -211,10 +242,13 @@ func handleRenewalAlreadyCollected(renewal Renewal) error {
 	return nil
 }
 
-/*PurgeOldRenewals deletes renewals past a certain age*/
+/*PurgeOldRenewals deletes renewals past a certain age, releasing the coupon redemptions of unpaid ones*/
 func PurgeOldRenewals(hoursToRetain int) error {
-	err := DB.Where("updated_at < ?",
-		time.Now().Add(-1*time.Hour*time.Duration(hoursToRetain))).Delete(&Renewal{}).Error
+	updatedBefore := time.Now().Add(-1 * time.Hour * time.Duration(hoursToRetain))
+	if err := releaseUnpaidCouponRedemptions(&Renewal{}, "updated_at < ?", updatedBefore); err != nil {
+		return err
+	}
+	err := DB.Where("updated_at < ?", updatedBefore).Delete(&Renewal{}).Error
 
 	return err
 }
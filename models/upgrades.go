@@ -24,7 +24,10 @@ type Upgrade struct {
 	PaymentMethod   PaymentMethodType `json:"paymentMethod" gorm:"default:0"`
 	OpctCost        float64           `json:"opctCost" binding:"omitempty,gte=0" example:"1.56"`
 	//UsdCost          float64           `json:"usdcost" binding:"omitempty,gte=0" example:"39.99"`
-	DurationInMonths int `json:"durationInMonths" gorm:"default:12" binding:"required,gte=1" minimum:"1" example:"12"`
+	DurationInMonths int     `json:"durationInMonths" gorm:"default:12" binding:"required,gte=1" minimum:"1" example:"12"`
+	CouponID         uint    `json:"couponID" gorm:"default:0"`
+	DiscountInOPCT   float64 `json:"discountInOPCT" binding:"omitempty,gte=0" gorm:"default:0"` // taken off OpctCost by the coupon
+	ExtraMonths      int     `json:"extraMonths" binding:"omitempty,gte=0" gorm:"default:0"`    // added to the subscription by the coupon
 }
 
 /*UpgradeCollectionFunctions maps a PaymentStatus to the method that should be run
@@ -61,20 +64,47 @@ func (upgrade *Upgrade) BeforeDelete(scope *gorm.Scope) error {
 }
 
 /*GetOrCreateUpgrade will either get or create an upgrade.  If the upgrade already existed it will update the OpctCost
-but will not update the EthAddress and EthPrivateKey*/
+and coupon but will not update the EthAddress and EthPrivateKey.  A redemption is reserved for a coupon the upgrade
+didn't have, and released for one it no longer has.*/
 func GetOrCreateUpgrade(upgrade Upgrade) (*Upgrade, error) {
 	var upgradeFromDB Upgrade
 
 	upgradeFromDB, err := GetUpgradeFromAccountIDAndStorageLimits(upgrade.AccountID, int(upgrade.NewStorageLimit), int(upgrade.OldStorageLimit))
 	if len(upgradeFromDB.AccountID) == 0 {
-		err = DB.Create(&upgrade).Error
+		if err := ReserveCouponRedemption(upgrade.CouponID); err != nil {
+			return &upgrade, err
+		}
+		if err = DB.Create(&upgrade).Error; err != nil {
+			ReleaseCouponRedemption(upgrade.CouponID)
+		}
 		upgradeFromDB = upgrade
 	} else {
 		targetTime := time.Now().Add(-60 * time.Minute)
-		if targetTime.After(upgradeFromDB.UpdatedAt) {
+		if targetTime.After(upgradeFromDB.UpdatedAt) || upgrade.CouponID != upgradeFromDB.CouponID {
+			oldCouponID := upgradeFromDB.CouponID
+			if upgrade.CouponID != oldCouponID {
+				if err := ReserveCouponRedemption(upgrade.CouponID); err != nil {
+					return &upgradeFromDB, err
+				}
+			}
 			upgradeFromDB.OpctCost = upgrade.OpctCost
 			//upgradeFromDB.UsdCost = upgrade.UsdCost
-			err = DB.Model(&upgradeFromDB).Updates(Upgrade{OpctCost: upgrade.OpctCost}).Error
+			upgradeFromDB.CouponID = upgrade.CouponID
+			upgradeFromDB.DiscountInOPCT = upgrade.DiscountInOPCT
+			upgradeFromDB.ExtraMonths = upgrade.ExtraMonths
+			err = DB.Model(&upgradeFromDB).Updates(map[string]interface{}{
+				"opct_cost":        upgrade.OpctCost,
+				"coupon_id":        upgrade.CouponID,
+				"discount_in_opct": upgrade.DiscountInOPCT,
+				"extra_months":     upgrade.ExtraMonths,
+			}).Error
+			if upgrade.CouponID != oldCouponID {
+				if err != nil {
+					ReleaseCouponRedemption(upgrade.CouponID)
+				} else if upgradeFromDB.PaymentStatus == InitialPaymentInProgress {
+					ReleaseCouponRedemption(oldCouponID)
+				}
+			}
 		}
 	}
 
@@ -201,13 +231,14 @@ func handleUpgradeReadyForCollection(upgrade Upgrade) error {
 		return errors.New("got negative balance for ethBalance")
 	}
 
-	success, _, _ := EthWrapper.TransferToken(
+	success, txHash, _ := EthWrapper.TransferToken(
 		services.StringToAddress(upgrade.EthAddress),
 		privateKey,
 		services.MainWalletAddress,
 		*tokenBalance,
 		services.SlowGasPrice)
 	if success {
+		logOPCTCollection(upgrade.AccountID, tokenBalance, txHash, upgradeLedgerDescription(upgrade.NewStorageLimit))
 		SetUpgradesToNextPaymentStatus([]Upgrade{upgrade})
 		return nil
 	}
@@ -228,10 +259,13 @@ func handleUpgradeAlreadyCollected(upgrade Upgrade) error {
 	return nil
 }
 
-/*PurgeOldUpgrades deletes upgrades past a certain age*/
+/*PurgeOldUpgrades deletes upgrades past a certain age, releasing the coupon redemptions of unpaid ones*/
 func PurgeOldUpgrades(hoursToRetain int) error {
-	err := DB.Where("updated_at < ?",
-		time.Now().Add(-1*time.Hour*time.Duration(hoursToRetain))).Delete(&Upgrade{}).Error
+	updatedBefore := time.Now().Add(-1 * time.Hour * time.Duration(hoursToRetain))
+	if err := releaseUnpaidCouponRedemptions(&Upgrade{}, "updated_at < ?", updatedBefore); err != nil {
+		return err
+	}
+	err := DB.Where("updated_at < ?", updatedBefore).Delete(&Upgrade{}).Error
 
 	return err
 }
@@ -0,0 +1,279 @@
+package models
+
+import (
+	"math"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/opacity/storage-node/utils"
+)
+
+/*UsageSample records how much a paid account stored on a day, which is its usage history.  Whatever was over its
+plan that day is overage, which is billed once, at renewal or by card, and then marked billed.*/
+type UsageSample struct {
+	ID                 uint             `gorm:"primary_key" json:"id"`
+	AccountID          string           `gorm:"type:varchar(64);unique_index:idx_usage_sample_account_day" json:"accountID" binding:"required,len=64"`
+	SampledOn          time.Time        `gorm:"type:date;unique_index:idx_usage_sample_account_day;index" json:"sampledOn"`
+	StorageUsedInByte  int64            `json:"storageUsedInByte" binding:"omitempty,gte=0" example:"30"`
+	FileCount          int              `json:"fileCount" binding:"omitempty,gte=0" example:"12"`
+	FolderCount        int              `json:"folderCount" binding:"omitempty,gte=0" example:"2"`
+	MetadataSizeInByte int64            `json:"metadataSizeInByte" binding:"omitempty,gte=0" example:"1245765"`
+	StorageLimit       StorageLimitType `json:"storageLimit" example:"128"`                          // the plan the account was on, in GB
+	OverageInByte      int64            `json:"overageInByte" binding:"omitempty,gte=0" example:"0"` // how much was stored over the plan
+	BilledAt           *time.Time       `json:"billedAt"`                                            // nil until the overage is billed
+	BilledReference    string           `json:"billedReference"`                                     // the renewal's address or the Stripe charge ID
+	CreatedAt          time.Time        `json:"createdAt"`
+}
+
+/*UsageReport adds up what the accounts on a plan, or on every plan, stored on the day they were last sampled*/
+type UsageReport struct {
+	SpaceReport
+	StorageLimit       StorageLimitType
+	AccountCount       int
+	FileCount          int
+	FolderCount        int
+	MetadataSizeInByte int64
+}
+
+// overage is billed per GB-month, and each daily sample is this fraction of a month
+const daysPerOverageMonth = 30
+
+// samples a card charge has claimed, and that aren't billed yet, carry a billed reference starting with this
+const overageClaimPrefix = "pending:"
+
+/*BeforeCreate - callback called before the row is created*/
+func (sample *UsageSample) BeforeCreate(scope *gorm.Scope) error {
+	return utils.Validator.Struct(sample)
+}
+
+/*BeforeUpdate - callback called before the row is updated*/
+func (sample *UsageSample) BeforeUpdate(scope *gorm.Scope) error {
+	return utils.Validator.Struct(sample)
+}
+
+/*SampleUsage records, for the day sampledOn falls on, how much every paid account stores.  Sampling the same day
+again does nothing, since the job runs hourly and a day that has samples was already sampled.  Only accounts that
+opted in to overage, and don't store in an organization's pool, are sampled as having any.*/
+func SampleUsage(sampledOn time.Time) error {
+	day := sampledOn.UTC().Truncate(24 * time.Hour)
+
+	var sampledCount int
+	if err := DB.Model(&UsageSample{}).Where("sampled_on = ?", day).Count(&sampledCount).Error; err != nil {
+		return err
+	}
+	if sampledCount > 0 {
+		return nil
+	}
+
+	var accounts []Account
+	if err := DB.Where("payment_status >= ? OR overage_enabled = ?", InitialPaymentReceived, true).
+		Find(&accounts).Error; err != nil {
+		return err
+	}
+	fileCounts, err := countCompletedFilesByAccount()
+	if err != nil {
+		return err
+	}
+	var memberAccountIDs []string
+	if err := DB.Model(&OrganizationMember{}).Where("status = ?", OrganizationMemberJoined).
+		Pluck("account_id", &memberAccountIDs).Error; err != nil {
+		return err
+	}
+	inOrganization := make(map[string]bool)
+	for _, accountID := range memberAccountIDs {
+		inOrganization[accountID] = true
+	}
+
+	var errs []error
+	for _, account := range accounts {
+		sample := UsageSample{
+			AccountID:          account.AccountID,
+			SampledOn:          day,
+			StorageUsedInByte:  account.StorageUsedInByte,
+			FileCount:          fileCounts[account.AccountID],
+			FolderCount:        account.TotalFolders,
+			MetadataSizeInByte: account.TotalMetadataSizeInBytes,
+			StorageLimit:       account.StorageLimit,
+		}
+		if account.OverageEnabled && !inOrganization[account.AccountID] {
+			sample.OverageInByte = account.overageInByte()
+		}
+		errs = append(errs, DB.Where(UsageSample{AccountID: account.AccountID, SampledOn: day}).
+			FirstOrCreate(&sample).Error)
+	}
+	return utils.CollectErrors(errs)
+}
+
+/*countCompletedFilesByAccount returns how many completed files each account has*/
+func countCompletedFilesByAccount() (map[string]int, error) {
+	rows, err := DB.Model(&CompletedFile{}).Select("account_id, COUNT(*)").Group("account_id").Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var accountID string
+		var count int
+		if err := rows.Scan(&accountID, &count); err != nil {
+			return nil, err
+		}
+		counts[accountID] = count
+	}
+	return counts, rows.Err()
+}
+
+/*GetLatestUsageReports adds up the samples of the last day accounts were sampled, for every plan and for each plan.
+Both are empty until the first day is sampled.*/
+func GetLatestUsageReports() (UsageReport, map[StorageLimitType]UsageReport, error) {
+	total := UsageReport{}
+	byPlan := make(map[StorageLimitType]UsageReport)
+
+	latest := UsageSample{}
+	err := DB.Order("sampled_on desc").First(&latest).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return total, byPlan, nil
+	}
+	if err != nil {
+		return total, byPlan, err
+	}
+
+	var reports []UsageReport
+	if err := DB.Model(&UsageSample{}).
+		Select("storage_limit, SUM(storage_limit) AS space_allotted_sum, "+
+			"SUM(storage_used_in_byte) AS space_used_sum, COUNT(*) AS account_count, SUM(file_count) AS file_count, "+
+			"SUM(folder_count) AS folder_count, SUM(metadata_size_in_byte) AS metadata_size_in_byte").
+		Where("sampled_on = ?", latest.SampledOn).Group("storage_limit").Scan(&reports).Error; err != nil {
+		return total, byPlan, err
+	}
+
+	for _, report := range reports {
+		byPlan[report.StorageLimit] = report
+		total.SpaceAllottedSum += report.SpaceAllottedSum
+		total.SpaceUsedSum += report.SpaceUsedSum
+		total.AccountCount += report.AccountCount
+		total.FileCount += report.FileCount
+		total.FolderCount += report.FolderCount
+		total.MetadataSizeInByte += report.MetadataSizeInByte
+	}
+	return total, byPlan, nil
+}
+
+/*UnbilledOverageInGBMonths returns the overage the account stored before a time and hasn't been billed for or
+claimed to be charged for*/
+func UnbilledOverageInGBMonths(accountID string, before time.Time) (float64, error) {
+	return sumOverageInGBMonths(DB.
+		Where("account_id = ? AND billed_at IS NULL AND billed_reference = '' AND sampled_on < ?", accountID, before))
+}
+
+/*BillOverage marks the overage the account stored before a time, and that isn't claimed, as billed on reference*/
+func BillOverage(accountID string, before time.Time, reference string) error {
+	return DB.Model(&UsageSample{}).
+		Where("account_id = ? AND billed_at IS NULL AND billed_reference = '' AND sampled_on < ?", accountID, before).
+		UpdateColumns(map[string]interface{}{"billed_at": time.Now(), "billed_reference": reference}).Error
+}
+
+/*ClaimOverage claims the overage the account stored before a time and hasn't been billed for, so only one card
+charge can bill it, and returns what the claimed samples add up to.  The claim is an unguessable pending
+reference, which BillOverageClaim replaces once the card is charged and ReleaseOverageClaim clears if it isn't.*/
+func ClaimOverage(accountID string, before time.Time) (string, float64, error) {
+	claim := overageClaimPrefix + utils.RandHexString(32)
+
+	tx := DB.Begin()
+	if err := tx.Error; err != nil {
+		return "", 0, err
+	}
+	if err := tx.Model(&UsageSample{}).
+		Where("account_id = ? AND billed_at IS NULL AND billed_reference = '' AND sampled_on < ?", accountID, before).
+		UpdateColumn("billed_reference", claim).Error; err != nil {
+		tx.Rollback()
+		return "", 0, err
+	}
+	gbMonths, err := sumOverageInGBMonths(tx.Where("billed_reference = ?", claim))
+	if err != nil {
+		tx.Rollback()
+		return "", 0, err
+	}
+	return claim, gbMonths, tx.Commit().Error
+}
+
+/*BillOverageClaim marks the samples claimed by ClaimOverage as billed on reference*/
+func BillOverageClaim(claim string, reference string) error {
+	return DB.Model(&UsageSample{}).Where("billed_at IS NULL AND billed_reference = ?", claim).
+		UpdateColumns(map[string]interface{}{"billed_at": time.Now(), "billed_reference": reference}).Error
+}
+
+/*ReleaseOverageClaim returns the samples claimed by ClaimOverage to the unbilled overage*/
+func ReleaseOverageClaim(claim string) error {
+	return DB.Model(&UsageSample{}).Where("billed_at IS NULL AND billed_reference = ?", claim).
+		UpdateColumn("billed_reference", "").Error
+}
+
+func sumOverageInGBMonths(db *gorm.DB) (float64, error) {
+	var result struct {
+		OverageInByte int64
+	}
+	err := db.Model(&UsageSample{}).Select("COALESCE(SUM(overage_in_byte), 0) AS overage_in_byte").Scan(&result).Error
+	return overageInGBMonths(float64(result.OverageInByte), 1), err
+}
+
+/*GetUsageSamples returns the account's samples from the day start falls on through the day end falls on, oldest
+first*/
+func GetUsageSamples(accountID string, start, end time.Time) ([]UsageSample, error) {
+	samples := []UsageSample{}
+	err := DB.Where("account_id = ? AND sampled_on >= ? AND sampled_on <= ?", accountID,
+		start.UTC().Truncate(24*time.Hour), end.UTC().Truncate(24*time.Hour)).
+		Order("sampled_on asc").Find(&samples).Error
+	return samples, err
+}
+
+/*ProjectedOverageInGBMonths returns the overage the account hasn't been billed for plus what it will add if it
+stores as much over its plan until it expires*/
+func (account *Account) ProjectedOverageInGBMonths() (float64, error) {
+	unbilled, err := UnbilledOverageInGBMonths(account.AccountID, time.Now())
+	return unbilled + account.remainingOverageInGBMonths(time.Now()), err
+}
+
+/*overageInByte returns how much more than its plan the account stores*/
+func (account *Account) overageInByte() int64 {
+	overage := account.StorageUsedInByte - int64(account.StorageLimit)*1e9
+	if overage < 0 {
+		return 0
+	}
+	return overage
+}
+
+/*remainingOverageInGBMonths returns the overage the account's current usage adds up to from now until it
+expires*/
+func (account *Account) remainingOverageInGBMonths(now time.Time) float64 {
+	expiresAt := account.CreatedAt.AddDate(0, account.MonthsInSubscription, 0)
+	days := math.Max(expiresAt.Sub(now).Hours()/24, 0)
+	return overageInGBMonths(float64(account.overageInByte()), days)
+}
+
+func overageInGBMonths(overageInByte float64, days float64) float64 {
+	return overageInByte / 1e9 * days / daysPerOverageMonth
+}
+
+/*AddUnbilledOverage adds the overage the account hasn't been billed for to the renewal.  It goes on after any credit
+and coupon, which only come off the subscription.*/
+func (renewal *Renewal) AddUnbilledOverage() error {
+	gbMonths, err := UnbilledOverageInGBMonths(renewal.AccountID, time.Now())
+	if err != nil {
+		return err
+	}
+	renewal.OverageInOPCT = utils.OverageCost(gbMonths)
+	renewal.OpctCost = utils.RoundCost(renewal.OpctCost + renewal.OverageInOPCT)
+	return nil
+}
+
+/*BillOverage marks the overage on the renewal as billed once it is paid for.  The renewal is already paid, so
+failing to mark it is logged rather than returned.*/
+func (renewal *Renewal) BillOverage() {
+	if renewal.OverageInOPCT == 0 {
+		return
+	}
+	err := BillOverage(renewal.AccountID, renewal.CreatedAt, renewal.EthAddress)
+	utils.LogIfError(err, map[string]interface{}{"accountID": renewal.AccountID})
+}
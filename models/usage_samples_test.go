@@ -0,0 +1,169 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/opacity/storage-node/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Init_Usage_Samples(t *testing.T) {
+	utils.SetTesting("../.env")
+	Connect(utils.Env.TestDatabaseURL)
+}
+
+func Test_StorageLimitWithOverage_Needs_Opting_In_And_A_Price(t *testing.T) {
+	defer func() { utils.Env.OveragePricePerGBMonth = 0 }()
+	account := returnValidAccount()
+	account.OverageCapInGB = 64
+	assert.Equal(t, int(BasicStorageLimit), account.StorageLimitWithOverage())
+
+	account.OverageEnabled = true
+	assert.Equal(t, int(BasicStorageLimit), account.StorageLimitWithOverage())
+
+	utils.Env.OveragePricePerGBMonth = 0.01
+	assert.Equal(t, int(BasicStorageLimit)+64, account.StorageLimitWithOverage())
+}
+
+func Test_Remaining_Overage_Until_The_Account_Expires(t *testing.T) {
+	now := time.Now()
+	account := returnValidAccount()
+	account.CreatedAt = now.AddDate(0, -DefaultMonthsPerSubscription, 30)
+	assert.Equal(t, 0.0, account.remainingOverageInGBMonths(now))
+
+	account.StorageUsedInByte = int64(BasicStorageLimit)*1e9 + 3*1e9
+	assert.Equal(t, int64(3*1e9), account.overageInByte())
+	assert.InDelta(t, 3.0, account.remainingOverageInGBMonths(now), 0.001)
+
+	account.CreatedAt = now.AddDate(-2, 0, 0)
+	assert.Equal(t, 0.0, account.remainingOverageInGBMonths(now))
+}
+
+func Test_SampleUsage_Bills_Each_Day_Once(t *testing.T) {
+	DeleteAccountsForTest(t)
+	DeleteUsageSamplesForTest(t)
+	account := returnValidAccount()
+	account.OverageEnabled = true
+	account.OverageCapInGB = 64
+	account.StorageUsedInByte = int64(BasicStorageLimit)*1e9 + 30*1e9
+	assert.Nil(t, DB.Create(&account).Error)
+
+	yesterday := time.Now().AddDate(0, 0, -1)
+	assert.Nil(t, SampleUsage(yesterday))
+	joinedLater := returnValidAccount()
+	joinedLater.PaymentStatus = PaymentRetrievalComplete
+	assert.Nil(t, DB.Create(&joinedLater).Error)
+	assert.Nil(t, SampleUsage(yesterday))
+	samples, err := GetUsageSamples(account.AccountID, yesterday, time.Now())
+	assert.Nil(t, err)
+	assert.Len(t, samples, 1)
+	samples, err = GetUsageSamples(joinedLater.AccountID, yesterday, time.Now())
+	assert.Nil(t, err)
+	assert.Len(t, samples, 0)
+	assert.Equal(t, int64(30*1e9), samples[0].OverageInByte)
+
+	gbMonths, err := UnbilledOverageInGBMonths(account.AccountID, time.Now())
+	assert.Nil(t, err)
+	assert.Equal(t, 1.0, gbMonths)
+
+	assert.Nil(t, BillOverage(account.AccountID, time.Now(), "ch_test"))
+	gbMonths, err = UnbilledOverageInGBMonths(account.AccountID, time.Now())
+	assert.Nil(t, err)
+	assert.Equal(t, 0.0, gbMonths)
+}
+
+func Test_ClaimOverage_Claims_Each_Sample_Once(t *testing.T) {
+	DeleteUsageSamplesForTest(t)
+	accountID := utils.RandHexString(64)
+	assert.Nil(t, DB.Create(&UsageSample{
+		AccountID:     accountID,
+		SampledOn:     time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -1),
+		OverageInByte: 30 * 1e9,
+	}).Error)
+
+	claim, gbMonths, err := ClaimOverage(accountID, time.Now())
+	assert.Nil(t, err)
+	assert.Equal(t, 1.0, gbMonths)
+	_, gbMonths, err = ClaimOverage(accountID, time.Now())
+	assert.Nil(t, err)
+	assert.Equal(t, 0.0, gbMonths)
+	gbMonths, err = UnbilledOverageInGBMonths(accountID, time.Now())
+	assert.Nil(t, err)
+	assert.Equal(t, 0.0, gbMonths)
+
+	assert.Nil(t, ReleaseOverageClaim(claim))
+	gbMonths, err = UnbilledOverageInGBMonths(accountID, time.Now())
+	assert.Nil(t, err)
+	assert.Equal(t, 1.0, gbMonths)
+
+	claim, _, err = ClaimOverage(accountID, time.Now())
+	assert.Nil(t, err)
+	assert.Nil(t, BillOverageClaim(claim, "ch_test"))
+	assert.Nil(t, ReleaseOverageClaim(claim))
+	samples, err := GetUsageSamples(accountID, time.Now().AddDate(0, 0, -1), time.Now())
+	assert.Nil(t, err)
+	assert.Len(t, samples, 1)
+	assert.NotNil(t, samples[0].BilledAt)
+	assert.Equal(t, "ch_test", samples[0].BilledReference)
+}
+
+func Test_SampleUsage_Records_Paid_Accounts_History(t *testing.T) {
+	DeleteAccountsForTest(t)
+	DeleteCompletedFilesForTest(t)
+	DeleteUsageSamplesForTest(t)
+	paid := returnValidAccount()
+	paid.PaymentStatus = PaymentRetrievalComplete
+	paid.TotalFolders = 3
+	paid.TotalMetadataSizeInBytes = 2048
+	assert.Nil(t, DB.Create(&paid).Error)
+	unpaid := returnValidAccount()
+	assert.Nil(t, DB.Create(&unpaid).Error)
+	for i := 0; i < 2; i++ {
+		assert.Nil(t, DB.Create(&CompletedFile{
+			FileID:         utils.RandSeqFromRunes(64, []rune("abcdef01234567890")),
+			ModifierHash:   utils.RandSeqFromRunes(64, []rune("abcdef01234567890")),
+			FileSizeInByte: 100,
+			AccountID:      paid.AccountID,
+		}).Error)
+	}
+
+	assert.Nil(t, SampleUsage(time.Now()))
+
+	samples, err := GetUsageSamples(paid.AccountID, time.Now(), time.Now())
+	assert.Nil(t, err)
+	assert.Len(t, samples, 1)
+	assert.Equal(t, paid.StorageUsedInByte, samples[0].StorageUsedInByte)
+	assert.Equal(t, 2, samples[0].FileCount)
+	assert.Equal(t, 3, samples[0].FolderCount)
+	assert.Equal(t, int64(2048), samples[0].MetadataSizeInByte)
+	assert.Equal(t, int64(0), samples[0].OverageInByte)
+
+	samples, err = GetUsageSamples(unpaid.AccountID, time.Now(), time.Now())
+	assert.Nil(t, err)
+	assert.Len(t, samples, 0)
+
+	total, byPlan, err := GetLatestUsageReports()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, total.AccountCount)
+	assert.Equal(t, 2, total.FileCount)
+	assert.Equal(t, int(BasicStorageLimit), byPlan[BasicStorageLimit].SpaceAllottedSum)
+	assert.Equal(t, float64(paid.StorageUsedInByte), byPlan[BasicStorageLimit].SpaceUsedSum)
+}
+
+func Test_Renewal_AddUnbilledOverage(t *testing.T) {
+	defer func() { utils.Env.OveragePricePerGBMonth = 0 }()
+	utils.Env.OveragePricePerGBMonth = 0.5
+	DeleteUsageSamplesForTest(t)
+	account := returnValidAccount()
+	assert.Nil(t, DB.Create(&UsageSample{
+		AccountID:     account.AccountID,
+		SampledOn:     time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -1),
+		OverageInByte: 60 * 1e9,
+	}).Error)
+
+	renewal := Renewal{AccountID: account.AccountID, OpctCost: 2}
+	assert.Nil(t, renewal.AddUnbilledOverage())
+	assert.Equal(t, 1.0, renewal.OverageInOPCT)
+	assert.Equal(t, 3.0, renewal.OpctCost)
+}
@@ -0,0 +1,71 @@
+package routes
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/opacity/storage-node/models"
+)
+
+// the last lifecycle state each path still works in.  Paths that aren't listed only work while the account is
+// active, so anything that changes an account's files, metadata or plan is refused once it expires.
+var pathLastAccountStates = map[string]models.AccountState{
+	// reading what is stored, while the account is in its grace period
+	MetadataGetPath:     models.AccountStateGrace,
+	MetadataHistoryPath: models.AccountStateGrace,
+	MetadataExportPath:  models.AccountStateGrace,
+	DownloadPath:        models.AccountStateGrace,
+
+	// seeing the account, keeping it secure and paying for it, until it is purged
+	AccountDataPath:            models.AccountStateSuspended,
+	AccountLedgerPath:          models.AccountStateSuspended,
+	AccountUsagePath:           models.AccountStateSuspended,
+	AccountRenewInvoicePath:    models.AccountStateSuspended,
+	AccountRenewPath:           models.AccountStateSuspended,
+	AccountUpgradeInvoicePath:  models.AccountStateSuspended,
+	AccountUpgradePath:         models.AccountStateSuspended,
+	StripeOveragePath:          models.AccountStateSuspended,
+	AccountDowngradeCancelPath: models.AccountStateSuspended,
+	RotateKeyPath:              models.AccountStateSuspended,
+	DelegatedKeyRemovePath:     models.AccountStateSuspended,
+	DelegatedKeyListPath:       models.AccountStateSuspended,
+	SessionLoginPath:           models.AccountStateSuspended,
+	SessionRefreshPath:         models.AccountStateSuspended,
+	SessionLogoutPath:          models.AccountStateSuspended,
+	AccountDeletePath:          models.AccountStateSuspended,
+	AccountDeleteConfirmPath:   models.AccountStateSuspended,
+	AccountDeleteCancelPath:    models.AccountStateSuspended,
+	OrganizationPath:           models.AccountStateSuspended,
+	OrganizationRemovePath:     models.AccountStateSuspended,
+}
+
+/*verifyAccountState refuses the request if the account's lifecycle state doesn't allow the request's path*/
+func verifyAccountState(account models.Account, c *gin.Context) error {
+	if c.Request == nil {
+		return nil
+	}
+	state := account.State()
+	if state == models.AccountStateActive {
+		return nil
+	}
+
+	lastState, ok := pathLastAccountStates[strings.TrimPrefix(c.Request.URL.Path, V1Path)]
+	if ok && state <= lastState {
+		return nil
+	}
+	return AccountExpiredResponse(c, accountInvoiceResponse(account))
+}
+
+/*verifyFileAccountState refuses downloading a file once the account it belongs to is past its grace period.
+Files uploaded before completed files recorded their account are served as before.*/
+func verifyFileAccountState(fileID string, c *gin.Context) error {
+	completedFile, err := models.GetCompletedFileByFileID(fileID)
+	if err != nil || completedFile.AccountID == "" {
+		return nil
+	}
+	account, err := models.GetAccountById(completedFile.AccountID)
+	if err != nil {
+		return nil
+	}
+	return verifyAccountState(account, c)
+}
@@ -0,0 +1,131 @@
+package routes
+
+import (
+	"math/big"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Init_Account_Lifecycle(t *testing.T) {
+	setupTests(t)
+}
+
+func expireAccountForTest(t *testing.T, accountID string, daysAgo int) models.Account {
+	account := CreatePaidAccountForTest(t, accountID)
+	account.CreatedAt = time.Now().AddDate(0, -account.MonthsInSubscription, -daysAgo)
+	assert.Nil(t, models.DB.Save(&account).Error)
+	return account
+}
+
+func returnMetadataReqForTest(t *testing.T, metadataKey string, daysExpired int) metadataKeyReq {
+	v, b, _ := returnValidVerificationAndRequestBodyWithRandomPrivateKey(t, metadataKeyObject{
+		MetadataKey: metadataKey,
+		Timestamp:   time.Now().Unix(),
+	})
+	accountID, _ := utils.HashString(v.PublicKey)
+	expireAccountForTest(t, accountID, daysExpired)
+	return metadataKeyReq{verification: v, requestBody: b}
+}
+
+func Test_Account_In_Grace_Period_Can_Read_Metadata(t *testing.T) {
+	models.DeleteAccountsForTest(t)
+	testMetadataKey := utils.GenerateFileHandle()
+	testMetadataValue := utils.GenerateFileHandle()
+	assert.Nil(t, utils.BatchSet(&utils.KVPairs{testMetadataKey: testMetadataValue}, utils.TestValueTimeToLive))
+
+	get := returnMetadataReqForTest(t, testMetadataKey, 1)
+
+	w := httpPostRequestHelperForTest(t, MetadataGetPath, get)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), testMetadataValue)
+}
+
+func Test_Account_In_Grace_Period_Cannot_Write_Metadata(t *testing.T) {
+	models.DeleteAccountsForTest(t)
+
+	v, b, _ := returnValidVerificationAndRequestBodyWithRandomPrivateKey(t, updateMetadataObject{
+		MetadataKey: utils.GenerateFileHandle(),
+		Metadata:    utils.GenerateFileHandle(),
+		Timestamp:   time.Now().Unix(),
+	})
+	accountID, _ := utils.HashString(v.PublicKey)
+	expireAccountForTest(t, accountID, 1)
+
+	w := httpPostRequestHelperForTest(t, MetadataSetPath, updateMetadataReq{verification: v, requestBody: b})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func Test_Suspended_Account_Cannot_Read_Metadata(t *testing.T) {
+	models.DeleteAccountsForTest(t)
+	testMetadataKey := utils.GenerateFileHandle()
+	assert.Nil(t, utils.BatchSet(&utils.KVPairs{testMetadataKey: utils.GenerateFileHandle()}, utils.TestValueTimeToLive))
+
+	get := returnMetadataReqForTest(t, testMetadataKey, utils.Env.AccountGracePeriodDays+1)
+
+	w := httpPostRequestHelperForTest(t, MetadataGetPath, get)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func Test_Suspended_Account_Can_See_Its_State(t *testing.T) {
+	models.DeleteAccountsForTest(t)
+
+	v, b, _ := returnValidVerificationAndRequestBodyWithRandomPrivateKey(t, accountGetReqObj{
+		Timestamp: time.Now().Unix(),
+	})
+	accountID, _ := utils.HashString(v.PublicKey)
+	expireAccountForTest(t, accountID, utils.Env.AccountGracePeriodDays+1)
+
+	w := httpPostRequestHelperForTest(t, AccountDataPath, getAccountDataReq{verification: v, requestBody: b})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"state":"suspended"`)
+}
+
+func Test_Account_In_Grace_Period_Can_Upgrade(t *testing.T) {
+	models.DeleteAccountsForTest(t)
+	models.DeleteUpgradesForTest(t)
+
+	privateKey, err := utils.GenerateKey()
+	assert.Nil(t, err)
+	accountID, _ := utils.HashString(utils.PubkeyCompressedToHex(privateKey.PublicKey))
+	account := expireAccountForTest(t, accountID, 1)
+	assert.Equal(t, models.AccountStateGrace, account.State())
+
+	newStorageLimit := 1024
+	v, b := returnValidVerificationAndRequestBody(t, getUpgradeAccountInvoiceObject{
+		StorageLimit:     newStorageLimit,
+		DurationInMonths: models.DefaultMonthsPerSubscription,
+	}, privateKey)
+	w := httpPostRequestHelperForTest(t, AccountUpgradeInvoicePath, getUpgradeAccountInvoiceReq{
+		verification: v,
+		requestBody:  b,
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"opctInvoice"`)
+
+	models.BackendManager.CheckIfPaid = func(address common.Address, amount *big.Int) (bool, error) {
+		return true, nil
+	}
+	v, b = returnValidVerificationAndRequestBody(t, checkUpgradeStatusObject{
+		StorageLimit:     newStorageLimit,
+		DurationInMonths: models.DefaultMonthsPerSubscription,
+		MetadataKeys:     []string{},
+		FileHandles:      []string{},
+	}, privateKey)
+	w = httpPostRequestHelperForTest(t, AccountUpgradePath, checkUpgradeStatusReq{
+		verification: v,
+		requestBody:  b,
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `Success with OPCT`)
+
+	account, err = models.GetAccountById(accountID)
+	assert.Nil(t, err)
+	assert.Equal(t, newStorageLimit, int(account.StorageLimit))
+	assert.Equal(t, models.AccountStateActive, account.State())
+}
@@ -17,8 +17,9 @@ const Paid = "paid"
 const Expired = "expired"
 
 type accountCreateObj struct {
-	StorageLimit     int `json:"storageLimit" binding:"required,gte=10" minimum:"10" maximum:"2048" example:"100"`
-	DurationInMonths int `json:"durationInMonths" binding:"required,gte=1" minimum:"1" example:"12"`
+	StorageLimit     int    `json:"storageLimit" binding:"required,gte=10" minimum:"10" maximum:"2048" example:"100"`
+	DurationInMonths int    `json:"durationInMonths" binding:"required,gte=1" minimum:"1" example:"12"`
+	CouponCode       string `json:"couponCode" binding:"omitempty,max=64" maxLength:"64" example:"SPRING20"`
 }
 
 type accountCreateReq struct {
@@ -58,6 +59,12 @@ type accountGetObj struct {
 	TotalMetadataSizeInMB float64                 `json:"totalMetadataSizeInMB" binding:"exists" example:"1.245765432"`
 	MaxFolders            int                     `json:"maxFolders" binding:"exists" example:"2000"`
 	MaxMetadataSizeInMB   int64                   `json:"maxMetadataSizeInMB" binding:"exists" example:"200"`
+	CreditInOPCT          float64                 `json:"creditInOPCT" binding:"exists" example:"0.5"` // taken off their next renewal
+	State                 string                  `json:"state" binding:"required" example:"active"`   // active, grace (read only), suspended or purged
+	OverageEnabled        bool                    `json:"overageEnabled" example:"false"`              // whether they can store more than their plan
+	OverageCapInGB        int                     `json:"overageCapInGB" example:"0"`                  // how much more than their plan they can store
+	ProjectedOverage      float64                 `json:"projectedOverage" example:"0"`                // unpaid overage plus what their usage adds until they expire, in OPCT
+	ProjectedOverageInUSD float64                 `json:"projectedOverageInUSD" example:"0"`
 }
 
 type accountGetReqObj struct {
@@ -88,10 +95,15 @@ func (v *getAccountDataReq) getObjectRef() interface{} {
 // @description {
 // @description 	"storageLimit": 100,
 // @description 	"durationInMonths": 12,
+// @description 	"couponCode": "SPRING20"
 // @description }
+// @description couponCode is optional.  Its discount is taken off the invoice's cost and its extra months are added
+// @description to the subscription.
 // @Success 200 {object} routes.accountCreateRes
 // @Failure 400 {string} string "bad request, unable to parse request body: (with the error)"
+// @Failure 404 {string} string "no coupon with that code"
 // @Failure 503 {string} string "error encrypting private key: (with the error)"
+// @Failure 429 {string} string "too many requests, try again later"
 // @Router /api/v1/accounts [post]
 /*CreateAccountHandler is a handler for post requests to create accounts*/
 func CreateAccountHandler() gin.HandlerFunc {
@@ -139,11 +151,22 @@ func createAccount(c *gin.Context) error {
 		return err
 	}
 
+	if err := verifySubscriptionMonths(request.accountCreateObj.StorageLimit, request.accountCreateObj.DurationInMonths,
+		c); err != nil {
+		return err
+	}
+
 	accountId, err := request.getAccountId(c)
 	if err != nil {
 		return err
 	}
 
+	if rotatedAway, err := models.KeyWasRotatedAway(accountId); err != nil {
+		return InternalErrorResponse(c, err)
+	} else if rotatedAway {
+		return BadRequestResponse(c, models.ErrKeyRotatedAway)
+	}
+
 	encryptedKeyInBytes, encryptErr := utils.EncryptWithErrorReturn(
 		utils.Env.EncryptionKey,
 		privKey,
@@ -164,8 +187,23 @@ func createAccount(c *gin.Context) error {
 		ExpiredAt:            time.Now().AddDate(0, request.accountCreateObj.DurationInMonths, 0),
 	}
 
+	if request.accountCreateObj.CouponCode != "" {
+		coupon, err := models.GetRedeemableCoupon(request.accountCreateObj.CouponCode, accountId,
+			request.accountCreateObj.StorageLimit)
+		if err == nil {
+			err = account.ApplyCoupon(coupon)
+		}
+		if err == nil {
+			err = models.ReserveCouponRedemption(coupon.ID)
+		}
+		if err != nil {
+			return couponErrorResponse(c, err)
+		}
+	}
+
 	// Add account to DB
 	if err := models.DB.Create(&account).Error; err != nil {
+		models.ReleaseCouponRedemption(account.CouponID)
 		return BadRequestResponse(c, err)
 	}
 
@@ -173,11 +211,15 @@ func createAccount(c *gin.Context) error {
 	if err != nil {
 		return BadRequestResponse(c, err)
 	}
+	if err := account.RecordInvoice(); err != nil {
+		return InternalErrorResponse(c, err)
+	}
 
 	response := accountCreateRes{
 		Invoice: models.Invoice{
 			Cost:       cost,
 			EthAddress: ethAddr.String(),
+			Discount:   account.DiscountInOPCT,
 		},
 		ExpirationDate: account.ExpirationDate(),
 	}
@@ -248,8 +290,18 @@ func checkAccountPaymentStatus(c *gin.Context) error {
 		ApiVersion:            account.ApiVersion,
 		TotalFolders:          account.TotalFolders,
 		TotalMetadataSizeInMB: float64(account.TotalMetadataSizeInBytes) / 1e6,
-		MaxFolders:            utils.Env.Plans[int(account.StorageLimit)].MaxFolders,
-		MaxMetadataSizeInMB:   utils.Env.Plans[int(account.StorageLimit)].MaxMetadataSizeInMB,
+		MaxFolders:            account.Plan().MaxFolders,
+		MaxMetadataSizeInMB:   account.Plan().MaxMetadataSizeInMB,
+		CreditInOPCT:          account.CreditInOPCT,
+		State:                 models.AccountStateMap[account.State()],
+		OverageEnabled:        account.OverageEnabled,
+		OverageCapInGB:        account.OverageCapInGB,
+	}
+	if projected, err := account.ProjectedOverageInGBMonths(); err != nil {
+		return InternalErrorResponse(c, err)
+	} else if projected > 0 {
+		res.Account.ProjectedOverage = utils.OverageCost(projected)
+		res.Account.ProjectedOverageInUSD = utils.OverageCostInUSD(projected)
 	}
 
 	if res.PaymentStatus == Paid {
@@ -261,6 +313,7 @@ func checkAccountPaymentStatus(c *gin.Context) error {
 		Invoice: models.Invoice{
 			Cost:       cost,
 			EthAddress: account.EthAddress,
+			Discount:   account.DiscountInOPCT,
 		},
 	})
 }
@@ -113,6 +113,20 @@ func Test_ExpectErrorWithInvalidDurationInMonths(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 }
 
+func Test_ExpectErrorWithDurationShorterThanPlanMinimum(t *testing.T) {
+	minMonths := utils.Env.MinMonthsInSubscription
+	defer func() { utils.Env.MinMonthsInSubscription = minMonths }()
+	utils.Env.MinMonthsInSubscription = 6
+
+	body := returnValidCreateAccountBody()
+	body.DurationInMonths = 3
+	post := returnValidCreateAccountReq(t, body)
+
+	w := httpPostRequestHelperForTest(t, AccountsPath, post)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "at least 6 months")
+}
+
 func Test_CheckAccountPaymentStatusHandler_ExpectErrorIfNoAccount(t *testing.T) {
 	_, privateKey := returnValidAccountAndPrivateKey(t)
 	validReq := returnValidGetAccountReq(t, accountGetReqObj{
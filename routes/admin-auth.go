@@ -0,0 +1,147 @@
+package routes
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/utils"
+)
+
+const (
+	adminUserKey           = "adminUser"
+	adminRoleKey           = "adminRole"
+	adminRealm             = `Basic realm="Storage Node Admin"`
+	adminRoleResponse      = "your admin role does not allow this"
+	redactedAuditParameter = "[redacted]"
+)
+
+// form values that never go in the audit log
+var secretAuditParameters = map[string]bool{
+	"password": true,
+}
+
+/*adminAuthMiddleware signs in admins with basic auth.  The ADMIN_USER from the environment is always a
+superuser, so a node can be set up before any admin users exist.*/
+func adminAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		username, password, ok := c.Request.BasicAuth()
+		if !ok {
+			c.Header("WWW-Authenticate", adminRealm)
+			UnauthorizedResponse(c, models.ErrInvalidAdminCredentials)
+			return
+		}
+
+		if isEnvAdmin(username, password) {
+			c.Set(adminUserKey, username)
+			c.Set(adminRoleKey, models.AdminRoleSuperuser)
+			c.Next()
+			return
+		}
+
+		adminUser, err := models.AuthenticateAdminUser(username, password)
+		if err == models.ErrInvalidAdminCredentials {
+			c.Header("WWW-Authenticate", adminRealm)
+			UnauthorizedResponse(c, err)
+			return
+		}
+		if err != nil {
+			InternalErrorResponse(c, err)
+			return
+		}
+		c.Set(adminUserKey, adminUser.Username)
+		c.Set(adminRoleKey, adminUser.Role)
+		c.Next()
+	}
+}
+
+/*requireAdminRole only lets admins with at least role through*/
+func requireAdminRole(role models.AdminRoleType) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if adminRole(c) < role {
+			// kept on the context so the audit log says why the attempt was refused
+			c.Error(ForbiddenResponse(c, errors.New(adminRoleResponse)))
+			return
+		}
+		c.Next()
+	}
+}
+
+/*auditAdminAction records the request in the admin audit log once it has been handled.  targetParam names the
+query or form value that says what the action was done to.  It goes before requireAdminRole, so attempts the role
+gate refuses are recorded with their 403 too.*/
+func auditAdminAction(targetParam string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		result := "ok"
+		if c.Writer.Status() >= http.StatusBadRequest {
+			result = http.StatusText(c.Writer.Status())
+			if lastError := c.Errors.Last(); lastError != nil {
+				result = lastError.Error()
+			}
+		}
+		_, err := models.CreateAdminAuditLog(models.AdminAuditLog{
+			Actor:      c.GetString(adminUserKey),
+			Role:       models.AdminRoleMap[adminRole(c)],
+			Action:     c.Request.Method + " " + c.Request.URL.Path,
+			Target:     c.Request.FormValue(targetParam),
+			Parameters: adminActionParameters(c),
+			Status:     c.Writer.Status(),
+			Result:     result,
+		})
+		getLogger(c).LogIfError(err, map[string]interface{}{"adminAction": c.Request.URL.Path})
+	}
+}
+
+func adminRole(c *gin.Context) models.AdminRoleType {
+	role, ok := c.Get(adminRoleKey)
+	if !ok {
+		return 0
+	}
+	return role.(models.AdminRoleType)
+}
+
+func isEnvAdmin(username, password string) bool {
+	if utils.Env.AdminUser == "" || utils.Env.AdminPassword == "" {
+		return false
+	}
+	usernameMatches := subtle.ConstantTimeCompare([]byte(username), []byte(utils.Env.AdminUser)) == 1
+	passwordMatches := subtle.ConstantTimeCompare([]byte(password), []byte(utils.Env.AdminPassword)) == 1
+	return usernameMatches && passwordMatches
+}
+
+/*adminActionParameters returns the query and form values of the request as JSON, with secrets redacted and
+uploaded files listed by name*/
+func adminActionParameters(c *gin.Context) string {
+	if c.Request.Form == nil {
+		c.Request.ParseMultipartForm(MaxRequestSize)
+	}
+
+	parameters := make(map[string]string)
+	for key, values := range c.Request.Form {
+		parameters[key] = strings.Join(values, ",")
+		if secretAuditParameters[key] {
+			parameters[key] = redactedAuditParameter
+		}
+	}
+	if c.Request.MultipartForm != nil {
+		for key, files := range c.Request.MultipartForm.File {
+			var names []string
+			for _, file := range files {
+				names = append(names, file.Filename)
+			}
+			parameters[key] = "file:" + strings.Join(names, ",")
+		}
+	}
+
+	parametersJSON, err := json.Marshal(parameters)
+	if err != nil {
+		return ""
+	}
+	return string(parametersJSON)
+}
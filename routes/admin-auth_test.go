@@ -0,0 +1,103 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Init_Admin_Auth(t *testing.T) {
+	setupTests(t)
+}
+
+func returnAdminRouterForTest(role models.AdminRoleType) *gin.Engine {
+	router := gin.New()
+	router.GET("/admin", adminAuthMiddleware(), requireAdminRole(role), func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString(adminUserKey))
+	})
+	return router
+}
+
+func adminRequestForTest(router *gin.Engine, username, password string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+	if username != "" {
+		req.SetBasicAuth(username, password)
+	}
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func Test_AdminAuth_Requires_Credentials(t *testing.T) {
+	w := adminRequestForTest(returnAdminRouterForTest(models.AdminRoleViewer), "", "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, adminRealm, w.Header().Get("WWW-Authenticate"))
+}
+
+func Test_AdminAuth_Env_Admin_Is_Superuser(t *testing.T) {
+	env := utils.Env
+	defer func() { utils.Env = env }()
+	utils.Env.AdminUser = "admin"
+	utils.Env.AdminPassword = "correct horse battery"
+
+	w := adminRequestForTest(returnAdminRouterForTest(models.AdminRoleSuperuser), "admin", "correct horse battery")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "admin", w.Body.String())
+}
+
+func Test_RequireAdminRole_Refuses_Lower_Roles(t *testing.T) {
+	router := gin.New()
+	router.GET("/admin", func(c *gin.Context) {
+		c.Set(adminRoleKey, models.AdminRoleViewer)
+	}, requireAdminRole(models.AdminRoleOperator), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin", nil))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), adminRoleResponse)
+}
+
+func Test_AuditAdminAction_Records_Refused_Attempts(t *testing.T) {
+	actor := "viewer-" + utils.RandHexString(8)
+	router := gin.New()
+	router.POST("/admin/plans/delete", func(c *gin.Context) {
+		c.Set(adminUserKey, actor)
+		c.Set(adminRoleKey, models.AdminRoleViewer)
+	}, auditAdminAction("id"), requireAdminRole(models.AdminRoleSuperuser), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/admin/plans/delete?id=3", nil))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	auditLogs, err := models.GetAdminAuditLogs(actor, 0, 1)
+	assert.Nil(t, err)
+	assert.Len(t, auditLogs, 1)
+	assert.Equal(t, http.StatusForbidden, auditLogs[0].Status)
+	assert.Equal(t, adminRoleResponse, auditLogs[0].Result)
+	assert.Equal(t, "3", auditLogs[0].Target)
+	assert.Equal(t, models.AdminRoleMap[models.AdminRoleViewer], auditLogs[0].Role)
+}
+
+func Test_AdminActionParameters_Redacts_Secrets(t *testing.T) {
+	form := url.Values{"username": {"operator"}, "password": {"correct horse battery"}}
+	req := httptest.NewRequest(http.MethodPost, "/admin/users", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = req
+
+	parameters := adminActionParameters(c)
+	assert.Contains(t, parameters, `"username":"operator"`)
+	assert.Contains(t, parameters, redactedAuditParameter)
+	assert.NotContains(t, parameters, "correct horse battery")
+}
@@ -0,0 +1,114 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/opacity/storage-node/utils"
+)
+
+const badgerBackupVersionHeader = "X-Badger-Backup-Version"
+
+/*AdminBadgerBackupDownloadHandler is a handler for streaming a badger backup to the admin*/
+func AdminBadgerBackupDownloadHandler() gin.HandlerFunc {
+	return ginHandlerFunc(adminBadgerBackupDownload)
+}
+
+/*AdminBadgerBackupHandler is a handler for writing a badger backup to the backup dir*/
+func AdminBadgerBackupHandler() gin.HandlerFunc {
+	return ginHandlerFunc(adminBadgerBackup)
+}
+
+/*AdminBadgerRestoreHandler is a handler for loading a badger backup into the K:V store*/
+func AdminBadgerRestoreHandler() gin.HandlerFunc {
+	return ginHandlerFunc(adminBadgerRestore)
+}
+
+/*adminBadgerBackupDownload streams a backup of every version newer than the since query param*/
+func adminBadgerBackupDownload(c *gin.Context) error {
+	since, err := parseBadgerBackupSince(c.Query("since"))
+	if err != nil {
+		return BadRequestResponse(c, err)
+	}
+	if utils.GetBadgerDb() == nil {
+		return BadRequestResponse(c, utils.ErrNotBadgerBackend)
+	}
+
+	// the version to pass as since next time is only known once the stream is written, so send it as a trailer
+	c.Header("Trailer", badgerBackupVersionHeader)
+	c.Header("Content-Type", "application/octet-stream")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="badger-since-%d.bak"`, since))
+	c.Status(http.StatusOK)
+
+	version, err := utils.BackupBadger(c.Writer, since)
+	if err != nil {
+		// the headers are already sent, all we can do is log and cut the stream short
+		utils.LogIfError(err, map[string]interface{}{"since": since})
+		return nil
+	}
+	c.Writer.Header().Set(badgerBackupVersionHeader, strconv.FormatUint(version, 10))
+	utils.SlackLog(fmt.Sprintf("Admin badger backup downloaded since %d up to version %d", since, version))
+	return nil
+}
+
+/*adminBadgerBackup writes a backup to the backup dir, and the bucket if configured, as the scheduled job does*/
+func adminBadgerBackup(c *gin.Context) error {
+	since, err := parseBadgerBackupSince(c.Request.FormValue("since"))
+	if err != nil {
+		return BadRequestResponse(c, err)
+	}
+
+	backup, err := utils.CreateBadgerBackup(utils.Env.BadgerBackupDir, since)
+	if err == utils.ErrNotBadgerBackend {
+		return BadRequestResponse(c, err)
+	}
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+
+	c.Header(badgerBackupVersionHeader, strconv.FormatUint(backup.Version, 10))
+	return OkResponse(c, backup)
+}
+
+/*adminBadgerRestore loads a backup uploaded as the "backup" form file, or one in the bucket named by the
+"objectKey" form value.  Set the "replace" form value to true to drop the current pairs first.*/
+func adminBadgerRestore(c *gin.Context) error {
+	defer c.Request.Body.Close()
+
+	replace := c.Request.FormValue("replace") == "true"
+	objectKey := c.Request.FormValue("objectKey")
+
+	var err error
+	if file, _, formErr := c.Request.FormFile("backup"); formErr == nil {
+		defer file.Close()
+		err = utils.RestoreBadger(file, replace)
+	} else if objectKey != "" {
+		err = utils.RestoreBadgerFromObjectStore(objectKey, replace)
+	} else {
+		return BadRequestResponse(c, errors.New("must upload a backup file or name an objectKey"))
+	}
+
+	if err == utils.ErrNotBadgerBackend {
+		return BadRequestResponse(c, err)
+	}
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+
+	utils.SlackLog(fmt.Sprintf("Admin badger restore succeeded, replace: %t, objectKey: %q", replace, objectKey))
+	return OkResponse(c, StatusRes{Status: "restore success"})
+}
+
+func parseBadgerBackupSince(since string) (uint64, error) {
+	if since == "" {
+		return 0, nil
+	}
+	parsed, err := strconv.ParseUint(since, 10, 64)
+	if err != nil {
+		return 0, errors.New("since must be a badger version number")
+	}
+	return parsed, nil
+}
@@ -0,0 +1,56 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/opacity/storage-node/models"
+)
+
+type expirationExtensionProgress struct {
+	models.ExpirationExtension
+	StatusName            string `json:"statusName"`
+	TotalIndexedMetadatas int    `json:"totalIndexedMetadatas"`
+	TotalFiles            int    `json:"totalFiles"`
+}
+
+/*AdminExpirationExtensionsHandler is a handler for reporting the progress of expiration extensions*/
+func AdminExpirationExtensionsHandler() gin.HandlerFunc {
+	return ginHandlerFunc(adminExpirationExtensions)
+}
+
+/*adminExpirationExtensions reports the extension of the accountID query param, or every extension that is
+still running if there is none.  totalIndexedMetadatas only counts the account's indexed metadata, since that
+is all an extension covers.  Metadata that hasn't been touched since the index was added is skipped.*/
+func adminExpirationExtensions(c *gin.Context) error {
+	var extensions []models.ExpirationExtension
+	if accountID := c.Query("accountID"); accountID != "" {
+		extension, err := models.GetExpirationExtension(accountID)
+		if err != nil {
+			return NotFoundResponse(c, err)
+		}
+		extensions = append(extensions, extension)
+	} else {
+		var err error
+		if extensions, err = models.GetIncompleteExpirationExtensions(); err != nil {
+			return InternalErrorResponse(c, err)
+		}
+	}
+
+	progress := []expirationExtensionProgress{}
+	for _, extension := range extensions {
+		totalIndexedMetadatas, err := models.CountMetadataKeysByAccountID(extension.AccountID)
+		if err != nil {
+			return InternalErrorResponse(c, err)
+		}
+		totalFiles, err := models.CountCompletedFilesByAccountID(extension.AccountID)
+		if err != nil {
+			return InternalErrorResponse(c, err)
+		}
+		progress = append(progress, expirationExtensionProgress{
+			ExpirationExtension:   extension,
+			StatusName:            models.ExpirationExtensionStatusMap[extension.Status],
+			TotalIndexedMetadatas: totalIndexedMetadatas,
+			TotalFiles:            totalFiles,
+		})
+	}
+	return OkResponse(c, progress)
+}
@@ -0,0 +1,55 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/opacity/storage-node/models"
+)
+
+type keyRotationProgress struct {
+	models.KeyRotation
+	StatusName     string `json:"statusName"`
+	TotalMetadatas int    `json:"totalMetadatas"`
+	TotalFiles     int    `json:"totalFiles"`
+}
+
+/*AdminKeyRotationsHandler is a handler for reporting the progress of key rotations*/
+func AdminKeyRotationsHandler() gin.HandlerFunc {
+	return ginHandlerFunc(adminKeyRotations)
+}
+
+/*adminKeyRotations reports the rotation of the accountID query param, which can be the old or the new account
+ID, or every rotation that is still running if there is none*/
+func adminKeyRotations(c *gin.Context) error {
+	var rotations []models.KeyRotation
+	if accountID := c.Query("accountID"); accountID != "" {
+		rotation, err := models.GetKeyRotation(accountID)
+		if err != nil {
+			return NotFoundResponse(c, err)
+		}
+		rotations = append(rotations, rotation)
+	} else {
+		var err error
+		if rotations, err = models.GetIncompleteKeyRotations(); err != nil {
+			return InternalErrorResponse(c, err)
+		}
+	}
+
+	progress := []keyRotationProgress{}
+	for _, rotation := range rotations {
+		totalMetadatas, err := models.CountMetadataKeysByAccountID(rotation.NewAccountID)
+		if err != nil {
+			return InternalErrorResponse(c, err)
+		}
+		totalFiles, err := models.CountCompletedFilesByAccountID(rotation.NewAccountID)
+		if err != nil {
+			return InternalErrorResponse(c, err)
+		}
+		progress = append(progress, keyRotationProgress{
+			KeyRotation:    rotation,
+			StatusName:     models.KeyRotationStatusMap[rotation.Status],
+			TotalMetadatas: totalMetadatas,
+			TotalFiles:     totalFiles,
+		})
+	}
+	return OkResponse(c, progress)
+}
@@ -0,0 +1,174 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/utils"
+)
+
+const (
+	planIDError       = "id must be a plan version id"
+	planNotFoundError = "no plan version with that id"
+)
+
+/*AdminPlansHandler is a handler for listing every plan version*/
+func AdminPlansHandler() gin.HandlerFunc {
+	return ginHandlerFunc(adminPlans)
+}
+
+/*AdminCreatePlanHandler is a handler for adding a new version of a plan*/
+func AdminCreatePlanHandler() gin.HandlerFunc {
+	return ginHandlerFunc(adminCreatePlan)
+}
+
+/*AdminSetPlanAvailabilityHandler is a handler for changing when a plan version is sold*/
+func AdminSetPlanAvailabilityHandler() gin.HandlerFunc {
+	return ginHandlerFunc(adminSetPlanAvailability)
+}
+
+/*AdminDeletePlanHandler is a handler for deleting a plan version no account is on*/
+func AdminDeletePlanHandler() gin.HandlerFunc {
+	return ginHandlerFunc(adminDeletePlan)
+}
+
+func adminPlans(c *gin.Context) error {
+	plans, err := models.GetPlans()
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+	return OkResponse(c, plans)
+}
+
+/*adminCreatePlan takes the storageInGB, name, cost, costInUSD, maxFolders, maxMetadataSizeInMB,
+minMonthsInSubscription, effectiveFrom and effectiveUntil form values.  The version goes on sale now if
+effectiveFrom is empty.*/
+func adminCreatePlan(c *gin.Context) error {
+	defer c.Request.Body.Close()
+
+	plan := models.Plan{Name: c.Request.FormValue("name")}
+	var maxMetadataSizeInMB int
+	if err := utils.ReturnFirstError([]error{
+		parseIntFormValue(c, "storageInGB", &plan.StorageInGB),
+		parseFloatFormValue(c, "cost", &plan.Cost),
+		parseFloatFormValue(c, "costInUSD", &plan.CostInUSD),
+		parseIntFormValue(c, "maxFolders", &plan.MaxFolders),
+		parseIntFormValue(c, "maxMetadataSizeInMB", &maxMetadataSizeInMB),
+		parseIntFormValue(c, "minMonthsInSubscription", &plan.MinMonthsInSubscription),
+	}); err != nil {
+		return BadRequestResponse(c, err)
+	}
+	plan.MaxMetadataSizeInMB = int64(maxMetadataSizeInMB)
+
+	effectiveFrom, err := parseAdminTime(c.Request.FormValue("effectiveFrom"))
+	if err != nil {
+		return BadRequestResponse(c, err)
+	}
+	if effectiveFrom != nil {
+		plan.EffectiveFrom = *effectiveFrom
+	}
+	if plan.EffectiveUntil, err = parseAdminTime(c.Request.FormValue("effectiveUntil")); err != nil {
+		return BadRequestResponse(c, err)
+	}
+
+	plan, err = models.CreatePlanVersion(plan)
+	if err != nil {
+		return BadRequestResponse(c, err)
+	}
+	return OkResponse(c, plan)
+}
+
+/*adminSetPlanAvailability takes the id, effectiveUntil and grandfathered form values.  An empty effectiveUntil
+keeps the version on sale until it is grandfathered.*/
+func adminSetPlanAvailability(c *gin.Context) error {
+	defer c.Request.Body.Close()
+
+	id, err := strconv.ParseUint(c.Request.FormValue("id"), 10, 64)
+	if err != nil {
+		return BadRequestResponse(c, errors.New(planIDError))
+	}
+	effectiveUntil, err := parseAdminTime(c.Request.FormValue("effectiveUntil"))
+	if err != nil {
+		return BadRequestResponse(c, err)
+	}
+	grandfathered := c.Request.FormValue("grandfathered") == "true"
+
+	plan, err := models.SetPlanAvailability(uint(id), effectiveUntil, grandfathered)
+	if gorm.IsRecordNotFoundError(err) {
+		return NotFoundResponse(c, errors.New(planNotFoundError))
+	}
+	if err != nil {
+		return BadRequestResponse(c, err)
+	}
+	return OkResponse(c, plan)
+}
+
+/*adminDeletePlan takes the id form value*/
+func adminDeletePlan(c *gin.Context) error {
+	defer c.Request.Body.Close()
+
+	id, err := strconv.ParseUint(c.Request.FormValue("id"), 10, 64)
+	if err != nil {
+		return BadRequestResponse(c, errors.New(planIDError))
+	}
+
+	err = models.DeletePlan(uint(id))
+	if gorm.IsRecordNotFoundError(err) {
+		return NotFoundResponse(c, errors.New(planNotFoundError))
+	}
+	if err == models.ErrPlanInUse {
+		return ForbiddenResponse(c, err)
+	}
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+	return OkResponse(c, StatusRes{Status: "plan version deleted"})
+}
+
+/*parseIntFormValue parses an optional number form value into value*/
+func parseIntFormValue(c *gin.Context, key string, value *int) error {
+	formValue := c.Request.FormValue(key)
+	if formValue == "" {
+		return nil
+	}
+	parsed, err := strconv.Atoi(formValue)
+	if err != nil {
+		return fmt.Errorf("%s must be a number", key)
+	}
+	*value = parsed
+	return nil
+}
+
+/*parseFloatFormValue parses an optional decimal form value into value*/
+func parseFloatFormValue(c *gin.Context, key string, value *float64) error {
+	formValue := c.Request.FormValue(key)
+	if formValue == "" {
+		return nil
+	}
+	parsed, err := strconv.ParseFloat(formValue, 64)
+	if err != nil {
+		return fmt.Errorf("%s must be a number", key)
+	}
+	*value = parsed
+	return nil
+}
+
+/*parseAdminTime parses a date like 2006-01-02 or an RFC 3339 time, returning nil for an empty value*/
+func parseAdminTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if parsed, err := time.Parse("2006-01-02", value); err == nil {
+		return &parsed, nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("%q must be a date like 2006-01-02 or an RFC 3339 time", value)
+	}
+	return &parsed, nil
+}
@@ -0,0 +1,54 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/opacity/storage-node/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Init_Admin_Plans(t *testing.T) {
+	setupTests(t)
+}
+
+func Test_ParseAdminTime(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected *time.Time
+		valid    bool
+	}{
+		{"", nil, true},
+		{"2030-01-02", timePointerForTest(time.Date(2030, 1, 2, 0, 0, 0, 0, time.UTC)), true},
+		{"2030-01-02T03:04:05Z", timePointerForTest(time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)), true},
+		{"next tuesday", nil, false},
+	}
+
+	for _, tt := range tests {
+		parsed, err := parseAdminTime(tt.value)
+		if !tt.valid {
+			assert.NotNil(t, err, tt.value)
+			continue
+		}
+		assert.Nil(t, err, tt.value)
+		assert.Equal(t, tt.expected, parsed, tt.value)
+	}
+}
+
+func Test_GetPlans_Returns_The_Plans_On_Sale(t *testing.T) {
+	router := returnEngine()
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/plans", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	res := PlanResponse{}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.Equal(t, models.GetPurchasablePlans(), res.Plans)
+}
+
+func timePointerForTest(t time.Time) *time.Time {
+	return &t
+}
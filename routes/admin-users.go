@@ -0,0 +1,133 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"github.com/opacity/storage-node/models"
+)
+
+const (
+	adminUsernameError       = "username must be 1 to 64 characters"
+	adminPasswordError       = "password must be at least 12 characters"
+	adminRoleError           = "role must be viewer, operator or superuser"
+	adminUserNotFoundError   = "no admin user with that username"
+	adminAuditLogBeforeError = "before must be an audit log id"
+	minAdminPasswordLength   = 12
+)
+
+type adminUserRes struct {
+	Username string `json:"username"`
+	Role     string `json:"role"`
+}
+
+/*AdminUsersHandler is a handler for listing the admin users*/
+func AdminUsersHandler() gin.HandlerFunc {
+	return ginHandlerFunc(adminUsers)
+}
+
+/*AdminSetUserHandler is a handler for adding an admin user or changing one's password and role*/
+func AdminSetUserHandler() gin.HandlerFunc {
+	return ginHandlerFunc(adminSetUser)
+}
+
+/*AdminDeleteUserHandler is a handler for removing an admin user*/
+func AdminDeleteUserHandler() gin.HandlerFunc {
+	return ginHandlerFunc(adminDeleteUser)
+}
+
+/*AdminAuditLogHandler is a handler for browsing the admin audit log*/
+func AdminAuditLogHandler() gin.HandlerFunc {
+	return ginHandlerFunc(adminAuditLog)
+}
+
+func adminUsers(c *gin.Context) error {
+	adminUsers, err := models.GetAdminUsers()
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+	res := []adminUserRes{}
+	for _, adminUser := range adminUsers {
+		res = append(res, toAdminUserRes(adminUser))
+	}
+	return OkResponse(c, res)
+}
+
+/*adminSetUser takes the username, password and role form values*/
+func adminSetUser(c *gin.Context) error {
+	defer c.Request.Body.Close()
+
+	username := c.Request.FormValue("username")
+	password := c.Request.FormValue("password")
+	if username == "" || len(username) > 64 {
+		return BadRequestResponse(c, errors.New(adminUsernameError))
+	}
+	if len(password) < minAdminPasswordLength {
+		return BadRequestResponse(c, errors.New(adminPasswordError))
+	}
+	role, ok := models.AdminRoleFromName(c.Request.FormValue("role"))
+	if !ok {
+		return BadRequestResponse(c, errors.New(adminRoleError))
+	}
+
+	adminUser, err := models.SetAdminUser(username, password, role)
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+	return OkResponse(c, toAdminUserRes(adminUser))
+}
+
+/*adminDeleteUser takes the username form value*/
+func adminDeleteUser(c *gin.Context) error {
+	defer c.Request.Body.Close()
+
+	err := models.DeleteAdminUser(c.Request.FormValue("username"))
+	if gorm.IsRecordNotFoundError(err) {
+		return NotFoundResponse(c, errors.New(adminUserNotFoundError))
+	}
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+	return OkResponse(c, StatusRes{Status: "admin user deleted"})
+}
+
+/*adminAuditLog shows a page of the audit log, newest first.  The actor query param only shows what one admin
+did, and before pages back past an entry id.*/
+func adminAuditLog(c *gin.Context) error {
+	var beforeID uint64
+	if before := c.Query("before"); before != "" {
+		var err error
+		if beforeID, err = strconv.ParseUint(before, 10, 64); err != nil {
+			return BadRequestResponse(c, errors.New(adminAuditLogBeforeError))
+		}
+	}
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	actor := c.Query("actor")
+	auditLogs, err := models.GetAdminAuditLogs(actor, uint(beforeID), limit)
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+
+	var nextBeforeID uint
+	if len(auditLogs) > 0 {
+		nextBeforeID = auditLogs[len(auditLogs)-1].ID
+	}
+	c.HTML(http.StatusOK, "admin-audit-log.tmpl", gin.H{
+		"title":        "Admin Audit Log",
+		"actor":        actor,
+		"auditLogs":    auditLogs,
+		"nextBeforeID": nextBeforeID,
+	})
+	return nil
+}
+
+func toAdminUserRes(adminUser models.AdminUser) adminUserRes {
+	return adminUserRes{
+		Username: adminUser.Username,
+		Role:     models.AdminRoleMap[adminUser.Role],
+	}
+}
@@ -0,0 +1,117 @@
+package routes
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/utils"
+)
+
+const (
+	noCouponWithThatCode = "no coupon with that code"
+	couponIDError        = "id must be a coupon id"
+	couponNotFoundError  = "no coupon with that id"
+)
+
+/*AdminCouponsHandler is a handler for listing every coupon*/
+func AdminCouponsHandler() gin.HandlerFunc {
+	return ginHandlerFunc(adminCoupons)
+}
+
+/*AdminCreateCouponHandler is a handler for creating a coupon*/
+func AdminCreateCouponHandler() gin.HandlerFunc {
+	return ginHandlerFunc(adminCreateCoupon)
+}
+
+/*AdminExpireCouponHandler is a handler for stopping a coupon from being used*/
+func AdminExpireCouponHandler() gin.HandlerFunc {
+	return ginHandlerFunc(adminExpireCoupon)
+}
+
+/*AdminCouponRedemptionsHandler is a handler for listing the coupons an account redeemed*/
+func AdminCouponRedemptionsHandler() gin.HandlerFunc {
+	return ginHandlerFunc(adminCouponRedemptions)
+}
+
+/*couponErrorResponse responds to a coupon that couldn't be looked up or applied to an invoice*/
+func couponErrorResponse(c *gin.Context, err error) error {
+	if gorm.IsRecordNotFoundError(err) {
+		return NotFoundResponse(c, errors.New(noCouponWithThatCode))
+	}
+	switch err {
+	case models.ErrCouponExpired, models.ErrCouponRedeemedOut, models.ErrCouponWrongPlan,
+		models.ErrCouponAlreadyRedeemed, models.ErrCouponAlreadyApplied:
+		return BadRequestResponse(c, err)
+	}
+	return InternalErrorResponse(c, err)
+}
+
+func adminCoupons(c *gin.Context) error {
+	coupons, err := models.GetCoupons()
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+	return OkResponse(c, coupons)
+}
+
+/*adminCreateCoupon takes the code, percentOff, amountOffInOPCT, amountOffInUSD, extraMonths, storageLimits,
+maxRedemptions and expiresAt form values.  storageLimits is a comma separated list of the plans, in GB, the coupon is
+for, and the coupon is for every plan if it is empty.*/
+func adminCreateCoupon(c *gin.Context) error {
+	defer c.Request.Body.Close()
+
+	coupon := models.Coupon{
+		Code:          c.Request.FormValue("code"),
+		StorageLimits: c.Request.FormValue("storageLimits"),
+	}
+	if err := utils.ReturnFirstError([]error{
+		parseFloatFormValue(c, "percentOff", &coupon.PercentOff),
+		parseFloatFormValue(c, "amountOffInOPCT", &coupon.AmountOffInOPCT),
+		parseFloatFormValue(c, "amountOffInUSD", &coupon.AmountOffInUSD),
+		parseIntFormValue(c, "extraMonths", &coupon.ExtraMonths),
+		parseIntFormValue(c, "maxRedemptions", &coupon.MaxRedemptions),
+	}); err != nil {
+		return BadRequestResponse(c, err)
+	}
+
+	var err error
+	if coupon.ExpiresAt, err = parseAdminTime(c.Request.FormValue("expiresAt")); err != nil {
+		return BadRequestResponse(c, err)
+	}
+
+	if err := models.DB.Create(&coupon).Error; err != nil {
+		return BadRequestResponse(c, err)
+	}
+	return OkResponse(c, coupon)
+}
+
+/*adminExpireCoupon takes the id form value*/
+func adminExpireCoupon(c *gin.Context) error {
+	defer c.Request.Body.Close()
+
+	id, err := strconv.ParseUint(c.Request.FormValue("id"), 10, 64)
+	if err != nil {
+		return BadRequestResponse(c, errors.New(couponIDError))
+	}
+
+	coupon, err := models.ExpireCoupon(uint(id))
+	if gorm.IsRecordNotFoundError(err) {
+		return NotFoundResponse(c, errors.New(couponNotFoundError))
+	}
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+	return OkResponse(c, coupon)
+}
+
+/*adminCouponRedemptions returns the coupons redeemed by the accountID query param*/
+func adminCouponRedemptions(c *gin.Context) error {
+	redemptions, err := models.GetCouponRedemptions(c.Query("accountID"))
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+	return OkResponse(c, redemptions)
+}
@@ -0,0 +1,120 @@
+package routes
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func createCouponForTest(t *testing.T, coupon models.Coupon) models.Coupon {
+	abortIfNotTesting(t)
+
+	coupon.Code = utils.RandHexString(16)
+	if err := models.DB.Create(&coupon).Error; err != nil {
+		t.Fatalf("should have created coupon but didn't: " + err.Error())
+	}
+	return coupon
+}
+
+func Test_Init_Coupons(t *testing.T) {
+	setupTests(t)
+}
+
+func Test_CreateAccount_With_Coupon_Discounts_The_Invoice(t *testing.T) {
+	models.DeleteAccountsForTest(t)
+	models.DeleteCouponsForTest(t)
+	coupon := createCouponForTest(t, models.Coupon{PercentOff: 50, ExtraMonths: 1})
+
+	body := returnValidCreateAccountBody()
+	body.CouponCode = coupon.Code
+	post := returnValidCreateAccountReq(t, body)
+
+	w := httpPostRequestHelperForTest(t, AccountsPath, post)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	accountID, _ := utils.HashString(post.PublicKey)
+	account, err := models.GetAccountById(accountID)
+	assert.Nil(t, err)
+	assert.Equal(t, coupon.ID, account.CouponID)
+	assert.Equal(t, models.DefaultMonthsPerSubscription+1, account.MonthsInSubscription)
+	assert.True(t, account.DiscountInOPCT > 0)
+	assert.Contains(t, w.Body.String(), `"discount":`)
+}
+
+func Test_CreateAccount_Reserves_Coupon_Redemption_Before_Payment(t *testing.T) {
+	models.DeleteAccountsForTest(t)
+	models.DeleteCouponsForTest(t)
+	coupon := createCouponForTest(t, models.Coupon{PercentOff: 50, MaxRedemptions: 1})
+
+	body := returnValidCreateAccountBody()
+	body.CouponCode = coupon.Code
+	w := httpPostRequestHelperForTest(t, AccountsPath, returnValidCreateAccountReq(t, body))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// the first account hasn't paid, but its invoice holds the only redemption
+	body = returnValidCreateAccountBody()
+	body.CouponCode = coupon.Code
+	w = httpPostRequestHelperForTest(t, AccountsPath, returnValidCreateAccountReq(t, body))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), models.ErrCouponRedeemedOut.Error())
+}
+
+func Test_CreateAccount_With_Unknown_Coupon_Fails(t *testing.T) {
+	models.DeleteCouponsForTest(t)
+
+	body := returnValidCreateAccountBody()
+	body.CouponCode = "not-a-coupon"
+	post := returnValidCreateAccountReq(t, body)
+
+	w := httpPostRequestHelperForTest(t, AccountsPath, post)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), noCouponWithThatCode)
+}
+
+func Test_GetAccountUpgradeInvoiceHandler_With_Coupon(t *testing.T) {
+	models.DeleteAccountsForTest(t)
+	models.DeleteUpgradesForTest(t)
+	models.DeleteCouponsForTest(t)
+	coupon := createCouponForTest(t, models.Coupon{PercentOff: 20, StorageLimits: "2048"})
+
+	v, b, _ := returnValidVerificationAndRequestBodyWithRandomPrivateKey(t, getUpgradeAccountInvoiceObject{
+		StorageLimit:     2048,
+		DurationInMonths: 12,
+		CouponCode:       coupon.Code,
+	})
+	accountID, _ := utils.HashString(v.PublicKey)
+	account := CreatePaidAccountForTest(t, accountID)
+	account.StorageLimit = models.StorageLimitType(1024)
+	account.CreatedAt = time.Now().Add(time.Hour * 24 * (365 / 2) * -1)
+	models.DB.Save(&account)
+
+	w := httpPostRequestHelperForTest(t, AccountUpgradeInvoicePath,
+		getUpgradeAccountInvoiceReq{verification: v, requestBody: b})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"opctInvoice":{"cost":19.2,`)
+	assert.Contains(t, w.Body.String(), `"discount":4.8`)
+}
+
+func Test_GetAccountUpgradeInvoiceHandler_With_Coupon_For_Another_Plan_Fails(t *testing.T) {
+	models.DeleteAccountsForTest(t)
+	models.DeleteUpgradesForTest(t)
+	models.DeleteCouponsForTest(t)
+	coupon := createCouponForTest(t, models.Coupon{PercentOff: 20, StorageLimits: "1024"})
+
+	v, b, _ := returnValidVerificationAndRequestBodyWithRandomPrivateKey(t, getUpgradeAccountInvoiceObject{
+		StorageLimit:     2048,
+		DurationInMonths: 12,
+		CouponCode:       coupon.Code,
+	})
+	accountID, _ := utils.HashString(v.PublicKey)
+	CreatePaidAccountForTest(t, accountID)
+
+	w := httpPostRequestHelperForTest(t, AccountUpgradeInvoicePath,
+		getUpgradeAccountInvoiceReq{verification: v, requestBody: b})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), models.ErrCouponWrongPlan.Error())
+}
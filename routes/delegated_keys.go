@@ -0,0 +1,295 @@
+package routes
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/utils"
+)
+
+const (
+	delegatedKeyContextKey      = "delegatedKey"
+	delegatedKeyExpiredResponse = "delegated key has expired"
+	delegatedKeyScopeResponse   = "delegated key does not allow this request"
+	delegatedKeyNotFoundError   = "the account has not delegated that key"
+	delegateOwnKeyError         = "delegatedPublicKey must be different from publicKey"
+	delegateAccountKeyError     = "delegatedPublicKey already has its own account"
+	delegatedKeyExpiresAtError  = "expiresAt must be in the future"
+)
+
+// must be sorted alphabetically for JSON marshaling/stringifying
+type addDelegatedKeyObject struct {
+	DelegatedPublicKey string   `json:"delegatedPublicKey" binding:"required,len=66" minLength:"66" maxLength:"66" example:"a 66-character public key"`
+	ExpiresAt          int64    `json:"expiresAt" example:"an optional unix timestamp in seconds after which the key stops working"`
+	Scopes             []string `json:"scopes" binding:"required,min=1" example:"an array of scopes, like files:upload"`
+	Timestamp          int64    `json:"timestamp" binding:"required"`
+}
+
+// must be sorted alphabetically for JSON marshaling/stringifying
+type removeDelegatedKeyObject struct {
+	DelegatedPublicKey string `json:"delegatedPublicKey" binding:"required,len=66" minLength:"66" maxLength:"66" example:"a 66-character public key"`
+	Timestamp          int64  `json:"timestamp" binding:"required"`
+}
+
+type listDelegatedKeysObject struct {
+	Timestamp int64 `json:"timestamp" binding:"required"`
+}
+
+type addDelegatedKeyReq struct {
+	verification
+	requestBody
+	addDelegatedKeyObject addDelegatedKeyObject
+}
+
+type removeDelegatedKeyReq struct {
+	verification
+	requestBody
+	removeDelegatedKeyObject removeDelegatedKeyObject
+}
+
+type listDelegatedKeysReq struct {
+	verification
+	requestBody
+	listDelegatedKeysObject listDelegatedKeysObject
+}
+
+type delegatedKeyRes struct {
+	PublicKey string     `json:"publicKey" binding:"required,len=66"`
+	Scopes    []string   `json:"scopes" binding:"required"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+type listDelegatedKeysRes struct {
+	DelegatedKeys []delegatedKeyRes `json:"delegatedKeys"`
+}
+
+var removeDelegatedKeyRes = StatusRes{
+	Status: "delegated key removed",
+}
+
+func (v *addDelegatedKeyReq) getObjectRef() interface{} {
+	return &v.addDelegatedKeyObject
+}
+
+func (v *removeDelegatedKeyReq) getObjectRef() interface{} {
+	return &v.removeDelegatedKeyObject
+}
+
+func (v *listDelegatedKeysReq) getObjectRef() interface{} {
+	return &v.listDelegatedKeysObject
+}
+
+// AddDelegatedKeyHandler godoc
+// @Summary let another key act for the account within some scopes
+// @Accept  json
+// @Produce  json
+// @Param addDelegatedKeyReq body routes.addDelegatedKeyReq true "add delegated key object"
+// @description A delegated key signs requests with its own publicKey and acts for the account that delegated
+// @description it, but only on the account-data, metadata, upload, download and delete endpoints its scopes
+// @description allow.  Scopes can be account:read, metadata:read, metadata:write, files:upload, files:download
+// @description and files:delete.  Adding a key the account already delegated replaces its scopes and expiresAt.
+// @description This must be signed with the account's own key.
+// @description requestBody should be a stringified version of (values are just examples):
+// @description {
+// @description 	"delegatedPublicKey": "a 66-character public key",
+// @description 	"expiresAt": 1589968389,
+// @description 	"scopes": ["files:upload"],
+// @description 	"timestamp": 1557346389
+// @description }
+// @Success 200 {object} routes.delegatedKeyRes
+// @Failure 400 {string} string "bad request, unable to parse request body: (with the error)"
+// @Failure 403 {string} string "signature did not match"
+// @Failure 404 {string} string "no account with that id: (with your accountID)"
+// @Failure 500 {string} string "some information about the internal error"
+// @Router /api/v1/delegated-keys/add [post]
+/*AddDelegatedKeyHandler is a handler for delegating a key*/
+func AddDelegatedKeyHandler() gin.HandlerFunc {
+	return ginHandlerFunc(addDelegatedKey)
+}
+
+// RemoveDelegatedKeyHandler godoc
+// @Summary stop a delegated key from acting for the account
+// @Accept  json
+// @Produce  json
+// @Param removeDelegatedKeyReq body routes.removeDelegatedKeyReq true "remove delegated key object"
+// @description This must be signed with the account's own key.
+// @description requestBody should be a stringified version of (values are just examples):
+// @description {
+// @description 	"delegatedPublicKey": "a 66-character public key",
+// @description 	"timestamp": 1557346389
+// @description }
+// @Success 200 {object} routes.StatusRes
+// @Failure 400 {string} string "bad request, unable to parse request body: (with the error)"
+// @Failure 403 {string} string "signature did not match"
+// @Failure 404 {string} string "the account has not delegated that key"
+// @Failure 500 {string} string "some information about the internal error"
+// @Router /api/v1/delegated-keys/remove [post]
+/*RemoveDelegatedKeyHandler is a handler for revoking a delegated key*/
+func RemoveDelegatedKeyHandler() gin.HandlerFunc {
+	return ginHandlerFunc(removeDelegatedKey)
+}
+
+// ListDelegatedKeysHandler godoc
+// @Summary list the keys the account delegated
+// @Accept  json
+// @Produce  json
+// @Param listDelegatedKeysReq body routes.listDelegatedKeysReq true "list delegated keys object"
+// @description This must be signed with the account's own key.
+// @description requestBody should be a stringified version of (values are just examples):
+// @description {
+// @description 	"timestamp": 1557346389
+// @description }
+// @Success 200 {object} routes.listDelegatedKeysRes
+// @Failure 400 {string} string "bad request, unable to parse request body: (with the error)"
+// @Failure 403 {string} string "signature did not match"
+// @Failure 404 {string} string "no account with that id: (with your accountID)"
+// @Failure 500 {string} string "some information about the internal error"
+// @Router /api/v1/delegated-keys/list [post]
+/*ListDelegatedKeysHandler is a handler for listing delegated keys*/
+func ListDelegatedKeysHandler() gin.HandlerFunc {
+	return ginHandlerFunc(listDelegatedKeys)
+}
+
+func addDelegatedKey(c *gin.Context) error {
+	if !utils.WritesEnabled() {
+		return ServiceUnavailableResponse(c, maintenanceError)
+	}
+
+	request := addDelegatedKeyReq{}
+	if err := verifyAndParseBodyRequest(&request, c); err != nil {
+		return err
+	}
+
+	// delegated keys can't reach this path, so the account is the signer's own
+	if _, err := request.getAccount(c); err != nil {
+		return err
+	}
+
+	delegatedPublicKey := request.addDelegatedKeyObject.DelegatedPublicKey
+	if delegatedPublicKey == request.PublicKey {
+		return BadRequestResponse(c, errors.New(delegateOwnKeyError))
+	}
+	if err := verifyScopes(request.addDelegatedKeyObject.Scopes, c); err != nil {
+		return err
+	}
+	var expiresAt *time.Time
+	if request.addDelegatedKeyObject.ExpiresAt != 0 {
+		expiration := time.Unix(request.addDelegatedKeyObject.ExpiresAt, 0)
+		if !expiration.After(time.Now()) {
+			return BadRequestResponse(c, errors.New(delegatedKeyExpiresAtError))
+		}
+		expiresAt = &expiration
+	}
+
+	// a key with its own account would always act as that account
+	delegatedAccountID, err := utils.HashString(delegatedPublicKey)
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+	if _, err := models.GetAccountById(delegatedAccountID); err == nil {
+		return BadRequestResponse(c, errors.New(delegateAccountKeyError))
+	}
+
+	delegatedKey, err := models.SetDelegatedKey(request.PublicKey, delegatedPublicKey,
+		request.addDelegatedKeyObject.Scopes, expiresAt)
+	if err == models.ErrDelegatedKeyTaken {
+		return BadRequestResponse(c, err)
+	}
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+	return OkResponse(c, toDelegatedKeyRes(delegatedKey))
+}
+
+func removeDelegatedKey(c *gin.Context) error {
+	request := removeDelegatedKeyReq{}
+	if err := verifyAndParseBodyRequest(&request, c); err != nil {
+		return err
+	}
+
+	account, err := request.getAccount(c)
+	if err != nil {
+		return err
+	}
+
+	err = models.RemoveDelegatedKey(account.AccountID, request.removeDelegatedKeyObject.DelegatedPublicKey)
+	if gorm.IsRecordNotFoundError(err) {
+		return NotFoundResponse(c, errors.New(delegatedKeyNotFoundError))
+	}
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+	return OkResponse(c, removeDelegatedKeyRes)
+}
+
+func listDelegatedKeys(c *gin.Context) error {
+	request := listDelegatedKeysReq{}
+	if err := verifyAndParseBodyRequest(&request, c); err != nil {
+		return err
+	}
+
+	account, err := request.getAccount(c)
+	if err != nil {
+		return err
+	}
+
+	delegatedKeys, err := models.GetDelegatedKeysByAccountID(account.AccountID)
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+	res := listDelegatedKeysRes{DelegatedKeys: []delegatedKeyRes{}}
+	for _, delegatedKey := range delegatedKeys {
+		res.DelegatedKeys = append(res.DelegatedKeys, toDelegatedKeyRes(delegatedKey))
+	}
+	return OkResponse(c, res)
+}
+
+func toDelegatedKeyRes(delegatedKey models.DelegatedKey) delegatedKeyRes {
+	return delegatedKeyRes{
+		PublicKey: delegatedKey.PublicKey,
+		Scopes:    delegatedKey.ScopeList(),
+		ExpiresAt: delegatedKey.ExpiresAt,
+		CreatedAt: delegatedKey.CreatedAt,
+	}
+}
+
+/*getDelegatedKey returns the key an account delegated to publicKey, if there is one.  Keys that expired or
+don't have the scope the request's path needs are refused.*/
+func getDelegatedKey(publicKey string, c *gin.Context) (models.DelegatedKey, bool, error) {
+	if delegatedKey, ok := c.Get(delegatedKeyContextKey); ok && delegatedKey.(models.DelegatedKey).PublicKey == publicKey {
+		return delegatedKey.(models.DelegatedKey), true, nil
+	}
+
+	delegatedKey, err := models.GetDelegatedKey(publicKey)
+	if gorm.IsRecordNotFoundError(err) {
+		return delegatedKey, false, nil
+	}
+	if err != nil {
+		return delegatedKey, false, InternalErrorResponse(c, err)
+	}
+	if delegatedKey.Expired() {
+		return delegatedKey, false, ForbiddenResponse(c, errors.New(delegatedKeyExpiredResponse))
+	}
+	if scope, ok := requestScope(c); !ok || !delegatedKey.HasScope(scope) {
+		return delegatedKey, false, ForbiddenResponse(c, errors.New(delegatedKeyScopeResponse))
+	}
+
+	c.Set(delegatedKeyContextKey, delegatedKey)
+	return delegatedKey, true, nil
+}
+
+/*ownerPublicKey returns the key permission hashes are derived from for publicKey, which is the account owner's
+key if getAccount found publicKey to be a delegated key*/
+func ownerPublicKey(publicKey string, c *gin.Context) string {
+	if c == nil {
+		return publicKey
+	}
+	if delegatedKey, ok := c.Get(delegatedKeyContextKey); ok && delegatedKey.(models.DelegatedKey).PublicKey == publicKey {
+		return delegatedKey.(models.DelegatedKey).ParentPublicKey
+	}
+	return publicKey
+}
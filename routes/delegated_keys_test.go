@@ -0,0 +1,139 @@
+package routes
+
+import (
+	"crypto/ecdsa"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Init_Delegated_Keys(t *testing.T) {
+	setupTests(t)
+}
+
+func returnAddDelegatedKeyReqForTest(t *testing.T, privateKey, delegatedPrivateKey *ecdsa.PrivateKey, scopes []string,
+	expiresAt int64) addDelegatedKeyReq {
+	addDelegatedKeyObj := addDelegatedKeyObject{
+		DelegatedPublicKey: utils.PubkeyCompressedToHex(delegatedPrivateKey.PublicKey),
+		ExpiresAt:          expiresAt,
+		Scopes:             scopes,
+		Timestamp:          time.Now().Unix(),
+	}
+	v, b := returnValidVerificationAndRequestBody(t, addDelegatedKeyObj, privateKey)
+	return addDelegatedKeyReq{
+		verification: v,
+		requestBody:  b,
+	}
+}
+
+func returnDeleteMetadataReqForTest(t *testing.T, privateKey *ecdsa.PrivateKey, metadataKey string) metadataKeyReq {
+	v, b := returnValidVerificationAndRequestBody(t, metadataKeyObject{
+		MetadataKey: metadataKey,
+		Timestamp:   time.Now().Unix(),
+	}, privateKey)
+	return metadataKeyReq{
+		verification: v,
+		requestBody:  b,
+	}
+}
+
+func Test_AddDelegatedKey_Lets_Key_Act_Within_Its_Scopes(t *testing.T) {
+	accountID, privateKey := generateValidateAccountId(t)
+	CreatePaidAccountForTest(t, accountID)
+	metadataKey := createIndexedMetadataForTest(t, accountID, privateKey, "metadata", nil)
+	_, delegatedPrivateKey := generateValidateAccountId(t)
+
+	w := httpPostRequestHelperForTest(t, DelegatedKeyAddPath,
+		returnAddDelegatedKeyReqForTest(t, privateKey, delegatedPrivateKey, []string{ScopeMetadataRead}, 0))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	v, b := returnValidVerificationAndRequestBody(t, metadataKeyObject{
+		MetadataKey: metadataKey,
+		Timestamp:   time.Now().Unix(),
+	}, delegatedPrivateKey)
+	w = httpPostRequestHelperForTest(t, MetadataGetPath, metadataKeyReq{verification: v, requestBody: b})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "metadata")
+
+	// metadata:read doesn't allow deleting
+	w = httpPostRequestHelperForTest(t, MetadataDeletePath,
+		returnDeleteMetadataReqForTest(t, delegatedPrivateKey, metadataKey))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), delegatedKeyScopeResponse)
+
+	w = httpPostRequestHelperForTest(t, DelegatedKeyAddPath,
+		returnAddDelegatedKeyReqForTest(t, privateKey, delegatedPrivateKey, []string{ScopeMetadataWrite}, 0))
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = httpPostRequestHelperForTest(t, MetadataDeletePath,
+		returnDeleteMetadataReqForTest(t, delegatedPrivateKey, metadataKey))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func Test_DelegatedKey_Is_Refused_Once_Expired(t *testing.T) {
+	accountID, privateKey := generateValidateAccountId(t)
+	CreatePaidAccountForTest(t, accountID)
+	metadataKey := createIndexedMetadataForTest(t, accountID, privateKey, "metadata", nil)
+	_, delegatedPrivateKey := generateValidateAccountId(t)
+
+	expiresAt := time.Now().Add(-time.Minute)
+	_, err := models.SetDelegatedKey(utils.PubkeyCompressedToHex(privateKey.PublicKey),
+		utils.PubkeyCompressedToHex(delegatedPrivateKey.PublicKey), []string{ScopeMetadataWrite}, &expiresAt)
+	assert.Nil(t, err)
+
+	w := httpPostRequestHelperForTest(t, MetadataDeletePath,
+		returnDeleteMetadataReqForTest(t, delegatedPrivateKey, metadataKey))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), delegatedKeyExpiredResponse)
+}
+
+func Test_DelegatedKey_Cannot_Delegate_Keys(t *testing.T) {
+	accountID, privateKey := generateValidateAccountId(t)
+	CreatePaidAccountForTest(t, accountID)
+	_, delegatedPrivateKey := generateValidateAccountId(t)
+	_, otherPrivateKey := generateValidateAccountId(t)
+
+	w := httpPostRequestHelperForTest(t, DelegatedKeyAddPath,
+		returnAddDelegatedKeyReqForTest(t, privateKey, delegatedPrivateKey, []string{ScopeMetadataWrite}, 0))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httpPostRequestHelperForTest(t, DelegatedKeyAddPath,
+		returnAddDelegatedKeyReqForTest(t, delegatedPrivateKey, otherPrivateKey, []string{ScopeMetadataWrite}, 0))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func Test_AddDelegatedKey_Refuses_Unknown_Scopes_And_Past_Expiration(t *testing.T) {
+	accountID, privateKey := generateValidateAccountId(t)
+	CreatePaidAccountForTest(t, accountID)
+	_, delegatedPrivateKey := generateValidateAccountId(t)
+
+	w := httpPostRequestHelperForTest(t, DelegatedKeyAddPath,
+		returnAddDelegatedKeyReqForTest(t, privateKey, delegatedPrivateKey, []string{"account:delete"}, 0))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), unknownScopeError)
+
+	w = httpPostRequestHelperForTest(t, DelegatedKeyAddPath, returnAddDelegatedKeyReqForTest(t, privateKey,
+		delegatedPrivateKey, []string{ScopeMetadataRead}, time.Now().Add(-time.Hour).Unix()))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), delegatedKeyExpiresAtError)
+}
+
+func Test_RemoveDelegatedKey_Not_Found(t *testing.T) {
+	accountID, privateKey := generateValidateAccountId(t)
+	CreatePaidAccountForTest(t, accountID)
+	_, delegatedPrivateKey := generateValidateAccountId(t)
+
+	v, b := returnValidVerificationAndRequestBody(t, removeDelegatedKeyObject{
+		DelegatedPublicKey: utils.PubkeyCompressedToHex(delegatedPrivateKey.PublicKey),
+		Timestamp:          time.Now().Unix(),
+	}, privateKey)
+	w := httpPostRequestHelperForTest(t, DelegatedKeyRemovePath, removeDelegatedKeyReq{verification: v, requestBody: b})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func Test_OwnerPublicKey_Without_Delegated_Key(t *testing.T) {
+	assert.Equal(t, "publicKey", ownerPublicKey("publicKey", nil))
+}
@@ -0,0 +1,267 @@
+package routes
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/utils"
+)
+
+const (
+	accountDeletionNotFoundError = "no account deletion was requested"
+	receiptNotFoundError         = "no deletion receipt for that account"
+)
+
+// must be sorted alphabetically for JSON marshaling/stringifying
+type deleteAccountObject struct {
+	DelayInHours int   `json:"delayInHours" binding:"omitempty,gte=0,lte=720" maximum:"720" example:"24"`
+	Timestamp    int64 `json:"timestamp" binding:"required"`
+}
+
+type confirmDeleteAccountObject struct {
+	ConfirmationCode string `json:"confirmationCode" binding:"required" example:"the code returned when the deletion was requested"`
+	Timestamp        int64  `json:"timestamp" binding:"required"`
+}
+
+type deleteAccountReq struct {
+	verification
+	requestBody
+	deleteAccountObject deleteAccountObject
+}
+
+type confirmDeleteAccountReq struct {
+	verification
+	requestBody
+	confirmDeleteAccountObject confirmDeleteAccountObject
+}
+
+type deleteAccountTimestampReq struct {
+	verification
+	requestBody
+	timestampObject timestampObject
+}
+
+type deleteAccountRes struct {
+	Status           string    `json:"status" example:"requested"`
+	ConfirmationCode string    `json:"confirmationCode,omitempty" example:"a code to send to /account/delete/confirm"`
+	ConfirmBy        time.Time `json:"confirmBy"`
+	ExecuteAt        time.Time `json:"executeAt"`
+}
+
+type deleteAccountReceiptRes struct {
+	Status  string                        `json:"status" example:"deleted"`
+	Receipt models.AccountDeletionReceipt `json:"receipt"`
+}
+
+func (v *deleteAccountReq) getObjectRef() interface{} {
+	return &v.deleteAccountObject
+}
+
+func (v *confirmDeleteAccountReq) getObjectRef() interface{} {
+	return &v.confirmDeleteAccountObject
+}
+
+func (v *deleteAccountTimestampReq) getObjectRef() interface{} {
+	return &v.timestampObject
+}
+
+// DeleteAccountHandler godoc
+// @Summary request deleting an account
+// @Accept  json
+// @Produce  json
+// @Param deleteAccountReq body routes.deleteAccountReq true "delete account object"
+// @description Returns a code to confirm the deletion with at /api/v1/account/delete/confirm within an hour.
+// @description Once confirmed the account, its files, uploads in progress, metadata and payments are deleted
+// @description after delayInHours, and can be cancelled until then.
+// @description requestBody should be a stringified version of (values are just examples):
+// @description {
+// @description 	"delayInHours": 24,
+// @description 	"timestamp": 1557346389
+// @description }
+// @Success 200 {object} routes.deleteAccountRes
+// @Failure 400 {string} string "bad request, unable to parse request body: (with the error)"
+// @Failure 403 {string} string "signature did not match"
+// @Failure 404 {string} string "no account with that id: (with your accountID)"
+// @Failure 500 {string} string "some information about the internal error"
+// @Router /api/v1/account/delete [post]
+/*DeleteAccountHandler is a handler for requesting an account be deleted*/
+func DeleteAccountHandler() gin.HandlerFunc {
+	return ginHandlerFunc(deleteAccount)
+}
+
+// ConfirmDeleteAccountHandler godoc
+// @Summary confirm deleting an account
+// @Accept  json
+// @Produce  json
+// @Param confirmDeleteAccountReq body routes.confirmDeleteAccountReq true "confirm delete account object"
+// @description If the deletion wasn't delayed the account is deleted right away and the signed receipt is
+// @description returned.  Otherwise the deletion is scheduled, and the receipt can be fetched from
+// @description /api/v1/account/delete/receipt once it is done.
+// @description requestBody should be a stringified version of (values are just examples):
+// @description {
+// @description 	"confirmationCode": "the code returned when the deletion was requested",
+// @description 	"timestamp": 1557346389
+// @description }
+// @Success 200 {object} routes.deleteAccountReceiptRes
+// @Failure 400 {string} string "the confirmation code does not match the deletion request"
+// @Failure 403 {string} string "signature did not match"
+// @Failure 404 {string} string "no account deletion was requested"
+// @Failure 500 {string} string "some information about the internal error"
+// @Router /api/v1/account/delete/confirm [post]
+/*ConfirmDeleteAccountHandler is a handler for confirming an account deletion*/
+func ConfirmDeleteAccountHandler() gin.HandlerFunc {
+	return ginHandlerFunc(confirmDeleteAccount)
+}
+
+// CancelDeleteAccountHandler godoc
+// @Summary cancel deleting an account
+// @Accept  json
+// @Produce  json
+// @Param deleteAccountTimestampReq body routes.deleteAccountTimestampReq true "cancel delete account object"
+// @description requestBody should be a stringified version of (values are just examples):
+// @description {
+// @description 	"timestamp": 1557346389
+// @description }
+// @Success 200 {object} routes.StatusRes
+// @Failure 400 {string} string "bad request, unable to parse request body: (with the error)"
+// @Failure 403 {string} string "signature did not match"
+// @Failure 404 {string} string "no account deletion was requested"
+// @Failure 500 {string} string "some information about the internal error"
+// @Router /api/v1/account/delete/cancel [post]
+/*CancelDeleteAccountHandler is a handler for cancelling an account deletion before it happens*/
+func CancelDeleteAccountHandler() gin.HandlerFunc {
+	return ginHandlerFunc(cancelDeleteAccount)
+}
+
+// DeleteAccountReceiptHandler godoc
+// @Summary get the receipt for a deleted account
+// @Accept  json
+// @Produce  json
+// @Param deleteAccountTimestampReq body routes.deleteAccountTimestampReq true "deletion receipt object"
+// @description The receipt is signed by the node's main wallet, at signerAddress, over the keccak256 hash of
+// @description the accountID, the unix times requestedAt and deletedAt, completedFilesDeleted, uploadsDeleted
+// @description and metadatasDeleted, joined by newlines.
+// @description requestBody should be a stringified version of (values are just examples):
+// @description {
+// @description 	"timestamp": 1557346389
+// @description }
+// @Success 200 {object} routes.deleteAccountReceiptRes
+// @Failure 400 {string} string "bad request, unable to parse request body: (with the error)"
+// @Failure 403 {string} string "signature did not match"
+// @Failure 404 {string} string "no deletion receipt for that account"
+// @Failure 500 {string} string "some information about the internal error"
+// @Router /api/v1/account/delete/receipt [post]
+/*DeleteAccountReceiptHandler is a handler for getting the signed receipt of an account deletion*/
+func DeleteAccountReceiptHandler() gin.HandlerFunc {
+	return ginHandlerFunc(deleteAccountReceipt)
+}
+
+func deleteAccount(c *gin.Context) error {
+	request := deleteAccountReq{}
+	if err := verifyAndParseBodyRequest(&request, c); err != nil {
+		return err
+	}
+
+	account, err := request.getAccount(c)
+	if err != nil {
+		return err
+	}
+
+	delay := time.Duration(request.deleteAccountObject.DelayInHours) * time.Hour
+	deletion, code, err := models.RequestAccountDeletion(account.AccountID, delay)
+	if err == models.ErrAccountDeletionDelay {
+		return BadRequestResponse(c, err)
+	}
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+	return OkResponse(c, deleteAccountRes{
+		Status:           models.AccountDeletionStatusMap[deletion.Status],
+		ConfirmationCode: code,
+		ConfirmBy:        deletion.ConfirmBy,
+		ExecuteAt:        deletion.ExecuteAt,
+	})
+}
+
+func confirmDeleteAccount(c *gin.Context) error {
+	request := confirmDeleteAccountReq{}
+	if err := verifyAndParseBodyRequest(&request, c); err != nil {
+		return err
+	}
+
+	account, err := request.getAccount(c)
+	if err != nil {
+		return err
+	}
+
+	deletion, err := models.ConfirmAccountDeletion(account.AccountID, request.confirmDeleteAccountObject.ConfirmationCode)
+	if gorm.IsRecordNotFoundError(err) {
+		return NotFoundResponse(c, errors.New(accountDeletionNotFoundError))
+	}
+	if err == models.ErrAccountDeletionCodeInvalid || err == models.ErrAccountDeletionConfirmationExpired {
+		return BadRequestResponse(c, err)
+	}
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+
+	if deletion.ExecuteAt.After(time.Now()) {
+		return OkResponse(c, deleteAccountRes{
+			Status:    models.AccountDeletionStatusMap[deletion.Status],
+			ConfirmBy: deletion.ConfirmBy,
+			ExecuteAt: deletion.ExecuteAt,
+		})
+	}
+
+	receipt, err := deletion.Execute()
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+	return OkResponse(c, deleteAccountReceiptRes{Status: "deleted", Receipt: receipt})
+}
+
+func cancelDeleteAccount(c *gin.Context) error {
+	request := deleteAccountTimestampReq{}
+	if err := verifyAndParseBodyRequest(&request, c); err != nil {
+		return err
+	}
+
+	account, err := request.getAccount(c)
+	if err != nil {
+		return err
+	}
+
+	err = models.CancelAccountDeletion(account.AccountID)
+	if gorm.IsRecordNotFoundError(err) {
+		return NotFoundResponse(c, errors.New(accountDeletionNotFoundError))
+	}
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+	return OkResponse(c, StatusRes{Status: "account deletion cancelled"})
+}
+
+/*deleteAccountReceipt only checks the signature, since the account it is for no longer exists*/
+func deleteAccountReceipt(c *gin.Context) error {
+	request := deleteAccountTimestampReq{}
+	if err := verifyAndParseBodyRequest(&request, c); err != nil {
+		return err
+	}
+
+	accountID, err := utils.HashString(request.PublicKey)
+	if err != nil {
+		return BadRequestResponse(c, err)
+	}
+
+	receipt, err := models.GetAccountDeletionReceipt(accountID)
+	if gorm.IsRecordNotFoundError(err) {
+		return NotFoundResponse(c, errors.New(receiptNotFoundError))
+	}
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+	return OkResponse(c, deleteAccountReceiptRes{Status: "deleted", Receipt: receipt})
+}
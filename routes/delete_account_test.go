@@ -0,0 +1,104 @@
+package routes
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/services"
+	"github.com/opacity/storage-node/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Init_Delete_Account(t *testing.T) {
+	setupTests(t)
+}
+
+func requestAccountDeletionForTest(t *testing.T, delayInHours int) (models.Account, *ecdsa.PrivateKey, string) {
+	v, b, privateKey := returnValidVerificationAndRequestBodyWithRandomPrivateKey(t, deleteAccountObject{
+		DelayInHours: delayInHours,
+		Timestamp:    time.Now().Unix(),
+	})
+	accountID, _ := utils.HashString(v.PublicKey)
+	account := CreatePaidAccountForTest(t, accountID)
+
+	w := httpPostRequestHelperForTest(t, AccountDeletePath, deleteAccountReq{verification: v, requestBody: b})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	res := deleteAccountRes{}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.Equal(t, "requested", res.Status)
+	return account, privateKey, res.ConfirmationCode
+}
+
+func confirmAccountDeletionForTest(t *testing.T, privateKey *ecdsa.PrivateKey, code string) *httptest.ResponseRecorder {
+	v, b := returnValidVerificationAndRequestBody(t, confirmDeleteAccountObject{
+		ConfirmationCode: code,
+		Timestamp:        time.Now().Unix(),
+	}, privateKey)
+	return httpPostRequestHelperForTest(t, AccountDeleteConfirmPath, confirmDeleteAccountReq{verification: v, requestBody: b})
+}
+
+func Test_DeleteAccount_Confirmed_Deletes_Right_Away(t *testing.T) {
+	models.DeleteAccountsForTest(t)
+	models.DeleteAccountDeletionsForTest(t)
+	oldKey, oldAddress := services.MainWalletPrivateKey, services.MainWalletAddress
+	defer func() {
+		services.MainWalletPrivateKey, services.MainWalletAddress = oldKey, oldAddress
+	}()
+	services.MainWalletPrivateKey, _ = utils.GenerateKey()
+	services.MainWalletAddress = utils.PubkeyToAddress(services.MainWalletPrivateKey.PublicKey)
+
+	account, privateKey, code := requestAccountDeletionForTest(t, 0)
+
+	w := confirmAccountDeletionForTest(t, privateKey, code)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"deleted"`)
+	assert.Contains(t, w.Body.String(), services.MainWalletAddress.Hex())
+
+	_, err := models.GetAccountById(account.AccountID)
+	assert.True(t, gorm.IsRecordNotFoundError(err))
+
+	v, b := returnValidVerificationAndRequestBody(t, timestampObject{Timestamp: time.Now().Unix()}, privateKey)
+	w = httpPostRequestHelperForTest(t, AccountDeleteReceiptPath, deleteAccountTimestampReq{verification: v, requestBody: b})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), account.AccountID)
+}
+
+func Test_DeleteAccount_Delayed_Can_Be_Cancelled(t *testing.T) {
+	models.DeleteAccountsForTest(t)
+	models.DeleteAccountDeletionsForTest(t)
+
+	account, privateKey, code := requestAccountDeletionForTest(t, 24)
+
+	w := confirmAccountDeletionForTest(t, privateKey, code)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"confirmed"`)
+
+	v, b := returnValidVerificationAndRequestBody(t, timestampObject{Timestamp: time.Now().Unix()}, privateKey)
+	w = httpPostRequestHelperForTest(t, AccountDeleteCancelPath, deleteAccountTimestampReq{verification: v, requestBody: b})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	_, err := models.GetAccountById(account.AccountID)
+	assert.Nil(t, err)
+	_, err = models.GetAccountDeletion(account.AccountID)
+	assert.True(t, gorm.IsRecordNotFoundError(err))
+}
+
+func Test_DeleteAccount_Wrong_Code(t *testing.T) {
+	models.DeleteAccountsForTest(t)
+	models.DeleteAccountDeletionsForTest(t)
+
+	account, privateKey, _ := requestAccountDeletionForTest(t, 0)
+
+	w := confirmAccountDeletionForTest(t, privateKey, "wrong")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	_, err := models.GetAccountById(account.AccountID)
+	assert.Nil(t, err)
+}
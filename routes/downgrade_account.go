@@ -0,0 +1,220 @@
+package routes
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"github.com/opacity/storage-node/models"
+)
+
+const (
+	/*DowngradeImmediately applies a downgrade right away and credits what is left of the term*/
+	DowngradeImmediately = "immediately"
+
+	/*DowngradeAtRenewal applies a downgrade when the account is next renewed*/
+	DowngradeAtRenewal = "renewal"
+
+	/*DowngradeCreditAsTime credits an immediate downgrade as more months on the smaller plan*/
+	DowngradeCreditAsTime = "time"
+
+	/*DowngradeCreditAsBalance credits an immediate downgrade as a balance taken off the next renewal*/
+	DowngradeCreditAsBalance = "balance"
+)
+
+const (
+	downgradeApplyAtError      = "applyAt must be immediately or renewal"
+	downgradeCreditError       = "credit must be time or balance"
+	downgradeStorageLimitError = "cannot downgrade to storage limit higher than or equal to current limit"
+	downgradeNotFoundError     = "no downgrade is scheduled"
+)
+
+// must be sorted alphabetically for JSON marshaling/stringifying
+type downgradeAccountObject struct {
+	ApplyAt      string `json:"applyAt" binding:"required" example:"renewal"`
+	Credit       string `json:"credit" example:"time"`
+	StorageLimit int    `json:"storageLimit" binding:"required,gte=10" minimum:"10" example:"128"`
+	Timestamp    int64  `json:"timestamp" binding:"required"`
+}
+
+type cancelDowngradeObject struct {
+	Timestamp int64 `json:"timestamp" binding:"required"`
+}
+
+type downgradeAccountReq struct {
+	verification
+	requestBody
+	downgradeAccountObject downgradeAccountObject
+}
+
+type cancelDowngradeReq struct {
+	verification
+	requestBody
+	cancelDowngradeObject cancelDowngradeObject
+}
+
+type downgradeAccountRes struct {
+	Status         string                  `json:"status" example:"Downgraded"`
+	StorageLimit   models.StorageLimitType `json:"storageLimit" example:"128"`
+	ExpirationDate time.Time               `json:"expirationDate"`
+	CreditInOPCT   float64                 `json:"creditInOPCT" example:"0.5"`
+}
+
+func (v *downgradeAccountReq) getObjectRef() interface{} {
+	return &v.downgradeAccountObject
+}
+
+func (v *cancelDowngradeReq) getObjectRef() interface{} {
+	return &v.cancelDowngradeObject
+}
+
+// DowngradeAccountHandler godoc
+// @Summary move an account to a smaller plan
+// @Accept  json
+// @Produce  json
+// @Param downgradeAccountReq body routes.downgradeAccountReq true "downgrade account object"
+// @description The account's storage, folders and metadata must fit in the smaller plan.  With applyAt
+// @description "renewal" the account moves when it is next renewed, and the renewal is priced at the smaller
+// @description plan.  With applyAt "immediately" it moves now, and the rest of the term is credited:  with
+// @description credit "time" it stretches into more months of the smaller plan, with credit "balance" the
+// @description difference in price is taken off the next renewal.
+// @description requestBody should be a stringified version of (values are just examples):
+// @description {
+// @description 	"applyAt": "immediately",
+// @description 	"credit": "time",
+// @description 	"storageLimit": 128,
+// @description 	"timestamp": 1557346389
+// @description }
+// @Success 200 {object} routes.downgradeAccountRes
+// @Failure 400 {string} string "bad request, unable to parse request body: (with the error)"
+// @Failure 403 {string} string "signature did not match"
+// @Failure 404 {string} string "no account with that id: (with your accountID)"
+// @Failure 500 {string} string "some information about the internal error"
+// @Router /api/v1/downgrade [post]
+/*DowngradeAccountHandler is a handler for moving an account to a smaller plan*/
+func DowngradeAccountHandler() gin.HandlerFunc {
+	return ginHandlerFunc(downgradeAccount)
+}
+
+// CancelDowngradeHandler godoc
+// @Summary cancel a downgrade scheduled for the next renewal
+// @Accept  json
+// @Produce  json
+// @Param cancelDowngradeReq body routes.cancelDowngradeReq true "cancel downgrade object"
+// @description requestBody should be a stringified version of (values are just examples):
+// @description {
+// @description 	"timestamp": 1557346389
+// @description }
+// @Success 200 {object} routes.StatusRes
+// @Failure 400 {string} string "bad request, unable to parse request body: (with the error)"
+// @Failure 403 {string} string "signature did not match"
+// @Failure 404 {string} string "no downgrade is scheduled"
+// @Failure 500 {string} string "some information about the internal error"
+// @Router /api/v1/downgrade/cancel [post]
+/*CancelDowngradeHandler is a handler for cancelling a scheduled downgrade*/
+func CancelDowngradeHandler() gin.HandlerFunc {
+	return ginHandlerFunc(cancelDowngrade)
+}
+
+func downgradeAccount(c *gin.Context) error {
+	request := downgradeAccountReq{}
+	if err := verifyAndParseBodyRequest(&request, c); err != nil {
+		return err
+	}
+
+	account, err := request.getAccount(c)
+	if err != nil {
+		return err
+	}
+
+	object := request.downgradeAccountObject
+	if err := verifyDowngradeEligible(account, object, c); err != nil {
+		return err
+	}
+
+	if object.ApplyAt == DowngradeAtRenewal {
+		if _, err := account.ScheduleDowngrade(object.StorageLimit); err != nil {
+			return downgradeErrorResponse(c, err)
+		}
+		return OkResponse(c, downgradeAccountRes{
+			Status:         "Scheduled",
+			StorageLimit:   models.StorageLimitType(object.StorageLimit),
+			ExpirationDate: account.ExpirationDate(),
+		})
+	}
+
+	credit, err := account.DowngradeAccount(object.StorageLimit, object.Credit == DowngradeCreditAsTime)
+	if err != nil {
+		return downgradeErrorResponse(c, err)
+	}
+	return OkResponse(c, downgradeAccountRes{
+		Status:         "Downgraded",
+		StorageLimit:   account.StorageLimit,
+		ExpirationDate: account.ExpirationDate(),
+		CreditInOPCT:   credit.CreditInOPCT,
+	})
+}
+
+func cancelDowngrade(c *gin.Context) error {
+	request := cancelDowngradeReq{}
+	if err := verifyAndParseBodyRequest(&request, c); err != nil {
+		return err
+	}
+
+	account, err := request.getAccount(c)
+	if err != nil {
+		return err
+	}
+
+	err = models.CancelDowngrade(account.AccountID)
+	if gorm.IsRecordNotFoundError(err) {
+		return NotFoundResponse(c, errors.New(downgradeNotFoundError))
+	}
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+	return OkResponse(c, StatusRes{Status: "downgrade cancelled"})
+}
+
+func verifyDowngradeEligible(account models.Account, object downgradeAccountObject, c *gin.Context) error {
+	if object.ApplyAt != DowngradeImmediately && object.ApplyAt != DowngradeAtRenewal {
+		return BadRequestResponse(c, errors.New(downgradeApplyAtError))
+	}
+	if object.ApplyAt == DowngradeImmediately && object.Credit != DowngradeCreditAsTime &&
+		object.Credit != DowngradeCreditAsBalance {
+		return BadRequestResponse(c, errors.New(downgradeCreditError))
+	}
+	if err := verifyValidStorageLimit(object.StorageLimit, c); err != nil {
+		return err
+	}
+	if object.StorageLimit >= int(account.StorageLimit) {
+		return BadRequestResponse(c, errors.New(downgradeStorageLimitError))
+	}
+	return verifyIfPaidWithContext(account, c)
+}
+
+/*verifyScheduledDowngradeFits checks that the account still fits in the plan of the downgrade it scheduled for
+its renewal, so it isn't charged for a plan it can't use*/
+func verifyScheduledDowngradeFits(account models.Account, c *gin.Context) error {
+	downgrade, err := models.GetScheduledDowngrade(account.AccountID)
+	if gorm.IsRecordNotFoundError(err) {
+		return nil
+	}
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+	if err := account.CheckDowngradeFits(downgrade.Plan()); err != nil {
+		return BadRequestResponse(c, err)
+	}
+	return nil
+}
+
+func downgradeErrorResponse(c *gin.Context, err error) error {
+	switch err {
+	case models.ErrDowngradeStorageUsed, models.ErrDowngradeTooManyFolders, models.ErrDowngradeMetadataTooLarge,
+		models.ErrDowngradeCreditAsTime, models.InvalidStorageLimitError:
+		return BadRequestResponse(c, err)
+	}
+	return InternalErrorResponse(c, err)
+}
@@ -0,0 +1,121 @@
+package routes
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Init_Downgrade_Accounts(t *testing.T) {
+	setupTests(t)
+}
+
+func returnDowngradeAccountReqForTest(t *testing.T, object downgradeAccountObject) (downgradeAccountReq, models.Account) {
+	object.Timestamp = time.Now().Unix()
+	v, b, _ := returnValidVerificationAndRequestBodyWithRandomPrivateKey(t, object)
+
+	accountID, _ := utils.HashString(v.PublicKey)
+	account := CreatePaidAccountForTest(t, accountID)
+	account.StorageLimit = models.ProfessionalStorageLimit
+	account.CreatedAt = time.Now().AddDate(0, -6, 0)
+	models.DB.Save(&account)
+
+	return downgradeAccountReq{verification: v, requestBody: b}, account
+}
+
+func Test_DowngradeAccountHandler_Immediately_With_Credit_As_Time(t *testing.T) {
+	models.DeleteAccountsForTest(t)
+	models.DeleteDowngradesForTest(t)
+
+	req, account := returnDowngradeAccountReqForTest(t, downgradeAccountObject{
+		ApplyAt:      DowngradeImmediately,
+		Credit:       DowngradeCreditAsTime,
+		StorageLimit: int(models.BasicStorageLimit),
+	})
+
+	w := httpPostRequestHelperForTest(t, AccountDowngradePath, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"Downgraded"`)
+
+	accountFromDB, _ := models.GetAccountById(account.AccountID)
+	assert.Equal(t, models.BasicStorageLimit, accountFromDB.StorageLimit)
+	assert.Equal(t, 54, accountFromDB.MonthsInSubscription)
+}
+
+func Test_DowngradeAccountHandler_At_Renewal(t *testing.T) {
+	models.DeleteAccountsForTest(t)
+	models.DeleteDowngradesForTest(t)
+
+	req, account := returnDowngradeAccountReqForTest(t, downgradeAccountObject{
+		ApplyAt:      DowngradeAtRenewal,
+		StorageLimit: int(models.BasicStorageLimit),
+	})
+
+	w := httpPostRequestHelperForTest(t, AccountDowngradePath, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"Scheduled"`)
+
+	accountFromDB, _ := models.GetAccountById(account.AccountID)
+	assert.Equal(t, models.ProfessionalStorageLimit, accountFromDB.StorageLimit)
+	downgrade, err := models.GetScheduledDowngrade(account.AccountID)
+	assert.Nil(t, err)
+	assert.Equal(t, models.BasicStorageLimit, downgrade.NewStorageLimit)
+}
+
+func Test_DowngradeAccountHandler_Rejects_Larger_Plan(t *testing.T) {
+	models.DeleteAccountsForTest(t)
+	models.DeleteDowngradesForTest(t)
+
+	req, _ := returnDowngradeAccountReqForTest(t, downgradeAccountObject{
+		ApplyAt:      DowngradeAtRenewal,
+		StorageLimit: 2048,
+	})
+
+	w := httpPostRequestHelperForTest(t, AccountDowngradePath, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), downgradeStorageLimitError)
+}
+
+func Test_DowngradeAccountHandler_Rejects_Account_That_Does_Not_Fit(t *testing.T) {
+	models.DeleteAccountsForTest(t)
+	models.DeleteDowngradesForTest(t)
+
+	req, account := returnDowngradeAccountReqForTest(t, downgradeAccountObject{
+		ApplyAt:      DowngradeImmediately,
+		Credit:       DowngradeCreditAsBalance,
+		StorageLimit: int(models.BasicStorageLimit),
+	})
+	account.TotalFolders = 3000
+	models.DB.Save(&account)
+
+	w := httpPostRequestHelperForTest(t, AccountDowngradePath, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), models.ErrDowngradeTooManyFolders.Error())
+}
+
+func Test_CancelDowngradeHandler(t *testing.T) {
+	models.DeleteAccountsForTest(t)
+	models.DeleteDowngradesForTest(t)
+
+	v, b, _ := returnValidVerificationAndRequestBodyWithRandomPrivateKey(t,
+		cancelDowngradeObject{Timestamp: time.Now().Unix()})
+	req := cancelDowngradeReq{verification: v, requestBody: b}
+
+	accountID, _ := utils.HashString(v.PublicKey)
+	account := CreatePaidAccountForTest(t, accountID)
+	account.StorageLimit = models.ProfessionalStorageLimit
+	models.DB.Save(&account)
+
+	w := httpPostRequestHelperForTest(t, AccountDowngradeCancelPath, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	_, err := account.ScheduleDowngrade(int(models.BasicStorageLimit))
+	assert.Nil(t, err)
+
+	w = httpPostRequestHelperForTest(t, AccountDowngradeCancelPath, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
@@ -27,8 +27,10 @@ type downloadFileRes struct {
 // @Param downloadFileObj body routes.downloadFileObj true "download object for non-signed requests"
 // @Success 200 {object} routes.downloadFileRes
 // @Failure 400 {string} string "bad request, unable to parse request body: (with the error)"
+// @Failure 403 {string} string "the account the file belongs to is suspended and must be renewed"
 // @Failure 404 {string} string "such data does not exist"
 // @Failure 500 {string} string "some information about the internal error"
+// @Failure 429 {string} string "too many requests, try again later"
 // @Router /api/v1/download [post]
 /*DownloadFileHandler handles the downloading of a file without cryptographic verification*/
 func DownloadFileHandler() gin.HandlerFunc {
@@ -42,6 +44,10 @@ func downloadFile(c *gin.Context) error {
 		err = fmt.Errorf("bad request, unable to parse request body:  %v", err)
 		return BadRequestResponse(c, err)
 	}
+	if err := verifyFileAccountState(request.FileID, c); err != nil {
+		return err
+	}
+
 	// verify object existed in S3
 	if !utils.DoesDefaultBucketObjectExist(models.GetFileDataKey(request.FileID)) {
 		return NotFoundResponse(c, errors.New("such data does not exist"))
@@ -95,6 +95,7 @@ func initFileUploadWithRequest(request InitFileUploadReq, c *gin.Context) error
 		AwsObjectKey: objKey,
 		ExpiredAt:    account.ExpirationDate(),
 		ModifierHash: modifierHash,
+		AccountID:    account.AccountID,
 	}
 
 	if err := models.DB.Create(&file).Error; err != nil {
@@ -107,8 +108,11 @@ func initFileUploadWithRequest(request InitFileUploadReq, c *gin.Context) error
 }
 
 func checkHaveEnoughStorageSpace(account models.Account, fileSizeInByte int64, c *gin.Context) error {
-	plannedInGB := (float64(fileSizeInByte) + float64(account.StorageUsedInByte)) / 1e9
-	if plannedInGB > float64(account.StorageLimit) {
+	hasSpace, err := account.HasStorageSpaceFor(fileSizeInByte)
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+	if !hasSpace {
 		return AccountNotEnoughSpaceResponse(c)
 	}
 	return nil
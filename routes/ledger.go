@@ -0,0 +1,147 @@
+package routes
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/utils"
+)
+
+const (
+	ledgerRefundAmountError = "a refund needs an amountInOPCT or an amountInUSD above 0"
+	ledgerBeforeError       = "before must be a ledger entry id"
+)
+
+// must be sorted alphabetically for JSON marshaling/stringifying
+type getLedgerObject struct {
+	BeforeID  uint  `json:"beforeID" binding:"omitempty,gte=0" example:"0"`
+	Limit     int   `json:"limit" binding:"omitempty,gte=0,lte=500" maximum:"500" example:"100"`
+	Timestamp int64 `json:"timestamp" binding:"required"`
+}
+
+type getLedgerReq struct {
+	verification
+	requestBody
+	getLedgerObject getLedgerObject
+}
+
+type getLedgerRes struct {
+	Entries      []models.LedgerEntry `json:"entries"`
+	NextBeforeID uint                 `json:"nextBeforeID" example:"0"`
+}
+
+func (v *getLedgerReq) getObjectRef() interface{} {
+	return &v.getLedgerObject
+}
+
+// GetLedgerHandler godoc
+// @Summary get an account's invoices and payments
+// @Accept  json
+// @Produce  json
+// @Param getLedgerReq body routes.getLedgerReq true "get ledger object"
+// @description Returns the account's invoices, payments, credits, refunds and plan changes, newest first.
+// @description Entries are kept after the invoices they came from expire.  Pass nextBeforeID as beforeID to get
+// @description the next page, until no entries come back.
+// @description requestBody should be a stringified version of (values are just examples):
+// @description {
+// @description 	"beforeID": 0,
+// @description 	"limit": 100,
+// @description 	"timestamp": 1557346389
+// @description }
+// @Success 200 {object} routes.getLedgerRes
+// @Failure 400 {string} string "bad request, unable to parse request body: (with the error)"
+// @Failure 403 {string} string "signature did not match"
+// @Failure 404 {string} string "no account with that id: (with your accountID)"
+// @Failure 500 {string} string "some information about the internal error"
+// @Router /api/v1/account/ledger [post]
+/*GetLedgerHandler is a handler for getting an account's invoice and payment history*/
+func GetLedgerHandler() gin.HandlerFunc {
+	return ginHandlerFunc(getLedger)
+}
+
+/*AdminLedgerHandler is a handler for browsing an account's ledger*/
+func AdminLedgerHandler() gin.HandlerFunc {
+	return ginHandlerFunc(adminLedger)
+}
+
+/*AdminRecordRefundHandler is a handler for recording a refund made outside the node*/
+func AdminRecordRefundHandler() gin.HandlerFunc {
+	return ginHandlerFunc(adminRecordRefund)
+}
+
+func getLedger(c *gin.Context) error {
+	request := getLedgerReq{}
+	if err := verifyAndParseBodyRequest(&request, c); err != nil {
+		return err
+	}
+
+	account, err := request.getAccount(c)
+	if err != nil {
+		return err
+	}
+
+	entries, err := models.GetLedgerEntries(account.AccountID, request.getLedgerObject.BeforeID,
+		request.getLedgerObject.Limit)
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+
+	res := getLedgerRes{Entries: entries}
+	if len(entries) > 0 {
+		res.NextBeforeID = entries[len(entries)-1].ID
+	}
+	return OkResponse(c, res)
+}
+
+/*adminLedger returns the ledger of the accountID query param, a page at a time like the admin audit log*/
+func adminLedger(c *gin.Context) error {
+	var beforeID uint64
+	if before := c.Query("before"); before != "" {
+		var err error
+		if beforeID, err = strconv.ParseUint(before, 10, 64); err != nil {
+			return BadRequestResponse(c, errors.New(ledgerBeforeError))
+		}
+	}
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	entries, err := models.GetLedgerEntries(c.Query("accountID"), uint(beforeID), limit)
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+	return OkResponse(c, entries)
+}
+
+/*adminRecordRefund takes the accountID, amountInOPCT, amountInUSD, reference and description form values.  The
+refund itself is made in Stripe or from the node's wallet, this only records it.*/
+func adminRecordRefund(c *gin.Context) error {
+	defer c.Request.Body.Close()
+
+	var amountInOPCT, amountInUSD float64
+	if err := utils.ReturnFirstError([]error{
+		parseFloatFormValue(c, "amountInOPCT", &amountInOPCT),
+		parseFloatFormValue(c, "amountInUSD", &amountInUSD),
+	}); err != nil {
+		return BadRequestResponse(c, err)
+	}
+	if amountInOPCT < 0 || amountInUSD < 0 || amountInOPCT+amountInUSD == 0 {
+		return BadRequestResponse(c, errors.New(ledgerRefundAmountError))
+	}
+
+	account, err := models.GetAccountById(c.Request.FormValue("accountID"))
+	if gorm.IsRecordNotFoundError(err) {
+		return NotFoundResponse(c, errors.New(noAccountWithThatID))
+	}
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+
+	entry, err := account.RecordRefund(amountInOPCT, amountInUSD, c.Request.FormValue("reference"),
+		c.Request.FormValue("description"))
+	if err != nil {
+		return BadRequestResponse(c, err)
+	}
+	return OkResponse(c, entry)
+}
@@ -0,0 +1,55 @@
+package routes
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Init_Ledger(t *testing.T) {
+	setupTests(t)
+}
+
+func Test_GetLedgerHandler_Returns_The_Accounts_Entries(t *testing.T) {
+	models.DeleteAccountsForTest(t)
+	models.DeleteLedgerEntriesForTest(t)
+
+	v, b, _ := returnValidVerificationAndRequestBodyWithRandomPrivateKey(t, getLedgerObject{
+		Timestamp: time.Now().Unix(),
+	})
+	accountID, _ := utils.HashString(v.PublicKey)
+	account := CreatePaidAccountForTest(t, accountID)
+	assert.Nil(t, account.RecordInvoice())
+	other := CreatePaidAccountForTest(t, utils.RandHexString(64))
+	assert.Nil(t, other.RecordInvoice())
+
+	w := httpPostRequestHelperForTest(t, AccountLedgerPath, getLedgerReq{verification: v, requestBody: b})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"type":"invoice"`)
+	assert.Contains(t, w.Body.String(), account.EthAddress)
+	assert.NotContains(t, w.Body.String(), other.EthAddress)
+}
+
+func Test_GetLedgerHandler_After_Renewal_Rows_Are_Purged(t *testing.T) {
+	models.DeleteAccountsForTest(t)
+	models.DeleteLedgerEntriesForTest(t)
+	models.DeleteRenewalsForTest(t)
+
+	v, b, _ := returnValidVerificationAndRequestBodyWithRandomPrivateKey(t, getLedgerObject{
+		Timestamp: time.Now().Unix(),
+	})
+	accountID, _ := utils.HashString(v.PublicKey)
+	account := CreatePaidAccountForTest(t, accountID)
+	renewal := CreateRenewalForTest(t, account)
+	assert.Nil(t, renewal.RecordInvoice(account))
+	assert.Nil(t, models.PurgeOldRenewals(-1))
+
+	w := httpPostRequestHelperForTest(t, AccountLedgerPath, getLedgerReq{verification: v, requestBody: b})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"description":"renewal"`)
+	assert.Contains(t, w.Body.String(), renewal.EthAddress)
+}
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/dgraph-io/badger"
 	"github.com/gin-gonic/gin"
 	"github.com/opacity/storage-node/models"
 	"github.com/opacity/storage-node/utils"
@@ -162,7 +161,7 @@ func CreateMetadataHandler() gin.HandlerFunc {
 // @description 	"timestamp": 1557346389
 // @description }
 // @Success 200 {object} routes.StatusRes
-// @Failure 404 {string} string "account not found"
+// @Failure 404 {string} string "no value found for that key, or account not found"
 // @Failure 403 {string} string "subscription expired, or the invoice resonse"
 // @Failure 400 {string} string "bad request, unable to parse request body: (with the error)"
 // @Failure 500 {string} string "some information about the internal error"
@@ -173,122 +172,30 @@ func DeleteMetadataHandler() gin.HandlerFunc {
 }
 
 func getMetadata(c *gin.Context) error {
-	request := metadataKeyReq{}
-
-	if err := verifyAndParseBodyRequest(&request, c); err != nil {
-		return err
-	}
-
-	account, err := request.getAccount(c)
+	_, res, err := getCurrentMetadata(true, c)
 	if err != nil {
 		return err
 	}
 
-	if paid := verifyIfPaid(account); !paid {
-		cost, _ := account.Cost()
-		return AccountNotPaidResponse(c, accountCreateRes{
-			Invoice: models.Invoice{
-				Cost:       cost,
-				EthAddress: account.EthAddress,
-			},
-			ExpirationDate: account.ExpirationDate(),
-		})
-	}
-
-	requestBodyParsed := metadataKeyObject{}
-
-	if err := verifyAndParseStringRequest(request.RequestBody, &requestBodyParsed, request.verification, c); err != nil {
-		return err
-	}
-
-	permissionHashKey := getPermissionHashKeyForBadger(requestBodyParsed.MetadataKey)
-	permissionHashInBadger, _, err := utils.GetValueFromKV(permissionHashKey)
-
-	if err != nil {
-		// TODO: Enable this after everyone should have migrated.
-		// cannot enable it now since many users already created metadatas without permission hashes
-		// being stored
-		//
-		//return NotFoundResponse(c, err)
-	}
-
-	// TODO remove this if block wrapping the other if after everyone should have migrated
-	// This is only in effect for a limited time because many users already created metadatas without
-	// permission hashes being stored
-	if permissionHashInBadger != "" {
-		if err := verifyPermissions(request.PublicKey, requestBodyParsed.MetadataKey,
-			permissionHashInBadger, c); err != nil {
-			return err
-		}
-	}
-
-	metadata, expirationTime, err := utils.GetValueFromKV(request.metadataKeyObject.MetadataKey)
-
-	if err != nil {
-		return NotFoundResponse(c, err)
-	}
-
-	return OkResponse(c, getMetadataRes{
-		Metadata:       metadata,
-		ExpirationDate: expirationTime,
-	})
+	return OkResponse(c, res)
 }
 
 func getMetadataHistory(c *gin.Context) error {
-	request := metadataKeyReq{}
-
-	if err := verifyAndParseBodyRequest(&request, c); err != nil {
-		return err
-	}
-
-	account, err := request.getAccount(c)
-	if err != nil {
-		return err
-	}
-
-	if paid := verifyIfPaid(account); !paid {
-		cost, _ := account.Cost()
-		return AccountNotPaidResponse(c, accountCreateRes{
-			Invoice: models.Invoice{
-				Cost:       cost,
-				EthAddress: account.EthAddress,
-			},
-			ExpirationDate: account.ExpirationDate(),
-		})
-	}
-
-	requestBodyParsed := metadataKeyObject{}
-
-	if err := verifyAndParseStringRequest(request.RequestBody, &requestBodyParsed, request.verification, c); err != nil {
-		return err
-	}
-
-	permissionHashKey := getPermissionHashKeyForBadger(requestBodyParsed.MetadataKey)
-	permissionHashInBadger, _, err := utils.GetValueFromKV(permissionHashKey)
-
+	// history has never been readable without a permission hash
+	metadataKey, res, err := getCurrentMetadata(false, c)
 	if err != nil {
-		return NotFoundResponse(c, err)
-	}
-
-	if err := verifyPermissions(request.PublicKey, requestBodyParsed.MetadataKey,
-		permissionHashInBadger, c); err != nil {
 		return err
 	}
 
-	currentMetadata, expirationTime, err := utils.GetValueFromKV(request.metadataKeyObject.MetadataKey)
-	if err != nil {
-		return NotFoundResponse(c, err)
-	}
-
-	metadataHistory, err := getMetadataHistoryWithoutContext(request.metadataKeyObject.MetadataKey)
+	metadataHistory, err := getMetadataHistoryWithoutContext(metadataKey)
 	if err != nil {
 		return InternalErrorResponse(c, err)
 	}
 
 	return OkResponse(c, getMetadataHistoryRes{
-		Metadata:        currentMetadata,
+		Metadata:        res.Metadata,
 		MetadataHistory: metadataHistory,
-		ExpirationDate:  expirationTime,
+		ExpirationDate:  res.ExpirationDate,
 	})
 }
 
@@ -335,7 +242,7 @@ func setMetadata(c *gin.Context) error {
 		return ForbiddenResponse(c, err)
 	}
 
-	ttl := time.Until(account.ExpirationDate())
+	ttl := time.Until(account.ExpirationDate().Add(models.MetadataExpirationGracePeriod()))
 
 	if err := utils.BatchSet(&utils.KVPairs{
 		requestBodyParsed.MetadataKey: requestBodyParsed.Metadata,
@@ -348,6 +255,10 @@ func setMetadata(c *gin.Context) error {
 		return err
 	}
 
+	if err := indexMetadataKey(account.AccountID, requestBodyParsed.MetadataKey); err != nil {
+		return InternalErrorResponse(c, err)
+	}
+
 	return OkResponse(c, updateMetadataRes{
 		MetadataKey:    request.updateMetadataObject.MetadataKey,
 		Metadata:       request.updateMetadataObject.Metadata,
@@ -378,7 +289,7 @@ func createMetadata(c *gin.Context) error {
 		return err
 	}
 
-	ttl := time.Until(account.ExpirationDate())
+	ttl := time.Until(account.ExpirationDate().Add(models.MetadataExpirationGracePeriod()))
 
 	permissionHash, err := getPermissionHash(request.PublicKey, requestBodyParsed.MetadataKey, c)
 	if err != nil {
@@ -405,6 +316,10 @@ func createMetadata(c *gin.Context) error {
 		return InternalErrorResponse(c, err)
 	}
 
+	if err := indexMetadataKey(account.AccountID, requestBodyParsed.MetadataKey); err != nil {
+		return InternalErrorResponse(c, err)
+	}
+
 	return OkResponse(c, createMetadataRes{
 		ExpirationDate: account.ExpirationDate(),
 	})
@@ -434,35 +349,19 @@ func deleteMetadata(c *gin.Context) error {
 	}
 
 	permissionHashKey := getPermissionHashKeyForBadger(requestBodyParsed.MetadataKey)
-	permissionHashInBadger, _, err := utils.GetValueFromKV(permissionHashKey)
-
+	permissionHashInBadger, err := verifyMetadataPermission(request.PublicKey, requestBodyParsed.MetadataKey,
+		true, c)
 	if err != nil {
-		// TODO: Enable this after everyone should have migrated.
-		// cannot enable it now since many users already created metadatas without permission hashes
-		// being stored
-		//
-		//return NotFoundResponse(c, err)
-	}
-
-	// TODO remove this if block wrapping the other if after everyone should have migrated
-	// This is only in effect for a limited time because many users already created metadatas without
-	// permission hashes being stored
-	if permissionHashInBadger != "" {
-		if err := verifyPermissions(request.PublicKey, requestBodyParsed.MetadataKey,
-			permissionHashInBadger, c); err != nil {
-			return err
-		}
+		return err
 	}
 
 	oldMetadata, _, err := utils.GetValueFromKV(requestBodyParsed.MetadataKey)
+	if err != nil {
+		return NotFoundResponse(c, err)
+	}
 
-	// TODO remove this if block wrapping the other if after everyone should have migrated
-	// This is only in effect for a limited time because many users already created metadatas without
-	// permission hashes being stored
-	if permissionHashInBadger != "" {
-		if err := account.RemoveMetadata(int64(len(oldMetadata))); err != nil {
-			return InternalErrorResponse(c, err)
-		}
+	if err := removeMetadataFromAccount(&account, oldMetadata, permissionHashInBadger != ""); err != nil {
+		return InternalErrorResponse(c, err)
 	}
 
 	if err = utils.BatchDelete(&utils.KVKeys{
@@ -472,6 +371,9 @@ func deleteMetadata(c *gin.Context) error {
 		return InternalErrorResponse(c, err)
 	}
 
+	err = models.RemoveAccountMetadataKey(account.AccountID, requestBodyParsed.MetadataKey)
+	utils.LogIfError(err, map[string]interface{}{"accountID": account.AccountID})
+
 	return OkResponse(c, metadataDeletedRes)
 }
 
@@ -489,7 +391,7 @@ func storeMetadataHistory(metadataKey string, oldMetadata string, ttl time.Durat
 		}, ttl); err != nil {
 			return InternalErrorResponse(c, err)
 		}
-		if err == badger.ErrKeyNotFound {
+		if err == utils.ErrKeyNotFound {
 			stopOnNextKey = true
 		}
 		newValue = oldValue
@@ -501,7 +403,7 @@ func getMetadataHistoryWithoutContext(metadataKey string) ([]string, error) {
 	metadataHistory := []string{}
 	for i := 0; i < numMetadatasToRetain; i++ {
 		oldMetadata, _, err := utils.GetValueFromKV(getVersionKeyForBadger(metadataKey, i))
-		if err == badger.ErrKeyNotFound {
+		if err == utils.ErrKeyNotFound {
 			break
 		}
 		if err != nil {
@@ -512,8 +414,90 @@ func getMetadataHistoryWithoutContext(metadataKey string) ([]string, error) {
 	return metadataHistory, nil
 }
 
-func getCurrentMetadata() {
-	// TODO:  After everyone has migrated, consolidate the first parts of getMetadata and
-	// getMetadataHistory since they are very similar except for the exceptions for accounts
-	// that haven't been migrated
+/*getCurrentMetadata is the shared first part of getMetadata and getMetadataHistory.  It verifies the request,
+that the account has paid and that the caller may read the metadata, then returns the metadata key and the
+current metadata.  Set allowLegacy to let metadata without a permission hash be read until the cutoff.*/
+func getCurrentMetadata(allowLegacy bool, c *gin.Context) (string, getMetadataRes, error) {
+	request := metadataKeyReq{}
+
+	if err := verifyAndParseBodyRequest(&request, c); err != nil {
+		return "", getMetadataRes{}, err
+	}
+
+	account, err := request.getAccount(c)
+	if err != nil {
+		return "", getMetadataRes{}, err
+	}
+
+	if paid := verifyIfPaid(account); !paid {
+		return "", getMetadataRes{}, AccountNotPaidResponse(c, accountInvoiceResponse(account))
+	}
+
+	metadataKey := request.metadataKeyObject.MetadataKey
+	permissionHashInBadger, err := verifyMetadataPermission(request.PublicKey, metadataKey, allowLegacy, c)
+	if err != nil {
+		return "", getMetadataRes{}, err
+	}
+
+	metadata, expirationTime, err := utils.GetValueFromKV(metadataKey)
+	if err != nil {
+		return "", getMetadataRes{}, NotFoundResponse(c, err)
+	}
+
+	// metadata created before the per account index existed gets indexed the next time its owner reads it
+	if permissionHashInBadger != "" {
+		if err := indexMetadataKey(account.AccountID, metadataKey); err != nil {
+			return "", getMetadataRes{}, InternalErrorResponse(c, err)
+		}
+	}
+
+	return metadataKey, getMetadataRes{
+		Metadata:       metadata,
+		ExpirationDate: expirationTime,
+	}, nil
+}
+
+/*verifyMetadataPermission checks the caller's permission hash for a metadata key and returns the stored
+permission hash.  Metadata created before permission hashes were stored has none.  If allowLegacy is set, it
+stays open to anyone with its key until utils.MetadataPermissionHashesEnforced, in which case the returned hash
+is empty.  Otherwise it is not found.*/
+func verifyMetadataPermission(publicKey, metadataKey string, allowLegacy bool, c *gin.Context) (string, error) {
+	permissionHashInBadger, _, err := utils.GetValueFromKV(getPermissionHashKeyForBadger(metadataKey))
+
+	if err == utils.ErrKeyNotFound {
+		if !allowLegacy || utils.MetadataPermissionHashesEnforced() {
+			return "", NotFoundResponse(c, err)
+		}
+		return "", nil
+	}
+	if err != nil {
+		return "", InternalErrorResponse(c, err)
+	}
+
+	return permissionHashInBadger, verifyPermissions(publicKey, metadataKey, permissionHashInBadger, c)
+}
+
+/*removeMetadataFromAccount takes a deleted metadata off the account's folder count and metadata size.
+Metadata without a permission hash may predate that bookkeeping, so it never takes either below 0.*/
+func removeMetadataFromAccount(account *models.Account, oldMetadata string, hasPermissionHash bool) error {
+	oldMetadataSizeInBytes := int64(len(oldMetadata))
+	if hasPermissionHash {
+		return account.RemoveMetadata(oldMetadataSizeInBytes)
+	}
+
+	if oldMetadataSizeInBytes > account.TotalMetadataSizeInBytes {
+		oldMetadataSizeInBytes = account.TotalMetadataSizeInBytes
+	}
+	if !account.CanRemoveMetadata() {
+		return account.UpdateMetadataSizeInBytes(oldMetadataSizeInBytes, 0)
+	}
+	return account.RemoveMetadata(oldMetadataSizeInBytes)
+}
+
+/*indexMetadataKey adds a metadata key to the account's index of metadata keys.  Export, expiration extensions,
+key rotation and account deletion only find metadata through the index, so a request that can't update it fails.*/
+func indexMetadataKey(accountID, metadataKey string) error {
+	err := models.AddAccountMetadataKey(accountID, metadataKey)
+	utils.LogIfError(err, map[string]interface{}{"accountID": accountID, "metadataKey": metadataKey})
+	return err
 }
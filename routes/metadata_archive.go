@@ -0,0 +1,357 @@
+package routes
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/utils"
+)
+
+const metadataArchiveVersion = 1
+
+const metadataArchiveContentType = "application/x-ndjson"
+
+// must be sorted alphabetically for JSON marshaling/stringifying
+type metadataExportObject struct {
+	Timestamp int64 `json:"timestamp" binding:"required"`
+}
+
+type metadataExportReq struct {
+	verification
+	requestBody
+	metadataExportObject metadataExportObject
+}
+
+// must be sorted alphabetically for JSON marshaling/stringifying
+type metadataImportObject struct {
+	ArchiveHash string `json:"archiveHash" binding:"required,len=64" example:"a 64-char hex keccak256 hash of the archive file"`
+	Timestamp   int64  `json:"timestamp" binding:"required"`
+}
+
+type metadataImportReq struct {
+	verification
+	requestBody
+	Archive              string `formFile:"archive" binding:"required" example:"an archive file returned by /api/v1/metadata/export"`
+	metadataImportObject metadataImportObject
+}
+
+/*metadataArchiveHeader is the first line of a metadata archive*/
+type metadataArchiveHeader struct {
+	ArchiveVersion int       `json:"archiveVersion"`
+	ExportedAt     time.Time `json:"exportedAt"`
+}
+
+/*metadataArchiveEntry is every line of a metadata archive after the header, one per metadata*/
+type metadataArchiveEntry struct {
+	MetadataKey     string   `json:"metadataKey" binding:"required,len=64"`
+	Metadata        string   `json:"metadata"`
+	MetadataHistory []string `json:"metadataHistory" binding:"max=5"`
+	PermissionHash  string   `json:"permissionHash"`
+}
+
+type metadataImportRes struct {
+	Imported       int       `json:"imported" example:"12"`
+	Overwritten    int       `json:"overwritten" example:"2"`
+	Conflicts      []string  `json:"conflicts" example:"metadata keys that already belong to someone else and were skipped"`
+	ExpirationDate time.Time `json:"expirationDate"`
+}
+
+func (v *metadataExportReq) getObjectRef() interface{} {
+	return &v.metadataExportObject
+}
+
+func (v *metadataImportReq) getObjectRef() interface{} {
+	return &v.metadataImportObject
+}
+
+// ExportMetadataHandler godoc
+// @Summary export all of an account's metadata
+// @Accept  json
+// @Produce  application/x-ndjson
+// @Param metadataExportReq body routes.metadataExportReq true "metadata export object"
+// @description Streams every metadata the account owns as newline delimited JSON.  The first line is a header
+// @description with the archiveVersion and exportedAt, every line after it holds the metadataKey, metadata,
+// @description metadataHistory and permissionHash of one metadata.
+// @description requestBody should be a stringified version of (values are just examples):
+// @description {
+// @description 	"timestamp": 1557346389
+// @description }
+// @Success 200 {string} string "the metadata archive"
+// @Failure 400 {string} string "bad request, unable to parse request body: (with the error)"
+// @Failure 404 {string} string "account not found"
+// @Failure 403 {string} string "subscription expired, or the invoice response"
+// @Failure 500 {string} string "some information about the internal error"
+// @Router /api/v1/metadata/export [post]
+/*ExportMetadataHandler is a handler for exporting an account's metadata*/
+func ExportMetadataHandler() gin.HandlerFunc {
+	return ginHandlerFunc(exportMetadata)
+}
+
+// ImportMetadataHandler godoc
+// @Summary import a metadata archive into an account
+// @Accept  mpfd
+// @Produce  json
+// @Param metadataImportReq body routes.metadataImportReq true "metadata import object"
+// @description Imports an archive created by /api/v1/metadata/export.  Metadata is stored with a permission hash
+// @description for the signing public key.  Metadata that already exists is overwritten if it belongs to the
+// @description signing public key and reported as a conflict otherwise.
+// @description requestBody should be a stringified version of (values are just examples):
+// @description {
+// @description 	"archiveHash": "a 64-char hex keccak256 hash of the archive file",
+// @description 	"timestamp": 1557346389
+// @description }
+// @Success 200 {object} routes.metadataImportRes
+// @Failure 400 {string} string "bad request, unable to parse request body or archive: (with the error)"
+// @Failure 404 {string} string "account not found"
+// @Failure 403 {string} string "subscription expired, the invoice response, or the archive does not fit in the account's plan"
+// @Failure 500 {string} string "some information about the internal error"
+// @Failure 503 {string} string "maintenance in progress, currently rejecting writes"
+// @Router /api/v1/metadata/import [post]
+/*ImportMetadataHandler is a handler for importing a metadata archive*/
+func ImportMetadataHandler() gin.HandlerFunc {
+	return ginHandlerFunc(importMetadata)
+}
+
+func exportMetadata(c *gin.Context) error {
+	request := metadataExportReq{}
+
+	if err := verifyAndParseBodyRequest(&request, c); err != nil {
+		return err
+	}
+
+	account, err := request.getAccount(c)
+	if err != nil {
+		return err
+	}
+
+	if err := verifyIfPaidWithContext(account, c); err != nil {
+		return err
+	}
+
+	metadataKeys, err := models.GetMetadataKeysByAccountID(account.AccountID)
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+
+	c.Header("Content-Type", metadataArchiveContentType)
+	c.Header("Content-Disposition", `attachment; filename="metadata-archive.ndjson"`)
+	c.Status(http.StatusOK)
+	utils.Metrics_200_Response_Counter.Inc()
+
+	encoder := json.NewEncoder(c.Writer)
+	if err := encoder.Encode(metadataArchiveHeader{
+		ArchiveVersion: metadataArchiveVersion,
+		ExportedAt:     time.Now(),
+	}); err != nil {
+		utils.LogIfError(err, map[string]interface{}{"accountID": account.AccountID})
+		return nil
+	}
+
+	for _, metadataKey := range metadataKeys {
+		entry, ok, err := getMetadataArchiveEntry(ownerPublicKey(request.PublicKey, c), metadataKey)
+		if err == nil && ok {
+			err = encoder.Encode(entry)
+		}
+		if err != nil {
+			// the headers are already sent, all we can do is log and cut the stream short
+			utils.LogIfError(err, map[string]interface{}{"accountID": account.AccountID,
+				"metadataKey": metadataKey})
+			return nil
+		}
+	}
+	return nil
+}
+
+func importMetadata(c *gin.Context) error {
+	if !utils.WritesEnabled() {
+		return ServiceUnavailableResponse(c, maintenanceError)
+	}
+
+	request := metadataImportReq{}
+
+	if err := verifyAndParseFormRequest(&request, c); err != nil {
+		return err
+	}
+
+	// the signature only covers the request body, so that has to pin down the archive
+	if hex.EncodeToString(utils.Hash([]byte(request.Archive))) != strings.ToLower(request.metadataImportObject.ArchiveHash) {
+		return BadRequestResponse(c, errors.New("archiveHash does not match the archive"))
+	}
+
+	account, err := request.getAccount(c)
+	if err != nil {
+		return err
+	}
+
+	if err := verifyIfPaidWithContext(account, c); err != nil {
+		return err
+	}
+
+	entries, err := readMetadataArchive(strings.NewReader(request.Archive))
+	if err != nil {
+		return BadRequestResponse(c, fmt.Errorf("bad request, unable to parse archive: %v", err))
+	}
+
+	res := metadataImportRes{
+		Conflicts:      []string{},
+		ExpirationDate: account.ExpirationDate(),
+	}
+	var toImport []metadataArchiveEntry
+	var oldMetadataSizeInBytes, newMetadataSizeInBytes int64
+	newMetadatas := 0
+	for _, entry := range entries {
+		oldMetadata, _, err := utils.GetValueFromKV(entry.MetadataKey)
+		if err == utils.ErrKeyNotFound {
+			newMetadatas++
+		} else if err != nil {
+			return InternalErrorResponse(c, err)
+		} else if owned, err := ownsMetadata(ownerPublicKey(request.PublicKey, c), entry.MetadataKey); err != nil {
+			return InternalErrorResponse(c, err)
+		} else if !owned {
+			res.Conflicts = append(res.Conflicts, entry.MetadataKey)
+			continue
+		} else {
+			res.Overwritten++
+			oldMetadataSizeInBytes += int64(len(oldMetadata))
+		}
+		newMetadataSizeInBytes += int64(len(entry.Metadata))
+		toImport = append(toImport, entry)
+	}
+
+	if err := account.AddImportedMetadatas(newMetadatas, oldMetadataSizeInBytes, newMetadataSizeInBytes); err != nil {
+		return ForbiddenResponse(c, err)
+	}
+
+	ttl := time.Until(account.ExpirationDate().Add(models.MetadataExpirationGracePeriod()))
+	if err := writeMetadataArchiveEntries(ownerPublicKey(request.PublicKey, c), toImport, ttl); err != nil {
+		revertErr := account.AddImportedMetadatas(-newMetadatas, newMetadataSizeInBytes, oldMetadataSizeInBytes)
+		utils.LogIfError(revertErr, map[string]interface{}{"accountID": account.AccountID})
+		return InternalErrorResponse(c, err)
+	}
+
+	for _, entry := range toImport {
+		if err := indexMetadataKey(account.AccountID, entry.MetadataKey); err != nil {
+			return InternalErrorResponse(c, err)
+		}
+	}
+	res.Imported = len(toImport)
+
+	return OkResponse(c, res)
+}
+
+/*getMetadataArchiveEntry reads a metadata, its history and its permission hash.  It returns false if the
+metadata is gone or no longer belongs to publicKey, which is expected for index entries that went stale.*/
+func getMetadataArchiveEntry(publicKey, metadataKey string) (metadataArchiveEntry, bool, error) {
+	entry := metadataArchiveEntry{MetadataKey: metadataKey}
+
+	owned, err := ownsMetadata(publicKey, metadataKey)
+	if err != nil || !owned {
+		return entry, false, err
+	}
+	entry.PermissionHash, _, err = utils.GetValueFromKV(getPermissionHashKeyForBadger(metadataKey))
+	if err != nil {
+		return entry, false, err
+	}
+
+	entry.Metadata, _, err = utils.GetValueFromKV(metadataKey)
+	if err == utils.ErrKeyNotFound {
+		return entry, false, nil
+	}
+	if err != nil {
+		return entry, false, err
+	}
+
+	entry.MetadataHistory, err = getMetadataHistoryWithoutContext(metadataKey)
+	return entry, err == nil, err
+}
+
+/*ownsMetadata returns whether the stored permission hash of a metadata key is the one for publicKey*/
+func ownsMetadata(publicKey, metadataKey string) (bool, error) {
+	permissionHashInBadger, _, err := utils.GetValueFromKV(getPermissionHashKeyForBadger(metadataKey))
+	if err == utils.ErrKeyNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return hasPermission(publicKey, metadataKey, permissionHashInBadger)
+}
+
+/*readMetadataArchive parses and validates an archive written by exportMetadata*/
+func readMetadataArchive(r io.Reader) ([]metadataArchiveEntry, error) {
+	decoder := json.NewDecoder(r)
+
+	header := metadataArchiveHeader{}
+	if err := decoder.Decode(&header); err != nil {
+		return nil, err
+	}
+	if header.ArchiveVersion != metadataArchiveVersion {
+		return nil, fmt.Errorf("unsupported archiveVersion %d", header.ArchiveVersion)
+	}
+
+	var entries []metadataArchiveEntry
+	seen := make(map[string]bool)
+	for {
+		entry := metadataArchiveEntry{}
+		err := decoder.Decode(&entry)
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := utils.Validator.Struct(entry); err != nil {
+			return nil, err
+		}
+		if !isMetadataKey(entry.MetadataKey) {
+			return nil, fmt.Errorf("invalid metadataKey %s", entry.MetadataKey)
+		}
+		if seen[entry.MetadataKey] {
+			return nil, fmt.Errorf("duplicate metadataKey %s", entry.MetadataKey)
+		}
+		seen[entry.MetadataKey] = true
+		entries = append(entries, entry)
+	}
+}
+
+/*writeMetadataArchiveEntries stores imported metadata and its history under a permission hash for publicKey.
+The permission hash in the archive belongs to the exporting key, which may not be the importing one.*/
+func writeMetadataArchiveEntries(publicKey string, entries []metadataArchiveEntry, ttl time.Duration) error {
+	kvPairs := utils.KVPairs{}
+	kvKeys := utils.KVKeys{}
+	for _, entry := range entries {
+		permissionHash, err := utils.HashString(publicKey + entry.MetadataKey)
+		if err != nil {
+			return err
+		}
+		kvPairs[entry.MetadataKey] = entry.Metadata
+		kvPairs[getPermissionHashKeyForBadger(entry.MetadataKey)] = permissionHash
+		for i := 0; i < numMetadatasToRetain; i++ {
+			versionKey := getVersionKeyForBadger(entry.MetadataKey, i)
+			if i < len(entry.MetadataHistory) {
+				kvPairs[versionKey] = entry.MetadataHistory[i]
+			} else {
+				// drop whatever history an overwritten metadata had beyond the archive's
+				kvKeys = append(kvKeys, versionKey)
+			}
+		}
+	}
+
+	if len(kvKeys) > 0 {
+		if err := utils.BatchDelete(&kvKeys); err != nil {
+			return err
+		}
+	}
+	if len(kvPairs) == 0 {
+		return nil
+	}
+	return utils.BatchSet(&kvPairs, ttl)
+}
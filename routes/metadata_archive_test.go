@@ -0,0 +1,175 @@
+package routes
+
+import (
+	"crypto/ecdsa"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Init_Metadata_Archive(t *testing.T) {
+	setupTests(t)
+}
+
+func createIndexedMetadataForTest(t *testing.T, accountID string, privateKey *ecdsa.PrivateKey, metadata string,
+	history []string) string {
+	metadataKey := utils.RandSeqFromRunes(64, []rune("abcdef01234567890"))
+	permissionHash, err := utils.HashString(utils.PubkeyCompressedToHex(privateKey.PublicKey) + metadataKey)
+	assert.Nil(t, err)
+
+	kvPairs := utils.KVPairs{
+		metadataKey: metadata,
+		getPermissionHashKeyForBadger(metadataKey): permissionHash,
+	}
+	for i, oldMetadata := range history {
+		kvPairs[getVersionKeyForBadger(metadataKey, i)] = oldMetadata
+	}
+	assert.Nil(t, utils.BatchSet(&kvPairs, utils.TestValueTimeToLive))
+	assert.Nil(t, models.AddAccountMetadataKey(accountID, metadataKey))
+	return metadataKey
+}
+
+func returnMetadataImportReqForTest(t *testing.T, archive string, privateKey *ecdsa.PrivateKey) metadataImportReq {
+	importObj := metadataImportObject{
+		ArchiveHash: hex.EncodeToString(utils.Hash([]byte(archive))),
+		Timestamp:   time.Now().Unix(),
+	}
+	v, b := returnValidVerificationAndRequestBody(t, importObj, privateKey)
+	return metadataImportReq{
+		verification: v,
+		requestBody:  b,
+	}
+}
+
+func exportMetadataForTest(t *testing.T, privateKey *ecdsa.PrivateKey) string {
+	exportObj := metadataExportObject{
+		Timestamp: time.Now().Unix(),
+	}
+	v, b := returnValidVerificationAndRequestBody(t, exportObj, privateKey)
+
+	w := httpPostRequestHelperForTest(t, MetadataExportPath, metadataExportReq{
+		verification: v,
+		requestBody:  b,
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, metadataArchiveContentType, w.Header().Get("Content-Type"))
+	return w.Body.String()
+}
+
+func Test_ExportMetadata_Streams_Owned_Metadata(t *testing.T) {
+	accountID, privateKey := generateValidateAccountId(t)
+	CreatePaidAccountForTest(t, accountID)
+
+	metadataKey := createIndexedMetadataForTest(t, accountID, privateKey, "current", []string{"older", "oldest"})
+	// a stale index entry for metadata someone else now owns must not be exported
+	otherKey := utils.RandSeqFromRunes(64, []rune("abcdef01234567890"))
+	assert.Nil(t, utils.BatchSet(&utils.KVPairs{
+		otherKey:                                "not yours",
+		getPermissionHashKeyForBadger(otherKey): "someOtherPermissionHash",
+	}, utils.TestValueTimeToLive))
+	assert.Nil(t, models.AddAccountMetadataKey(accountID, otherKey))
+
+	entries, err := readMetadataArchive(strings.NewReader(exportMetadataForTest(t, privateKey)))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(entries))
+	assert.Equal(t, metadataKey, entries[0].MetadataKey)
+	assert.Equal(t, "current", entries[0].Metadata)
+	assert.Equal(t, []string{"older", "oldest"}, entries[0].MetadataHistory)
+}
+
+func Test_ImportMetadata_Into_Another_Account(t *testing.T) {
+	accountID, privateKey := generateValidateAccountId(t)
+	CreatePaidAccountForTest(t, accountID)
+	metadataKey := createIndexedMetadataForTest(t, accountID, privateKey, "current", []string{"older"})
+	archive := exportMetadataForTest(t, privateKey)
+
+	// the keys are moving, so clear them out of the old account first
+	assert.Nil(t, utils.BatchDelete(&utils.KVKeys{metadataKey, getPermissionHashKeyForBadger(metadataKey),
+		getVersionKeyForBadger(metadataKey, 0)}))
+
+	newAccountID, newPrivateKey := generateValidateAccountId(t)
+	CreatePaidAccountForTest(t, newAccountID)
+
+	w := httpPostFormRequestHelperForTest(t, MetadataImportPath, returnMetadataImportReqForTest(t, archive, newPrivateKey),
+		map[string]string{}, map[string]string{"archive": archive})
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"imported":1`)
+
+	metadata, _, err := utils.GetValueFromKV(metadataKey)
+	assert.Nil(t, err)
+	assert.Equal(t, "current", metadata)
+	history, err := getMetadataHistoryWithoutContext(metadataKey)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"older"}, history)
+
+	owned, err := ownsMetadata(utils.PubkeyCompressedToHex(newPrivateKey.PublicKey), metadataKey)
+	assert.Nil(t, err)
+	assert.True(t, owned)
+
+	account, _ := models.GetAccountById(newAccountID)
+	assert.Equal(t, 1, account.TotalFolders)
+	assert.Equal(t, int64(len("current")), account.TotalMetadataSizeInBytes)
+
+	metadataKeys, err := models.GetMetadataKeysByAccountID(newAccountID)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{metadataKey}, metadataKeys)
+}
+
+func Test_ImportMetadata_Reports_Conflicts(t *testing.T) {
+	accountID, privateKey := generateValidateAccountId(t)
+	CreatePaidAccountForTest(t, accountID)
+	metadataKey := createIndexedMetadataForTest(t, accountID, privateKey, "current", nil)
+	archive := exportMetadataForTest(t, privateKey)
+
+	newAccountID, newPrivateKey := generateValidateAccountId(t)
+	CreatePaidAccountForTest(t, newAccountID)
+
+	w := httpPostFormRequestHelperForTest(t, MetadataImportPath, returnMetadataImportReqForTest(t, archive, newPrivateKey),
+		map[string]string{}, map[string]string{"archive": archive})
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"imported":0`)
+	assert.Contains(t, w.Body.String(), metadataKey)
+
+	owned, err := ownsMetadata(utils.PubkeyCompressedToHex(privateKey.PublicKey), metadataKey)
+	assert.Nil(t, err)
+	assert.True(t, owned)
+}
+
+func Test_ImportMetadata_Fails_If_Archive_Hash_Does_Not_Match(t *testing.T) {
+	accountID, privateKey := generateValidateAccountId(t)
+	CreatePaidAccountForTest(t, accountID)
+
+	post := returnMetadataImportReqForTest(t, `{"archiveVersion":1}`, privateKey)
+	w := httpPostFormRequestHelperForTest(t, MetadataImportPath, post, map[string]string{},
+		map[string]string{"archive": `{"archiveVersion":1}` + "\n" + `{"metadataKey":"tampered"}`})
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "archiveHash does not match the archive")
+}
+
+func Test_ReadMetadataArchive(t *testing.T) {
+	metadataKey := utils.RandSeqFromRunes(64, []rune("abcdef01234567890"))
+	entry := `{"metadataKey":"` + metadataKey + `","metadata":"m","metadataHistory":["h"],"permissionHash":""}`
+
+	entries, err := readMetadataArchive(strings.NewReader(`{"archiveVersion":1}` + "\n" + entry + "\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(entries))
+	assert.Equal(t, []string{"h"}, entries[0].MetadataHistory)
+
+	_, err = readMetadataArchive(strings.NewReader(`{"archiveVersion":2}` + "\n" + entry))
+	assert.NotNil(t, err)
+
+	_, err = readMetadataArchive(strings.NewReader(`{"archiveVersion":1}` + "\n" + entry + "\n" + entry))
+	assert.NotNil(t, err)
+
+	_, err = readMetadataArchive(strings.NewReader(`{"archiveVersion":1}` + "\n" + `{"metadataKey":"short"}`))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,201 @@
+package routes
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/utils"
+)
+
+const (
+	metadataKeyLength         = 64
+	permissionHashKeySuffix   = "_permissionHash"
+	claimDeadlinePassedReason = "the deadline to claim metadata without a permission hash has passed"
+)
+
+var metadataClaimedRes = StatusRes{
+	Status: "metadata successfully claimed",
+}
+
+/*MetadataMigrationReport counts the metadata that still has no permission hash*/
+type MetadataMigrationReport struct {
+	MetadataKeys             int       `json:"metadataKeys"`
+	WithPermissionHash       int       `json:"withPermissionHash"`
+	MissingPermissionHash    int       `json:"missingPermissionHash"`
+	Cutoff                   time.Time `json:"cutoff"`
+	PermissionHashesEnforced bool      `json:"permissionHashesEnforced"`
+}
+
+// ClaimMetadataHandler godoc
+// @Summary claim a metadata that was created without a permission hash
+// @Accept  json
+// @Produce  json
+// @Param metadataKeyReq body routes.metadataKeyReq true "object for endpoints that only need metadataKey and timestamp"
+// @description Metadata created before permission hashes were stored can be read and deleted by anyone with its key.
+// @description Claiming it stores a permission hash for the signing public key, after which only that key can access it.
+// @description Claiming it adds it to the account's folder count and metadata size, and fails if that goes over the plan.
+// @description Claims are accepted until the permission hash cutoff date.
+// @description requestBody should be a stringified version of (values are just examples):
+// @description {
+// @description 	"metadataKey": "a 64-char hex string created deterministically, will be a key for the metadata of one of your folders",
+// @description 	"timestamp": 1557346389
+// @description }
+// @Success 200 {object} routes.StatusRes
+// @Failure 400 {string} string "bad request, unable to parse request body: (with the error)"
+// @Failure 404 {string} string "no value found for that key, or account not found"
+// @Failure 403 {string} string "subscription expired, the invoice response, the metadata already belongs to someone else, the account has no room for it, or the claim deadline has passed"
+// @Failure 500 {string} string "some information about the internal error"
+// @Router /api/v1/metadata/claim [post]
+/*ClaimMetadataHandler is a handler for claiming a metadata that has no permission hash*/
+func ClaimMetadataHandler() gin.HandlerFunc {
+	return ginHandlerFunc(claimMetadata)
+}
+
+/*AdminMetadataMigrationReportHandler is a handler for reporting how much metadata has no permission hash*/
+func AdminMetadataMigrationReportHandler() gin.HandlerFunc {
+	return ginHandlerFunc(adminMetadataMigrationReport)
+}
+
+func claimMetadata(c *gin.Context) error {
+	request := metadataKeyReq{}
+
+	if err := verifyAndParseBodyRequest(&request, c); err != nil {
+		return err
+	}
+
+	account, err := request.getAccount(c)
+	if err != nil {
+		return err
+	}
+
+	if err := verifyIfPaidWithContext(account, c); err != nil {
+		return err
+	}
+
+	if utils.MetadataPermissionHashesEnforced() {
+		return ForbiddenResponse(c, errors.New(claimDeadlinePassedReason))
+	}
+
+	metadataKey := request.metadataKeyObject.MetadataKey
+	metadata, _, err := utils.GetValueFromKV(metadataKey)
+	if err != nil {
+		return NotFoundResponse(c, err)
+	}
+
+	permissionHash, err := getPermissionHash(request.PublicKey, metadataKey, c)
+	if err != nil {
+		return err
+	}
+
+	// once claimed, deleting the metadata takes it off the claimant's folder count and metadata size, so it is
+	// added to them first
+	metadataSizeInBytes := int64(len(metadata))
+	if err := account.AddImportedMetadatas(1, 0, metadataSizeInBytes); err != nil {
+		return ForbiddenResponse(c, err)
+	}
+
+	permissionHashKey := getPermissionHashKeyForBadger(metadataKey)
+	ttl := time.Until(account.ExpirationDate().Add(models.MetadataExpirationGracePeriod()))
+	claimed, err := utils.SetIfNotExists(permissionHashKey, permissionHash, ttl)
+	if err != nil || !claimed {
+		revertErr := account.AddImportedMetadatas(-1, metadataSizeInBytes, 0)
+		utils.LogIfError(revertErr, map[string]interface{}{"accountID": account.AccountID})
+	}
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+
+	if claimed {
+		if err := extendClaimedMetadata(metadataKey, ttl); err != nil {
+			return InternalErrorResponse(c, err)
+		}
+	} else {
+		// already has a permission hash, which is fine as long as it is the caller's
+		permissionHashInBadger, _, err := utils.GetValueFromKV(permissionHashKey)
+		if err != nil {
+			return InternalErrorResponse(c, err)
+		}
+		if err := verifyPermissions(request.PublicKey, metadataKey, permissionHashInBadger, c); err != nil {
+			return err
+		}
+	}
+
+	if err := indexMetadataKey(account.AccountID, metadataKey); err != nil {
+		return InternalErrorResponse(c, err)
+	}
+
+	return OkResponse(c, metadataClaimedRes)
+}
+
+/*extendClaimedMetadata makes a claimed metadata and its history live as long as the claimant's account.  Each value
+is only set again if it is unchanged, so one written in the meantime keeps its new value.*/
+func extendClaimedMetadata(metadataKey string, ttl time.Duration) error {
+	kvKeys := utils.KVKeys{metadataKey}
+	for i := 0; i < numMetadatasToRetain; i++ {
+		kvKeys = append(kvKeys, getVersionKeyForBadger(metadataKey, i))
+	}
+	kvs, err := utils.BatchGet(&kvKeys)
+	if err != nil {
+		return err
+	}
+	for key, value := range *kvs {
+		if _, err := utils.CompareAndSwap(key, value, value, ttl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func adminMetadataMigrationReport(c *gin.Context) error {
+	report, err := CreateMetadataMigrationReport()
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+	return OkResponse(c, report)
+}
+
+/*CreateMetadataMigrationReport goes through the whole K:V store and counts the metadata keys with and
+without a permission hash*/
+func CreateMetadataMigrationReport() (MetadataMigrationReport, error) {
+	report := MetadataMigrationReport{
+		Cutoff:                   utils.Env.MetadataPermissionHashCutoffTime,
+		PermissionHashesEnforced: utils.MetadataPermissionHashesEnforced(),
+	}
+
+	store := utils.GetKvStore()
+	if store == nil {
+		return report, errors.New("kv store not initialized")
+	}
+
+	metadataKeys := make(map[string]bool)
+	permissionHashes := make(map[string]bool)
+	err := store.Iterate(func(key string, value string, expirationTime time.Time) error {
+		if isMetadataKey(key) {
+			metadataKeys[key] = true
+		} else if strings.HasSuffix(key, permissionHashKeySuffix) {
+			permissionHashes[strings.TrimSuffix(key, permissionHashKeySuffix)] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return report, err
+	}
+
+	for metadataKey := range metadataKeys {
+		report.MetadataKeys++
+		if permissionHashes[metadataKey] {
+			report.WithPermissionHash++
+		} else {
+			report.MissingPermissionHash++
+		}
+	}
+	return report, nil
+}
+
+// metadata keys are the only 64 character keys, everything stored alongside them has an "_" suffix
+func isMetadataKey(key string) bool {
+	return len(key) == metadataKeyLength && !strings.Contains(key, "_")
+}
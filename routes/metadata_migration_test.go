@@ -0,0 +1,141 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Init_Metadata_Migration(t *testing.T) {
+	setupTests(t)
+}
+
+func returnClaimMetadataReqForTest(t *testing.T, metadataKey string) (metadataKeyReq, verification) {
+	claimMetadataObj := metadataKeyObject{
+		MetadataKey: metadataKey,
+		Timestamp:   time.Now().Unix(),
+	}
+
+	v, b, _ := returnValidVerificationAndRequestBodyWithRandomPrivateKey(t, claimMetadataObj)
+
+	accountID, _ := utils.HashString(v.PublicKey)
+	CreatePaidAccountForTest(t, accountID)
+
+	return metadataKeyReq{
+		verification: v,
+		requestBody:  b,
+	}, v
+}
+
+func Test_ClaimMetadata_Success(t *testing.T) {
+	testMetadataKey := utils.RandSeqFromRunes(64, []rune("abcdef01234567890"))
+	if err := utils.BatchSet(&utils.KVPairs{testMetadataKey: "someValue"}, utils.TestValueTimeToLive); err != nil {
+		t.Fatalf("there should not have been an error")
+	}
+
+	post, v := returnClaimMetadataReqForTest(t, testMetadataKey)
+
+	w := httpPostRequestHelperForTest(t, MetadataClaimPath, post)
+
+	// Check to see if the response was what you expected
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), metadataClaimedRes.Status)
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	permissionHash, _ := getPermissionHash(v.PublicKey, testMetadataKey, c)
+	permissionHashInBadger, _, err := utils.GetValueFromKV(getPermissionHashKeyForBadger(testMetadataKey))
+	assert.Nil(t, err)
+	assert.Equal(t, permissionHash, permissionHashInBadger)
+
+	accountID, _ := utils.HashString(v.PublicKey)
+	account, err := models.GetAccountById(accountID)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, account.TotalFolders)
+	assert.Equal(t, int64(len("someValue")), account.TotalMetadataSizeInBytes)
+	_, expirationTime, err := utils.GetValueFromKV(testMetadataKey)
+	assert.Nil(t, err)
+	assert.True(t, expirationTime.After(time.Now().Add(utils.TestValueTimeToLive)))
+}
+
+func Test_ClaimMetadata_Fails_If_The_Account_Has_No_Room(t *testing.T) {
+	testMetadataKey := utils.RandSeqFromRunes(64, []rune("abcdef01234567890"))
+	if err := utils.BatchSet(&utils.KVPairs{testMetadataKey: "someValue"}, utils.TestValueTimeToLive); err != nil {
+		t.Fatalf("there should not have been an error")
+	}
+
+	post, v := returnClaimMetadataReqForTest(t, testMetadataKey)
+	accountID, _ := utils.HashString(v.PublicKey)
+	account, err := models.GetAccountById(accountID)
+	assert.Nil(t, err)
+	assert.Nil(t, models.DB.Model(&account).Update("total_folders", account.MaxAllowedMetadatas()).Error)
+
+	w := httpPostRequestHelperForTest(t, MetadataClaimPath, post)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	_, _, err = utils.GetValueFromKV(getPermissionHashKeyForBadger(testMetadataKey))
+	assert.Equal(t, utils.ErrKeyNotFound, err)
+}
+
+func Test_ClaimMetadata_Fails_If_Claimed_By_Someone_Else(t *testing.T) {
+	testMetadataKey := utils.RandSeqFromRunes(64, []rune("abcdef01234567890"))
+	if err := utils.BatchSet(&utils.KVPairs{
+		testMetadataKey: "someValue",
+		getPermissionHashKeyForBadger(testMetadataKey): "someOtherPermissionHash",
+	}, utils.TestValueTimeToLive); err != nil {
+		t.Fatalf("there should not have been an error")
+	}
+
+	post, _ := returnClaimMetadataReqForTest(t, testMetadataKey)
+
+	w := httpPostRequestHelperForTest(t, MetadataClaimPath, post)
+
+	// Check to see if the response was what you expected
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), notAuthorizedResponse)
+}
+
+func Test_ClaimMetadata_Fails_After_Cutoff(t *testing.T) {
+	testMetadataKey := utils.RandSeqFromRunes(64, []rune("abcdef01234567890"))
+	if err := utils.BatchSet(&utils.KVPairs{testMetadataKey: "someValue"}, utils.TestValueTimeToLive); err != nil {
+		t.Fatalf("there should not have been an error")
+	}
+
+	post, _ := returnClaimMetadataReqForTest(t, testMetadataKey)
+
+	utils.Env.MetadataPermissionHashCutoffTime = time.Now().Add(-time.Hour)
+	defer func() { utils.Env.MetadataPermissionHashCutoffTime = time.Time{} }()
+
+	w := httpPostRequestHelperForTest(t, MetadataClaimPath, post)
+
+	// Check to see if the response was what you expected
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), claimDeadlinePassedReason)
+}
+
+func Test_CreateMetadataMigrationReport(t *testing.T) {
+	before, err := CreateMetadataMigrationReport()
+	assert.Nil(t, err)
+
+	claimedKey := utils.RandSeqFromRunes(64, []rune("abcdef01234567890"))
+	unclaimedKey := utils.RandSeqFromRunes(64, []rune("abcdef01234567890"))
+	if err := utils.BatchSet(&utils.KVPairs{
+		claimedKey: "someValue",
+		getPermissionHashKeyForBadger(claimedKey): "somePermissionHash",
+		getVersionKeyForBadger(claimedKey, 0):     "someOldValue",
+		unclaimedKey:                              "someValue",
+	}, utils.TestValueTimeToLive); err != nil {
+		t.Fatalf("there should not have been an error")
+	}
+
+	report, err := CreateMetadataMigrationReport()
+	assert.Nil(t, err)
+	assert.Equal(t, before.MetadataKeys+2, report.MetadataKeys)
+	assert.Equal(t, before.WithPermissionHash+1, report.WithPermissionHash)
+	assert.Equal(t, before.MissingPermissionHash+1, report.MissingPermissionHash)
+}
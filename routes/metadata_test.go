@@ -243,6 +243,37 @@ func Test_GetMetadataHistoryHandler_Error_If_Not_In_KV_Store(t *testing.T) {
 	assert.Equal(t, http.StatusNotFound, w.Code)
 }
 
+func Test_GetMetadataHistoryHandler_Error_If_No_Permission_Hash(t *testing.T) {
+	ttl := utils.TestValueTimeToLive
+
+	testMetadataKey := utils.GenerateFileHandle()
+	testMetadataValue := utils.GenerateFileHandle()
+
+	if err := utils.BatchSet(&utils.KVPairs{testMetadataKey: testMetadataValue}, ttl); err != nil {
+		t.Fatalf("there should not have been an error")
+	}
+
+	getMetadata := metadataKeyObject{
+		MetadataKey: testMetadataKey,
+		Timestamp:   time.Now().Unix(),
+	}
+
+	v, b, _ := returnValidVerificationAndRequestBodyWithRandomPrivateKey(t, getMetadata)
+
+	get := metadataKeyReq{
+		verification: v,
+		requestBody:  b,
+	}
+
+	accountID, _ := utils.HashString(v.PublicKey)
+	CreatePaidAccountForTest(t, accountID)
+
+	// the cutoff hasn't passed, but history was never readable without a permission hash
+	w := httpPostRequestHelperForTest(t, MetadataHistoryPath, get)
+	// Check to see if the response was what you expected
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
 func Test_UpdateMetadataHandler_Can_Update_Metadata(t *testing.T) {
 	ttl := utils.TestValueTimeToLive
 
@@ -817,3 +848,108 @@ func Test_Delete_Metadata_Success(t *testing.T) {
 	assert.Equal(t, int64(0), accountFromDB.TotalMetadataSizeInBytes)
 	assert.Equal(t, 0, accountFromDB.TotalFolders)
 }
+
+func Test_GetMetadataHandler_Error_If_No_Permission_Hash_After_Cutoff(t *testing.T) {
+	ttl := utils.TestValueTimeToLive
+
+	testMetadataKey := utils.GenerateFileHandle()
+	testMetadataValue := utils.GenerateFileHandle()
+
+	if err := utils.BatchSet(&utils.KVPairs{testMetadataKey: testMetadataValue}, ttl); err != nil {
+		t.Fatalf("there should not have been an error")
+	}
+
+	getMetadata := metadataKeyObject{
+		MetadataKey: testMetadataKey,
+		Timestamp:   time.Now().Unix(),
+	}
+
+	v, b, _ := returnValidVerificationAndRequestBodyWithRandomPrivateKey(t, getMetadata)
+
+	get := metadataKeyReq{
+		verification: v,
+		requestBody:  b,
+	}
+
+	accountID, _ := utils.HashString(v.PublicKey)
+	CreatePaidAccountForTest(t, accountID)
+
+	utils.Env.MetadataPermissionHashCutoffTime = time.Now().Add(-time.Hour)
+	defer func() { utils.Env.MetadataPermissionHashCutoffTime = time.Time{} }()
+
+	w := httpPostRequestHelperForTest(t, MetadataGetPath, get)
+	// Check to see if the response was what you expected
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func Test_Delete_Metadata_Fails_If_Not_In_KV_Store(t *testing.T) {
+	testMetadataKey := utils.RandSeqFromRunes(64, []rune("abcdef01234567890"))
+
+	deleteMetadataObj := metadataKeyObject{
+		MetadataKey: testMetadataKey,
+		Timestamp:   time.Now().Unix(),
+	}
+
+	v, b, _ := returnValidVerificationAndRequestBodyWithRandomPrivateKey(t, deleteMetadataObj)
+
+	post := metadataKeyReq{
+		verification: v,
+		requestBody: requestBody{
+			RequestBody: b.RequestBody,
+		},
+	}
+
+	accountID, _ := utils.HashString(v.PublicKey)
+	account := CreatePaidAccountForTest(t, accountID)
+	account.TotalFolders = 1
+	err := models.DB.Save(&account).Error
+	assert.Nil(t, err)
+
+	w := httpPostRequestHelperForTest(t, MetadataDeletePath, post)
+
+	// Check to see if the response was what you expected
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	accountFromDB, _ := models.GetAccountById(account.AccountID)
+	assert.Equal(t, 1, accountFromDB.TotalFolders)
+}
+
+func Test_Delete_Metadata_Without_Permission_Hash_Decrements_Folders(t *testing.T) {
+	testMetadataKey := utils.RandSeqFromRunes(64, []rune("abcdef01234567890"))
+	testMetadataValue := "someValue"
+
+	deleteMetadataObj := metadataKeyObject{
+		MetadataKey: testMetadataKey,
+		Timestamp:   time.Now().Unix(),
+	}
+
+	v, b, _ := returnValidVerificationAndRequestBodyWithRandomPrivateKey(t, deleteMetadataObj)
+
+	post := metadataKeyReq{
+		verification: v,
+		requestBody: requestBody{
+			RequestBody: b.RequestBody,
+		},
+	}
+
+	accountID, _ := utils.HashString(v.PublicKey)
+	account := CreatePaidAccountForTest(t, accountID)
+	// the size of metadata from before permission hashes may never have been counted
+	account.TotalFolders = 2
+	account.TotalMetadataSizeInBytes = 0
+	err := models.DB.Save(&account).Error
+	assert.Nil(t, err)
+
+	if err := utils.BatchSet(&utils.KVPairs{
+		testMetadataKey: testMetadataValue,
+	}, time.Until(account.ExpirationDate())); err != nil {
+		t.Fatalf("there should not have been an error")
+	}
+
+	w := httpPostRequestHelperForTest(t, MetadataDeletePath, post)
+
+	// Check to see if the response was what you expected
+	assert.Equal(t, http.StatusOK, w.Code)
+	accountFromDB, _ := models.GetAccountById(account.AccountID)
+	assert.Equal(t, int64(0), accountFromDB.TotalMetadataSizeInBytes)
+	assert.Equal(t, 1, accountFromDB.TotalFolders)
+}
@@ -0,0 +1,399 @@
+package routes
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"github.com/opacity/storage-node/models"
+)
+
+const (
+	noOrganizationError        = "the account does not own or belong to an organization"
+	notOrganizationOwnerError  = "only the organization's owner can do that"
+	organizationInviteError    = "the account was not invited to that organization"
+	organizationMemberNotFound = "the account is not a member of the organization"
+)
+
+// must be sorted alphabetically for JSON marshaling/stringifying
+type createOrganizationObject struct {
+	Name      string `json:"name" binding:"omitempty,max=64" maxLength:"64" example:"Acme"`
+	Timestamp int64  `json:"timestamp" binding:"required"`
+}
+
+// must be sorted alphabetically for JSON marshaling/stringifying
+type organizationMemberObject struct {
+	MemberAccountID string  `json:"memberAccountID" binding:"required,len=64" minLength:"64" maxLength:"64" example:"the hash of the member's public key"`
+	StorageCapInGB  float64 `json:"storageCapInGB" binding:"omitempty,gte=0" example:"50"`
+	Timestamp       int64   `json:"timestamp" binding:"required"`
+}
+
+// must be sorted alphabetically for JSON marshaling/stringifying
+type joinOrganizationObject struct {
+	OrganizationID uint  `json:"organizationID" binding:"required" example:"1"`
+	Timestamp      int64 `json:"timestamp" binding:"required"`
+}
+
+type createOrganizationReq struct {
+	verification
+	requestBody
+	createOrganizationObject createOrganizationObject
+}
+
+type organizationMemberReq struct {
+	verification
+	requestBody
+	organizationMemberObject organizationMemberObject
+}
+
+type joinOrganizationReq struct {
+	verification
+	requestBody
+	joinOrganizationObject joinOrganizationObject
+}
+
+type getOrganizationReq struct {
+	verification
+	requestBody
+	timestampObject timestampObject
+}
+
+type organizationRes struct {
+	Organization models.Organization         `json:"organization"`
+	StorageLimit models.StorageLimitType     `json:"storageLimit" example:"1024"` // the pool everyone stores in, in GB
+	Members      []models.OrganizationMember `json:"members"`                     // only the requester's own, for a member
+}
+
+var removeOrganizationMemberRes = StatusRes{
+	Status: "member removed",
+}
+
+func (v *createOrganizationReq) getObjectRef() interface{} {
+	return &v.createOrganizationObject
+}
+
+func (v *organizationMemberReq) getObjectRef() interface{} {
+	return &v.organizationMemberObject
+}
+
+func (v *joinOrganizationReq) getObjectRef() interface{} {
+	return &v.joinOrganizationObject
+}
+
+func (v *getOrganizationReq) getObjectRef() interface{} {
+	return &v.timestampObject
+}
+
+// CreateOrganizationHandler godoc
+// @Summary share the account's plan with other accounts
+// @Accept  json
+// @Produce  json
+// @Param createOrganizationReq body routes.createOrganizationReq true "create organization object"
+// @description Creates an organization the account owns.  The accounts it invites store in a pool the size of the
+// @description owner's storageLimit, which the owner's own files count towards too.  An account can only own or
+// @description belong to one organization.
+// @description requestBody should be a stringified version of (values are just examples):
+// @description {
+// @description 	"name": "Acme",
+// @description 	"timestamp": 1557346389
+// @description }
+// @Success 200 {object} routes.organizationRes
+// @Failure 400 {string} string "bad request, unable to parse request body: (with the error)"
+// @Failure 403 {string} string "signature did not match"
+// @Failure 404 {string} string "no account with that id: (with your accountID)"
+// @Failure 500 {string} string "some information about the internal error"
+// @Router /api/v1/organization/create [post]
+/*CreateOrganizationHandler is a handler for creating an organization*/
+func CreateOrganizationHandler() gin.HandlerFunc {
+	return ginHandlerFunc(createOrganization)
+}
+
+// GetOrganizationHandler godoc
+// @Summary get the organization the account owns or belongs to
+// @Accept  json
+// @Produce  json
+// @Param getOrganizationReq body routes.getOrganizationReq true "get organization object"
+// @description The owner gets every member it invited and what each stored.  A member only gets its own.
+// @description requestBody should be a stringified version of (values are just examples):
+// @description {
+// @description 	"timestamp": 1557346389
+// @description }
+// @Success 200 {object} routes.organizationRes
+// @Failure 400 {string} string "bad request, unable to parse request body: (with the error)"
+// @Failure 403 {string} string "signature did not match"
+// @Failure 404 {string} string "the account does not own or belong to an organization"
+// @Failure 500 {string} string "some information about the internal error"
+// @Router /api/v1/organization [post]
+/*GetOrganizationHandler is a handler for getting an account's organization*/
+func GetOrganizationHandler() gin.HandlerFunc {
+	return ginHandlerFunc(getOrganization)
+}
+
+// InviteOrganizationMemberHandler godoc
+// @Summary invite an account to the organization
+// @Accept  json
+// @Produce  json
+// @Param organizationMemberReq body routes.organizationMemberReq true "organization member object"
+// @description The invited account joins at /api/v1/organization/join.  storageCapInGB limits what it can store in
+// @description the pool, and there is no limit if it is 0.  Inviting an account again changes its cap.
+// @description This must be signed by the organization's owner.
+// @description requestBody should be a stringified version of (values are just examples):
+// @description {
+// @description 	"memberAccountID": "the hash of the member's public key",
+// @description 	"storageCapInGB": 50,
+// @description 	"timestamp": 1557346389
+// @description }
+// @Success 200 {object} models.OrganizationMember
+// @Failure 400 {string} string "bad request, unable to parse request body: (with the error)"
+// @Failure 403 {string} string "only the organization's owner can do that"
+// @Failure 500 {string} string "some information about the internal error"
+// @Router /api/v1/organization/invite [post]
+/*InviteOrganizationMemberHandler is a handler for inviting an account to an organization*/
+func InviteOrganizationMemberHandler() gin.HandlerFunc {
+	return ginHandlerFunc(inviteOrganizationMember)
+}
+
+// JoinOrganizationHandler godoc
+// @Summary join an organization the account was invited to
+// @Accept  json
+// @Produce  json
+// @Param joinOrganizationReq body routes.joinOrganizationReq true "join organization object"
+// @description Once joined, what the account stores is charged to the organization's pool instead of its own
+// @description storageLimit.  What it already stored moves to the pool, so it has to fit there.
+// @description requestBody should be a stringified version of (values are just examples):
+// @description {
+// @description 	"organizationID": 1,
+// @description 	"timestamp": 1557346389
+// @description }
+// @Success 200 {object} models.OrganizationMember
+// @Failure 400 {string} string "unable to store more data in the organization's storage"
+// @Failure 403 {string} string "signature did not match"
+// @Failure 404 {string} string "the account was not invited to that organization"
+// @Failure 500 {string} string "some information about the internal error"
+// @Router /api/v1/organization/join [post]
+/*JoinOrganizationHandler is a handler for joining an organization*/
+func JoinOrganizationHandler() gin.HandlerFunc {
+	return ginHandlerFunc(joinOrganization)
+}
+
+// RemoveOrganizationMemberHandler godoc
+// @Summary remove a member from the organization
+// @Accept  json
+// @Produce  json
+// @Param organizationMemberReq body routes.organizationMemberReq true "organization member object"
+// @description The owner can remove any member or invitation, and a member can remove itself to leave.  What the
+// @description member stored counts against its own storageLimit again.  storageCapInGB is ignored.
+// @description requestBody should be a stringified version of (values are just examples):
+// @description {
+// @description 	"memberAccountID": "the hash of the member's public key",
+// @description 	"timestamp": 1557346389
+// @description }
+// @Success 200 {object} routes.StatusRes
+// @Failure 400 {string} string "bad request, unable to parse request body: (with the error)"
+// @Failure 403 {string} string "only the organization's owner can do that"
+// @Failure 404 {string} string "the account is not a member of the organization"
+// @Failure 500 {string} string "some information about the internal error"
+// @Router /api/v1/organization/remove [post]
+/*RemoveOrganizationMemberHandler is a handler for removing a member from an organization*/
+func RemoveOrganizationMemberHandler() gin.HandlerFunc {
+	return ginHandlerFunc(removeOrganizationMember)
+}
+
+// CapOrganizationMemberHandler godoc
+// @Summary cap what a member can store in the organization
+// @Accept  json
+// @Produce  json
+// @Param organizationMemberReq body routes.organizationMemberReq true "organization member object"
+// @description A storageCapInGB of 0 lifts the cap.  A cap below what the member already stored only stops it
+// @description storing more.  This must be signed by the organization's owner.
+// @description requestBody should be a stringified version of (values are just examples):
+// @description {
+// @description 	"memberAccountID": "the hash of the member's public key",
+// @description 	"storageCapInGB": 50,
+// @description 	"timestamp": 1557346389
+// @description }
+// @Success 200 {object} models.OrganizationMember
+// @Failure 400 {string} string "bad request, unable to parse request body: (with the error)"
+// @Failure 403 {string} string "only the organization's owner can do that"
+// @Failure 404 {string} string "the account is not a member of the organization"
+// @Failure 500 {string} string "some information about the internal error"
+// @Router /api/v1/organization/cap [post]
+/*CapOrganizationMemberHandler is a handler for capping what a member stores*/
+func CapOrganizationMemberHandler() gin.HandlerFunc {
+	return ginHandlerFunc(capOrganizationMember)
+}
+
+func createOrganization(c *gin.Context) error {
+	request := createOrganizationReq{}
+	if err := verifyAndParseBodyRequest(&request, c); err != nil {
+		return err
+	}
+
+	account, err := request.getAccount(c)
+	if err != nil {
+		return err
+	}
+	if err := verifyIfPaidWithContext(account, c); err != nil {
+		return err
+	}
+
+	organization, err := models.CreateOrganization(account, request.createOrganizationObject.Name)
+	if err != nil {
+		return organizationErrorResponse(c, err, noOrganizationError)
+	}
+	return OkResponse(c, organizationRes{
+		Organization: organization,
+		StorageLimit: account.StorageLimit,
+		Members:      []models.OrganizationMember{},
+	})
+}
+
+func getOrganization(c *gin.Context) error {
+	request := getOrganizationReq{}
+	if err := verifyAndParseBodyRequest(&request, c); err != nil {
+		return err
+	}
+
+	account, err := request.getAccount(c)
+	if err != nil {
+		return err
+	}
+
+	organization, member, err := models.GetAccountOrganization(account.AccountID)
+	if err != nil {
+		return organizationErrorResponse(c, err, noOrganizationError)
+	}
+	owner, err := models.GetAccountById(organization.OwnerAccountID)
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+
+	res := organizationRes{Organization: organization, StorageLimit: owner.StorageLimit}
+	if member != nil {
+		member.StorageUsedInByte = account.StorageUsedInByte
+		res.Members = []models.OrganizationMember{*member}
+	} else if res.Members, err = organization.Members(); err != nil {
+		return InternalErrorResponse(c, err)
+	}
+	return OkResponse(c, res)
+}
+
+func inviteOrganizationMember(c *gin.Context) error {
+	request := organizationMemberReq{}
+	if err := verifyAndParseBodyRequest(&request, c); err != nil {
+		return err
+	}
+
+	organization, err := getOwnedOrganization(request, c)
+	if err != nil {
+		return err
+	}
+
+	member, err := organization.InviteMember(request.organizationMemberObject.MemberAccountID,
+		storageCapInByte(request.organizationMemberObject))
+	if err != nil {
+		return organizationErrorResponse(c, err, organizationMemberNotFound)
+	}
+	return OkResponse(c, member)
+}
+
+func joinOrganization(c *gin.Context) error {
+	request := joinOrganizationReq{}
+	if err := verifyAndParseBodyRequest(&request, c); err != nil {
+		return err
+	}
+
+	account, err := request.getAccount(c)
+	if err != nil {
+		return err
+	}
+
+	organization, err := models.GetOrganization(request.joinOrganizationObject.OrganizationID)
+	if err != nil {
+		return organizationErrorResponse(c, err, organizationInviteError)
+	}
+	member, err := organization.Join(account)
+	if err != nil {
+		return organizationErrorResponse(c, err, organizationInviteError)
+	}
+	return OkResponse(c, member)
+}
+
+func removeOrganizationMember(c *gin.Context) error {
+	request := organizationMemberReq{}
+	if err := verifyAndParseBodyRequest(&request, c); err != nil {
+		return err
+	}
+
+	account, err := request.getAccount(c)
+	if err != nil {
+		return err
+	}
+
+	memberAccountID := request.organizationMemberObject.MemberAccountID
+	organization, member, err := models.GetAccountOrganization(account.AccountID)
+	if err != nil {
+		return organizationErrorResponse(c, err, noOrganizationError)
+	}
+	if member != nil && memberAccountID != account.AccountID {
+		return ForbiddenResponse(c, errors.New(notOrganizationOwnerError))
+	}
+
+	if err := organization.RemoveMember(memberAccountID); err != nil {
+		return organizationErrorResponse(c, err, organizationMemberNotFound)
+	}
+	return OkResponse(c, removeOrganizationMemberRes)
+}
+
+func capOrganizationMember(c *gin.Context) error {
+	request := organizationMemberReq{}
+	if err := verifyAndParseBodyRequest(&request, c); err != nil {
+		return err
+	}
+
+	organization, err := getOwnedOrganization(request, c)
+	if err != nil {
+		return err
+	}
+
+	member, err := organization.SetMemberStorageCap(request.organizationMemberObject.MemberAccountID,
+		storageCapInByte(request.organizationMemberObject))
+	if err != nil {
+		return organizationErrorResponse(c, err, organizationMemberNotFound)
+	}
+	return OkResponse(c, member)
+}
+
+/*getOwnedOrganization returns the organization owned by the account that signed the request*/
+func getOwnedOrganization(request organizationMemberReq, c *gin.Context) (models.Organization, error) {
+	account, err := request.getAccount(c)
+	if err != nil {
+		return models.Organization{}, err
+	}
+
+	organization, err := models.GetOwnedOrganization(account.AccountID)
+	if gorm.IsRecordNotFoundError(err) {
+		return organization, ForbiddenResponse(c, errors.New(notOrganizationOwnerError))
+	}
+	if err != nil {
+		return organization, InternalErrorResponse(c, err)
+	}
+	return organization, nil
+}
+
+func storageCapInByte(object organizationMemberObject) int64 {
+	return int64(object.StorageCapInGB * 1e9)
+}
+
+/*organizationErrorResponse responds to an organization error, with notFoundError if a row wasn't found*/
+func organizationErrorResponse(c *gin.Context, err error, notFoundError string) error {
+	if gorm.IsRecordNotFoundError(err) {
+		return NotFoundResponse(c, errors.New(notFoundError))
+	}
+	switch err {
+	case models.ErrAlreadyInOrganization, models.ErrInviteOrganizationOwner, models.ErrOrganizationStorageFull,
+		models.ErrOrganizationMemberCap, models.ErrOrganizationExpired:
+		return BadRequestResponse(c, err)
+	}
+	return InternalErrorResponse(c, err)
+}
@@ -0,0 +1,64 @@
+package routes
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Init_Organizations(t *testing.T) {
+	setupTests(t)
+}
+
+func Test_Organization_Invite_And_Join(t *testing.T) {
+	models.DeleteAccountsForTest(t)
+	models.DeleteOrganizationsForTest(t)
+
+	memberAccountID, memberPrivateKey := generateValidateAccountId(t)
+	CreatePaidAccountForTest(t, memberAccountID)
+	ownerAccountID, ownerPrivateKey := generateValidateAccountId(t)
+	organization, err := models.CreateOrganization(CreatePaidAccountForTest(t, ownerAccountID), "Acme")
+	assert.Nil(t, err)
+
+	v, b := returnValidVerificationAndRequestBody(t, organizationMemberObject{
+		MemberAccountID: memberAccountID,
+		StorageCapInGB:  50,
+		Timestamp:       time.Now().Unix(),
+	}, ownerPrivateKey)
+	w := httpPostRequestHelperForTest(t, OrganizationInvitePath, organizationMemberReq{verification: v, requestBody: b})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"invited"`)
+	assert.Contains(t, w.Body.String(), `"storageCapInByte":50000000000`)
+
+	v, b = returnValidVerificationAndRequestBody(t, joinOrganizationObject{
+		OrganizationID: organization.ID,
+		Timestamp:      time.Now().Unix(),
+	}, memberPrivateKey)
+	w = httpPostRequestHelperForTest(t, OrganizationJoinPath, joinOrganizationReq{verification: v, requestBody: b})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"joined"`)
+
+	_, member, err := models.GetAccountOrganization(memberAccountID)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(50*1e9), member.StorageCapInByte)
+}
+
+func Test_Organization_Invite_Needs_The_Owner(t *testing.T) {
+	models.DeleteAccountsForTest(t)
+	models.DeleteOrganizationsForTest(t)
+
+	v, b, _ := returnValidVerificationAndRequestBodyWithRandomPrivateKey(t, organizationMemberObject{
+		MemberAccountID: utils.RandHexString(64),
+		Timestamp:       time.Now().Unix(),
+	})
+	accountID, _ := utils.HashString(v.PublicKey)
+	CreatePaidAccountForTest(t, accountID)
+
+	w := httpPostRequestHelperForTest(t, OrganizationInvitePath, organizationMemberReq{verification: v, requestBody: b})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), notOrganizationOwnerError)
+}
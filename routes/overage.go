@@ -0,0 +1,220 @@
+package routes
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/utils"
+)
+
+const (
+	overageNotOfferedError = "storing more than a plan is not offered"
+	overageCapError        = "overageCapInGB can be at most the account's storageLimit"
+	overageOnRenewalError  = "the overage is already on the renewal invoice"
+	overageInUseError      = "the account stores more than that would allow, delete files first"
+)
+
+// must be sorted alphabetically for JSON marshaling/stringifying
+type setOverageObject struct {
+	CapInGB   int   `json:"capInGB" binding:"omitempty,gte=0" example:"64"`
+	Enabled   bool  `json:"enabled"`
+	Timestamp int64 `json:"timestamp" binding:"required"`
+}
+
+// must be sorted alphabetically for JSON marshaling/stringifying
+type payOverageObject struct {
+	StripeToken string `json:"stripeToken" binding:"required" example:"tok_KPte7942xySKBKyrBu11yEpf"`
+	Timestamp   int64  `json:"timestamp" binding:"required"`
+}
+
+type setOverageReq struct {
+	verification
+	requestBody
+	setOverageObject setOverageObject
+}
+
+type payOverageReq struct {
+	verification
+	requestBody
+	payOverageObject payOverageObject
+}
+
+type setOverageRes struct {
+	OverageEnabled bool `json:"overageEnabled" example:"true"`
+	OverageCapInGB int  `json:"overageCapInGB" example:"64"`
+}
+
+func (v *setOverageReq) getObjectRef() interface{} {
+	return &v.setOverageObject
+}
+
+func (v *payOverageReq) getObjectRef() interface{} {
+	return &v.payOverageObject
+}
+
+// SetAccountOverageHandler godoc
+// @Summary opt in to or out of storing more than the account's plan
+// @Accept  json
+// @Produce  json
+// @Param setOverageReq body routes.setOverageReq true "set overage object"
+// @description While enabled, uploads can go up to capInGB over the account's storageLimit instead of failing.
+// @description What is stored over the plan is sampled daily and billed per GB-month, added to the renewal invoice
+// @description or paid by card at /api/v1/stripe/overage.  capInGB can be at most the storageLimit.  It can't be
+// @description disabled or lowered below what the account stores.  It doesn't apply while the account stores in an
+// @description organization's pool.
+// @description requestBody should be a stringified version of (values are just examples):
+// @description {
+// @description 	"capInGB": 64,
+// @description 	"enabled": true,
+// @description 	"timestamp": 1557346389
+// @description }
+// @Success 200 {object} routes.setOverageRes
+// @Failure 400 {string} string "bad request, unable to parse request body: (with the error)"
+// @Failure 403 {string} string "storing more than a plan is not offered"
+// @Failure 403 {string} string "the account stores more than that would allow, delete files first"
+// @Failure 404 {string} string "no account with that id: (with your accountID)"
+// @Failure 500 {string} string "some information about the internal error"
+// @Router /api/v1/account/overage [post]
+/*SetAccountOverageHandler is a handler for opting in to or out of overage*/
+func SetAccountOverageHandler() gin.HandlerFunc {
+	return ginHandlerFunc(setAccountOverage)
+}
+
+// PayOverageWithStripeHandler godoc
+// @Summary pay for overage by card
+// @Accept  json
+// @Produce  json
+// @Param payOverageReq body routes.payOverageReq true "pay overage object"
+// @description Charges the card for the overage the account stored and hasn't been billed for.  Overage that is
+// @description already on an unpaid renewal invoice has to be paid with the renewal.
+// @description requestBody should be a stringified version of (values are just examples):
+// @description {
+// @description 	"stripeToken": "tok_KPte7942xySKBKyrBu11yEpf",
+// @description 	"timestamp": 1557346389
+// @description }
+// @Success 200 {object} routes.stripeDataRes
+// @Failure 400 {string} string "bad request, unable to parse request body: (with the error)"
+// @Failure 403 {string} string "cannot create stripe charge for less than $0.50"
+// @Failure 404 {string} string "no account with that id: (with your accountID)"
+// @Failure 500 {string} string "some information about the internal error"
+// @Router /api/v1/stripe/overage [post]
+/*PayOverageWithStripeHandler is a handler for paying for overage by card*/
+func PayOverageWithStripeHandler() gin.HandlerFunc {
+	return ginHandlerFunc(payOverageWithStripe)
+}
+
+func setAccountOverage(c *gin.Context) error {
+	request := setOverageReq{}
+	if err := verifyAndParseBodyRequest(&request, c); err != nil {
+		return err
+	}
+
+	account, err := request.getAccount(c)
+	if err != nil {
+		return err
+	}
+	if err := verifyIfPaidWithContext(account, c); err != nil {
+		return err
+	}
+
+	enabled := request.setOverageObject.Enabled
+	capInGB := request.setOverageObject.CapInGB
+	if enabled && !utils.OverageOffered() {
+		return ForbiddenResponse(c, errors.New(overageNotOfferedError))
+	}
+	if capInGB > int(account.StorageLimit) {
+		return BadRequestResponse(c, errors.New(overageCapError))
+	}
+	// overage is only sampled while it is enabled, so it can't be turned off or lowered below what is stored
+	limitInGB := int(account.StorageLimit)
+	if enabled {
+		limitInGB += capInGB
+	}
+	if account.StorageUsedInByte > int64(limitInGB)*1e9 {
+		_, _, err := models.GetAccountOrganization(account.AccountID)
+		if gorm.IsRecordNotFoundError(err) {
+			return ForbiddenResponse(c, errors.New(overageInUseError))
+		}
+		if err != nil {
+			return InternalErrorResponse(c, err)
+		}
+	}
+
+	if err := account.SetOverage(enabled, capInGB); err != nil {
+		return InternalErrorResponse(c, err)
+	}
+	return OkResponse(c, setOverageRes{
+		OverageEnabled: account.OverageEnabled,
+		OverageCapInGB: account.OverageCapInGB,
+	})
+}
+
+func payOverageWithStripe(c *gin.Context) error {
+	if !utils.Env.EnableCreditCards && !utils.IsTestEnv() {
+		return ForbiddenResponse(c, errors.New("not accepting credit cards yet"))
+	}
+
+	request := payOverageReq{}
+	if err := verifyAndParseBodyRequest(&request, c); err != nil {
+		return err
+	}
+
+	account, err := request.getAccount(c)
+	if err != nil {
+		return err
+	}
+
+	renewals, err := models.GetRenewalsFromAccountID(account.AccountID)
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+	if len(renewals) > 0 && renewals[0].PaymentStatus == models.InitialPaymentInProgress &&
+		renewals[0].OverageInOPCT > 0 {
+		return ForbiddenResponse(c, errors.New(overageOnRenewalError))
+	}
+
+	// the samples are claimed before the card is charged so concurrent requests can't charge for them twice
+	claim, gbMonths, err := models.ClaimOverage(account.AccountID, time.Now())
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+	costInDollars := utils.OverageCostInUSD(gbMonths)
+	if costInDollars <= float64(0.50) {
+		releaseOverageClaim(claim, account.AccountID)
+		return ForbiddenResponse(c, errors.New("cannot create stripe charge for less than $0.50"))
+	}
+
+	charge, err := createCharge(c, costInDollars, request.payOverageObject.StripeToken, account.AccountID)
+	if err != nil {
+		releaseOverageClaim(claim, account.AccountID)
+		return err
+	}
+	amount := float64(charge.Amount) / 100.00
+
+	// the card is already charged, so the claim is kept rather than released if marking it billed fails
+	err = models.BillOverageClaim(claim, charge.ID)
+	utils.LogIfError(err, map[string]interface{}{"accountID": account.AccountID, "chargeID": charge.ID})
+	if err := account.RecordOverageStripePayment(amount, charge.ID); err != nil {
+		return InternalErrorResponse(c, err)
+	}
+
+	return OkResponse(c, stripeDataRes{
+		StatusRes: StatusRes{
+			Status: "successfully charged card for overage",
+		},
+		stripeDataObj: stripeDataObj{
+			ChargePaid:  charge.Paid,
+			StripeToken: request.payOverageObject.StripeToken,
+			ChargeID:    charge.ID,
+			Amount:      amount,
+		},
+	})
+}
+
+func releaseOverageClaim(claim string, accountID string) {
+	err := models.ReleaseOverageClaim(claim)
+	utils.LogIfError(err, map[string]interface{}{"accountID": accountID})
+}
@@ -0,0 +1,135 @@
+package routes
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/services"
+	"github.com/opacity/storage-node/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Init_Overage(t *testing.T) {
+	setupTests(t)
+}
+
+func Test_SetAccountOverage(t *testing.T) {
+	defer func() { utils.Env.OveragePricePerGBMonth = 0 }()
+	utils.Env.OveragePricePerGBMonth = 0.01
+	models.DeleteAccountsForTest(t)
+
+	v, b, _ := returnValidVerificationAndRequestBodyWithRandomPrivateKey(t, setOverageObject{
+		CapInGB:   64,
+		Enabled:   true,
+		Timestamp: time.Now().Unix(),
+	})
+	accountID, _ := utils.HashString(v.PublicKey)
+	CreatePaidAccountForTest(t, accountID)
+
+	w := httpPostRequestHelperForTest(t, AccountOveragePath, setOverageReq{verification: v, requestBody: b})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"overageEnabled":true`)
+
+	account, err := models.GetAccountById(accountID)
+	assert.Nil(t, err)
+	assert.Equal(t, 64, account.OverageCapInGB)
+	assert.Equal(t, int(account.StorageLimit)+64, account.StorageLimitWithOverage())
+}
+
+func Test_SetAccountOverage_Not_Offered(t *testing.T) {
+	models.DeleteAccountsForTest(t)
+
+	v, b, _ := returnValidVerificationAndRequestBodyWithRandomPrivateKey(t, setOverageObject{
+		CapInGB:   64,
+		Enabled:   true,
+		Timestamp: time.Now().Unix(),
+	})
+	accountID, _ := utils.HashString(v.PublicKey)
+	CreatePaidAccountForTest(t, accountID)
+
+	w := httpPostRequestHelperForTest(t, AccountOveragePath, setOverageReq{verification: v, requestBody: b})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), overageNotOfferedError)
+}
+
+func Test_SetAccountOverage_Cannot_Disable_While_Over_Plan(t *testing.T) {
+	defer func() { utils.Env.OveragePricePerGBMonth = 0 }()
+	utils.Env.OveragePricePerGBMonth = 0.01
+	models.DeleteAccountsForTest(t)
+
+	v, b, _ := returnValidVerificationAndRequestBodyWithRandomPrivateKey(t, setOverageObject{
+		CapInGB:   16,
+		Enabled:   true,
+		Timestamp: time.Now().Unix(),
+	})
+	accountID, _ := utils.HashString(v.PublicKey)
+	account := CreatePaidAccountForTest(t, accountID)
+	assert.Nil(t, account.SetOverage(true, 64))
+	assert.Nil(t, models.DB.Model(&account).Update("storage_used_in_byte", 150*1e9).Error)
+
+	w := httpPostRequestHelperForTest(t, AccountOveragePath, setOverageReq{verification: v, requestBody: b})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), overageInUseError)
+
+	account, err := models.GetAccountById(accountID)
+	assert.Nil(t, err)
+	assert.True(t, account.OverageEnabled)
+	assert.Equal(t, 64, account.OverageCapInGB)
+}
+
+func Test_PayOverageWithStripe(t *testing.T) {
+	defer func() { utils.Env.OveragePricePerGBMonthInUSD = 0 }()
+	utils.Env.OveragePricePerGBMonthInUSD = 0.05
+	models.DeleteAccountsForTest(t)
+	models.DeleteUsageSamplesForTest(t)
+	models.DeleteRenewalsForTest(t)
+
+	v, b, _ := returnValidVerificationAndRequestBodyWithRandomPrivateKey(t, payOverageObject{
+		StripeToken: services.RandTestStripeToken(),
+		Timestamp:   time.Now().Unix(),
+	})
+	accountID, _ := utils.HashString(v.PublicKey)
+	CreatePaidAccountForTest(t, accountID)
+	assert.Nil(t, models.DB.Create(&models.UsageSample{
+		AccountID:     accountID,
+		SampledOn:     time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -1),
+		OverageInByte: 600 * 1e9,
+	}).Error)
+
+	w := httpPostRequestHelperForTest(t, StripeOveragePath, payOverageReq{verification: v, requestBody: b})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"amount":1`)
+
+	gbMonths, err := models.UnbilledOverageInGBMonths(accountID, time.Now())
+	assert.Nil(t, err)
+	assert.Equal(t, 0.0, gbMonths)
+}
+
+func Test_PayOverageWithStripe_Needs_At_Least_Fifty_Cents(t *testing.T) {
+	defer func() { utils.Env.OveragePricePerGBMonthInUSD = 0 }()
+	utils.Env.OveragePricePerGBMonthInUSD = 0.05
+	models.DeleteAccountsForTest(t)
+	models.DeleteUsageSamplesForTest(t)
+
+	v, b, _ := returnValidVerificationAndRequestBodyWithRandomPrivateKey(t, payOverageObject{
+		StripeToken: services.RandTestStripeToken(),
+		Timestamp:   time.Now().Unix(),
+	})
+	accountID, _ := utils.HashString(v.PublicKey)
+	CreatePaidAccountForTest(t, accountID)
+	assert.Nil(t, models.DB.Create(&models.UsageSample{
+		AccountID:     accountID,
+		SampledOn:     time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -1),
+		OverageInByte: 30 * 1e9,
+	}).Error)
+
+	w := httpPostRequestHelperForTest(t, StripeOveragePath, payOverageReq{verification: v, requestBody: b})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// the claimed samples are released for the next charge or renewal
+	gbMonths, err := models.UnbilledOverageInGBMonths(accountID, time.Now())
+	assert.Nil(t, err)
+	assert.Equal(t, 1.0, gbMonths)
+}
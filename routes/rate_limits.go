@@ -0,0 +1,122 @@
+package routes
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/utils"
+)
+
+const (
+	rateLimitGroupAccountCreation = "account_creation"
+	rateLimitGroupDownload        = "download"
+	rateLimitGroupDefault         = "default"
+	rateLimitedByIP               = "ip"
+	rateLimitedByAccount          = "account"
+	rateLimitedAccountKey         = "rateLimitedAccount"
+	rateLimitedResponse           = "too many requests, try again later"
+)
+
+/*rateLimitMiddleware throttles requests by client IP, with a separate limit for each route group.  Accounts are
+throttled once verifyRequest knows who signed the request.  c.ClientIP isn't used since it believes whatever
+forwarded headers the client sends.*/
+func rateLimitMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		group, limit := requestRateLimitGroup(c)
+		if err := limitRequests(group, rateLimitedByIP, group+"_"+rateLimitClientIP(c), limit, c); err != nil {
+			return
+		}
+		c.Next()
+	}
+}
+
+/*limitAccountRequests throttles the requests of the account publicKey signed for.  It only counts once per
+request, since some handlers verify a request more than once.*/
+func limitAccountRequests(publicKey string, c *gin.Context) error {
+	if c.Request == nil || c.GetString(rateLimitedAccountKey) == publicKey {
+		return nil
+	}
+	c.Set(rateLimitedAccountKey, publicKey)
+
+	accountID, err := rateLimitAccountID(publicKey, c)
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+	group, _ := requestRateLimitGroup(c)
+	return limitRequests(group, rateLimitedByAccount, accountID,
+		utils.RateLimit{PerMinute: utils.Env.RateLimitPerAccountPerMinute}, c)
+}
+
+/*rateLimitAccountID returns the ID of the account publicKey acts for, so the keys an account delegated share its
+limit instead of each getting their own.  Whether the key is expired or has the scope is left to getAccount.*/
+func rateLimitAccountID(publicKey string, c *gin.Context) (string, error) {
+	if delegatedKey, ok := c.Get(delegatedKeyContextKey); ok && delegatedKey.(models.DelegatedKey).PublicKey == publicKey {
+		return delegatedKey.(models.DelegatedKey).AccountID, nil
+	}
+
+	delegatedKey, err := models.GetDelegatedKey(publicKey)
+	if err == nil {
+		return delegatedKey.AccountID, nil
+	}
+	if !gorm.IsRecordNotFoundError(err) {
+		return "", err
+	}
+	return utils.HashString(publicKey)
+}
+
+/*rateLimitClientIP returns the address of the client that sent the request.  The X-Forwarded-For and X-Real-Ip
+headers are only read when the request came straight from one of utils.Env.TrustedProxies.*/
+func rateLimitClientIP(c *gin.Context) string {
+	peer, _, err := net.SplitHostPort(strings.TrimSpace(c.Request.RemoteAddr))
+	if err != nil {
+		peer = strings.TrimSpace(c.Request.RemoteAddr)
+	}
+	if !utils.IsTrustedProxy(peer) {
+		return peer
+	}
+
+	// each proxy appends the address it got the request from, so the client is the last one that isn't ours
+	forwardedFor := strings.Split(c.GetHeader("X-Forwarded-For"), ",")
+	for i := len(forwardedFor) - 1; i >= 0; i-- {
+		ip := strings.TrimSpace(forwardedFor[i])
+		if ip != "" && !utils.IsTrustedProxy(ip) {
+			return ip
+		}
+	}
+	if realIP := strings.TrimSpace(c.GetHeader("X-Real-Ip")); realIP != "" {
+		return realIP
+	}
+	return peer
+}
+
+/*requestRateLimitGroup returns the route group of the request and the limit for each client IP in it*/
+func requestRateLimitGroup(c *gin.Context) (string, utils.RateLimit) {
+	path := strings.TrimPrefix(c.Request.URL.Path, V1Path)
+	switch {
+	case path == AccountsPath && c.Request.Method == http.MethodPost:
+		return rateLimitGroupAccountCreation, utils.RateLimit{PerMinute: utils.Env.RateLimitAccountCreationPerMinute}
+	case path == DownloadPath:
+		return rateLimitGroupDownload, utils.RateLimit{PerMinute: utils.Env.RateLimitDownloadPerMinute}
+	default:
+		return rateLimitGroupDefault, utils.RateLimit{PerMinute: utils.Env.RateLimitPerIPPerMinute}
+	}
+}
+
+func limitRequests(group, limitedBy, key string, limit utils.RateLimit, c *gin.Context) error {
+	allowed, retryAfter, err := utils.AllowRequest(limitedBy+"_"+key, limit)
+	if err != nil {
+		// an unavailable store shouldn't take the whole API down with it
+		getLogger(c).LogIfError(err, map[string]interface{}{"rateLimitGroup": group})
+		return nil
+	}
+	if !allowed {
+		utils.Metrics_Rate_Limited_Request_Counter.WithLabelValues(group, limitedBy).Inc()
+		return TooManyRequestsResponse(c, retryAfter, errors.New(rateLimitedResponse))
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package routes
+
+import (
+	"crypto/ecdsa"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Init_Rate_Limits(t *testing.T) {
+	setupTests(t)
+}
+
+func returnRateLimitedEngineForTest() *gin.Engine {
+	router := returnEngine()
+	v1 := returnV1Group(router)
+	v1.Use(rateLimitMiddleware())
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	v1.POST(AccountsPath, ok)
+	v1.POST(MetadataGetPath, ok)
+	return router
+}
+
+func enableRateLimitsForTest() func() {
+	utils.Env.RateLimitBackend = utils.RateLimitBackendMemory
+	utils.InitRateLimiter()
+	return func() {
+		utils.Env.RateLimitBackend = utils.RateLimitBackendOff
+		utils.InitRateLimiter()
+	}
+}
+
+func Test_RateLimitMiddleware_Throttles_Account_Creation_By_IP(t *testing.T) {
+	defer enableRateLimitsForTest()()
+
+	router := returnRateLimitedEngineForTest()
+	post := func(path, ip string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, V1Path+path, nil)
+		req.RemoteAddr = ip + ":1234"
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	for i := 0; i < utils.Env.RateLimitAccountCreationPerMinute; i++ {
+		assert.Equal(t, http.StatusOK, post(AccountsPath, "198.51.100.1").Code)
+	}
+	w := post(AccountsPath, "198.51.100.1")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+
+	// other route groups and other addresses have their own limits
+	assert.Equal(t, http.StatusOK, post(MetadataGetPath, "198.51.100.1").Code)
+	assert.Equal(t, http.StatusOK, post(AccountsPath, "198.51.100.2").Code)
+}
+
+func Test_RateLimitMiddleware_Ignores_Forwarded_Headers_From_Untrusted_Peers(t *testing.T) {
+	defer enableRateLimitsForTest()()
+
+	router := returnRateLimitedEngineForTest()
+	post := func(peer, forwardedFor string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, V1Path+AccountsPath, nil)
+		req.RemoteAddr = peer + ":1234"
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		req.Header.Set("X-Real-Ip", forwardedFor)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// a new spoofed address on every request doesn't get a new bucket
+	for i := 0; i < utils.Env.RateLimitAccountCreationPerMinute; i++ {
+		assert.Equal(t, http.StatusOK, post("198.51.100.3", "203.0.113."+strconv.Itoa(i)).Code)
+	}
+	assert.Equal(t, http.StatusTooManyRequests, post("198.51.100.3", "203.0.113.250").Code)
+
+	// behind a trusted proxy, each client has its own bucket
+	_, trustedProxyNet, err := net.ParseCIDR("198.51.100.3/32")
+	assert.Nil(t, err)
+	utils.Env.TrustedProxyNets = []*net.IPNet{trustedProxyNet}
+	defer func() { utils.Env.TrustedProxyNets = nil }()
+
+	assert.Equal(t, http.StatusOK, post("198.51.100.3", "203.0.113.250").Code)
+	assert.Equal(t, http.StatusOK, post("198.51.100.3", "203.0.113.251").Code)
+}
+
+func Test_VerifyRequest_Throttles_Account(t *testing.T) {
+	defer enableRateLimitsForTest()()
+	perAccount := utils.Env.RateLimitPerAccountPerMinute
+	utils.Env.RateLimitPerAccountPerMinute = 1
+	defer func() { utils.Env.RateLimitPerAccountPerMinute = perAccount }()
+
+	privateKey, err := utils.GenerateKey()
+	assert.Nil(t, err)
+	verify := func(data string) *httptest.ResponseRecorder {
+		v, b := returnValidVerificationAndRequestBody(t, testTimestampedRequestObject{
+			Data:      data,
+			Timestamp: time.Now().Unix(),
+		}, privateKey)
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPost, V1Path+MetadataGetPath, nil)
+		verifyAndParseStringRequest(b.RequestBody, &testTimestampedRequestObject{}, v, c)
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, verify("first").Code)
+	assert.Equal(t, http.StatusTooManyRequests, verify("second").Code)
+}
+
+func Test_VerifyRequest_Throttles_Delegated_Keys_With_Their_Account(t *testing.T) {
+	defer enableRateLimitsForTest()()
+	perAccount := utils.Env.RateLimitPerAccountPerMinute
+	utils.Env.RateLimitPerAccountPerMinute = 2
+	defer func() { utils.Env.RateLimitPerAccountPerMinute = perAccount }()
+
+	privateKey, err := utils.GenerateKey()
+	assert.Nil(t, err)
+	var delegatedPrivateKeys []*ecdsa.PrivateKey
+	for i := 0; i < 2; i++ {
+		delegatedPrivateKey, err := utils.GenerateKey()
+		assert.Nil(t, err)
+		_, err = models.SetDelegatedKey(utils.PubkeyCompressedToHex(privateKey.PublicKey),
+			utils.PubkeyCompressedToHex(delegatedPrivateKey.PublicKey), []string{ScopeMetadataRead}, nil)
+		assert.Nil(t, err)
+		delegatedPrivateKeys = append(delegatedPrivateKeys, delegatedPrivateKey)
+	}
+	verify := func(signer *ecdsa.PrivateKey) *httptest.ResponseRecorder {
+		v, b := returnValidVerificationAndRequestBody(t, testTimestampedRequestObject{
+			Data:      utils.RandHexString(8),
+			Timestamp: time.Now().Unix(),
+		}, signer)
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPost, V1Path+MetadataGetPath, nil)
+		verifyAndParseStringRequest(b.RequestBody, &testTimestampedRequestObject{}, v, c)
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, verify(privateKey).Code)
+	assert.Equal(t, http.StatusOK, verify(delegatedPrivateKeys[0]).Code)
+	assert.Equal(t, http.StatusTooManyRequests, verify(delegatedPrivateKeys[1]).Code)
+}
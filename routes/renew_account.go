@@ -4,16 +4,17 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"math"
 	"math/big"
 
 	"github.com/gin-gonic/gin"
 	"github.com/opacity/storage-node/models"
 	"github.com/opacity/storage-node/services"
 	"github.com/opacity/storage-node/utils"
-	"time"
 )
 
 type getRenewalAccountInvoiceObject struct {
+	CouponCode string `json:"couponCode" binding:"omitempty,max=64" maxLength:"64" example:"SPRING20"`
 }
 
 type checkRenewalStatusObject struct {
@@ -55,7 +56,11 @@ func (v *checkRenewalStatusReq) getObjectRef() interface{} {
 // @Param getRenewalAccountInvoiceReq body routes.getRenewalAccountInvoiceReq true "get renewal invoice object"
 // @description requestBody should be a stringified version of (values are just examples):
 // @description {
+// @description 	"couponCode": "SPRING20"
 // @description }
+// @description couponCode is optional.  Its discount is taken off the invoice's cost, after any credit, and its
+// @description extra months are added to the renewal once it is paid.  Overage the account stored over its plan and
+// @description hasn't paid for is added to the cost, after the coupon, and shows up as overage.
 // @Success 200 {object} routes.getRenewalAccountInvoiceRes
 // @Failure 400 {string} string "bad request, unable to parse request body: (with the error)"
 // @Failure 404 {string} string "no account with that id: (with your accountID)"
@@ -103,12 +108,19 @@ func getAccountRenewalInvoice(c *gin.Context) error {
 		return err
 	}
 
-	renewalCostInOPCT, err := account.Cost()
+	renewalCostInOPCT, err := account.RenewalCost()
 	if err != nil {
 		return InternalErrorResponse(c, err)
 	}
+	if err := verifyScheduledDowngradeFits(account, c); err != nil {
+		return err
+	}
+
+	// credit from downgrading comes off the renewal
+	creditInOPCT := math.Min(account.CreditInOPCT, renewalCostInOPCT)
+	renewalCostInOPCT = utils.RoundCost(renewalCostInOPCT - creditInOPCT)
 
-	//renewalCostInUSD := utils.Env.Plans[int(account.StorageLimit)].CostInUSD
+	//renewalCostInUSD := utils.SubscriptionCostInUSD(account.Plan(), models.DefaultMonthsPerSubscription)
 
 	ethAddr, privKey, err := services.EthWrapper.GenerateWallet()
 	if err != nil {
@@ -132,19 +144,42 @@ func getAccountRenewalInvoice(c *gin.Context) error {
 		EthPrivateKey:    hex.EncodeToString(encryptedKeyInBytes),
 		PaymentStatus:    models.InitialPaymentInProgress,
 		OpctCost:         renewalCostInOPCT,
-		DurationInMonths: 12,
+		CreditInOPCT:     creditInOPCT,
+		DurationInMonths: models.DefaultMonthsPerSubscription,
+	}
+
+	if request.getRenewalAccountInvoiceObject.CouponCode != "" {
+		coupon, err := models.GetRedeemableCoupon(request.getRenewalAccountInvoiceObject.CouponCode, account.AccountID,
+			account.RenewalStorageLimit())
+		if err == nil {
+			err = renewal.ApplyCoupon(coupon, account)
+		}
+		if err != nil {
+			return couponErrorResponse(c, err)
+		}
+	}
+	if err := renewal.AddUnbilledOverage(); err != nil {
+		return InternalErrorResponse(c, err)
 	}
 
 	renewalInDB, err := models.GetOrCreateRenewal(renewal)
+	if err == models.ErrCouponRedeemedOut {
+		return couponErrorResponse(c, err)
+	}
 	if err != nil {
 		err = fmt.Errorf("error getting or creating renewal:  %v", err)
 		return ServiceUnavailableResponse(c, err)
 	}
+	if err := renewalInDB.RecordInvoice(account); err != nil {
+		return InternalErrorResponse(c, err)
+	}
 
 	return OkResponse(c, getRenewalAccountInvoiceRes{
 		OpctInvoice: models.Invoice{
-			Cost:       renewalCostInOPCT,
+			Cost:       renewalInDB.OpctCost,
 			EthAddress: renewalInDB.EthAddress,
+			Discount:   renewalInDB.DiscountInOPCT,
+			Overage:    renewalInDB.OverageInOPCT,
 		},
 		// TODO: uncomment out if we decide to support stripe for renewals
 		// UsdInvoice: renewalCostInUSD,
@@ -195,25 +230,31 @@ func checkRenewalStatus(c *gin.Context) error {
 	if err := models.DB.Model(&renewals[0]).Update("payment_status", models.InitialPaymentReceived).Error; err != nil {
 		return InternalErrorResponse(c, err)
 	}
-	if err := renewalAccountAndUpdateExpireDates(account, request, c); err != nil {
+	if err := renewalAccountAndUpdateExpireDates(account, renewals[0].ExtraMonths, request, c); err != nil {
+		return InternalErrorResponse(c, err)
+	}
+	if err := account.UseCredit(renewals[0].CreditInOPCT); err != nil {
 		return InternalErrorResponse(c, err)
 	}
+	renewals[0].RecordCouponRedemption()
+	renewals[0].BillOverage()
 	return OkResponse(c, StatusRes{
 		Status: "Success with OPCT",
 	})
 }
 
-func renewalAccountAndUpdateExpireDates(account models.Account, request checkRenewalStatusReq, c *gin.Context) error {
-	if err := account.RenewAccount(); err != nil {
+func renewalAccountAndUpdateExpireDates(account models.Account, extraMonths int, request checkRenewalStatusReq,
+	c *gin.Context) error {
+	if err := account.RenewAccountWithExtraMonths(extraMonths); err != nil {
 		return err
 	}
 	filesErr := models.UpdateExpiredAt(request.checkRenewalStatusObject.FileHandles,
 		request.verification.PublicKey, account.ExpirationDate())
 
-	// Setting ttls on metadata to 2 months post account expiration date so the metadatas won't
-	// be deleted too soon
+	// Extend the metadata and files the client sent right away, the expirationExtender job catches
+	// anything the client left out
 	metadatasErr := updateMetadataExpiration(request.checkRenewalStatusObject.MetadataKeys,
-		request.verification.PublicKey, account.ExpirationDate().Add(24*time.Hour*60), c)
+		request.verification.PublicKey, account.ExpirationDate().Add(models.MetadataExpirationGracePeriod()), c)
 
 	return utils.CollectErrors([]error{filesErr, metadatasErr})
 }
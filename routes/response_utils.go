@@ -4,8 +4,10 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"math"
 	"net/http"
 	"runtime/debug"
+	"strconv"
 	"strings"
 
 	"time"
@@ -52,12 +54,25 @@ func FileNotFoundResponse(c *gin.Context, fileId string) error {
 	return NotFoundResponse(c, fmt.Errorf("no file with that id: %s", fileId))
 }
 
+func UnauthorizedResponse(c *gin.Context, err error) error {
+	c.AbortWithStatusJSON(http.StatusUnauthorized, err.Error())
+	utils.Metrics_401_Response_Counter.Inc()
+	return err
+}
+
 func ForbiddenResponse(c *gin.Context, err error) error {
 	c.AbortWithStatusJSON(http.StatusForbidden, err.Error())
 	utils.Metrics_403_Response_Counter.Inc()
 	return err
 }
 
+func TooManyRequestsResponse(c *gin.Context, retryAfter time.Duration, err error) error {
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, err.Error())
+	utils.Metrics_429_Response_Counter.Inc()
+	return err
+}
+
 func AccountNotPaidResponse(c *gin.Context, response interface{}) error {
 	if err := utils.Validator.Struct(response); err != nil {
 		err = fmt.Errorf("could not create a valid response:  %v", err)
@@ -127,7 +142,10 @@ func ginHandlerFunc(f handlerFunc) gin.HandlerFunc {
 			}
 		}()
 
-		f(c)
+		// kept on the context for middleware that reports on the request, like the admin audit log
+		if err := f(c); err != nil {
+			c.Error(err)
+		}
 	}
 	return gin.HandlerFunc(injectToRecoverFromPanic)
 }
@@ -154,40 +172,48 @@ func verifyIfPaid(account models.Account) bool {
 }
 
 func verifyAccountStillActive(account models.Account) bool {
-	return account.ExpirationDate().After(time.Now()) || utils.Env.Plans[int(account.StorageLimit)].Name == "Free"
+	return account.State() == models.AccountStateActive
 }
 
+/*verifyIfPaidWithContext refuses the request if the account hasn't been paid for.  Whether an expired account
+can still make the request is checked by verifyAccountState when the account is looked up.*/
 func verifyIfPaidWithContext(account models.Account, c *gin.Context) error {
-	paid := verifyIfPaid(account)
+	if !verifyIfPaid(account) {
+		return AccountNotPaidResponse(c, accountInvoiceResponse(account))
+	}
+	return nil
+}
 
+/*accountInvoiceResponse returns the invoice and expiration date sent with responses refusing an account that
+isn't paid for or has expired*/
+func accountInvoiceResponse(account models.Account) accountCreateRes {
 	cost, _ := account.Cost()
-	response := accountCreateRes{
+	return accountCreateRes{
 		Invoice: models.Invoice{
 			Cost:       cost,
 			EthAddress: account.EthAddress,
+			Discount:   account.DiscountInOPCT,
 		},
 		ExpirationDate: account.ExpirationDate(),
 	}
-
-	if !paid {
-		return AccountNotPaidResponse(c, response)
-	}
-
-	if !verifyAccountStillActive(account) {
-		return AccountExpiredResponse(c, response)
-	}
-
-	return nil
 }
 
 func verifyValidStorageLimit(storageLimit int, c *gin.Context) error {
-	_, ok := utils.Env.Plans[storageLimit]
+	_, ok := models.GetPurchasablePlan(storageLimit)
 	if !ok {
 		return BadRequestResponse(c, models.InvalidStorageLimitError)
 	}
 	return nil
 }
 
+func verifySubscriptionMonths(storageLimit int, months int, c *gin.Context) error {
+	plan, _ := models.GetPurchasablePlan(storageLimit)
+	if err := utils.CheckSubscriptionMonths(plan.Info(), months); err != nil {
+		return BadRequestResponse(c, err)
+	}
+	return nil
+}
+
 func verifyUpgradeEligible(account models.Account, newStorageLimit int, c *gin.Context) error {
 	err := verifyValidStorageLimit(newStorageLimit, c)
 	if err != nil {
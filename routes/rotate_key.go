@@ -0,0 +1,101 @@
+package routes
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/utils"
+)
+
+const sameKeyRotationError = "newPublicKey must be different from publicKey"
+
+// must be sorted alphabetically for JSON marshaling/stringifying
+type rotateKeyObject struct {
+	NewPublicKey string `json:"newPublicKey" binding:"required,len=66" minLength:"66" maxLength:"66" example:"a 66-character public key"`
+	Timestamp    int64  `json:"timestamp" binding:"required"`
+}
+
+type rotateKeyReq struct {
+	verification
+	requestBody
+	NewKeySignature string `json:"newKeySignature" binding:"required,min=128,max=130" minLength:"128" maxLength:"130" example:"the same request signed with the new private key"`
+	rotateKeyObject rotateKeyObject
+}
+
+type rotateKeyRes struct {
+	NewAccountID string `json:"newAccountID" binding:"required,len=64"`
+	Status       string `json:"status" example:"RekeyingMetadatas"`
+}
+
+func (v *rotateKeyReq) getObjectRef() interface{} {
+	return &v.rotateKeyObject
+}
+
+// RotateKeyHandler godoc
+// @Summary move an account to a new key
+// @Accept  json
+// @Produce  json
+// @Param rotateKeyReq body routes.rotateKeyReq true "rotate key object"
+// @description The request must be signed with both keys:  signature with the current private key, as usual,
+// @description and newKeySignature with the new private key over the same requestBody (and, for v2 signatures,
+// @description the same method, path and timestamp).  The account moves to the new key right away and the old
+// @description key stops working.  Metadata and files keep working with the new key while their permission
+// @description hashes are re-derived in the background.
+// @description requestBody should be a stringified version of (values are just examples):
+// @description {
+// @description 	"newPublicKey": "a 66-character public key",
+// @description 	"timestamp": 1557346389
+// @description }
+// @Success 200 {object} routes.rotateKeyRes
+// @Failure 400 {string} string "bad request, unable to parse request body: (with the error)"
+// @Failure 403 {string} string "signature did not match"
+// @Failure 404 {string} string "no account with that id: (with your accountID)"
+// @Failure 500 {string} string "some information about the internal error"
+// @Failure 503 {string} string "maintenance in progress, currently rejecting writes"
+// @Router /api/v1/rotate-key [post]
+/*RotateKeyHandler is a handler for moving an account to a new key*/
+func RotateKeyHandler() gin.HandlerFunc {
+	return ginHandlerFunc(rotateKey)
+}
+
+func rotateKey(c *gin.Context) error {
+	if !utils.WritesEnabled() {
+		return ServiceUnavailableResponse(c, maintenanceError)
+	}
+
+	request := rotateKeyReq{}
+	if err := verifyAndParseBodyRequest(&request, c); err != nil {
+		return err
+	}
+
+	newPublicKey := request.rotateKeyObject.NewPublicKey
+	if newPublicKey == request.PublicKey {
+		return BadRequestResponse(c, errors.New(sameKeyRotationError))
+	}
+	// proves the caller holds the new key too, so an account can't be handed to a key nobody controls
+	if err := verifyRequest(request.RequestBody, verification{
+		PublicKey: newPublicKey,
+		Signature: request.NewKeySignature,
+	}, c); err != nil {
+		return err
+	}
+
+	if _, err := request.getAccount(c); err != nil {
+		return err
+	}
+
+	rotation, err := models.StartKeyRotation(request.PublicKey, newPublicKey)
+	if err == models.ErrKeyRotationTargetExists || err == models.ErrKeyRotatedAway ||
+		err == models.ErrKeyRotationInProgress {
+		return BadRequestResponse(c, err)
+	}
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+
+	return OkResponse(c, rotateKeyRes{
+		NewAccountID: rotation.NewAccountID,
+		Status:       models.KeyRotationStatusMap[rotation.Status],
+	})
+}
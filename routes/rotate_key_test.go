@@ -0,0 +1,87 @@
+package routes
+
+import (
+	"crypto/ecdsa"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Init_Rotate_Key(t *testing.T) {
+	setupTests(t)
+}
+
+func returnRotateKeyReqForTest(t *testing.T, privateKey, newPrivateKey *ecdsa.PrivateKey) rotateKeyReq {
+	rotateKeyObj := rotateKeyObject{
+		NewPublicKey: utils.PubkeyCompressedToHex(newPrivateKey.PublicKey),
+		Timestamp:    time.Now().Unix(),
+	}
+	v, b := returnValidVerificationAndRequestBody(t, rotateKeyObj, privateKey)
+	return rotateKeyReq{
+		verification:    v,
+		requestBody:     b,
+		NewKeySignature: setupVerificationWithPrivateKeyForTest(t, b.RequestBody, newPrivateKey).Signature,
+	}
+}
+
+func Test_RotateKey_Moves_Account_And_Keeps_Metadata_Access(t *testing.T) {
+	accountID, privateKey := generateValidateAccountId(t)
+	CreatePaidAccountForTest(t, accountID)
+	metadataKey := createIndexedMetadataForTest(t, accountID, privateKey, "current", nil)
+
+	newAccountID, newPrivateKey := generateValidateAccountId(t)
+	w := httpPostRequestHelperForTest(t, RotateKeyPath, returnRotateKeyReqForTest(t, privateKey, newPrivateKey))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), newAccountID)
+
+	_, err := models.GetAccountById(accountID)
+	assert.NotNil(t, err)
+	_, err = models.GetAccountById(newAccountID)
+	assert.Nil(t, err)
+
+	// the permission hash hasn't been re-derived yet, but the new key can already use it and the old one can't
+	owned, err := ownsMetadata(utils.PubkeyCompressedToHex(newPrivateKey.PublicKey), metadataKey)
+	assert.Nil(t, err)
+	assert.True(t, owned)
+
+	rotation, err := models.GetKeyRotation(newAccountID)
+	assert.Nil(t, err)
+	for done := false; !done; {
+		done, err = rotation.Run(1)
+		assert.Nil(t, err)
+	}
+	permissionHash, _, err := utils.GetValueFromKV(getPermissionHashKeyForBadger(metadataKey))
+	assert.Nil(t, err)
+	expectedPermissionHash, _ := utils.HashString(utils.PubkeyCompressedToHex(newPrivateKey.PublicKey) + metadataKey)
+	assert.Equal(t, expectedPermissionHash, permissionHash)
+}
+
+func Test_RotateKey_Requires_New_Key_Signature(t *testing.T) {
+	accountID, privateKey := generateValidateAccountId(t)
+	CreatePaidAccountForTest(t, accountID)
+	_, newPrivateKey := generateValidateAccountId(t)
+	_, otherPrivateKey := generateValidateAccountId(t)
+
+	post := returnRotateKeyReqForTest(t, privateKey, newPrivateKey)
+	post.NewKeySignature = setupVerificationWithPrivateKeyForTest(t, post.RequestBody, otherPrivateKey).Signature
+	w := httpPostRequestHelperForTest(t, RotateKeyPath, post)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	_, err := models.GetAccountById(accountID)
+	assert.Nil(t, err)
+}
+
+func Test_RotateKey_Refuses_Key_With_Account(t *testing.T) {
+	accountID, privateKey := generateValidateAccountId(t)
+	CreatePaidAccountForTest(t, accountID)
+	newAccountID, newPrivateKey := generateValidateAccountId(t)
+	CreatePaidAccountForTest(t, newAccountID)
+
+	w := httpPostRequestHelperForTest(t, RotateKeyPath, returnRotateKeyReqForTest(t, privateKey, newPrivateKey))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), models.ErrKeyRotationTargetExists.Error())
+}
@@ -9,9 +9,9 @@ import (
 
 	_ "github.com/opacity/storage-node/docs"
 
-	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/opacity/storage-node/jobs"
+	"github.com/opacity/storage-node/models"
 	"github.com/opacity/storage-node/services"
 	"github.com/opacity/storage-node/utils"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -52,6 +52,33 @@ const (
 	/*AccountUpgradePath is the path for checking the upgrade status of an account*/
 	AccountUpgradePath = "/upgrade"
 
+	/*AccountDowngradePath is the path for moving an account to a smaller plan*/
+	AccountDowngradePath = "/downgrade"
+
+	/*AccountDowngradeCancelPath is the path for cancelling a downgrade scheduled for the next renewal*/
+	AccountDowngradeCancelPath = "/downgrade/cancel"
+
+	/*AccountDeletePath is the path for requesting an account be deleted*/
+	AccountDeletePath = "/account/delete"
+
+	/*AccountDeleteConfirmPath is the path for confirming an account deletion*/
+	AccountDeleteConfirmPath = "/account/delete/confirm"
+
+	/*AccountDeleteCancelPath is the path for cancelling an account deletion before it happens*/
+	AccountDeleteCancelPath = "/account/delete/cancel"
+
+	/*AccountDeleteReceiptPath is the path for getting the signed receipt of an account deletion*/
+	AccountDeleteReceiptPath = "/account/delete/receipt"
+
+	/*AccountLedgerPath is the path for getting an account's invoice and payment history*/
+	AccountLedgerPath = "/account/ledger"
+
+	/*AccountUsagePath is the path for getting what an account stored each day*/
+	AccountUsagePath = "/account/usage"
+
+	/*AccountOveragePath is the path for opting in to or out of storing more than an account's plan*/
+	AccountOveragePath = "/account/overage"
+
 	/*AccountUpgradeInvoicePath is the path for getting an invoice to renew an account*/
 	AccountRenewInvoicePath = "/renew/invoice"
 
@@ -61,6 +88,9 @@ const (
 	/*AdminPath is a router group for admin task. */
 	AdminPath = "/admin"
 
+	/*SwaggerPath is where the Swagger UI and docs are served*/
+	SwaggerPath = "/swagger"
+
 	/*MetadataGetPath is the path for getting metadata*/
 	MetadataGetPath = "/metadata/get"
 
@@ -76,6 +106,54 @@ const (
 	/*MetadataDeletePath is the path for deleting a metadata*/
 	MetadataDeletePath = "/metadata/delete"
 
+	/*MetadataClaimPath is the path for claiming a metadata that was created without a permission hash*/
+	MetadataClaimPath = "/metadata/claim"
+
+	/*MetadataExportPath is the path for exporting all of an account's metadata*/
+	MetadataExportPath = "/metadata/export"
+
+	/*MetadataImportPath is the path for importing a metadata archive into an account*/
+	MetadataImportPath = "/metadata/import"
+
+	/*RotateKeyPath is the path for moving an account to a new key*/
+	RotateKeyPath = "/rotate-key"
+
+	/*DelegatedKeyAddPath is the path for letting another key act for an account*/
+	DelegatedKeyAddPath = "/delegated-keys/add"
+
+	/*DelegatedKeyRemovePath is the path for revoking a delegated key*/
+	DelegatedKeyRemovePath = "/delegated-keys/remove"
+
+	/*DelegatedKeyListPath is the path for listing an account's delegated keys*/
+	DelegatedKeyListPath = "/delegated-keys/list"
+
+	/*OrganizationPath is the path for getting the organization an account owns or belongs to*/
+	OrganizationPath = "/organization"
+
+	/*OrganizationCreatePath is the path for sharing an account's plan with other accounts*/
+	OrganizationCreatePath = "/organization/create"
+
+	/*OrganizationInvitePath is the path for inviting an account to an organization*/
+	OrganizationInvitePath = "/organization/invite"
+
+	/*OrganizationJoinPath is the path for joining an organization*/
+	OrganizationJoinPath = "/organization/join"
+
+	/*OrganizationRemovePath is the path for removing a member from an organization*/
+	OrganizationRemovePath = "/organization/remove"
+
+	/*OrganizationCapPath is the path for capping what a member stores in an organization*/
+	OrganizationCapPath = "/organization/cap"
+
+	/*SessionLoginPath is the path for getting a session token with a signed request*/
+	SessionLoginPath = "/session/login"
+
+	/*SessionRefreshPath is the path for swapping a session token for a new one*/
+	SessionRefreshPath = "/session/refresh"
+
+	/*SessionLogoutPath is the path for revoking a session token*/
+	SessionLogoutPath = "/session/logout"
+
 	/*InitUploadPath is the path for uploading files to paid accounts*/
 	InitUploadPath = "/init-upload"
 
@@ -93,6 +171,9 @@ const (
 
 	/*StripeCreatePath is the path for creating a stripe payment*/
 	StripeCreatePath = "/stripe/create"
+
+	/*StripeOveragePath is the path for paying for overage by card*/
+	StripeOveragePath = "/stripe/overage"
 )
 
 const MaxRequestSize = utils.MaxMultiPartSize + 1000
@@ -119,7 +200,7 @@ func CreateRoutes() {
 	setupV1Paths(returnV1Group(router))
 	setupAdminPaths(router)
 
-	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	router.GET(SwaggerPath+"/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	// Listen and Serve
 	err := router.Run(":" + os.Getenv("PORT"))
@@ -128,11 +209,8 @@ func CreateRoutes() {
 
 func returnEngine() *gin.Engine {
 	router := gin.Default()
-	config := cors.DefaultConfig()
-
-	// TODO:  update to only allow our frontend and localhost
-	config.AllowAllOrigins = true
-	router.Use(cors.New(config))
+	router.Use(securityHeadersMiddleware())
+	router.Use(corsMiddleware())
 
 	// Test app is running
 	router.GET("/", func(c *gin.Context) {
@@ -152,20 +230,56 @@ func returnV1Group(router *gin.Engine) *gin.RouterGroup {
 }
 
 func setupV1Paths(v1Router *gin.RouterGroup) {
+	v1Router.Use(rateLimitMiddleware())
+	v1Router.Use(sessionTokenMiddleware())
+
 	v1Router.POST(AccountsPath, CreateAccountHandler())
 	v1Router.POST(AccountDataPath, CheckAccountPaymentStatusHandler())
 
 	v1Router.POST(AccountUpgradeInvoicePath, GetAccountUpgradeInvoiceHandler())
 	v1Router.POST(AccountUpgradePath, CheckUpgradeStatusHandler())
 
+	v1Router.POST(AccountDowngradePath, DowngradeAccountHandler())
+	v1Router.POST(AccountDowngradeCancelPath, CancelDowngradeHandler())
+
+	v1Router.POST(AccountDeletePath, DeleteAccountHandler())
+	v1Router.POST(AccountDeleteConfirmPath, ConfirmDeleteAccountHandler())
+	v1Router.POST(AccountDeleteCancelPath, CancelDeleteAccountHandler())
+	v1Router.POST(AccountDeleteReceiptPath, DeleteAccountReceiptHandler())
+
+	v1Router.POST(AccountLedgerPath, GetLedgerHandler())
+
+	v1Router.POST(AccountUsagePath, GetUsageHistoryHandler())
+	v1Router.POST(AccountOveragePath, SetAccountOverageHandler())
+
 	v1Router.POST(AccountRenewInvoicePath, GetAccountRenewalInvoiceHandler())
 	v1Router.POST(AccountRenewPath, CheckRenewalStatusHandler())
 
+	v1Router.POST(RotateKeyPath, RotateKeyHandler())
+
+	v1Router.POST(DelegatedKeyAddPath, AddDelegatedKeyHandler())
+	v1Router.POST(DelegatedKeyRemovePath, RemoveDelegatedKeyHandler())
+	v1Router.POST(DelegatedKeyListPath, ListDelegatedKeysHandler())
+
+	v1Router.POST(OrganizationPath, GetOrganizationHandler())
+	v1Router.POST(OrganizationCreatePath, CreateOrganizationHandler())
+	v1Router.POST(OrganizationInvitePath, InviteOrganizationMemberHandler())
+	v1Router.POST(OrganizationJoinPath, JoinOrganizationHandler())
+	v1Router.POST(OrganizationRemovePath, RemoveOrganizationMemberHandler())
+	v1Router.POST(OrganizationCapPath, CapOrganizationMemberHandler())
+
 	v1Router.POST(MetadataSetPath, UpdateMetadataHandler())
 	v1Router.POST(MetadataGetPath, GetMetadataHandler())
 	v1Router.POST(MetadataHistoryPath, GetMetadataHistoryHandler())
 	v1Router.POST(MetadataCreatePath, CreateMetadataHandler())
 	v1Router.POST(MetadataDeletePath, DeleteMetadataHandler())
+	v1Router.POST(MetadataClaimPath, ClaimMetadataHandler())
+	v1Router.POST(MetadataExportPath, ExportMetadataHandler())
+	v1Router.POST(MetadataImportPath, ImportMetadataHandler())
+
+	v1Router.POST(SessionLoginPath, SessionLoginHandler())
+	v1Router.POST(SessionRefreshPath, SessionRefreshHandler())
+	v1Router.POST(SessionLogoutPath, SessionLogoutHandler())
 
 	v1Router.POST(InitUploadPath, InitFileUploadHandler())
 	v1Router.POST(UploadPath, UploadFileHandler())
@@ -177,26 +291,55 @@ func setupV1Paths(v1Router *gin.RouterGroup) {
 
 	// Stripe endpoints
 	v1Router.POST(StripeCreatePath, CreateStripePaymentHandler())
+	v1Router.POST(StripeOveragePath, PayOverageWithStripeHandler())
 }
 
 func setupAdminPaths(router *gin.Engine) {
 	router.LoadHTMLGlob("templates/*")
 
-	g := router.Group(AdminPath, gin.BasicAuth(gin.Accounts{
-		utils.Env.AdminUser: utils.Env.AdminPassword,
-	}))
+	g := router.Group(AdminPath, adminAuthMiddleware())
+	viewer := requireAdminRole(models.AdminRoleViewer)
+	operator := requireAdminRole(models.AdminRoleOperator)
+	superuser := requireAdminRole(models.AdminRoleSuperuser)
 
-	g.GET("/jobrunner/json", jobs.JobJson)
+	g.GET("/jobrunner/json", viewer, jobs.JobJson)
 
-	g.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	g.GET("/metrics", viewer, gin.WrapH(promhttp.Handler()))
 
-	g.GET("/delete", func(c *gin.Context) {
+	g.GET("/delete", operator, func(c *gin.Context) {
 		c.HTML(http.StatusOK, "admin-delete.tmpl", gin.H{
 			"title": "Delete File",
 		})
 	})
 
-	g.POST("/delete", AdminDeleteFileHandler())
+	g.POST("/delete", auditAdminAction("fileId"), operator, AdminDeleteFileHandler())
+
+	g.GET("/metadata/migration-report", viewer, AdminMetadataMigrationReportHandler())
+
+	g.GET("/badger/backup", auditAdminAction("since"), superuser, AdminBadgerBackupDownloadHandler())
+	g.POST("/badger/backup", auditAdminAction("since"), operator, AdminBadgerBackupHandler())
+	g.POST("/badger/restore", auditAdminAction("objectKey"), superuser, AdminBadgerRestoreHandler())
+
+	g.GET("/expiration-extensions", viewer, AdminExpirationExtensionsHandler())
+	g.GET("/key-rotations", viewer, AdminKeyRotationsHandler())
+
+	g.GET("/users", superuser, AdminUsersHandler())
+	g.POST("/users", auditAdminAction("username"), superuser, AdminSetUserHandler())
+	g.POST("/users/delete", auditAdminAction("username"), superuser, AdminDeleteUserHandler())
+	g.GET("/audit-log", superuser, AdminAuditLogHandler())
+
+	g.GET("/plans", viewer, AdminPlansHandler())
+	g.POST("/plans", auditAdminAction("storageInGB"), superuser, AdminCreatePlanHandler())
+	g.POST("/plans/availability", auditAdminAction("id"), superuser, AdminSetPlanAvailabilityHandler())
+	g.POST("/plans/delete", auditAdminAction("id"), superuser, AdminDeletePlanHandler())
+
+	g.GET("/ledger", viewer, AdminLedgerHandler())
+	g.POST("/ledger/refund", auditAdminAction("accountID"), superuser, AdminRecordRefundHandler())
+
+	g.GET("/coupons", viewer, AdminCouponsHandler())
+	g.POST("/coupons", auditAdminAction("code"), superuser, AdminCreateCouponHandler())
+	g.POST("/coupons/expire", auditAdminAction("id"), superuser, AdminExpireCouponHandler())
+	g.GET("/coupons/redemptions", viewer, AdminCouponRedemptionsHandler())
 
 	// Load template file location relative to the current working directory
 	// Unable to find the file.
@@ -218,6 +361,6 @@ func GetPlansHandler() gin.HandlerFunc {
 
 func getPlans(c *gin.Context) error {
 	return OkResponse(c, PlanResponse{
-		Plans: utils.Env.Plans,
+		Plans: models.GetPurchasablePlans(),
 	})
 }
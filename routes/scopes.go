@@ -0,0 +1,68 @@
+package routes
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const unknownScopeError = "unknown scope: "
+
+/*Scopes that session tokens and delegated keys can be limited to*/
+const (
+	ScopeAccountRead   = "account:read"
+	ScopeMetadataRead  = "metadata:read"
+	ScopeMetadataWrite = "metadata:write"
+	ScopeFilesUpload   = "files:upload"
+	ScopeFilesDownload = "files:download"
+	ScopeFilesDelete   = "files:delete"
+)
+
+// the only paths that session tokens and delegated keys can be used for, and the scope each needs.  Everything
+// else, including paying for or changing the account, needs a request signed with the account key.
+var pathScopes = map[string]string{
+	AccountDataPath:     ScopeAccountRead,
+	AccountUsagePath:    ScopeAccountRead,
+	MetadataGetPath:     ScopeMetadataRead,
+	MetadataHistoryPath: ScopeMetadataRead,
+	MetadataExportPath:  ScopeMetadataRead,
+	MetadataSetPath:     ScopeMetadataWrite,
+	MetadataCreatePath:  ScopeMetadataWrite,
+	MetadataDeletePath:  ScopeMetadataWrite,
+	MetadataClaimPath:   ScopeMetadataWrite,
+	MetadataImportPath:  ScopeMetadataWrite,
+	InitUploadPath:      ScopeFilesUpload,
+	UploadPath:          ScopeFilesUpload,
+	UploadStatusPath:    ScopeFilesUpload,
+	DownloadPath:        ScopeFilesDownload,
+	DeletePath:          ScopeFilesDelete,
+}
+
+/*requestScope returns the scope the request's path needs, and false if the path needs the account key*/
+func requestScope(c *gin.Context) (string, bool) {
+	if c.Request == nil {
+		return "", false
+	}
+	scope, ok := pathScopes[strings.TrimPrefix(c.Request.URL.Path, V1Path)]
+	return scope, ok
+}
+
+func isScope(scope string) bool {
+	for _, s := range pathScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+/*verifyScopes checks that every scope is one we know about*/
+func verifyScopes(scopes []string, c *gin.Context) error {
+	for _, scope := range scopes {
+		if !isScope(scope) {
+			return BadRequestResponse(c, errors.New(unknownScopeError+scope))
+		}
+	}
+	return nil
+}
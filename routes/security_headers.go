@@ -0,0 +1,136 @@
+package routes
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+	"github.com/opacity/storage-node/utils"
+)
+
+type corsPolicy struct {
+	allowedOrigins   string
+	allowedMethods   string
+	allowedHeaders   string
+	maxAgeInSeconds  int
+	allowCredentials bool
+}
+
+/*corsMiddleware applies the CORS policy of the part of the API the request is for:  /admin and /swagger have
+their own allowed origins, methods, headers and max age, and everything else uses the /api/v1 ones.
+Cross-origin requests from origins a policy doesn't allow are refused.*/
+func corsMiddleware() gin.HandlerFunc {
+	api := newCorsHandler(corsPolicy{
+		allowedOrigins:  utils.Env.CorsAllowedOrigins,
+		allowedMethods:  utils.Env.CorsAllowedMethods,
+		allowedHeaders:  utils.Env.CorsAllowedHeaders,
+		maxAgeInSeconds: utils.Env.CorsMaxAgeInSeconds,
+	})
+	admin := newCorsHandler(corsPolicy{
+		allowedOrigins:  utils.Env.AdminCorsAllowedOrigins,
+		allowedMethods:  utils.Env.AdminCorsAllowedMethods,
+		allowedHeaders:  utils.Env.AdminCorsAllowedHeaders,
+		maxAgeInSeconds: utils.Env.AdminCorsMaxAgeInSeconds,
+		// the admin pages use basic auth, which a browser only sends cross-origin with credentials allowed
+		allowCredentials: true,
+	})
+	swagger := newCorsHandler(corsPolicy{
+		allowedOrigins:  utils.Env.SwaggerCorsAllowedOrigins,
+		allowedMethods:  utils.Env.SwaggerCorsAllowedMethods,
+		allowedHeaders:  utils.Env.SwaggerCorsAllowedHeaders,
+		maxAgeInSeconds: utils.Env.SwaggerCorsMaxAgeInSeconds,
+	})
+
+	return func(c *gin.Context) {
+		// cors only skips same-origin requests by the Host header, which net/http moves to Request.Host
+		if isSameOrigin(c) {
+			return
+		}
+		switch path := c.Request.URL.Path; {
+		case hasPathPrefix(path, AdminPath):
+			admin(c)
+		case hasPathPrefix(path, SwaggerPath):
+			swagger(c)
+		default:
+			api(c)
+		}
+	}
+}
+
+/*securityHeadersMiddleware sets the standard security headers on every response.  The admin pages and the
+Swagger UI also can't be framed by any page, including our own.*/
+func securityHeadersMiddleware() gin.HandlerFunc {
+	hsts := fmt.Sprintf("max-age=%d; includeSubDomains", utils.Env.HstsMaxAgeInSeconds)
+
+	return func(c *gin.Context) {
+		header := c.Writer.Header()
+		header.Set("Strict-Transport-Security", hsts)
+		header.Set("X-Frame-Options", "DENY")
+		header.Set("X-Content-Type-Options", "nosniff")
+		header.Set("Referrer-Policy", utils.Env.ReferrerPolicy)
+
+		path := c.Request.URL.Path
+		if hasPathPrefix(path, AdminPath) || hasPathPrefix(path, SwaggerPath) {
+			header.Set("Content-Security-Policy", "frame-ancestors 'none'")
+		}
+	}
+}
+
+func newCorsHandler(policy corsPolicy) gin.HandlerFunc {
+	config := cors.DefaultConfig()
+	config.AllowMethods = splitList(policy.allowedMethods)
+	config.AddAllowHeaders(utils.RequestSigningVersionHeader, utils.RequestSigningTimestampHeader,
+		utils.RequestSignatureTypeHeader, "Authorization")
+	config.AddAllowHeaders(splitList(policy.allowedHeaders)...)
+	config.MaxAge = time.Duration(policy.maxAgeInSeconds) * time.Second
+
+	origins := splitList(policy.allowedOrigins)
+	for _, origin := range origins {
+		if origin == "*" {
+			config.AllowAllOrigins = true
+			return cors.New(config)
+		}
+	}
+	if len(origins) == 0 {
+		// same-origin requests are let through by corsMiddleware, so this only refuses cross-origin ones
+		config.AllowOriginFunc = func(string) bool { return false }
+		return cors.New(config)
+	}
+	config.AllowOrigins = origins
+	// lets an origin like https://*.opacity.io allow every subdomain
+	config.AllowWildcard = true
+	config.AllowCredentials = policy.allowCredentials
+	return cors.New(config)
+}
+
+/*isSameOrigin returns whether the request's Origin is the host it was sent to, as for the forms on the admin
+pages.  Requests without an Origin aren't CORS requests at all, and are left to the CORS handler to skip.*/
+func isSameOrigin(c *gin.Context) bool {
+	origin := c.GetHeader("Origin")
+	if origin == "" {
+		return false
+	}
+	originURL, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return (originURL.Scheme == "http" || originURL.Scheme == "https") && originURL.Host != "" &&
+		strings.EqualFold(originURL.Host, c.Request.Host)
+}
+
+func hasPathPrefix(path, prefix string) bool {
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
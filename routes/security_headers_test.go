@@ -0,0 +1,117 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/opacity/storage-node/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Init_Security_Headers(t *testing.T) {
+	setupTests(t)
+}
+
+func returnEngineWithCorsForTest(apiOrigins, adminOrigins string) *gin.Engine {
+	env := utils.Env
+	defer func() { utils.Env = env }()
+	utils.Env.CorsAllowedOrigins = apiOrigins
+	utils.Env.AdminCorsAllowedOrigins = adminOrigins
+
+	router := returnEngine()
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.POST(V1Path+MetadataGetPath, ok)
+	router.GET(AdminPath+"/delete", ok)
+	router.POST(AdminPath+"/delete", ok)
+	router.GET(SwaggerPath+"/index.html", ok)
+	return router
+}
+
+func requestFromOriginForTest(router *gin.Engine, method, path, origin string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(method, path, nil)
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	}
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func Test_SecurityHeaders_Are_Set(t *testing.T) {
+	router := returnEngineWithCorsForTest("*", "")
+
+	w := requestFromOriginForTest(router, http.MethodPost, V1Path+MetadataGetPath, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Strict-Transport-Security"), "max-age=")
+	assert.Equal(t, "DENY", w.Header().Get("X-Frame-Options"))
+	assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+	assert.Equal(t, utils.Env.ReferrerPolicy, w.Header().Get("Referrer-Policy"))
+	assert.Empty(t, w.Header().Get("Content-Security-Policy"))
+
+	for _, path := range []string{AdminPath + "/delete", SwaggerPath + "/index.html"} {
+		w = requestFromOriginForTest(router, http.MethodGet, path, "")
+		assert.Equal(t, "frame-ancestors 'none'", w.Header().Get("Content-Security-Policy"), path)
+	}
+}
+
+func Test_Cors_Policies_Per_Path(t *testing.T) {
+	router := returnEngineWithCorsForTest("https://app.opacity.io, https://*.example.com", "https://admin.opacity.io")
+
+	w := requestFromOriginForTest(router, http.MethodOptions, V1Path+MetadataGetPath, "https://app.opacity.io")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "https://app.opacity.io", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Contains(t, w.Header().Get("Access-Control-Allow-Headers"), utils.RequestSigningVersionHeader)
+
+	w = requestFromOriginForTest(router, http.MethodOptions, V1Path+MetadataGetPath, "https://a.example.com")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = requestFromOriginForTest(router, http.MethodOptions, V1Path+MetadataGetPath, "https://evil.com")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// the api origins don't carry over to the admin pages, which have their own
+	w = requestFromOriginForTest(router, http.MethodGet, AdminPath+"/delete", "https://app.opacity.io")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = requestFromOriginForTest(router, http.MethodGet, AdminPath+"/delete", "https://admin.opacity.io")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+
+	// swagger allows no other origins by default
+	w = requestFromOriginForTest(router, http.MethodGet, SwaggerPath+"/index.html", "https://app.opacity.io")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func Test_Cors_Allows_Same_Origin_Admin_Forms(t *testing.T) {
+	// no admin origins are configured by default
+	router := returnEngineWithCorsForTest("*", "")
+
+	// httptest requests are sent to example.com, and the admin forms post back to the page's own origin
+	w := requestFromOriginForTest(router, http.MethodPost, AdminPath+"/delete", "http://example.com")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = requestFromOriginForTest(router, http.MethodPost, AdminPath+"/delete", "http://evil.com")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func Test_Cors_Methods_Headers_And_Max_Age_Per_Path(t *testing.T) {
+	env := utils.Env
+	defer func() { utils.Env = env }()
+	utils.Env.AdminCorsAllowedMethods = "GET"
+	utils.Env.AdminCorsAllowedHeaders = "X-Admin-Header"
+	utils.Env.AdminCorsMaxAgeInSeconds = 60
+	router := returnEngineWithCorsForTest("*", "https://admin.opacity.io")
+
+	w := requestFromOriginForTest(router, http.MethodOptions, AdminPath+"/delete", "https://admin.opacity.io")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "GET", w.Header().Get("Access-Control-Allow-Methods"))
+	assert.Contains(t, w.Header().Get("Access-Control-Allow-Headers"), "X-Admin-Header")
+	assert.Equal(t, "60", w.Header().Get("Access-Control-Max-Age"))
+
+	// the api keeps its own
+	w = requestFromOriginForTest(router, http.MethodOptions, V1Path+MetadataGetPath, "https://app.opacity.io")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Contains(t, w.Header().Get("Access-Control-Allow-Methods"), "DELETE")
+	assert.NotContains(t, w.Header().Get("Access-Control-Allow-Headers"), "X-Admin-Header")
+}
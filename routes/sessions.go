@@ -0,0 +1,254 @@
+package routes
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/opacity/storage-node/utils"
+)
+
+const (
+	sessionClaimsKey        = "sessionClaims"
+	sessionRevokedKeyPrefix = "session_revoked_"
+	bearerPrefix            = "Bearer "
+	sessionRevokedResponse  = "session token has been revoked"
+	sessionScopeResponse    = "session token does not allow this request"
+	sessionTooOldResponse   = "session has reached its maximum lifetime, log in again"
+	sessionRequiredResponse = "an Authorization: Bearer session token is required"
+)
+
+// must be sorted alphabetically for JSON marshaling/stringifying
+type sessionLoginObject struct {
+	Scopes    []string `json:"scopes" example:"an optional array of scopes to limit the session to, like metadata:read"`
+	Timestamp int64    `json:"timestamp" binding:"required"`
+}
+
+type sessionLoginReq struct {
+	verification
+	requestBody
+	sessionLoginObject sessionLoginObject
+}
+
+type sessionRes struct {
+	Token     string    `json:"token" binding:"required" example:"a bearer token to send as Authorization: Bearer <token>"`
+	ExpiresAt time.Time `json:"expiresAt" binding:"required"`
+	Scopes    []string  `json:"scopes" example:"the scopes the token is limited to, empty if it is not limited"`
+}
+
+var sessionLoggedOutRes = StatusRes{
+	Status: "session token revoked",
+}
+
+func (v *sessionLoginReq) getObjectRef() interface{} {
+	return &v.sessionLoginObject
+}
+
+// SessionLoginHandler godoc
+// @Summary log in with a signed request and get a short-lived session token
+// @Accept  json
+// @Produce  json
+// @Param sessionLoginReq body routes.sessionLoginReq true "session login object"
+// @description The token can be sent as an Authorization: Bearer header instead of signing each request to the
+// @description account-data, metadata, upload, download and delete endpoints.  The publicKey still has to be
+// @description sent with those requests, and must be the one the token was issued to.  Scopes can be
+// @description account:read, metadata:read, metadata:write, files:upload, files:download and files:delete.
+// @description requestBody should be a stringified version of (values are just examples):
+// @description {
+// @description 	"scopes": ["metadata:read", "files:download"],
+// @description 	"timestamp": 1557346389
+// @description }
+// @Success 200 {object} routes.sessionRes
+// @Failure 400 {string} string "bad request, unable to parse request body: (with the error)"
+// @Failure 404 {string} string "account not found"
+// @Failure 403 {string} string "signature did not match"
+// @Failure 500 {string} string "some information about the internal error"
+// @Router /api/v1/session/login [post]
+/*SessionLoginHandler is a handler for getting a session token*/
+func SessionLoginHandler() gin.HandlerFunc {
+	return ginHandlerFunc(sessionLogin)
+}
+
+// SessionRefreshHandler godoc
+// @Summary swap a session token for a new one
+// @Produce  json
+// @Param Authorization header string true "Bearer <token>"
+// @description The new token has the same scopes and the old one is revoked.  A session can be refreshed until
+// @description its maximum lifetime after the signed login, after which the client has to log in again.
+// @Success 200 {object} routes.sessionRes
+// @Failure 401 {string} string "missing, invalid, expired or revoked session token"
+// @Failure 403 {string} string "session has reached its maximum lifetime"
+// @Failure 500 {string} string "some information about the internal error"
+// @Router /api/v1/session/refresh [post]
+/*SessionRefreshHandler is a handler for refreshing a session token*/
+func SessionRefreshHandler() gin.HandlerFunc {
+	return ginHandlerFunc(sessionRefresh)
+}
+
+// SessionLogoutHandler godoc
+// @Summary revoke a session token
+// @Produce  json
+// @Param Authorization header string true "Bearer <token>"
+// @Success 200 {object} routes.StatusRes
+// @Failure 401 {string} string "missing, invalid, expired or revoked session token"
+// @Failure 500 {string} string "some information about the internal error"
+// @Router /api/v1/session/logout [post]
+/*SessionLogoutHandler is a handler for revoking a session token*/
+func SessionLogoutHandler() gin.HandlerFunc {
+	return ginHandlerFunc(sessionLogout)
+}
+
+/*sessionTokenMiddleware checks the bearer token of requests to paths that accept session tokens.  A valid
+token is stored in the context, where verifyRequest accepts it in place of a signature.  Requests without a
+token go on to be verified by their signature.*/
+func sessionTokenMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, hasToken := bearerToken(c)
+		scope, acceptsSessions := requestScope(c)
+		if !hasToken || !acceptsSessions {
+			c.Next()
+			return
+		}
+
+		claims, err := verifySessionToken(token, c)
+		if err != nil {
+			return
+		}
+		if !claims.HasScope(scope) {
+			ForbiddenResponse(c, errors.New(sessionScopeResponse))
+			return
+		}
+
+		c.Set(sessionClaimsKey, claims)
+		c.Next()
+	}
+}
+
+func sessionLogin(c *gin.Context) error {
+	request := sessionLoginReq{}
+
+	// a session token can't be used to get another one without a signature
+	if err := verifyAndParseBodyRequest(&request, c); err != nil {
+		return err
+	}
+
+	account, err := request.getAccount(c)
+	if err != nil {
+		return err
+	}
+
+	if err := verifyScopes(request.sessionLoginObject.Scopes, c); err != nil {
+		return err
+	}
+
+	claims, err := utils.NewSessionClaims(account.AccountID, request.PublicKey, request.sessionLoginObject.Scopes,
+		time.Time{})
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+	return issueSessionToken(claims, c)
+}
+
+func sessionRefresh(c *gin.Context) error {
+	token, ok := bearerToken(c)
+	if !ok {
+		return UnauthorizedResponse(c, errors.New(sessionRequiredResponse))
+	}
+	claims, err := verifySessionToken(token, c)
+	if err != nil {
+		return err
+	}
+
+	loginAt := time.Unix(claims.LoginAt, 0)
+	if time.Since(loginAt) > time.Duration(utils.Env.SessionMaxLifetimeInSeconds)*time.Second {
+		return ForbiddenResponse(c, errors.New(sessionTooOldResponse))
+	}
+
+	newClaims, err := utils.NewSessionClaims(claims.AccountID, claims.PublicKey, claims.Scopes, loginAt)
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+	// revoking is what claims the refresh, so refreshing the same token twice at once only issues one new token
+	revoked, err := revokeSessionToken(claims)
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+	if !revoked {
+		return UnauthorizedResponse(c, errors.New(sessionRevokedResponse))
+	}
+	return issueSessionToken(newClaims, c)
+}
+
+func sessionLogout(c *gin.Context) error {
+	token, ok := bearerToken(c)
+	if !ok {
+		return UnauthorizedResponse(c, errors.New(sessionRequiredResponse))
+	}
+	claims, err := verifySessionToken(token, c)
+	if err != nil {
+		return err
+	}
+
+	if _, err := revokeSessionToken(claims); err != nil {
+		return InternalErrorResponse(c, err)
+	}
+	return OkResponse(c, sessionLoggedOutRes)
+}
+
+func issueSessionToken(claims utils.SessionClaims, c *gin.Context) error {
+	token, err := utils.CreateSessionToken(claims)
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+	return OkResponse(c, sessionRes{
+		Token:     token,
+		ExpiresAt: claims.ExpirationTime(),
+		Scopes:    claims.Scopes,
+	})
+}
+
+/*verifySessionToken checks a token's signature, expiration and that it hasn't been revoked*/
+func verifySessionToken(token string, c *gin.Context) (utils.SessionClaims, error) {
+	claims, err := utils.ParseSessionToken(token)
+	if err != nil {
+		return claims, UnauthorizedResponse(c, err)
+	}
+
+	_, _, err = utils.GetValueFromKV(sessionRevokedKeyPrefix + claims.TokenID)
+	if err == nil {
+		return claims, UnauthorizedResponse(c, errors.New(sessionRevokedResponse))
+	}
+	if err != utils.ErrKeyNotFound {
+		return claims, InternalErrorResponse(c, err)
+	}
+	return claims, nil
+}
+
+// a revoked token only needs to be remembered until it would have expired anyway.  Returns false if the token was
+// already revoked.
+func revokeSessionToken(claims utils.SessionClaims) (bool, error) {
+	ttl := time.Until(claims.ExpirationTime())
+	if ttl <= 0 {
+		return true, nil
+	}
+	return utils.SetIfNotExists(sessionRevokedKeyPrefix+claims.TokenID, "", ttl)
+}
+
+/*authenticatedBySession returns whether the request carried a valid session token for publicKey*/
+func authenticatedBySession(publicKey string, c *gin.Context) bool {
+	claims, ok := c.Get(sessionClaimsKey)
+	return ok && claims.(utils.SessionClaims).PublicKey == publicKey
+}
+
+func bearerToken(c *gin.Context) (string, bool) {
+	if c.Request == nil {
+		return "", false
+	}
+	authorization := c.GetHeader("Authorization")
+	if !strings.HasPrefix(authorization, bearerPrefix) {
+		return "", false
+	}
+	token := strings.TrimSpace(strings.TrimPrefix(authorization, bearerPrefix))
+	return token, token != ""
+}
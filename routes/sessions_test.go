@@ -0,0 +1,259 @@
+package routes
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/opacity/storage-node/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Init_Sessions(t *testing.T) {
+	setupTests(t)
+}
+
+func createSessionTokenForTest(t *testing.T, publicKey string, scopes []string) (string, utils.SessionClaims) {
+	claims, err := utils.NewSessionClaims(utils.RandSeqFromRunes(64, []rune("abcdef01234567890")), publicKey,
+		scopes, time.Time{})
+	assert.Nil(t, err)
+	token, err := utils.CreateSessionToken(claims)
+	assert.Nil(t, err)
+	return token, claims
+}
+
+func createSessionContextForTest(w *httptest.ResponseRecorder, path string, token string) *gin.Context {
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, V1Path+path, nil)
+	c.Request.Header.Set("Authorization", bearerPrefix+token)
+	return c
+}
+
+func returnSessionRequestBodyForTest(t *testing.T) requestBody {
+	body, err := json.Marshal(testTimestampedRequestObject{
+		Data:      "some body message",
+		Timestamp: time.Now().Unix(),
+	})
+	assert.Nil(t, err)
+	return requestBody{RequestBody: string(body)}
+}
+
+func httpPostSessionRequestHelperForTest(t *testing.T, path string, token string) *httptest.ResponseRecorder {
+	abortIfNotTesting(t)
+
+	router := returnEngine()
+	setupV1Paths(returnV1Group(router))
+
+	req, err := http.NewRequest(http.MethodPost, V1Path+path, nil)
+	assert.Nil(t, err)
+	req.Header.Set("Authorization", bearerPrefix+token)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func Test_SessionToken_Stands_In_For_Signature(t *testing.T) {
+	privateKey, err := utils.GenerateKey()
+	assert.Nil(t, err)
+	publicKey := utils.PubkeyCompressedToHex(privateKey.PublicKey)
+	token, _ := createSessionTokenForTest(t, publicKey, []string{ScopeMetadataRead})
+	b := returnSessionRequestBodyForTest(t)
+
+	c := createSessionContextForTest(httptest.NewRecorder(), MetadataGetPath, token)
+	sessionTokenMiddleware()(c)
+	assert.False(t, c.IsAborted())
+
+	// the same unsigned body can be sent again, since sessions have no signature to replay
+	for i := 0; i < 2; i++ {
+		err = verifyAndParseStringRequest(b.RequestBody, &testTimestampedRequestObject{},
+			verification{PublicKey: publicKey}, c)
+		assert.Nil(t, err)
+	}
+}
+
+func Test_SessionToken_Only_Stands_In_For_Its_Own_Public_Key(t *testing.T) {
+	privateKey, err := utils.GenerateKey()
+	assert.Nil(t, err)
+	token, _ := createSessionTokenForTest(t, utils.PubkeyCompressedToHex(privateKey.PublicKey), nil)
+
+	otherKey, err := utils.GenerateKey()
+	assert.Nil(t, err)
+
+	w := httptest.NewRecorder()
+	c := createSessionContextForTest(w, MetadataGetPath, token)
+	sessionTokenMiddleware()(c)
+	err = verifyAndParseStringRequest(returnSessionRequestBodyForTest(t).RequestBody, &testTimestampedRequestObject{},
+		verification{PublicKey: utils.PubkeyCompressedToHex(otherKey.PublicKey)}, c)
+	assert.NotNil(t, err)
+}
+
+func Test_SessionToken_Is_Ignored_On_Paths_That_Need_A_Signature(t *testing.T) {
+	privateKey, err := utils.GenerateKey()
+	assert.Nil(t, err)
+	publicKey := utils.PubkeyCompressedToHex(privateKey.PublicKey)
+	token, _ := createSessionTokenForTest(t, publicKey, nil)
+
+	c := createSessionContextForTest(httptest.NewRecorder(), AccountUpgradePath, token)
+	sessionTokenMiddleware()(c)
+	assert.False(t, authenticatedBySession(publicKey, c))
+}
+
+func Test_SessionToken_Rejects_Missing_Scope(t *testing.T) {
+	privateKey, err := utils.GenerateKey()
+	assert.Nil(t, err)
+	token, _ := createSessionTokenForTest(t, utils.PubkeyCompressedToHex(privateKey.PublicKey),
+		[]string{ScopeMetadataRead})
+
+	w := httptest.NewRecorder()
+	c := createSessionContextForTest(w, DeletePath, token)
+	sessionTokenMiddleware()(c)
+	assert.True(t, c.IsAborted())
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), sessionScopeResponse)
+}
+
+func Test_SessionToken_Rejects_Invalid_Token(t *testing.T) {
+	w := httptest.NewRecorder()
+	c := createSessionContextForTest(w, MetadataGetPath, "not a token")
+	sessionTokenMiddleware()(c)
+	assert.True(t, c.IsAborted())
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func Test_SessionLogout_Revokes_Token(t *testing.T) {
+	privateKey, err := utils.GenerateKey()
+	assert.Nil(t, err)
+	token, _ := createSessionTokenForTest(t, utils.PubkeyCompressedToHex(privateKey.PublicKey), nil)
+
+	w := httptest.NewRecorder()
+	c := createSessionContextForTest(w, SessionLogoutPath, token)
+	assert.Nil(t, sessionLogout(c))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	c = createSessionContextForTest(w, MetadataGetPath, token)
+	sessionTokenMiddleware()(c)
+	assert.True(t, c.IsAborted())
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), sessionRevokedResponse)
+}
+
+func Test_SessionRefresh_Issues_New_Token_And_Revokes_Old_One(t *testing.T) {
+	privateKey, err := utils.GenerateKey()
+	assert.Nil(t, err)
+	token, claims := createSessionTokenForTest(t, utils.PubkeyCompressedToHex(privateKey.PublicKey),
+		[]string{ScopeFilesDownload})
+
+	w := httpPostSessionRequestHelperForTest(t, SessionRefreshPath, token)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	res := sessionRes{}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &res))
+	newClaims, err := utils.ParseSessionToken(res.Token)
+	assert.Nil(t, err)
+	assert.NotEqual(t, claims.TokenID, newClaims.TokenID)
+	assert.Equal(t, claims.LoginAt, newClaims.LoginAt)
+	assert.Equal(t, []string{ScopeFilesDownload}, newClaims.Scopes)
+
+	w = httpPostSessionRequestHelperForTest(t, SessionRefreshPath, token)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func Test_SessionRefresh_Issues_One_Token_For_Concurrent_Refreshes(t *testing.T) {
+	privateKey, err := utils.GenerateKey()
+	assert.Nil(t, err)
+	token, _ := createSessionTokenForTest(t, utils.PubkeyCompressedToHex(privateKey.PublicKey), nil)
+
+	codes := make(chan int, 5)
+	var wg sync.WaitGroup
+	for i := 0; i < cap(codes); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			codes <- httpPostSessionRequestHelperForTest(t, SessionRefreshPath, token).Code
+		}()
+	}
+	wg.Wait()
+	close(codes)
+
+	refreshed := 0
+	for code := range codes {
+		if code == http.StatusOK {
+			refreshed++
+		}
+	}
+	assert.Equal(t, 1, refreshed)
+}
+
+func Test_SessionRefresh_Stops_At_Max_Lifetime(t *testing.T) {
+	privateKey, err := utils.GenerateKey()
+	assert.Nil(t, err)
+	loginAt := time.Now().Add(-time.Duration(utils.Env.SessionMaxLifetimeInSeconds+1) * time.Second)
+	claims, err := utils.NewSessionClaims("accountID", utils.PubkeyCompressedToHex(privateKey.PublicKey), nil,
+		loginAt)
+	assert.Nil(t, err)
+	token, err := utils.CreateSessionToken(claims)
+	assert.Nil(t, err)
+
+	w := httpPostSessionRequestHelperForTest(t, SessionRefreshPath, token)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), sessionTooOldResponse)
+}
+
+func Test_SessionLogin_Rejects_Unknown_Scope(t *testing.T) {
+	accountID, privateKey := generateValidateAccountId(t)
+	CreatePaidAccountForTest(t, accountID)
+
+	w := httpPostRequestHelperForTest(t, SessionLoginPath, returnSessionLoginReqForTest(t, []string{"admin"}, privateKey))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), unknownScopeError)
+}
+
+func Test_SessionLogin_Returns_Token_For_Account(t *testing.T) {
+	accountID, privateKey := generateValidateAccountId(t)
+	CreatePaidAccountForTest(t, accountID)
+
+	w := httpPostRequestHelperForTest(t, SessionLoginPath,
+		returnSessionLoginReqForTest(t, []string{ScopeMetadataRead}, privateKey))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	res := sessionRes{}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &res))
+	claims, err := utils.ParseSessionToken(res.Token)
+	assert.Nil(t, err)
+	assert.Equal(t, accountID, claims.AccountID)
+	assert.Equal(t, utils.PubkeyCompressedToHex(privateKey.PublicKey), claims.PublicKey)
+	assert.Equal(t, []string{ScopeMetadataRead}, res.Scopes)
+}
+
+func Test_SessionLogin_Requires_A_Signature(t *testing.T) {
+	accountID, privateKey := generateValidateAccountId(t)
+	CreatePaidAccountForTest(t, accountID)
+	token, _ := createSessionTokenForTest(t, utils.PubkeyCompressedToHex(privateKey.PublicKey), nil)
+
+	post := returnSessionLoginReqForTest(t, nil, privateKey)
+	post.Signature = ""
+	w := httpPostSessionRequestHelperForTest(t, SessionLoginPath, token)
+	assert.NotEqual(t, http.StatusOK, w.Code)
+
+	w = httpPostRequestHelperForTest(t, SessionLoginPath, post)
+	assert.NotEqual(t, http.StatusOK, w.Code)
+}
+
+func returnSessionLoginReqForTest(t *testing.T, scopes []string, privateKey *ecdsa.PrivateKey) sessionLoginReq {
+	loginObj := sessionLoginObject{
+		Scopes:    scopes,
+		Timestamp: time.Now().Unix(),
+	}
+	v, b := returnValidVerificationAndRequestBody(t, loginObj, privateKey)
+	return sessionLoginReq{
+		verification: v,
+		requestBody:  b,
+	}
+}
@@ -17,6 +17,7 @@ const (
 )
 
 type createStripePaymentObject struct {
+	CouponCode       string `json:"couponCode" binding:"omitempty,max=64" maxLength:"64" example:"SPRING20"`
 	StripeToken      string `json:"stripeToken" binding:"required" example:"tok_KPte7942xySKBKyrBu11yEpf"`
 	Timestamp        int64  `json:"timestamp" binding:"required"`
 	UpgradeAccount   bool   `json:"upgradeAccount"`
@@ -56,11 +57,15 @@ func (v *createStripePaymentReq) getObjectRef() interface{} {
 // @Param createStripePaymentReq body routes.createStripePaymentReq true "stripe payment creation object"
 // @description requestBody should be a stringified version of (values are just examples):
 // @description {
+// @description 	"couponCode": "SPRING20",
 // @description 	"stripeToken": "tok_KPte7942xySKBKyrBu11yEpf",
 // @description }
+// @description couponCode is optional, for an account that was created without one.  Its discount in USD is
+// @description taken off the charge.
 // @Success 200 {object} routes.stripeDataRes
 // @Failure 400 {string} string "bad request, unable to parse request body: (with the error)"
 // @Failure 404 {string} string "no account with that id: (with your accountID)"
+// @Failure 404 {string} string "no coupon with that code"
 // @Failure 403 {string} string "account is already paid for"
 // @Failure 500 {string} string "some information about the internal error"
 // @Router /api/v1/stripe/create [post]
@@ -86,6 +91,7 @@ func createStripePayment(c *gin.Context) error {
 	}
 
 	var costInDollars float64
+	couponApplied := false
 	if request.createStripePaymentObject.UpgradeAccount {
 		// TODO remove if / when we decide to support Stripe for upgrade
 		return BadRequestResponse(c, errors.New("stripe not supported for upgrades"))
@@ -93,11 +99,40 @@ func createStripePayment(c *gin.Context) error {
 		if err := verifyValidStorageLimit(request.createStripePaymentObject.StorageLimit, c); err != nil {
 			return err
 		}
+		if err := verifySubscriptionMonths(request.createStripePaymentObject.StorageLimit,
+			request.createStripePaymentObject.DurationInMonths, c); err != nil {
+			return err
+		}
 		//costInDollars, _ = account.UpgradeCostInUSD(request.createStripePaymentObject.StorageLimit,
 		//	request.createStripePaymentObject.DurationInMonths)
 	} else {
-		costInDollars = utils.Env.Plans[int(account.StorageLimit)].CostInUSD
+		if err := verifySubscriptionMonths(int(account.StorageLimit), account.MonthsInSubscription, c); err != nil {
+			return err
+		}
+		// the coupon is only applied to this copy of the account until the card is charged
+		if request.createStripePaymentObject.CouponCode != "" && !verifyIfPaid(account) {
+			coupon, err := models.GetRedeemableCoupon(request.createStripePaymentObject.CouponCode, account.AccountID,
+				int(account.StorageLimit))
+			if err == nil {
+				err = account.ApplyCoupon(coupon)
+			}
+			if err == nil {
+				err = models.ReserveCouponRedemption(coupon.ID)
+			}
+			if err != nil {
+				return couponErrorResponse(c, err)
+			}
+			couponApplied = true
+		}
+		costInDollars, _ = account.CostInUSD()
 	}
+	// the coupon's redemption is given back unless the card is charged with its discount
+	charged := false
+	defer func() {
+		if couponApplied && !charged {
+			models.ReleaseCouponRedemption(account.CouponID)
+		}
+	}()
 
 	if costInDollars <= float64(0.50) {
 		return ForbiddenResponse(c, errors.New("cannot create stripe charge for less than $0.50"))
@@ -109,9 +144,15 @@ func createStripePayment(c *gin.Context) error {
 	}
 
 	charge, stripePayment, err := createChargeAndStripePayment(c, costInDollars, account, request.createStripePaymentObject)
+	charged = charge != nil && charge.ID != ""
 	if err != nil {
 		return err
 	}
+	if couponApplied {
+		if err := account.SaveCoupon(); err != nil {
+			return InternalErrorResponse(c, err)
+		}
+	}
 
 	if !request.createStripePaymentObject.UpgradeAccount {
 		if err := stripePayment.SendAccountOPCT(); err != nil {
@@ -143,17 +184,9 @@ func createStripePayment(c *gin.Context) error {
 
 func createChargeAndStripePayment(c *gin.Context, costInDollars float64, account models.Account,
 	reqBody createStripePaymentObject) (*stripe.Charge, models.StripePayment, error) {
-	var charge *stripe.Charge
-	var err error
-	for i := 0; i < stripeRetryCount; i++ {
-		charge, err = services.CreateCharge(costInDollars, reqBody.StripeToken, account.AccountID)
-		if !waitOnRetryableStripeError(err) {
-			break
-		}
-	}
-
+	charge, err := createCharge(c, costInDollars, reqBody.StripeToken, account.AccountID)
 	if err != nil {
-		return charge, models.StripePayment{}, handleStripeError(err, c)
+		return charge, models.StripePayment{}, err
 	}
 
 	stripePayment := models.StripePayment{
@@ -167,9 +200,25 @@ func createChargeAndStripePayment(c *gin.Context, costInDollars float64, account
 	if err := models.DB.Create(&stripePayment).Error; err != nil {
 		return charge, stripePayment, BadRequestResponse(c, err)
 	}
+	if err := account.RecordStripePayment(float64(charge.Amount)/100.00, charge.ID); err != nil {
+		return charge, stripePayment, InternalErrorResponse(c, err)
+	}
 	return charge, stripePayment, nil
 }
 
+/*createCharge charges the card, retrying while Stripe rate limits us*/
+func createCharge(c *gin.Context, costInDollars float64, stripeToken, accountID string) (*stripe.Charge, error) {
+	var charge *stripe.Charge
+	var err error
+	for i := 0; i < stripeRetryCount; i++ {
+		charge, err = services.CreateCharge(costInDollars, stripeToken, accountID)
+		if !waitOnRetryableStripeError(err) {
+			break
+		}
+	}
+	return charge, handleStripeError(err, c)
+}
+
 func payUpgradeCostWithStripe(c *gin.Context, stripePayment models.StripePayment, account models.Account, createStripePaymentObject createStripePaymentObject) error {
 	if err := stripePayment.SendUpgradeOPCT(account, createStripePaymentObject.StorageLimit); err != nil {
 		return InternalErrorResponse(c, err)
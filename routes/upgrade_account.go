@@ -12,8 +12,9 @@ import (
 )
 
 type getUpgradeAccountInvoiceObject struct {
-	StorageLimit     int `json:"storageLimit" binding:"required,gte=128" minimum:"128" example:"128"`
-	DurationInMonths int `json:"durationInMonths" binding:"required,gte=1" minimum:"1" example:"12"`
+	StorageLimit     int    `json:"storageLimit" binding:"required,gte=128" minimum:"128" example:"128"`
+	DurationInMonths int    `json:"durationInMonths" binding:"required,gte=1" minimum:"1" example:"12"`
+	CouponCode       string `json:"couponCode" binding:"omitempty,max=64" maxLength:"64" example:"SPRING20"`
 }
 
 type checkUpgradeStatusObject struct {
@@ -59,10 +60,14 @@ func (v *checkUpgradeStatusReq) getObjectRef() interface{} {
 // @description {
 // @description 	"storageLimit": 100,
 // @description 	"durationInMonths": 12,
+// @description 	"couponCode": "SPRING20"
 // @description }
+// @description couponCode is optional.  Its discount is taken off the invoice's cost and its extra months are added
+// @description to the subscription once the upgrade is paid.
 // @Success 200 {object} routes.getUpgradeAccountInvoiceRes
 // @Failure 400 {string} string "bad request, unable to parse request body: (with the error)"
 // @Failure 404 {string} string "no account with that id: (with your accountID)"
+// @Failure 404 {string} string "no coupon with that code"
 // @Failure 500 {string} string "some information about the internal error"
 // @Router /api/v1/upgrade/invoice [post]
 /*GetAccountUpgradeInvoiceHandler is a handler for getting an invoice to upgrade an account*/
@@ -108,6 +113,12 @@ func getAccountUpgradeInvoice(c *gin.Context) error {
 	if err := verifyUpgradeEligible(account, request.getUpgradeAccountInvoiceObject.StorageLimit, c); err != nil {
 		return err
 	}
+	// the upgrade is priced for the months left in the account's subscription, so those are what the new plan's
+	// minimum applies to
+	if err := verifySubscriptionMonths(request.getUpgradeAccountInvoiceObject.StorageLimit, account.MonthsInSubscription,
+		c); err != nil {
+		return err
+	}
 
 	upgradeCostInOPCT, _ := account.UpgradeCostInOPCT(request.getUpgradeAccountInvoiceObject.StorageLimit,
 		//request.getUpgradeAccountInvoiceObject.DurationInMonths)
@@ -142,16 +153,34 @@ func getAccountUpgradeInvoice(c *gin.Context) error {
 		DurationInMonths: account.MonthsInSubscription,
 	}
 
+	if request.getUpgradeAccountInvoiceObject.CouponCode != "" {
+		coupon, err := models.GetRedeemableCoupon(request.getUpgradeAccountInvoiceObject.CouponCode, account.AccountID,
+			request.getUpgradeAccountInvoiceObject.StorageLimit)
+		if err == nil {
+			err = upgrade.ApplyCoupon(coupon)
+		}
+		if err != nil {
+			return couponErrorResponse(c, err)
+		}
+	}
+
 	upgradeInDB, err := models.GetOrCreateUpgrade(upgrade)
+	if err == models.ErrCouponRedeemedOut {
+		return couponErrorResponse(c, err)
+	}
 	if err != nil {
 		err = fmt.Errorf("error getting or creating upgrade:  %v", err)
 		return ServiceUnavailableResponse(c, err)
 	}
+	if err := upgradeInDB.RecordInvoice(account); err != nil {
+		return InternalErrorResponse(c, err)
+	}
 
 	return OkResponse(c, getUpgradeAccountInvoiceRes{
 		OpctInvoice: models.Invoice{
-			Cost:       upgradeCostInOPCT,
+			Cost:       upgradeInDB.OpctCost,
 			EthAddress: upgradeInDB.EthAddress,
+			Discount:   upgradeInDB.DiscountInOPCT,
 		},
 		//UsdInvoice: upgradeCostInUSD,
 	})
@@ -218,27 +247,29 @@ func checkUpgradeStatus(c *gin.Context) error {
 	if err := models.DB.Model(&upgrade).Update("payment_status", models.InitialPaymentReceived).Error; err != nil {
 		return InternalErrorResponse(c, err)
 	}
-	if err := upgradeAccountAndUpdateExpireDates(account, request, c); err != nil {
+	if err := upgradeAccountAndUpdateExpireDates(account, upgrade.ExtraMonths, request, c); err != nil {
 		return InternalErrorResponse(c, err)
 	}
+	upgrade.RecordCouponRedemption()
 	return OkResponse(c, StatusRes{
 		Status: "Success with OPCT",
 	})
 }
 
-func upgradeAccountAndUpdateExpireDates(account models.Account, request checkUpgradeStatusReq, c *gin.Context) error {
+func upgradeAccountAndUpdateExpireDates(account models.Account, extraMonths int, request checkUpgradeStatusReq,
+	c *gin.Context) error {
 	if err := account.UpgradeAccount(request.checkUpgradeStatusObject.StorageLimit,
 		//request.checkUpgradeStatusObject.DurationInMonths); err != nil {
-		account.MonthsInSubscription); err != nil {
+		account.MonthsInSubscription+extraMonths); err != nil {
 		return err
 	}
 	filesErr := models.UpdateExpiredAt(request.checkUpgradeStatusObject.FileHandles,
 		request.verification.PublicKey, account.ExpirationDate())
 
-	// Setting ttls on metadata to 2 months post account expiration date so the metadatas won't
-	// be deleted too soon
+	// Extend the metadata and files the client sent right away, the expirationExtender job catches
+	// anything the client left out
 	metadatasErr := updateMetadataExpiration(request.checkUpgradeStatusObject.MetadataKeys,
-		request.verification.PublicKey, account.ExpirationDate().Add(24*time.Hour*60), c)
+		request.verification.PublicKey, account.ExpirationDate().Add(models.MetadataExpirationGracePeriod()), c)
 
 	return utils.CollectErrors([]error{filesErr, metadatasErr})
 }
@@ -247,6 +278,11 @@ func updateMetadataExpiration(metadataKeys []string, key string, newExpiredAtTim
 	var kvPairs = make(utils.KVPairs)
 	var kvKeys utils.KVKeys
 
+	accountID, err := utils.HashString(key)
+	if err != nil {
+		return err
+	}
+
 	for _, metadataKey := range metadataKeys {
 		permissionHashKey := getPermissionHashKeyForBadger(metadataKey)
 		permissionHashValue, _, err := utils.GetValueFromKV(permissionHashKey)
@@ -260,6 +296,9 @@ func updateMetadataExpiration(metadataKeys []string, key string, newExpiredAtTim
 		}
 		kvPairs[permissionHashKey] = permissionHashValue
 		kvKeys = append(kvKeys, metadataKey)
+		if err := indexMetadataKey(accountID, metadataKey); err != nil {
+			return err
+		}
 	}
 
 	kvs, err := utils.BatchGet(&kvKeys)
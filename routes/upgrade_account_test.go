@@ -50,6 +50,30 @@ func Test_GetAccountUpgradeInvoiceHandler_Returns_Invoice(t *testing.T) {
 	assert.Contains(t, w.Body.String(), `"opctInvoice":{"cost":24,`)
 }
 
+func Test_GetAccountUpgradeInvoiceHandler_Shorter_Than_Plan_Minimum(t *testing.T) {
+	minMonths := utils.Env.MinMonthsInSubscription
+	defer func() { utils.Env.MinMonthsInSubscription = minMonths }()
+	models.DeleteAccountsForTest(t)
+	models.DeleteUpgradesForTest(t)
+
+	v, b, _ := returnValidVerificationAndRequestBodyWithRandomPrivateKey(t, getUpgradeAccountInvoiceObject{
+		StorageLimit:     2048,
+		DurationInMonths: 12,
+	})
+	accountID, _ := utils.HashString(v.PublicKey)
+	account := CreatePaidAccountForTest(t, accountID)
+	account.StorageLimit = models.StorageLimitType(1024)
+	models.DB.Save(&account)
+	utils.Env.MinMonthsInSubscription = account.MonthsInSubscription + 1
+
+	w := httpPostRequestHelperForTest(t, AccountUpgradeInvoicePath, getUpgradeAccountInvoiceReq{
+		verification: v,
+		requestBody:  b,
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "at least")
+}
+
 func Test_CheckUpgradeStatusHandler_Returns_Status_OPCT_Upgrade_Success(t *testing.T) {
 	models.DeleteAccountsForTest(t)
 	models.DeleteUpgradesForTest(t)
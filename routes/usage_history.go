@@ -0,0 +1,108 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/opacity/storage-node/models"
+)
+
+// the most days of usage history one request can cover
+const maxUsageHistoryDays = 366
+
+const usageHistoryDateLayout = "2006-01-02"
+
+var (
+	usageHistoryDateError  = errors.New("startDate and endDate must be dates like 2006-01-02")
+	usageHistoryRangeError = fmt.Errorf("endDate must be on or after startDate, and at most %d days later",
+		maxUsageHistoryDays)
+)
+
+// must be sorted alphabetically for JSON marshaling/stringifying
+type getUsageHistoryObject struct {
+	EndDate   string `json:"endDate" binding:"omitempty,len=10" example:"2019-05-31"`
+	StartDate string `json:"startDate" binding:"required,len=10" example:"2019-05-01"`
+	Timestamp int64  `json:"timestamp" binding:"required"`
+}
+
+type getUsageHistoryReq struct {
+	verification
+	requestBody
+	getUsageHistoryObject getUsageHistoryObject
+}
+
+type getUsageHistoryRes struct {
+	Samples []models.UsageSample `json:"samples"`
+}
+
+func (v *getUsageHistoryReq) getObjectRef() interface{} {
+	return &v.getUsageHistoryObject
+}
+
+// GetUsageHistoryHandler godoc
+// @Summary get what an account stored each day
+// @Accept  json
+// @Produce  json
+// @Param getUsageHistoryReq body routes.getUsageHistoryReq true "get usage history object"
+// @description Returns a sample of what the account stored, its file count, folder count and metadata size for each
+// @description day from startDate through endDate, oldest first.  Paid accounts are sampled once a day, in UTC.
+// @description endDate is optional and defaults to today.  The range can be at most 366 days.
+// @description requestBody should be a stringified version of (values are just examples):
+// @description {
+// @description 	"endDate": "2019-05-31",
+// @description 	"startDate": "2019-05-01",
+// @description 	"timestamp": 1557346389
+// @description }
+// @Success 200 {object} routes.getUsageHistoryRes
+// @Failure 400 {string} string "bad request, unable to parse request body: (with the error)"
+// @Failure 403 {string} string "signature did not match"
+// @Failure 404 {string} string "no account with that id: (with your accountID)"
+// @Failure 500 {string} string "some information about the internal error"
+// @Router /api/v1/account/usage [post]
+/*GetUsageHistoryHandler is a handler for getting an account's usage history*/
+func GetUsageHistoryHandler() gin.HandlerFunc {
+	return ginHandlerFunc(getUsageHistory)
+}
+
+func getUsageHistory(c *gin.Context) error {
+	request := getUsageHistoryReq{}
+	if err := verifyAndParseBodyRequest(&request, c); err != nil {
+		return err
+	}
+
+	start, end, err := parseUsageHistoryRange(request.getUsageHistoryObject)
+	if err != nil {
+		return BadRequestResponse(c, err)
+	}
+
+	account, err := request.getAccount(c)
+	if err != nil {
+		return err
+	}
+
+	samples, err := models.GetUsageSamples(account.AccountID, start, end)
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+	return OkResponse(c, getUsageHistoryRes{Samples: samples})
+}
+
+/*parseUsageHistoryRange returns the days the request asks for, ending today if it has no endDate*/
+func parseUsageHistoryRange(object getUsageHistoryObject) (time.Time, time.Time, error) {
+	start, err := time.Parse(usageHistoryDateLayout, object.StartDate)
+	if err != nil {
+		return start, start, usageHistoryDateError
+	}
+	end := time.Now().UTC().Truncate(24 * time.Hour)
+	if object.EndDate != "" {
+		if end, err = time.Parse(usageHistoryDateLayout, object.EndDate); err != nil {
+			return start, end, usageHistoryDateError
+		}
+	}
+	if end.Before(start) || end.Sub(start) > maxUsageHistoryDays*24*time.Hour {
+		return start, end, usageHistoryRangeError
+	}
+	return start, end, nil
+}
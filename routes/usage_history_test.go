@@ -0,0 +1,64 @@
+package routes
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Init_Usage_History(t *testing.T) {
+	setupTests(t)
+}
+
+func Test_ParseUsageHistoryRange(t *testing.T) {
+	tests := []struct {
+		name      string
+		startDate string
+		endDate   string
+		err       error
+	}{
+		{"one day", "2019-05-01", "2019-05-01", nil},
+		{"a year", "2019-01-01", "2020-01-01", nil},
+		{"up to today", time.Now().UTC().AddDate(0, 0, -7).Format(usageHistoryDateLayout), "", nil},
+		{"not a date", "May 1st", "", usageHistoryDateError},
+		{"end is not a date", "2019-05-01", "tomorrow", usageHistoryDateError},
+		{"backwards", "2019-05-02", "2019-05-01", usageHistoryRangeError},
+		{"too long", "2019-01-01", "2020-01-03", usageHistoryRangeError},
+	}
+
+	for _, tt := range tests {
+		_, _, err := parseUsageHistoryRange(getUsageHistoryObject{StartDate: tt.startDate, EndDate: tt.endDate})
+		assert.Equal(t, tt.err, err, tt.name)
+	}
+}
+
+func Test_GetUsageHistoryHandler_Returns_The_Accounts_Samples(t *testing.T) {
+	models.DeleteAccountsForTest(t)
+	models.DeleteUsageSamplesForTest(t)
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	v, b, _ := returnValidVerificationAndRequestBodyWithRandomPrivateKey(t, getUsageHistoryObject{
+		StartDate: today.AddDate(0, 0, -1).Format(usageHistoryDateLayout),
+		Timestamp: time.Now().Unix(),
+	})
+	accountID, _ := utils.HashString(v.PublicKey)
+	CreatePaidAccountForTest(t, accountID)
+	for _, sample := range []models.UsageSample{
+		{AccountID: accountID, SampledOn: today.AddDate(0, 0, -2), StorageUsedInByte: 1},
+		{AccountID: accountID, SampledOn: today.AddDate(0, 0, -1), StorageUsedInByte: 2, FileCount: 5},
+		{AccountID: utils.RandHexString(64), SampledOn: today, StorageUsedInByte: 3},
+	} {
+		assert.Nil(t, models.DB.Create(&sample).Error)
+	}
+
+	w := httpPostRequestHelperForTest(t, AccountUsagePath, getUsageHistoryReq{verification: v, requestBody: b})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"storageUsedInByte":2`)
+	assert.Contains(t, w.Body.String(), `"fileCount":5`)
+	assert.NotContains(t, w.Body.String(), `"storageUsedInByte":1,`)
+	assert.NotContains(t, w.Body.String(), `"storageUsedInByte":3,`)
+}
@@ -10,6 +10,7 @@ import (
 	"net/http"
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/opacity/storage-node/models"
@@ -25,8 +26,21 @@ const (
 	postFormTag                  = "form"
 	postFormFileTag              = "formFile"
 	bindingTag                   = "binding"
+	replayedRequestResponse      = "request has already been received, sign a new request with a current timestamp"
+	staleTimestampResponse       = "request timestamp is outside the allowed window, check your clock and sign a new request"
+	missingTimestampResponse     = "request body must include a timestamp"
+	signingV2RequiredResponse    = "requests must be signed with signature version 2"
+	invalidSigningVersionError   = "invalid " + utils.RequestSigningVersionHeader + " header"
+	invalidSigningTimestampError = "invalid or missing " + utils.RequestSigningTimestampHeader + " header"
+	invalidSignatureTypeError    = "invalid " + utils.RequestSignatureTypeHeader + " header"
+	verifiedSignatureKey         = "verifiedSignature"
+	signedTimestampKey           = "signedTimestamp"
+	replayKeyPrefix              = "replay_"
 )
 
+// timestamps above this are assumed to be in milliseconds rather than seconds
+const maxTimestampInSeconds = 1e11
+
 type verificationInterface interface {
 	getVerification() verification
 	getAccount(c *gin.Context) (models.Account, error)
@@ -42,7 +56,8 @@ type verification struct {
 	// signature without 0x prefix is broken into
 	// R: sig[0:63]
 	// S: sig[64:127]
-	Signature string `json:"signature" form:"signature" binding:"required,len=128" minLength:"128" maxLength:"128" example:"a 128 character string created when you signed the request with your private key or account handle"`
+	// and, for wallet signatures, V: sig[128:129]
+	Signature string `json:"signature" form:"signature" binding:"omitempty,min=128,max=130" minLength:"128" maxLength:"130" example:"a 128 character string created when you signed the request with your private key or account handle, or a 130 character wallet signature, can be left out when sending a session token"`
 	PublicKey string `json:"publicKey" form:"publicKey" binding:"required,len=66" minLength:"66" maxLength:"66" example:"a 66-character public key"`
 }
 
@@ -66,11 +81,27 @@ func (v verification) getAccount(c *gin.Context) (models.Account, error) {
 
 	// validate user
 	account, err := models.GetAccountById(accountID)
-	if err != nil || len(account.AccountID) == 0 {
+	if err == nil && len(account.AccountID) != 0 {
+		return account, verifyAccountState(account, c)
+	}
+
+	// a key the owner delegated acts for the owner's account
+	delegatedKey, delegated, err := getDelegatedKey(v.PublicKey, c)
+	if err != nil {
+		return account, err
+	}
+	if !delegated {
 		return account, AccountNotFoundResponse(c, accountID)
 	}
+	account, err = models.GetAccountById(delegatedKey.AccountID)
+	if err != nil || len(account.AccountID) == 0 {
+		return account, AccountNotFoundResponse(c, delegatedKey.AccountID)
+	}
+	return account, verifyAccountState(account, c)
+}
 
-	return account, err
+type timestampObject struct {
+	Timestamp int64 `json:"timestamp"`
 }
 
 type requestBody struct {
@@ -191,9 +222,7 @@ func readFileFromForm(fileTag string, r *http.Request) (string, error) {
 }
 
 func verifyAndParseStringRequest(reqAsString string, dest interface{}, verificationData verification, c *gin.Context) error {
-	hash := utils.Hash([]byte(reqAsString))
-
-	if err := verifyRequest(hash, verificationData, c); err != nil {
+	if err := verifyRequest(reqAsString, verificationData, c); err != nil {
 		return err
 	}
 
@@ -204,21 +233,108 @@ func verifyAndParseStringRequest(reqAsString string, dest interface{}, verificat
 	return nil
 }
 
+/*verifyRequestNotReplayed rejects requests whose timestamp is outside the replay window and requests whose
+signature we have already seen within that window.  Requests without a timestamp are let through unless
+RequireRequestTimestamp is set, so clients that don't send one yet have time to migrate.*/
+func verifyRequestNotReplayed(reqAsString string, verificationData verification, c *gin.Context) error {
+	// session tokens expire on their own and there is no signature to remember
+	if authenticatedBySession(verificationData.PublicKey, c) {
+		return nil
+	}
+	// some handlers verify the same request body more than once
+	if c.GetString(verifiedSignatureKey) == verificationData.Signature {
+		return nil
+	}
+
+	timestamp := timestampObject{}
+	if signedTimestamp, ok := c.Get(signedTimestampKey); ok {
+		// a v2 signature covers its own timestamp
+		timestamp.Timestamp = signedTimestamp.(int64)
+	} else if err := json.Unmarshal([]byte(reqAsString), &timestamp); err != nil || timestamp.Timestamp == 0 {
+		if utils.Env.RequireRequestTimestamp {
+			return BadRequestResponse(c, errors.New(missingTimestampResponse))
+		}
+		utils.Metrics_Untimestamped_Request_Counter.Inc()
+		c.Set(verifiedSignatureKey, verificationData.Signature)
+		return nil
+	}
+
+	replayWindow := time.Duration(utils.Env.ReplayWindowInSeconds) * time.Second
+	skew := time.Since(timestampToTime(timestamp.Timestamp))
+	if skew > replayWindow || skew < -replayWindow {
+		utils.Metrics_Replayed_Request_Counter.Inc()
+		return ForbiddenResponse(c, errors.New(staleTimestampResponse))
+	}
+
+	replayKey, err := getReplayKeyForBadger(verificationData.Signature, c)
+	if err != nil {
+		return err
+	}
+
+	// a signature only needs to be remembered until its timestamp falls out of the window
+	firstSeen, err := utils.SetIfNotExists(replayKey, "", 2*replayWindow)
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+	if !firstSeen {
+		utils.Metrics_Replayed_Request_Counter.Inc()
+		return ForbiddenResponse(c, errors.New(replayedRequestResponse))
+	}
+
+	c.Set(verifiedSignatureKey, verificationData.Signature)
+	return nil
+}
+
+func timestampToTime(timestamp int64) time.Time {
+	if timestamp > maxTimestampInSeconds {
+		return time.Unix(0, timestamp*int64(time.Millisecond))
+	}
+	return time.Unix(timestamp, 0)
+}
+
 func verifyParsedRequest(reqBody interface{}, verificationData verification, c *gin.Context) error {
-	hash, err := hashRequestBody(reqBody, c)
+	reqAsString, err := marshalRequestBody(reqBody, c)
 	if err != nil {
 		return err
 	}
 
-	if err := verifyRequest(hash, verificationData, c); err != nil {
+	return verifyRequest(reqAsString, verificationData, c)
+}
+
+/*verifyRequest checks the signature over reqAsString and that the request isn't a replay.  Every signed request
+goes through here, however its body was parsed.*/
+func verifyRequest(reqAsString string, verificationData verification, c *gin.Context) error {
+	// sessionTokenMiddleware already checked the token, which stands in for the signature
+	if authenticatedBySession(verificationData.PublicKey, c) {
+		return limitAccountRequests(verificationData.PublicKey, c)
+	}
+
+	if err := verifySignature(utils.Hash([]byte(reqAsString)), verificationData, c); err != nil {
 		return err
 	}
 
-	return nil
+	if err := verifyRequestNotReplayed(reqAsString, verificationData, c); err != nil {
+		return err
+	}
+
+	return limitAccountRequests(verificationData.PublicKey, c)
 }
 
-func verifyRequest(hash []byte, verificationData verification, c *gin.Context) error {
-	verified, err := utils.VerifyFromStrings(verificationData.PublicKey, hex.EncodeToString(hash),
+func verifySignature(hash []byte, verificationData verification, c *gin.Context) error {
+	digest, err := requestDigest(hash, c)
+	if err != nil {
+		return err
+	}
+
+	signatureType, err := requestSignatureType(c)
+	if err != nil {
+		return err
+	}
+	if signatureType != utils.SignatureTypeRaw {
+		return verifyWalletSignature(signatureType, digest, verificationData, c)
+	}
+
+	verified, err := utils.VerifyFromStrings(verificationData.PublicKey, hex.EncodeToString(digest),
 		verificationData.Signature)
 	if err != nil {
 		return BadRequestResponse(c, errors.New(errVerifying))
@@ -230,15 +346,84 @@ func verifyRequest(hash []byte, verificationData verification, c *gin.Context) e
 	return nil
 }
 
-func hashRequestBody(reqBody interface{}, c *gin.Context) ([]byte, error) {
-	var err error
+/*requestDigest returns what the request's signature should cover, given the hash of its requestBody.  That is
+the hash itself for v1 signatures, and utils.RequestDigestV2FromBodyHash for v2 signatures.*/
+func requestDigest(bodyHash []byte, c *gin.Context) ([]byte, error) {
+	version := utils.RequestSigningV1
+	// contexts created outside of an HTTP request have no headers, and are treated as v1
+	if c.Request == nil {
+		return bodyHash, nil
+	}
+	if versionHeader := c.GetHeader(utils.RequestSigningVersionHeader); versionHeader != "" {
+		var err error
+		version, err = strconv.Atoi(versionHeader)
+		if err != nil || version < utils.RequestSigningV1 || version > utils.RequestSigningV2 {
+			return nil, BadRequestResponse(c, errors.New(invalidSigningVersionError))
+		}
+	}
+
+	if version == utils.RequestSigningV1 {
+		if utils.Env.RequireRequestSigningV2 {
+			return nil, BadRequestResponse(c, errors.New(signingV2RequiredResponse))
+		}
+		return bodyHash, nil
+	}
+
+	timestamp, err := strconv.ParseInt(c.GetHeader(utils.RequestSigningTimestampHeader), 10, 64)
+	if err != nil || timestamp <= 0 {
+		return nil, BadRequestResponse(c, errors.New(invalidSigningTimestampError))
+	}
+	c.Set(signedTimestampKey, timestamp)
+
+	return utils.RequestDigestV2FromBodyHash(c.Request.Method, c.Request.URL.Path, timestamp, bodyHash), nil
+}
+
+/*requestSignatureType returns how the request's digest was signed, from the utils.RequestSignatureTypeHeader*/
+func requestSignatureType(c *gin.Context) (string, error) {
+	if c.Request == nil {
+		return utils.SignatureTypeRaw, nil
+	}
+	switch signatureType := c.GetHeader(utils.RequestSignatureTypeHeader); signatureType {
+	case "":
+		return utils.SignatureTypeRaw, nil
+	case utils.SignatureTypeRaw, utils.SignatureTypePersonalSign, utils.SignatureTypeEIP712:
+		return signatureType, nil
+	default:
+		return "", BadRequestResponse(c, errors.New(invalidSignatureTypeError))
+	}
+}
+
+/*verifyWalletSignature recovers the key that made a personal_sign or EIP-712 signature over digest, and checks it
+is the publicKey the request was sent with*/
+func verifyWalletSignature(signatureType string, digest []byte, verificationData verification, c *gin.Context) error {
+	signature, err := hex.DecodeString(verificationData.Signature)
+	if err != nil {
+		return BadRequestResponse(c, errors.New(errVerifying))
+	}
+
+	hash := utils.PersonalSignDigest(digest)
+	if signatureType == utils.SignatureTypeEIP712 {
+		hash = utils.EIP712Digest(digest)
+	}
+	signer, err := utils.RecoverWalletSigner(hash, signature)
+	if err != nil {
+		return BadRequestResponse(c, err)
+	}
+
+	if !strings.EqualFold(signer, verificationData.PublicKey) {
+		return ForbiddenResponse(c, errors.New(signatureDidNotMatchResponse))
+	}
+	return nil
+}
+
+func marshalRequestBody(reqBody interface{}, c *gin.Context) (string, error) {
 	reqJSON, err := json.Marshal(reqBody)
 	if err != nil {
 		err = fmt.Errorf(marshalError+" %v", err)
-		return []byte{}, BadRequestResponse(c, err)
+		return "", BadRequestResponse(c, err)
 	}
 
-	return utils.Hash(reqJSON), err
+	return string(reqJSON), nil
 }
 
 func returnAccountIfVerifiedFromParsedRequest(reqBody interface{}, verificationData verification, c *gin.Context) (models.Account, error) {
@@ -258,20 +443,20 @@ func returnAccountIfVerifiedFromStringRequest(reqAsString string, dest interface
 }
 
 func returnAccountIdWithParsedRequest(reqBody interface{}, verificationData verification, c *gin.Context) (string, error) {
-	hash, err := hashRequestBody(reqBody, c)
+	reqAsString, err := marshalRequestBody(reqBody, c)
 	if err != nil {
 		return "", err
 	}
 
-	return returnAccountId(hash, verificationData, c)
+	return returnAccountId(reqAsString, verificationData, c)
 }
 
 func returnAccountIdWithStringRequest(reqAsString string, verificationData verification, c *gin.Context) (string, error) {
-	return returnAccountId(utils.Hash([]byte(reqAsString)), verificationData, c)
+	return returnAccountId(reqAsString, verificationData, c)
 }
 
-func returnAccountId(hash []byte, verificationData verification, c *gin.Context) (string, error) {
-	if err := verifyRequest(hash, verificationData, c); err != nil {
+func returnAccountId(reqAsString string, verificationData verification, c *gin.Context) (string, error) {
+	if err := verifyRequest(reqAsString, verificationData, c); err != nil {
 		return "", err
 	}
 
@@ -279,15 +464,27 @@ func returnAccountId(hash []byte, verificationData verification, c *gin.Context)
 }
 
 func getPermissionHash(publicKey, key string, c *gin.Context) (string, error) {
-	permissionHash, err := utils.HashString(publicKey + key)
+	permissionHash, err := utils.HashString(ownerPublicKey(publicKey, c) + key)
 	if err != nil {
 		return "", InternalErrorResponse(c, err)
 	}
 	return permissionHash, nil
 }
 
+func getReplayKeyForBadger(signature string, c *gin.Context) (string, error) {
+	// the recovery byte and the hex case don't change what was signed, so they can't make a request new
+	if len(signature) > 2*utils.SigLengthInBytes {
+		signature = signature[:2*utils.SigLengthInBytes]
+	}
+	signatureHash, err := utils.HashString(strings.ToLower(signature))
+	if err != nil {
+		return "", BadRequestResponse(c, errors.New(errVerifying))
+	}
+	return replayKeyPrefix + signatureHash, nil
+}
+
 func getPermissionHashKeyForBadger(prefix string) string {
-	return prefix + "_permissionHash"
+	return prefix + permissionHashKeySuffix
 }
 
 func getVersionKeyForBadger(prefix string, index int) string {
@@ -298,12 +495,47 @@ func verifyPermissions(publicKey, key, expectedPermissionHash string, c *gin.Con
 	if expectedPermissionHash == "" {
 		return ForbiddenResponse(c, errors.New("resource is ineligible for modification"))
 	}
-	permissionHash, err := getPermissionHash(publicKey, key, c)
+	permitted, err := hasPermission(publicKey, key, expectedPermissionHash)
 	if err != nil {
-		return err
+		return InternalErrorResponse(c, err)
 	}
-	if permissionHash != expectedPermissionHash {
+	if !permitted {
+		// a key the owner delegated has the owner's permissions
+		delegatedKey, delegated, err := getDelegatedKey(publicKey, c)
+		if err != nil {
+			return err
+		}
+		if delegated {
+			if permitted, err = hasPermission(delegatedKey.ParentPublicKey, key, expectedPermissionHash); err != nil {
+				return InternalErrorResponse(c, err)
+			}
+		}
+	}
+	if !permitted {
 		return ForbiddenResponse(c, errors.New(notAuthorizedResponse))
 	}
 	return nil
 }
+
+/*hasPermission returns whether expectedPermissionHash was derived from publicKey, or from a key the account
+rotated away from that the hash hasn't been re-derived from yet*/
+func hasPermission(publicKey, key, expectedPermissionHash string) (bool, error) {
+	permissionHash, err := utils.HashString(publicKey + key)
+	if err != nil || permissionHash == expectedPermissionHash {
+		return err == nil, err
+	}
+
+	rotatedPublicKeys, err := models.GetRotatedPublicKeys(publicKey)
+	if err != nil {
+		return false, err
+	}
+	for _, rotatedPublicKey := range rotatedPublicKeys {
+		if permissionHash, err = utils.HashString(rotatedPublicKey + key); err != nil {
+			return false, err
+		}
+		if permissionHash == expectedPermissionHash {
+			return true, nil
+		}
+	}
+	return false, nil
+}
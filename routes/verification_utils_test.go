@@ -2,12 +2,17 @@ package routes
 
 import (
 	"bytes"
+	"encoding/json"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/opacity/storage-node/utils"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -15,6 +20,11 @@ type testRequestObject struct {
 	Data string `json:"data"`
 }
 
+type testTimestampedRequestObject struct {
+	Data      string `json:"data"`
+	Timestamp int64  `json:"timestamp"`
+}
+
 type testVerifiedRequest struct {
 	verification
 	requestBody
@@ -140,3 +150,251 @@ func Test_NoRequiredFileFormNoExist(t *testing.T) {
 
 	assert.Nil(t, err)
 }
+
+func Test_verifyAndParseStringRequest_RejectsReplayedRequest(t *testing.T) {
+	obj := testTimestampedRequestObject{
+		Data:      "some body message",
+		Timestamp: time.Now().Unix(),
+	}
+	v, b, _ := returnValidVerificationAndRequestBodyWithRandomPrivateKey(t, obj)
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	err := verifyAndParseStringRequest(b.RequestBody, &testTimestampedRequestObject{}, v, c)
+	assert.Nil(t, err)
+
+	// verifying again within the same request is allowed
+	err = verifyAndParseStringRequest(b.RequestBody, &testTimestampedRequestObject{}, v, c)
+	assert.Nil(t, err)
+
+	w := httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	err = verifyAndParseStringRequest(b.RequestBody, &testTimestampedRequestObject{}, v, c)
+	assert.NotNil(t, err)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), replayedRequestResponse)
+}
+
+func Test_verifyParsedRequest_RejectsReplayedRequest(t *testing.T) {
+	obj := testTimestampedRequestObject{
+		Data:      "some body message",
+		Timestamp: time.Now().Unix(),
+	}
+	v, _, _ := returnValidVerificationAndRequestBodyWithRandomPrivateKey(t, obj)
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	err := verifyParsedRequest(obj, v, c)
+	assert.Nil(t, err)
+
+	w := httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	_, err = returnAccountIdWithParsedRequest(obj, v, c)
+	assert.NotNil(t, err)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), replayedRequestResponse)
+}
+
+func Test_verifyAndParseStringRequest_RejectsStaleTimestamp(t *testing.T) {
+	obj := testTimestampedRequestObject{
+		Data:      "some body message",
+		Timestamp: time.Now().Add(-2 * time.Duration(utils.Env.ReplayWindowInSeconds) * time.Second).Unix(),
+	}
+	v, b, _ := returnValidVerificationAndRequestBodyWithRandomPrivateKey(t, obj)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	err := verifyAndParseStringRequest(b.RequestBody, &testTimestampedRequestObject{}, v, c)
+	assert.NotNil(t, err)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), staleTimestampResponse)
+}
+
+func Test_verifyAndParseStringRequest_AcceptsMillisecondTimestamp(t *testing.T) {
+	obj := testTimestampedRequestObject{
+		Data:      "some body message",
+		Timestamp: time.Now().UnixNano() / int64(time.Millisecond),
+	}
+	v, b, _ := returnValidVerificationAndRequestBodyWithRandomPrivateKey(t, obj)
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	err := verifyAndParseStringRequest(b.RequestBody, &testTimestampedRequestObject{}, v, c)
+	assert.Nil(t, err)
+}
+
+func Test_verifyAndParseStringRequest_MissingTimestamp(t *testing.T) {
+	obj := testRequestObject{
+		Data: "some body message",
+	}
+	v, b, _ := returnValidVerificationAndRequestBodyWithRandomPrivateKey(t, obj)
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	err := verifyAndParseStringRequest(b.RequestBody, &testRequestObject{}, v, c)
+	assert.Nil(t, err)
+
+	utils.Env.RequireRequestTimestamp = true
+	defer func() { utils.Env.RequireRequestTimestamp = false }()
+
+	w := httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	err = verifyAndParseStringRequest(b.RequestBody, &testRequestObject{}, v, c)
+	assert.NotNil(t, err)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), missingTimestampResponse)
+}
+
+func createV2ContextForTest(w *httptest.ResponseRecorder, path string, timestamp int64) *gin.Context {
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, path, nil)
+	c.Request.Header.Set(utils.RequestSigningVersionHeader, strconv.Itoa(utils.RequestSigningV2))
+	c.Request.Header.Set(utils.RequestSigningTimestampHeader, strconv.FormatInt(timestamp, 10))
+	return c
+}
+
+func returnV2VerificationAndRequestBodyForTest(t *testing.T, path string, timestamp int64,
+	body interface{}) (verification, requestBody) {
+	privateKey, err := utils.GenerateKey()
+	assert.Nil(t, err)
+
+	bodyJSON, _ := json.Marshal(body)
+	signature, err := utils.SignRequestV2(http.MethodPost, path, timestamp, string(bodyJSON), privateKey)
+	assert.Nil(t, err)
+
+	return verification{
+		Signature: signature,
+		PublicKey: utils.PubkeyCompressedToHex(privateKey.PublicKey),
+	}, requestBody{RequestBody: string(bodyJSON)}
+}
+
+func Test_verifyAndParseStringRequest_AcceptsV2Signature(t *testing.T) {
+	timestamp := time.Now().Unix()
+	path := V1Path + MetadataGetPath
+	v, b := returnV2VerificationAndRequestBodyForTest(t, path, timestamp, testRequestObject{Data: "some body message"})
+
+	c := createV2ContextForTest(httptest.NewRecorder(), path, timestamp)
+	err := verifyAndParseStringRequest(b.RequestBody, &testRequestObject{}, v, c)
+	assert.Nil(t, err)
+
+	// the signed timestamp is used for replay protection even though the body has none
+	w := httptest.NewRecorder()
+	c = createV2ContextForTest(w, path, timestamp)
+	err = verifyAndParseStringRequest(b.RequestBody, &testRequestObject{}, v, c)
+	assert.NotNil(t, err)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), replayedRequestResponse)
+}
+
+func Test_verifyAndParseStringRequest_RejectsV2Signature_For_Another_Path(t *testing.T) {
+	timestamp := time.Now().Unix()
+	v, b := returnV2VerificationAndRequestBodyForTest(t, V1Path+MetadataGetPath, timestamp,
+		testRequestObject{Data: "some body message"})
+
+	w := httptest.NewRecorder()
+	c := createV2ContextForTest(w, V1Path+MetadataDeletePath, timestamp)
+	err := verifyAndParseStringRequest(b.RequestBody, &testRequestObject{}, v, c)
+	assert.NotNil(t, err)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), signatureDidNotMatchResponse)
+}
+
+func Test_verifyAndParseStringRequest_RejectsV2Signature_Without_Timestamp(t *testing.T) {
+	v, b := returnV2VerificationAndRequestBodyForTest(t, V1Path+MetadataGetPath, 0,
+		testRequestObject{Data: "some body message"})
+
+	w := httptest.NewRecorder()
+	c := createV2ContextForTest(w, V1Path+MetadataGetPath, 0)
+	c.Request.Header.Del(utils.RequestSigningTimestampHeader)
+	err := verifyAndParseStringRequest(b.RequestBody, &testRequestObject{}, v, c)
+	assert.NotNil(t, err)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), invalidSigningTimestampError)
+}
+
+func Test_verifyAndParseStringRequest_RequireRequestSigningV2(t *testing.T) {
+	obj := testTimestampedRequestObject{
+		Data:      "some body message",
+		Timestamp: time.Now().Unix(),
+	}
+	v, b, _ := returnValidVerificationAndRequestBodyWithRandomPrivateKey(t, obj)
+
+	utils.Env.RequireRequestSigningV2 = true
+	defer func() { utils.Env.RequireRequestSigningV2 = false }()
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, V1Path+MetadataGetPath, nil)
+	err := verifyAndParseStringRequest(b.RequestBody, &testTimestampedRequestObject{}, v, c)
+	assert.NotNil(t, err)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), signingV2RequiredResponse)
+}
+
+func createWalletSignedContextForTest(w *httptest.ResponseRecorder, signatureType string) *gin.Context {
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, V1Path+MetadataGetPath, nil)
+	c.Request.Header.Set(utils.RequestSignatureTypeHeader, signatureType)
+	return c
+}
+
+func returnWalletVerificationAndRequestBodyForTest(t *testing.T, signatureType string,
+	body interface{}) (verification, requestBody) {
+	privateKey, err := utils.GenerateKey()
+	assert.Nil(t, err)
+
+	bodyJSON, _ := json.Marshal(body)
+	signature, err := utils.SignWallet(signatureType, utils.Hash(bodyJSON), privateKey)
+	assert.Nil(t, err)
+
+	return verification{
+		Signature: signature,
+		PublicKey: utils.PubkeyCompressedToHex(privateKey.PublicKey),
+	}, requestBody{RequestBody: string(bodyJSON)}
+}
+
+func Test_verifyAndParseStringRequest_AcceptsWalletSignatures(t *testing.T) {
+	for _, signatureType := range []string{utils.SignatureTypePersonalSign, utils.SignatureTypeEIP712} {
+		obj := testTimestampedRequestObject{
+			Data:      "some body message",
+			Timestamp: time.Now().Unix(),
+		}
+		v, b := returnWalletVerificationAndRequestBodyForTest(t, signatureType, obj)
+
+		err := verifyAndParseStringRequest(b.RequestBody, &testTimestampedRequestObject{}, v,
+			createWalletSignedContextForTest(httptest.NewRecorder(), signatureType))
+		assert.Nil(t, err, signatureType)
+
+		// writing the recovery byte as 0 or 1 instead of 27 or 28 doesn't make it a new request
+		recoveryByte := "00"
+		if strings.HasSuffix(v.Signature, "1c") {
+			recoveryByte = "01"
+		}
+		v.Signature = v.Signature[:2*utils.SigLengthInBytes] + recoveryByte
+		w := httptest.NewRecorder()
+		err = verifyAndParseStringRequest(b.RequestBody, &testTimestampedRequestObject{}, v,
+			createWalletSignedContextForTest(w, signatureType))
+		assert.NotNil(t, err, signatureType)
+		assert.Contains(t, w.Body.String(), replayedRequestResponse)
+	}
+}
+
+func Test_verifyAndParseStringRequest_RejectsWalletSignature_Of_Another_Type(t *testing.T) {
+	v, b := returnWalletVerificationAndRequestBodyForTest(t, utils.SignatureTypeEIP712,
+		testRequestObject{Data: "some body message"})
+
+	w := httptest.NewRecorder()
+	err := verifyAndParseStringRequest(b.RequestBody, &testRequestObject{}, v,
+		createWalletSignedContextForTest(w, utils.SignatureTypePersonalSign))
+	assert.NotNil(t, err)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), signatureDidNotMatchResponse)
+}
+
+func Test_verifyAndParseStringRequest_RejectsUnknownSignatureType(t *testing.T) {
+	v, b := returnWalletVerificationAndRequestBodyForTest(t, utils.SignatureTypeEIP712,
+		testRequestObject{Data: "some body message"})
+
+	w := httptest.NewRecorder()
+	err := verifyAndParseStringRequest(b.RequestBody, &testRequestObject{}, v,
+		createWalletSignedContextForTest(w, "eth_sign"))
+	assert.NotNil(t, err)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), invalidSignatureTypeError)
+}
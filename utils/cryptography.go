@@ -5,6 +5,9 @@ import (
 	"crypto/cipher"
 	"crypto/ecdsa"
 	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -12,6 +15,54 @@ import (
 
 const SigLengthInBytes = 64
 
+const (
+	/*RequestSigningVersionHeader selects how a request was signed.  Without it, or with 1, the signature covers
+	the keccak256 hash of requestBody.  With 2 it covers RequestDigestV2.*/
+	RequestSigningVersionHeader = "X-Signature-Version"
+
+	/*RequestSigningTimestampHeader is the unix timestamp, in seconds, that a v2 signature covers*/
+	RequestSigningTimestampHeader = "X-Signature-Timestamp"
+
+	/*RequestSigningV1 signs only the body of the request*/
+	RequestSigningV1 = 1
+
+	/*RequestSigningV2 signs the method, path, timestamp and body of the request*/
+	RequestSigningV2 = 2
+
+	/*RequestSignatureTypeHeader selects how the request's digest was signed, so that browser wallets, which
+	can't sign a raw hash, can sign requests too.  Without it the signature is over the digest itself.*/
+	RequestSignatureTypeHeader = "X-Signature-Type"
+
+	/*SignatureTypeRaw is a 64 or 65 byte signature over the digest itself*/
+	SignatureTypeRaw = "raw"
+
+	/*SignatureTypePersonalSign is a 65 byte personal_sign (EIP-191) signature over the 32 byte digest*/
+	SignatureTypePersonalSign = "personal_sign"
+
+	/*SignatureTypeEIP712 is a 65 byte signTypedData (EIP-712) signature over EIP712RequestType, holding the
+	digest, in EIP712DomainName's domain*/
+	SignatureTypeEIP712 = "eip712"
+)
+
+const (
+	/*EIP712DomainName is the name in the EIP-712 domain requests are signed in*/
+	EIP712DomainName = "Opacity"
+
+	/*EIP712DomainVersion is the version in the EIP-712 domain requests are signed in*/
+	EIP712DomainVersion = "1"
+
+	/*EIP712RequestType is the EIP-712 type requests are signed as.  digest is what a raw signature would cover.*/
+	EIP712RequestType = "Request(bytes32 digest)"
+
+	eip712DomainType      = "EIP712Domain(string name,string version)"
+	personalSignPrefix    = "\x19Ethereum Signed Message:\n"
+	recoverableSigLength  = SigLengthInBytes + 1
+	walletRecoveryIDShift = 27
+)
+
+/*ErrInvalidWalletSignature is returned for wallet signatures that aren't 65 bytes or can't be recovered*/
+var ErrInvalidWalletSignature = errors.New("invalid wallet signature")
+
 /*Encrypt encrypts a secret using a key and a nonce*/
 func Encrypt(key string, secret string, nonce string) []byte {
 	keyInBytes, err := hex.DecodeString(key)
@@ -153,3 +204,77 @@ func PubkeyCompressedToHex(p ecdsa.PublicKey) string {
 	compressed := crypto.CompressPubkey(&p)
 	return hex.EncodeToString(compressed)
 }
+
+/*RequestDigestV2 returns what a v2 request signature covers:  the keccak256 hash of the upper case HTTP method,
+the request path, the timestamp and the hex keccak256 hash of requestBody, joined by newlines.  Binding the
+method and path means a signed body can't be sent to a different endpoint that accepts the same JSON.*/
+func RequestDigestV2(method string, path string, timestamp int64, requestBody string) []byte {
+	return RequestDigestV2FromBodyHash(method, path, timestamp, Hash([]byte(requestBody)))
+}
+
+/*RequestDigestV2FromBodyHash is RequestDigestV2 for when the keccak256 hash of requestBody is already known*/
+func RequestDigestV2FromBodyHash(method string, path string, timestamp int64, bodyHash []byte) []byte {
+	return Hash([]byte(fmt.Sprintf("%s\n%s\n%d\n%s", strings.ToUpper(method), path, timestamp,
+		hex.EncodeToString(bodyHash))))
+}
+
+/*SignRequestV2 signs a request with the v2 scheme and returns the hex signature to send as the signature.
+Send RequestSigningV2 in the RequestSigningVersionHeader and timestamp in the RequestSigningTimestampHeader
+along with it.*/
+func SignRequestV2(method string, path string, timestamp int64, requestBody string, prv *ecdsa.PrivateKey) (string, error) {
+	signature, err := Sign(RequestDigestV2(method, path, timestamp, requestBody), prv)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(signature[:SigLengthInBytes]), nil
+}
+
+/*PersonalSignDigest returns the hash a wallet signs when asked to personal_sign the 32 bytes of digest*/
+func PersonalSignDigest(digest []byte) []byte {
+	return Hash([]byte(fmt.Sprintf("%s%d", personalSignPrefix, len(digest))), digest)
+}
+
+/*EIP712Digest returns the hash a wallet signs when asked to signTypedData an EIP712RequestType holding digest*/
+func EIP712Digest(digest []byte) []byte {
+	domainSeparator := Hash(Hash([]byte(eip712DomainType)), Hash([]byte(EIP712DomainName)),
+		Hash([]byte(EIP712DomainVersion)))
+	structHash := Hash(Hash([]byte(EIP712RequestType)), digest)
+	return Hash([]byte{0x19, 0x01}, domainSeparator, structHash)
+}
+
+/*RecoverWalletSigner returns the compressed hex public key that made a 65 byte wallet signature over hash.  Wallets
+put 27 or 28 in the last byte where go-ethereum expects 0 or 1, so both are accepted.  Signatures with a high s
+value are refused, since they are a second valid signature for the same message.*/
+func RecoverWalletSigner(hash []byte, sig []byte) (string, error) {
+	if len(sig) != recoverableSigLength {
+		return "", ErrInvalidWalletSignature
+	}
+	normalized := make([]byte, recoverableSigLength)
+	copy(normalized, sig)
+	if normalized[SigLengthInBytes] >= walletRecoveryIDShift {
+		normalized[SigLengthInBytes] -= walletRecoveryIDShift
+	}
+
+	publicKey, err := Recover(hash, normalized)
+	if err != nil {
+		return "", ErrInvalidWalletSignature
+	}
+	if !crypto.VerifySignature(crypto.FromECDSAPub(publicKey), hash, normalized[:SigLengthInBytes]) {
+		return "", ErrInvalidWalletSignature
+	}
+	return PubkeyCompressedToHex(*publicKey), nil
+}
+
+/*SignWallet signs digest the way a browser wallet would for signatureType, and returns the hex signature*/
+func SignWallet(signatureType string, digest []byte, prv *ecdsa.PrivateKey) (string, error) {
+	hash := PersonalSignDigest(digest)
+	if signatureType == SignatureTypeEIP712 {
+		hash = EIP712Digest(digest)
+	}
+	signature, err := Sign(hash, prv)
+	if err != nil {
+		return "", err
+	}
+	signature[SigLengthInBytes] += walletRecoveryIDShift
+	return hex.EncodeToString(signature), nil
+}
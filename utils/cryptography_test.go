@@ -122,3 +122,60 @@ func Test_VerifyFromStrings(t *testing.T) {
 	assert.Nil(t, err)
 	assert.True(t, isMatch)
 }
+
+func Test_SignRequestV2(t *testing.T) {
+	privateKey, err := GenerateKey()
+	assert.Nil(t, err)
+	publicKey := PubkeyCompressedToHex(privateKey.PublicKey)
+	requestBody := `{"metadataKey":"someKey","timestamp":1557346389}`
+
+	signature, err := SignRequestV2("post", "/api/v1/metadata/get", 1557346389, requestBody, privateKey)
+	assert.Nil(t, err)
+	assert.Equal(t, 2*SigLengthInBytes, len(signature))
+
+	digest := hex.EncodeToString(RequestDigestV2("POST", "/api/v1/metadata/get", 1557346389, requestBody))
+	verified, err := VerifyFromStrings(publicKey, digest, signature)
+	assert.Nil(t, err)
+	assert.True(t, verified)
+
+	// the same body sent to another endpoint, at another time or as a v1 request doesn't verify
+	for _, otherDigest := range [][]byte{
+		RequestDigestV2("POST", "/api/v1/metadata/delete", 1557346389, requestBody),
+		RequestDigestV2("POST", "/api/v1/metadata/get", 1557346390, requestBody),
+		Hash([]byte(requestBody)),
+	} {
+		verified, err = VerifyFromStrings(publicKey, hex.EncodeToString(otherDigest), signature)
+		assert.Nil(t, err)
+		assert.False(t, verified)
+	}
+}
+
+func Test_RecoverWalletSigner(t *testing.T) {
+	privateKey, err := GenerateKey()
+	assert.Nil(t, err)
+	digest := Hash([]byte(RandSeqFromRunes(32, []rune("abcdef01234567890"))))
+
+	for _, signatureType := range []string{SignatureTypePersonalSign, SignatureTypeEIP712} {
+		signature, err := SignWallet(signatureType, digest, privateKey)
+		assert.Nil(t, err)
+		sig, _ := hex.DecodeString(signature)
+		assert.True(t, sig[SigLengthInBytes] == 27 || sig[SigLengthInBytes] == 28)
+
+		hash := PersonalSignDigest(digest)
+		if signatureType == SignatureTypeEIP712 {
+			hash = EIP712Digest(digest)
+		}
+		signer, err := RecoverWalletSigner(hash, sig)
+		assert.Nil(t, err)
+		assert.Equal(t, PubkeyCompressedToHex(privateKey.PublicKey), signer)
+	}
+
+	_, err = RecoverWalletSigner(digest, make([]byte, SigLengthInBytes))
+	assert.Equal(t, ErrInvalidWalletSignature, err)
+}
+
+func Test_PersonalSignDigest(t *testing.T) {
+	digest := Hash([]byte("someTestInput"))
+	expected := Hash(append([]byte("\x19Ethereum Signed Message:\n32"), digest...))
+	assert.Equal(t, expected, PersonalSignDigest(digest))
+}
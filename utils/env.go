@@ -3,10 +3,13 @@ package utils
 import (
 	"errors"
 	"log"
+	"net"
 
 	"os"
 
 	"strconv"
+	"strings"
+	"time"
 
 	"encoding/json"
 
@@ -16,6 +19,27 @@ import (
 
 const defaultAccountRetentionDays = 7
 const defaultStripeRetentionDays = 30
+const defaultReplayWindowInSeconds = 300
+const defaultBadgerBackupDir = "/var/lib/badger/backups"
+const defaultBadgerBackupRetentionCount = 7
+const defaultBadgerGCDiscardRatio = 0.5
+const defaultBadgerFlattenLevelThreshold = 3
+const defaultSessionTokenTTLInSeconds = 900
+const defaultSessionMaxLifetimeInSeconds = 86400
+const defaultRateLimitPerIPPerMinute = 600
+const defaultRateLimitPerAccountPerMinute = 600
+const defaultRateLimitAccountCreationPerMinute = 10
+const defaultRateLimitDownloadPerMinute = 120
+const defaultMinMonthsInSubscription = 1
+const defaultAccountGracePeriodDays = 30
+const defaultAccountSuspensionDays = 30
+const defaultCorsAllowedOrigins = "*"
+const defaultCorsAllowedMethods = "GET,POST,PUT,PATCH,DELETE,HEAD"
+const defaultCorsMaxAgeInSeconds = 43200
+const defaultAdminCorsAllowedMethods = "GET,POST"
+const defaultSwaggerCorsAllowedMethods = "GET,HEAD"
+const defaultHstsMaxAgeInSeconds = 31536000
+const defaultReferrerPolicy = "no-referrer"
 
 const defaultPlansJson = `{
 "10": {"name":"Free","cost":0,"costInUSD":0.00,"storageInGB":10,"maxFolders":200,"maxMetadataSizeInMB":20},
@@ -31,6 +55,9 @@ type PlanInfo struct {
 	StorageInGB         int     `json:"storageInGB" binding:"required,gt=0"`
 	MaxFolders          int     `json:"maxFolders" binding:"required,gt=0"`
 	MaxMetadataSizeInMB int64   `json:"maxMetadataSizeInMB" binding:"required,gt=0"`
+
+	// the shortest subscription the plan can be bought for, MIN_MONTHS_IN_SUBSCRIPTION if not set
+	MinMonthsInSubscription int `json:"minMonthsInSubscription,omitempty" binding:"omitempty,gte=1"`
 }
 
 type PlanResponseType map[int]PlanInfo
@@ -81,6 +108,17 @@ type StorageNodeEnv struct {
 	PlansJson string `env:"PLANS_JSON"`
 	Plans     PlanResponseType
 
+	// Pricing:  the shortest subscription any plan can be bought for, and the percent taken off subscriptions of
+	// at least some number of months as JSON, e.g. {"24": 10, "36": 15}
+	MinMonthsInSubscription   int    `env:"MIN_MONTHS_IN_SUBSCRIPTION" envDefault:"1"`
+	SubscriptionDiscountsJson string `env:"SUBSCRIPTION_DISCOUNTS_JSON" envDefault:""`
+	SubscriptionDiscounts     SubscriptionDiscounts
+
+	// Account lifecycle:  how many days an expired account stays read only (grace), and then how many more days
+	// it is suspended before its files and metadata are purged
+	AccountGracePeriodDays int `env:"ACCOUNT_GRACE_PERIOD_DAYS" envDefault:"30"`
+	AccountSuspensionDays  int `env:"ACCOUNT_SUSPENSION_DAYS" envDefault:"30"`
+
 	// Stripe Keys
 	StripeKeyTest string `env:"STRIPE_KEY_TEST" envDefault:"Unknown"`
 	StripeKeyProd string `env:"STRIPE_KEY_PROD" envDefault:"Unknown"`
@@ -88,6 +126,77 @@ type StorageNodeEnv struct {
 
 	// Whether accepting credit cards is enabled
 	EnableCreditCards bool `env:"ENABLE_CREDIT_CARDS" envDefault:"false"`
+
+	// Pay-as-you-go storage:  the price of each GB stored over a plan for a month, in OPCT and in USD.  Accounts
+	// can only opt in to going over their plan while at least one of them is set.
+	OveragePricePerGBMonth      float64 `env:"OVERAGE_PRICE_PER_GB_MONTH" envDefault:"0"`
+	OveragePricePerGBMonthInUSD float64 `env:"OVERAGE_PRICE_PER_GB_MONTH_IN_USD" envDefault:"0"`
+
+	// How far a signed request's timestamp may drift from the server clock, in either direction
+	ReplayWindowInSeconds int `env:"REPLAY_WINDOW_IN_SECONDS" envDefault:"300"`
+
+	// Whether to reject signed requests that have no timestamp.  Leave off until clients have migrated.
+	RequireRequestTimestamp bool `env:"REQUIRE_REQUEST_TIMESTAMP" envDefault:"false"`
+
+	// Whether to reject requests that are not signed with the v2 scheme.  Leave off until clients have migrated.
+	RequireRequestSigningV2 bool `env:"REQUIRE_REQUEST_SIGNING_V2" envDefault:"false"`
+
+	// Session tokens:  the HMAC secret they are signed with (derived from the encryption key if empty), how
+	// long each token lasts, and how long refreshing can keep a session going after its signed login
+	SessionTokenSecret          string `env:"SESSION_TOKEN_SECRET" envDefault:""`
+	SessionTokenTTLInSeconds    int    `env:"SESSION_TOKEN_TTL_IN_SECONDS" envDefault:"900"`
+	SessionMaxLifetimeInSeconds int    `env:"SESSION_MAX_LIFETIME_IN_SECONDS" envDefault:"86400"`
+
+	// Rate limiting:  where buckets are kept (memory, kv or off), and how many requests a minute are allowed
+	// from each client IP, by each account, to create accounts from each IP and to download from each IP
+	RateLimitBackend                  string `env:"RATE_LIMIT_BACKEND" envDefault:"memory"`
+	RateLimitPerIPPerMinute           int    `env:"RATE_LIMIT_PER_IP_PER_MINUTE" envDefault:"600"`
+	RateLimitPerAccountPerMinute      int    `env:"RATE_LIMIT_PER_ACCOUNT_PER_MINUTE" envDefault:"600"`
+	RateLimitAccountCreationPerMinute int    `env:"RATE_LIMIT_ACCOUNT_CREATION_PER_MINUTE" envDefault:"10"`
+	RateLimitDownloadPerMinute        int    `env:"RATE_LIMIT_DOWNLOAD_PER_MINUTE" envDefault:"120"`
+
+	// Comma separated IPs or CIDRs of the proxies in front of the node.  The client IP is only taken from the
+	// X-Forwarded-For and X-Real-Ip headers of requests that come from one of them.
+	TrustedProxies   string `env:"TRUSTED_PROXIES" envDefault:""`
+	TrustedProxyNets []*net.IPNet
+
+	// CORS:  for each of /api/v1, /admin and /swagger, the comma separated origins allowed to call it from another
+	// origin ("*" allows any, empty allows none), and the methods, extra request headers and preflight max age
+	// allowed for them.  Same-origin requests are always allowed.
+	CorsAllowedOrigins         string `env:"CORS_ALLOWED_ORIGINS" envDefault:"*"`
+	CorsAllowedMethods         string `env:"CORS_ALLOWED_METHODS" envDefault:"GET,POST,PUT,PATCH,DELETE,HEAD"`
+	CorsAllowedHeaders         string `env:"CORS_ALLOWED_HEADERS" envDefault:""`
+	CorsMaxAgeInSeconds        int    `env:"CORS_MAX_AGE_IN_SECONDS" envDefault:"43200"`
+	AdminCorsAllowedOrigins    string `env:"ADMIN_CORS_ALLOWED_ORIGINS" envDefault:""`
+	AdminCorsAllowedMethods    string `env:"ADMIN_CORS_ALLOWED_METHODS" envDefault:"GET,POST"`
+	AdminCorsAllowedHeaders    string `env:"ADMIN_CORS_ALLOWED_HEADERS" envDefault:""`
+	AdminCorsMaxAgeInSeconds   int    `env:"ADMIN_CORS_MAX_AGE_IN_SECONDS" envDefault:"43200"`
+	SwaggerCorsAllowedOrigins  string `env:"SWAGGER_CORS_ALLOWED_ORIGINS" envDefault:""`
+	SwaggerCorsAllowedMethods  string `env:"SWAGGER_CORS_ALLOWED_METHODS" envDefault:"GET,HEAD"`
+	SwaggerCorsAllowedHeaders  string `env:"SWAGGER_CORS_ALLOWED_HEADERS" envDefault:""`
+	SwaggerCorsMaxAgeInSeconds int    `env:"SWAGGER_CORS_MAX_AGE_IN_SECONDS" envDefault:"43200"`
+
+	// Security headers:  how long browsers should only use HTTPS, and the referrer policy for every response
+	HstsMaxAgeInSeconds int    `env:"HSTS_MAX_AGE_IN_SECONDS" envDefault:"31536000"`
+	ReferrerPolicy      string `env:"REFERRER_POLICY" envDefault:"no-referrer"`
+
+	// Where metadata and other K:V pairs are kept:  badger, sql or memory
+	KvStoreBackend string `env:"KV_STORE_BACKEND" envDefault:"badger"`
+
+	// Badger backups:  where they are written, how many are kept and whether they are also copied to the bucket
+	BadgerBackupDir            string `env:"BADGER_BACKUP_DIR" envDefault:"/var/lib/badger/backups"`
+	BadgerBackupRetentionCount int    `env:"BADGER_BACKUP_RETENTION_COUNT" envDefault:"7"`
+	BadgerBackupToObjectStore  bool   `env:"BADGER_BACKUP_TO_OBJECT_STORE" envDefault:"false"`
+
+	// Badger maintenance:  a value log file is rewritten once this fraction of it is garbage, and the LSM tree
+	// is flattened once more than this many levels hold tables
+	BadgerGCDiscardRatio        float64 `env:"BADGER_GC_DISCARD_RATIO" envDefault:"0.5"`
+	BadgerFlattenLevelThreshold int     `env:"BADGER_FLATTEN_LEVEL_THRESHOLD" envDefault:"3"`
+
+	// From this date (2006-01-02 or RFC 3339) on, metadata without a permission hash can no longer be read,
+	// deleted or claimed.  Leave empty until owners have had time to claim their legacy metadata.
+	MetadataPermissionHashCutoff     string `env:"METADATA_PERMISSION_HASH_CUTOFF" envDefault:""`
+	MetadataPermissionHashCutoffTime time.Time
 }
 
 /*Env is the environment for a particular node while the application is running*/
@@ -131,20 +240,97 @@ func SetLive() {
 func SetTesting(filenames ...string) {
 	initEnv(filenames...)
 	Env.PlansJson = defaultPlansJson
+	Env.MinMonthsInSubscription = defaultMinMonthsInSubscription
+	Env.SubscriptionDiscountsJson = ""
+	Env.AccountGracePeriodDays = defaultAccountGracePeriodDays
+	Env.AccountSuspensionDays = defaultAccountSuspensionDays
 	Env.GoEnv = "test"
 	Env.DatabaseURL = Env.TestDatabaseURL
 	Env.StripeKey = Env.StripeKeyTest
+	// unit tests send far more requests from one address than a client would
+	Env.RateLimitBackend = RateLimitBackendOff
 	runInitializations()
 }
 
 func runInitializations() {
 	InitKvStore()
+	InitRateLimiter()
 	newS3Session()
 
 	Env.Plans = make(PlanResponseType)
 	err := json.Unmarshal([]byte(Env.PlansJson), &Env.Plans)
 	LogIfError(err, nil)
 	createPlanMetrics()
+
+	Env.SubscriptionDiscounts, err = parseSubscriptionDiscounts(Env.SubscriptionDiscountsJson)
+	if err != nil {
+		log.Fatal("SUBSCRIPTION_DISCOUNTS_JSON must map months to a percent off, like {\"24\": 10}: " + err.Error())
+	}
+
+	Env.MetadataPermissionHashCutoffTime, err = parseCutoff(Env.MetadataPermissionHashCutoff)
+	if err != nil {
+		log.Fatal("METADATA_PERMISSION_HASH_CUTOFF must be a date like 2006-01-02 or in RFC 3339 format: " + err.Error())
+	}
+
+	Env.TrustedProxyNets, err = parseTrustedProxies(Env.TrustedProxies)
+	if err != nil {
+		log.Fatal("TRUSTED_PROXIES must be a comma separated list of IPs or CIDRs: " + err.Error())
+	}
+}
+
+/*IsTrustedProxy returns whether ip is one of the TRUSTED_PROXIES*/
+func IsTrustedProxy(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, trustedProxyNet := range Env.TrustedProxyNets {
+		if trustedProxyNet.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseTrustedProxies(trustedProxies string) ([]*net.IPNet, error) {
+	var trustedProxyNets []*net.IPNet
+	for _, trustedProxy := range strings.Split(trustedProxies, ",") {
+		trustedProxy = strings.TrimSpace(trustedProxy)
+		if trustedProxy == "" {
+			continue
+		}
+		// a single IP is a network of one
+		if ip := net.ParseIP(trustedProxy); ip != nil {
+			if ip.To4() != nil {
+				trustedProxy += "/32"
+			} else {
+				trustedProxy += "/128"
+			}
+		}
+		_, trustedProxyNet, err := net.ParseCIDR(trustedProxy)
+		if err != nil {
+			return nil, err
+		}
+		trustedProxyNets = append(trustedProxyNets, trustedProxyNet)
+	}
+	return trustedProxyNets, nil
+}
+
+/*MetadataPermissionHashesEnforced returns whether the METADATA_PERMISSION_HASH_CUTOFF has passed, after which
+every metadata must have a permission hash*/
+func MetadataPermissionHashesEnforced() bool {
+	cutoff := Env.MetadataPermissionHashCutoffTime
+	return !cutoff.IsZero() && !time.Now().Before(cutoff)
+}
+
+func parseCutoff(cutoff string) (time.Time, error) {
+	if cutoff == "" {
+		return time.Time{}, nil
+	}
+	if parsed, err := time.Parse("2006-01-02", cutoff); err == nil {
+		return parsed, nil
+	}
+	return time.Parse(time.RFC3339, cutoff)
 }
 
 /*IsTestEnv returns whether we are in the test environment*/
@@ -208,6 +394,55 @@ func tryLookUp() error {
 	enableCreditCardsStr, _ := os.LookupEnv("ENABLE_CREDIT_CARDS")
 	enableCreditCards := enableCreditCardsStr == "true"
 
+	minMonthsInSubscription := lookupOptionalInt("MIN_MONTHS_IN_SUBSCRIPTION", defaultMinMonthsInSubscription)
+	subscriptionDiscountsJson, _ := os.LookupEnv("SUBSCRIPTION_DISCOUNTS_JSON")
+
+	accountGracePeriodDays := lookupNonNegativeInt("ACCOUNT_GRACE_PERIOD_DAYS", defaultAccountGracePeriodDays)
+	accountSuspensionDays := lookupNonNegativeInt("ACCOUNT_SUSPENSION_DAYS", defaultAccountSuspensionDays)
+
+	overagePricePerGBMonth := lookupOptionalFloat("OVERAGE_PRICE_PER_GB_MONTH", 0)
+	overagePricePerGBMonthInUSD := lookupOptionalFloat("OVERAGE_PRICE_PER_GB_MONTH_IN_USD", 0)
+
+	replayWindowInSeconds := lookupOptionalInt("REPLAY_WINDOW_IN_SECONDS", defaultReplayWindowInSeconds)
+	requireRequestTimestamp := lookupOptionalBool("REQUIRE_REQUEST_TIMESTAMP")
+	requireRequestSigningV2 := lookupOptionalBool("REQUIRE_REQUEST_SIGNING_V2")
+	sessionTokenSecret, _ := os.LookupEnv("SESSION_TOKEN_SECRET")
+	sessionTokenTTLInSeconds := lookupOptionalInt("SESSION_TOKEN_TTL_IN_SECONDS", defaultSessionTokenTTLInSeconds)
+	sessionMaxLifetimeInSeconds := lookupOptionalInt("SESSION_MAX_LIFETIME_IN_SECONDS", defaultSessionMaxLifetimeInSeconds)
+
+	rateLimitBackend := lookupOptionalString("RATE_LIMIT_BACKEND", RateLimitBackendMemory)
+	rateLimitPerIPPerMinute := lookupOptionalInt("RATE_LIMIT_PER_IP_PER_MINUTE", defaultRateLimitPerIPPerMinute)
+	rateLimitPerAccountPerMinute := lookupOptionalInt("RATE_LIMIT_PER_ACCOUNT_PER_MINUTE",
+		defaultRateLimitPerAccountPerMinute)
+	rateLimitAccountCreationPerMinute := lookupOptionalInt("RATE_LIMIT_ACCOUNT_CREATION_PER_MINUTE",
+		defaultRateLimitAccountCreationPerMinute)
+	rateLimitDownloadPerMinute := lookupOptionalInt("RATE_LIMIT_DOWNLOAD_PER_MINUTE", defaultRateLimitDownloadPerMinute)
+	trustedProxies, _ := os.LookupEnv("TRUSTED_PROXIES")
+
+	corsAllowedOrigins := lookupOptionalString("CORS_ALLOWED_ORIGINS", defaultCorsAllowedOrigins)
+	corsAllowedMethods := lookupOptionalString("CORS_ALLOWED_METHODS", defaultCorsAllowedMethods)
+	corsAllowedHeaders, _ := os.LookupEnv("CORS_ALLOWED_HEADERS")
+	corsMaxAgeInSeconds := lookupOptionalInt("CORS_MAX_AGE_IN_SECONDS", defaultCorsMaxAgeInSeconds)
+	adminCorsAllowedOrigins, _ := os.LookupEnv("ADMIN_CORS_ALLOWED_ORIGINS")
+	adminCorsAllowedMethods := lookupOptionalString("ADMIN_CORS_ALLOWED_METHODS", defaultAdminCorsAllowedMethods)
+	adminCorsAllowedHeaders, _ := os.LookupEnv("ADMIN_CORS_ALLOWED_HEADERS")
+	adminCorsMaxAgeInSeconds := lookupOptionalInt("ADMIN_CORS_MAX_AGE_IN_SECONDS", defaultCorsMaxAgeInSeconds)
+	swaggerCorsAllowedOrigins, _ := os.LookupEnv("SWAGGER_CORS_ALLOWED_ORIGINS")
+	swaggerCorsAllowedMethods := lookupOptionalString("SWAGGER_CORS_ALLOWED_METHODS", defaultSwaggerCorsAllowedMethods)
+	swaggerCorsAllowedHeaders, _ := os.LookupEnv("SWAGGER_CORS_ALLOWED_HEADERS")
+	swaggerCorsMaxAgeInSeconds := lookupOptionalInt("SWAGGER_CORS_MAX_AGE_IN_SECONDS", defaultCorsMaxAgeInSeconds)
+	hstsMaxAgeInSeconds := lookupOptionalInt("HSTS_MAX_AGE_IN_SECONDS", defaultHstsMaxAgeInSeconds)
+	referrerPolicy := lookupOptionalString("REFERRER_POLICY", defaultReferrerPolicy)
+
+	kvStoreBackend := lookupOptionalString("KV_STORE_BACKEND", KvStoreBackendBadger)
+
+	badgerBackupDir := lookupOptionalString("BADGER_BACKUP_DIR", defaultBadgerBackupDir)
+	badgerBackupRetentionCount := lookupOptionalInt("BADGER_BACKUP_RETENTION_COUNT", defaultBadgerBackupRetentionCount)
+	badgerBackupToObjectStore := lookupOptionalBool("BADGER_BACKUP_TO_OBJECT_STORE")
+	badgerGCDiscardRatio := lookupOptionalFloat("BADGER_GC_DISCARD_RATIO", defaultBadgerGCDiscardRatio)
+	badgerFlattenLevelThreshold := lookupOptionalInt("BADGER_FLATTEN_LEVEL_THRESHOLD", defaultBadgerFlattenLevelThreshold)
+	metadataPermissionHashCutoff, _ := os.LookupEnv("METADATA_PERMISSION_HASH_CUTOFF")
+
 	serverEnv := StorageNodeEnv{
 		ProdDatabaseURL:      prodDBUrl,
 		TestDatabaseURL:      testDBUrl,
@@ -228,6 +463,55 @@ func tryLookUp() error {
 		StripeKeyTest:        stripeKeyTest,
 		StripeKeyProd:        stripeKeyProd,
 		EnableCreditCards:    enableCreditCards,
+
+		MinMonthsInSubscription:   minMonthsInSubscription,
+		SubscriptionDiscountsJson: subscriptionDiscountsJson,
+
+		AccountGracePeriodDays: accountGracePeriodDays,
+		AccountSuspensionDays:  accountSuspensionDays,
+
+		OveragePricePerGBMonth:      overagePricePerGBMonth,
+		OveragePricePerGBMonthInUSD: overagePricePerGBMonthInUSD,
+
+		ReplayWindowInSeconds:   replayWindowInSeconds,
+		RequireRequestTimestamp: requireRequestTimestamp,
+		RequireRequestSigningV2: requireRequestSigningV2,
+		KvStoreBackend:          kvStoreBackend,
+
+		SessionTokenSecret:          sessionTokenSecret,
+		SessionTokenTTLInSeconds:    sessionTokenTTLInSeconds,
+		SessionMaxLifetimeInSeconds: sessionMaxLifetimeInSeconds,
+
+		RateLimitBackend:                  rateLimitBackend,
+		RateLimitPerIPPerMinute:           rateLimitPerIPPerMinute,
+		RateLimitPerAccountPerMinute:      rateLimitPerAccountPerMinute,
+		RateLimitAccountCreationPerMinute: rateLimitAccountCreationPerMinute,
+		RateLimitDownloadPerMinute:        rateLimitDownloadPerMinute,
+		TrustedProxies:                    trustedProxies,
+
+		CorsAllowedOrigins:         corsAllowedOrigins,
+		CorsAllowedMethods:         corsAllowedMethods,
+		CorsAllowedHeaders:         corsAllowedHeaders,
+		CorsMaxAgeInSeconds:        corsMaxAgeInSeconds,
+		AdminCorsAllowedOrigins:    adminCorsAllowedOrigins,
+		AdminCorsAllowedMethods:    adminCorsAllowedMethods,
+		AdminCorsAllowedHeaders:    adminCorsAllowedHeaders,
+		AdminCorsMaxAgeInSeconds:   adminCorsMaxAgeInSeconds,
+		SwaggerCorsAllowedOrigins:  swaggerCorsAllowedOrigins,
+		SwaggerCorsAllowedMethods:  swaggerCorsAllowedMethods,
+		SwaggerCorsAllowedHeaders:  swaggerCorsAllowedHeaders,
+		SwaggerCorsMaxAgeInSeconds: swaggerCorsMaxAgeInSeconds,
+		HstsMaxAgeInSeconds:        hstsMaxAgeInSeconds,
+		ReferrerPolicy:             referrerPolicy,
+
+		BadgerBackupDir:            badgerBackupDir,
+		BadgerBackupRetentionCount: badgerBackupRetentionCount,
+		BadgerBackupToObjectStore:  badgerBackupToObjectStore,
+
+		BadgerGCDiscardRatio:        badgerGCDiscardRatio,
+		BadgerFlattenLevelThreshold: badgerFlattenLevelThreshold,
+
+		MetadataPermissionHashCutoff: metadataPermissionHashCutoff,
 	}
 
 	Env = serverEnv
@@ -243,3 +527,53 @@ func AppendLookupErrors(property string, collectedErrors *[]error) string {
 	}
 	return value
 }
+
+/*lookupOptionalString looks up an environment variable that is not required, falling back to the default
+if it is missing or empty*/
+func lookupOptionalString(property string, defaultValue string) string {
+	value, _ := os.LookupEnv(property)
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
+
+/*lookupOptionalInt looks up an environment variable that is not required, falling back to the default
+if it is missing, not a number, or not positive*/
+func lookupOptionalInt(property string, defaultValue int) int {
+	valueStr, _ := os.LookupEnv(property)
+	value, err := strconv.Atoi(valueStr)
+	if err != nil || value <= 0 {
+		return defaultValue
+	}
+	return value
+}
+
+/*lookupNonNegativeInt looks up an environment variable that is not required and can be 0, falling back to the
+default if it is missing, not a number, or negative*/
+func lookupNonNegativeInt(property string, defaultValue int) int {
+	valueStr, _ := os.LookupEnv(property)
+	value, err := strconv.Atoi(valueStr)
+	if err != nil || value < 0 {
+		return defaultValue
+	}
+	return value
+}
+
+/*lookupOptionalFloat looks up an environment variable that is not required, falling back to the default
+if it is missing, not a number, or not positive*/
+func lookupOptionalFloat(property string, defaultValue float64) float64 {
+	valueStr, _ := os.LookupEnv(property)
+	value, err := strconv.ParseFloat(valueStr, 64)
+	if err != nil || value <= 0 {
+		return defaultValue
+	}
+	return value
+}
+
+/*lookupOptionalBool looks up an environment variable that is not required, treating anything other
+than "true" as false*/
+func lookupOptionalBool(property string) bool {
+	valueStr, _ := os.LookupEnv(property)
+	return valueStr == "true"
+}
@@ -3,7 +3,9 @@ package utils
 import (
 	"testing"
 
+	"os"
 	"strings"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -28,3 +30,54 @@ func Test_SetTesting(t *testing.T) {
 
 	assert.True(t, Env.AccountRetentionDays > 0)
 }
+
+func Test_MetadataPermissionHashesEnforced(t *testing.T) {
+	cutoff := Env.MetadataPermissionHashCutoffTime
+	defer func() { Env.MetadataPermissionHashCutoffTime = cutoff }()
+
+	Env.MetadataPermissionHashCutoffTime = time.Time{}
+	assert.False(t, MetadataPermissionHashesEnforced())
+
+	Env.MetadataPermissionHashCutoffTime = time.Now().Add(time.Hour)
+	assert.False(t, MetadataPermissionHashesEnforced())
+
+	Env.MetadataPermissionHashCutoffTime = time.Now().Add(-time.Hour)
+	assert.True(t, MetadataPermissionHashesEnforced())
+}
+
+func Test_parseCutoff(t *testing.T) {
+	parsed, err := parseCutoff("")
+	assert.Nil(t, err)
+	assert.True(t, parsed.IsZero())
+
+	parsed, err = parseCutoff("2020-06-01")
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC), parsed)
+
+	parsed, err = parseCutoff("2020-06-01T12:00:00Z")
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC), parsed)
+
+	_, err = parseCutoff("June 1st")
+	assert.NotNil(t, err)
+}
+
+func Test_lookupNonNegativeInt(t *testing.T) {
+	property := "NOT_A_REAL_NON_NEGATIVE_PROPERTY"
+	defer os.Unsetenv(property)
+
+	assert.Equal(t, 30, lookupNonNegativeInt(property, 30))
+
+	os.Setenv(property, "0")
+	assert.Equal(t, 0, lookupNonNegativeInt(property, 30))
+	assert.Equal(t, 30, lookupOptionalInt(property, 30))
+
+	os.Setenv(property, "7")
+	assert.Equal(t, 7, lookupNonNegativeInt(property, 30))
+
+	os.Setenv(property, "-1")
+	assert.Equal(t, 30, lookupNonNegativeInt(property, 30))
+
+	os.Setenv(property, "soon")
+	assert.Equal(t, 30, lookupNonNegativeInt(property, 30))
+}
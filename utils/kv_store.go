@@ -2,25 +2,37 @@ package utils
 
 import (
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"time"
+)
 
-	"fmt"
+/*BadgerDirProd is where the badger db lives outside of unit tests*/
+const BadgerDirProd = "/var/lib/badger/prod"
 
-	"os"
+const (
+	/*KvStoreBackendBadger keeps K:V pairs in a badger db on local disk*/
+	KvStoreBackendBadger = "badger"
 
-	"github.com/dgraph-io/badger"
-)
+	/*KvStoreBackendMemory keeps K:V pairs in memory.  Only meant for unit tests.*/
+	KvStoreBackendMemory = "memory"
 
-const badgerDirProd = "/var/lib/badger/prod"
+	/*KvStoreBackendSQL keeps K:V pairs in a table in the SQL database.  It is set up by models.Connect.*/
+	KvStoreBackendSQL = "sql"
+)
 
 /*TestValueTimeToLive is some default value we can use in unit
 tests for K:V pairs in badger*/
 const TestValueTimeToLive = 1 * time.Minute
 
-// Singleton DB
-var badgerDB *badger.DB
+/*ErrKeyNotFound is returned by every KVStore when a key is missing or expired*/
+var ErrKeyNotFound = errors.New("Key not found")
+
+// Singleton store
+var kvStore KVStore
 var dbNoInitError error
+var errKeyAlreadyExists = errors.New("key already exists")
+var errValueChanged = errors.New("value changed")
 var badgerDirTest string
 
 /*KVPairs is a type.  Map key strings to value strings*/
@@ -29,245 +41,227 @@ type KVPairs map[string]string
 /*KVKeys is a type.  An array of key strings*/
 type KVKeys []string
 
+/*KVStore is implemented by every backend that can hold our K:V pairs.  A ttl of 0 means the
+value never expires.*/
+type KVStore interface {
+	Get(key string) (value string, expirationTime time.Time, err error)
+	BatchGet(ks *KVKeys) (*KVPairs, error)
+	BatchSet(kvs *KVPairs, ttl time.Duration) error
+	BatchDelete(ks *KVKeys) error
+	SetIfNotExists(key string, value string, ttl time.Duration) (bool, error)
+	/*CompareAndSwap atomically sets an unexpired key to newValue if its value is still oldValue*/
+	CompareAndSwap(key string, oldValue string, newValue string, ttl time.Duration) (bool, error)
+	/*Iterate calls fn for every unexpired K:V pair.  expirationTime is the zero time if the pair never expires.*/
+	Iterate(fn func(key string, value string, expirationTime time.Time) error) error
+	DropAll() error
+	Close() error
+}
+
 func init() {
-	dbNoInitError = errors.New("badgerDB not initialized, Call InitKvStore() first")
+	dbNoInitError = errors.New("kv store not initialized, Call InitKvStore() first")
 
 	badgerDirTest, _ = ioutil.TempDir("", "badgerForUnitTest")
 }
 
-/*InitKvStore returns db so that caller can call CloseKvStore to close it when it is done.*/
+/*InitKvStore opens the K:V store for the configured backend.  Caller can call CloseKvStore to close it when
+it is done.*/
 func InitKvStore() (err error) {
-	if badgerDB != nil {
+	if kvStore != nil {
 		return nil
 	}
 
-	// Setup opts
-	var opts badger.Options
-
-	if IsTestEnv() {
-		opts = badger.DefaultOptions(badgerDirTest).WithTruncate(true)
-	} else {
-		opts = badger.DefaultOptions(badgerDirProd).WithTruncate(true)
+	var store KVStore
+	switch Env.KvStoreBackend {
+	case KvStoreBackendMemory:
+		store = NewMemoryKVStore()
+	case KvStoreBackendSQL:
+		// models.Connect sets this one up once the database is available
+		return nil
+	default:
+		store, err = NewBadgerKVStore(getBadgerDir())
 	}
 
-	badgerDB, err = badger.Open(opts)
 	LogIfError(err, nil)
+	if err == nil {
+		kvStore = store
+	}
 	return err
 }
 
+/*SetKvStore replaces the singleton store, e.g. with one that needs a database connection.*/
+func SetKvStore(store KVStore) {
+	kvStore = store
+}
+
+/*GetKvStore returns the singleton store. If not call InitKvStore(), it will return nil*/
+func GetKvStore() KVStore {
+	return kvStore
+}
+
 /*CloseKvStore closes the db.*/
 func CloseKvStore() error {
-	if badgerDB == nil {
+	if kvStore == nil {
 		return nil
 	}
 
-	err := badgerDB.Close()
+	err := kvStore.Close()
 	LogIfError(err, nil)
-	badgerDB = nil
+	kvStore = nil
 	return err
 }
 
 /*RemoveAllKvStoreData removes all the data. Caller should call InitKvStore() again to create a new one.*/
 func RemoveAllKvStoreData() error {
-	if err := CloseKvStore(); err != nil {
-		return err
+	if kvStore == nil {
+		return dbNoInitError
 	}
 
-	var dir string
-	if IsTestEnv() {
-		dir = badgerDirTest
-	} else {
-		dir = badgerDirProd
+	err := kvStore.DropAll()
+	LogIfError(err, map[string]interface{}{"backend": Env.KvStoreBackend})
+	if err != nil {
+		return err
 	}
-	err := os.RemoveAll(dir)
-	LogIfError(err, map[string]interface{}{"badgerDir": dir})
-	return err
-}
-
-/*GetBadgerDb returns the underlying the database. If not call InitKvStore(), it will return nil*/
-func GetBadgerDb() *badger.DB {
-	return badgerDB
+	return CloseKvStore()
 }
 
 /*GetValueFromKV gets a single value from the provided key*/
 func GetValueFromKV(key string) (value string, expirationTime time.Time, err error) {
-	expirationTime = time.Now()
-	if badgerDB == nil {
-		return value, expirationTime, dbNoInitError
+	if kvStore == nil {
+		return value, time.Now(), dbNoInitError
+	}
+	if key == "" {
+		return value, time.Now(), errors.New("no key specified")
 	}
 
-	err = badgerDB.View(func(txn *badger.Txn) error {
-		if key == "" {
-			return errors.New("no key specified")
-		}
-
-		item, err := txn.Get([]byte(key))
-		if err != nil {
-			return err
-		}
-
-		value = ""
-		if item != nil {
-			var valBytes []byte
-			err := item.Value(func(val []byte) error {
-				if val == nil {
-					valBytes = nil
-				} else {
-					valBytes = append([]byte{}, val...)
-				}
-				return nil
-			})
-			if err != nil {
-				return err
-			}
-
-			value = string(valBytes)
-			expirationTime = time.Unix(int64(item.ExpiresAt()), 0)
-		}
-
-		return nil
-	})
-	if err != badger.ErrKeyNotFound {
+	value, expirationTime, err = kvStore.Get(key)
+	if err != ErrKeyNotFound {
 		LogIfError(err, nil)
 	}
-
 	return
 }
 
-/*BatchGet returns KVPairs for a set of keys. It won't treat Key missing as error.*/
-func BatchGet(ks *KVKeys) (kvs *KVPairs, err error) {
-	kvs = &KVPairs{}
-	if badgerDB == nil {
-		return kvs, dbNoInitError
+/*SetIfNotExists atomically sets a key only if it is not already present.  Returns false if the key
+already existed.*/
+func SetIfNotExists(key string, value string, ttl time.Duration) (bool, error) {
+	ttl = getTTL(ttl)
+	if kvStore == nil {
+		return false, dbNoInitError
+	}
+	if key == "" {
+		return false, errors.New("SetIfNotExists does not accept key as empty string")
 	}
 
-	err = badgerDB.View(func(txn *badger.Txn) error {
-		for _, k := range *ks {
-			// Skip any empty keys.
-			if k == "" {
-				continue
-			}
-
-			item, err := txn.Get([]byte(k))
-			if err == badger.ErrKeyNotFound {
-				continue
-			}
-			if err != nil {
-				return err
-			}
+	set, err := kvStore.SetIfNotExists(key, value, ttl)
+	LogIfError(err, map[string]interface{}{"key": key})
+	return set, err
+}
 
-			fmt.Println(item.UserMeta())
-
-			val := ""
-			if item != nil {
-				var valBytes []byte
-				err := item.Value(func(val []byte) error {
-					if val == nil {
-						valBytes = nil
-					} else {
-						valBytes = append([]byte{}, val...)
-					}
-					return nil
-				})
-				if err != nil {
-					return err
-				}
+/*CompareAndSwap atomically replaces the value of a key that is still oldValue.  Returns false if the key
+is missing, expired or holds another value.*/
+func CompareAndSwap(key string, oldValue string, newValue string, ttl time.Duration) (bool, error) {
+	ttl = getTTL(ttl)
+	if kvStore == nil {
+		return false, dbNoInitError
+	}
+	if key == "" {
+		return false, errors.New("CompareAndSwap does not accept key as empty string")
+	}
 
-				val = string(valBytes)
-			}
+	swapped, err := kvStore.CompareAndSwap(key, oldValue, newValue, ttl)
+	LogIfError(err, map[string]interface{}{"key": key})
+	return swapped, err
+}
 
-			// Mutate KV map
-			(*kvs)[k] = val
-		}
+/*BatchGet returns KVPairs for a set of keys. It won't treat Key missing as error.*/
+func BatchGet(ks *KVKeys) (kvs *KVPairs, err error) {
+	if kvStore == nil {
+		return &KVPairs{}, dbNoInitError
+	}
 
-		return nil
-	})
+	kvs, err = kvStore.BatchGet(ks)
 	LogIfError(err, map[string]interface{}{"batchSize": len(*ks)})
-
 	return
 }
 
 /*BatchSet updates a set of KVPairs. Return error if any fails.*/
 func BatchSet(kvs *KVPairs, ttl time.Duration) error {
 	ttl = getTTL(ttl)
-	if badgerDB == nil {
+	if kvStore == nil {
 		return dbNoInitError
 	}
-
-	var err error
-	txn := badgerDB.NewTransaction(true)
-	for k, v := range *kvs {
-		if k == "" {
-			err = errors.New("BatchSet does not accept key as empty string")
-			break
-		}
-
-		e := txn.SetEntry(badger.NewEntry([]byte(k), []byte(v)).WithTTL(ttl))
-		if e == nil {
-			continue
-		}
-
-		if e == badger.ErrTxnTooBig {
-			e = nil
-			if commitErr := txn.Commit(); commitErr != nil {
-				e = commitErr
-			} else {
-				txn = badgerDB.NewTransaction(true)
-				e = txn.SetEntry(badger.NewEntry([]byte(k), []byte(v)).WithTTL(ttl))
-			}
-		}
-
-		if e != nil {
-			err = e
-			break
-		}
+	if _, ok := (*kvs)[""]; ok {
+		return errors.New("BatchSet does not accept key as empty string")
 	}
 
-	defer txn.Discard()
-	if err == nil {
-		err = txn.Commit()
-	}
+	err := kvStore.BatchSet(kvs, ttl)
 	LogIfError(err, map[string]interface{}{"batchSize": len(*kvs)})
 	return err
 }
 
 /*BatchDelete deletes a set of KVKeys, Return error if any fails.*/
 func BatchDelete(ks *KVKeys) error {
-	if badgerDB == nil {
+	if kvStore == nil {
 		return dbNoInitError
 	}
 
-	var err error
-	txn := badgerDB.NewTransaction(true)
-	for _, key := range *ks {
-		e := txn.Delete([]byte(key))
-		if e == nil {
-			continue
-		}
+	err := kvStore.BatchDelete(ks)
+	LogIfError(err, map[string]interface{}{"batchSize": len(*ks)})
+	return err
+}
 
-		if e == badger.ErrTxnTooBig {
-			e = nil
-			if commitErr := txn.Commit(); commitErr != nil {
-				e = commitErr
-			} else {
-				txn = badgerDB.NewTransaction(true)
-				e = txn.Delete([]byte(key))
+/*CopyKvStore copies every unexpired K:V pair from one store to another, keeping the remaining time to live.
+Returns the number of pairs copied.*/
+func CopyKvStore(from KVStore, to KVStore) (int, error) {
+	const batchSize = 1000
+	copied := 0
+	buffered := 0
+	// pairs are grouped by expiration time since BatchSet takes a single ttl
+	batches := make(map[time.Time]KVPairs)
+
+	flush := func() error {
+		for expirationTime, kvs := range batches {
+			delete(batches, expirationTime)
+			ttl := time.Duration(0)
+			if !expirationTime.IsZero() {
+				ttl = time.Until(expirationTime)
+				if ttl <= 0 {
+					continue
+				}
 			}
+			if err := to.BatchSet(&kvs, ttl); err != nil {
+				return err
+			}
+			copied += len(kvs)
 		}
-
-		if e != nil {
-			err = e
-			break
-		}
+		buffered = 0
+		return nil
 	}
 
-	defer txn.Discard()
+	err := from.Iterate(func(key string, value string, expirationTime time.Time) error {
+		if _, ok := batches[expirationTime]; !ok {
+			batches[expirationTime] = KVPairs{}
+		}
+		batches[expirationTime][key] = value
+		buffered++
+		if buffered >= batchSize {
+			return flush()
+		}
+		return nil
+	})
 	if err == nil {
-		err = txn.Commit()
+		err = flush()
+	}
+	if err != nil {
+		return copied, fmt.Errorf("copied %d pairs before failing: %v", copied, err)
 	}
+	return copied, nil
+}
 
-	LogIfError(err, map[string]interface{}{"batchSize": len(*ks)})
-	return err
+func getBadgerDir() string {
+	if IsTestEnv() {
+		return badgerDirTest
+	}
+	return BadgerDirProd
 }
 
 func getTTL(ttl time.Duration) time.Duration {
@@ -0,0 +1,244 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+/*BadgerBackupObjectPrefix is the prefix of badger backups copied to the bucket*/
+const BadgerBackupObjectPrefix = "badger-backups/"
+
+const badgerBackupFilePrefix = "badger-"
+const badgerBackupFileExtension = ".bak"
+
+// records the last backup CreateIncrementalBadgerBackup wrote, and isn't a backup itself
+const badgerLastBackupFileName = "last-backup.json"
+
+// how many pending writes badger may buffer while loading a backup
+const badgerLoadMaxPendingWrites = 256
+
+/*BadgerBackup describes a backup file written by CreateBadgerBackup*/
+type BadgerBackup struct {
+	Path      string `json:"path"`
+	ObjectKey string `json:"objectKey,omitempty"`
+	Since     uint64 `json:"since"`
+	Version   uint64 `json:"version"`
+
+	// how many backups, counting this one, have to be restored in order starting from a full backup
+	ChainLength int `json:"chainLength,omitempty"`
+}
+
+/*BackupBadger streams a consistent backup of every version newer than since to w.  Pass 0 for a full
+backup.  Returns the version to pass as since for the next incremental backup.*/
+func BackupBadger(w io.Writer, since uint64) (uint64, error) {
+	db := GetBadgerDb()
+	if db == nil {
+		return 0, ErrNotBadgerBackend
+	}
+	return db.Backup(w, since)
+}
+
+/*RestoreBadger loads a backup written by BackupBadger into the K:V store.  Badger keeps the versions from
+the backup, so any pair written or deleted after the backup was taken wins over the restored one.  Pass
+replace to drop everything in the store first and get back exactly what is in the backup.*/
+func RestoreBadger(r io.Reader, replace bool) error {
+	db := GetBadgerDb()
+	if db == nil {
+		return ErrNotBadgerBackend
+	}
+	if replace {
+		if err := db.DropAll(); err != nil {
+			return err
+		}
+	}
+	return db.Load(r, badgerLoadMaxPendingWrites)
+}
+
+/*CreateBadgerBackup writes a backup to a new file in dir, copies it to the bucket if
+Env.BadgerBackupToObjectStore is set, and prunes old backups down to Env.BadgerBackupRetentionCount.*/
+func CreateBadgerBackup(dir string, since uint64) (BadgerBackup, error) {
+	backup := BadgerBackup{Since: since}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return backup, err
+	}
+
+	// write to a temp file first so a crash never leaves a truncated backup behind
+	tmpFile, err := ioutil.TempFile(dir, "tmp-"+badgerBackupFilePrefix)
+	if err != nil {
+		return backup, err
+	}
+	defer os.Remove(tmpFile.Name())
+
+	backup.Version, err = BackupBadger(tmpFile, since)
+	if err == nil {
+		err = tmpFile.Sync()
+	}
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return backup, err
+	}
+
+	fileName := badgerBackupFileName(time.Now(), since)
+	backup.Path = filepath.Join(dir, fileName)
+	if err := os.Rename(tmpFile.Name(), backup.Path); err != nil {
+		return backup, err
+	}
+
+	if Env.BadgerBackupToObjectStore {
+		backup.ObjectKey = BadgerBackupObjectPrefix + fileName
+		if err := uploadBadgerBackup(backup.Path, backup.ObjectKey); err != nil {
+			return backup, err
+		}
+	}
+
+	return backup, PruneBadgerBackups(dir, Env.BadgerBackupRetentionCount)
+}
+
+/*CreateIncrementalBadgerBackup writes a backup of every version newer than the last backup it wrote to dir.
+It writes a full backup instead if there is no last backup, or if maxChainLength backups already build on the
+same full backup, so pruning down to maxChainLength backups never leaves the newest chain without its start.*/
+func CreateIncrementalBadgerBackup(dir string, maxChainLength int) (BadgerBackup, error) {
+	last, err := readLastBadgerBackup(dir)
+	if err != nil {
+		return last, err
+	}
+
+	since := uint64(0)
+	chainLength := 1
+	if last.Version > 0 && (maxChainLength <= 0 || last.ChainLength < maxChainLength) {
+		since = last.Version
+		chainLength = last.ChainLength + 1
+	}
+
+	backup, err := CreateBadgerBackup(dir, since)
+	if err != nil {
+		return backup, err
+	}
+	backup.ChainLength = chainLength
+
+	return backup, writeLastBadgerBackup(dir, backup)
+}
+
+/*RestoreBadgerFromFile loads a backup file into the K:V store, see RestoreBadger*/
+func RestoreBadgerFromFile(path string, replace bool) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return RestoreBadger(file, replace)
+}
+
+/*RestoreBadgerFromObjectStore loads a backup that was copied to the bucket into the K:V store, see
+RestoreBadger*/
+func RestoreBadgerFromObjectStore(objectKey string, replace bool) error {
+	if !strings.HasPrefix(objectKey, BadgerBackupObjectPrefix) {
+		return fmt.Errorf("object key must start with %s", BadgerBackupObjectPrefix)
+	}
+
+	reader, err := GetDefaultBucketObjectReader(objectKey)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	return RestoreBadger(reader, replace)
+}
+
+/*PruneBadgerBackups deletes all but the newest keep backups in dir and, if Env.BadgerBackupToObjectStore is
+set, in the bucket*/
+func PruneBadgerBackups(dir string, keep int) error {
+	if keep <= 0 {
+		return nil
+	}
+
+	var collectedErrors []error
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	var fileNames []string
+	for _, file := range files {
+		if isBadgerBackupName(file.Name()) {
+			fileNames = append(fileNames, file.Name())
+		}
+	}
+	for _, fileName := range oldBadgerBackups(fileNames, keep) {
+		AppendIfError(os.Remove(filepath.Join(dir, fileName)), &collectedErrors)
+	}
+
+	if Env.BadgerBackupToObjectStore {
+		objectKeys, err := ListDefaultBucketObjectKeys(BadgerBackupObjectPrefix)
+		AppendIfError(err, &collectedErrors)
+		var backupKeys []string
+		for _, objectKey := range objectKeys {
+			if isBadgerBackupName(strings.TrimPrefix(objectKey, BadgerBackupObjectPrefix)) {
+				backupKeys = append(backupKeys, objectKey)
+			}
+		}
+		if oldKeys := oldBadgerBackups(backupKeys, keep); len(oldKeys) > 0 {
+			AppendIfError(DeleteDefaultBucketObjects(oldKeys), &collectedErrors)
+		}
+	}
+
+	return CollectErrors(collectedErrors)
+}
+
+func readLastBadgerBackup(dir string) (BadgerBackup, error) {
+	backup := BadgerBackup{}
+	data, err := ioutil.ReadFile(filepath.Join(dir, badgerLastBackupFileName))
+	if os.IsNotExist(err) {
+		return backup, nil
+	}
+	if err != nil {
+		return backup, err
+	}
+	return backup, json.Unmarshal(data, &backup)
+}
+
+func writeLastBadgerBackup(dir string, backup BadgerBackup) error {
+	data, err := json.Marshal(backup)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, badgerLastBackupFileName), data, 0600)
+}
+
+func uploadBadgerBackup(path string, objectKey string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return SetDefaultBucketObjectFromReader(objectKey, file)
+}
+
+// names sort in the order the backups were taken
+func badgerBackupFileName(takenAt time.Time, since uint64) string {
+	return fmt.Sprintf("%s%s-%d%s", badgerBackupFilePrefix, takenAt.UTC().Format("20060102T150405.000000000Z"),
+		since, badgerBackupFileExtension)
+}
+
+func isBadgerBackupName(name string) bool {
+	return strings.HasPrefix(name, badgerBackupFilePrefix) && strings.HasSuffix(name, badgerBackupFileExtension)
+}
+
+func oldBadgerBackups(names []string, keep int) []string {
+	if len(names) <= keep {
+		return nil
+	}
+	sort.Strings(names)
+	return names[:len(names)-keep]
+}
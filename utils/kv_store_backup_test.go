@@ -0,0 +1,121 @@
+package utils
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_BackupBadger_RestoreBadger(t *testing.T) {
+	SetTesting("../.env")
+	InitKvStore()
+	defer CloseKvStore()
+
+	kvs := KVPairs{"backupKey1": "value1", "backupKey2": "value2"}
+	assert.Nil(t, BatchSet(&kvs, TestValueTimeToLive))
+
+	var buf bytes.Buffer
+	version, err := BackupBadger(&buf, 0)
+	assert.Nil(t, err)
+	assert.True(t, version > 0)
+
+	assert.Nil(t, BatchDelete(&KVKeys{"backupKey1", "backupKey2"}))
+	result, _ := BatchGet(&KVKeys{"backupKey1", "backupKey2"})
+	assert.Equal(t, 0, len(*result))
+
+	// the deletes are newer than the backup, so they win unless the store is replaced
+	restore := bytes.NewReader(buf.Bytes())
+	assert.Nil(t, RestoreBadger(restore, false))
+	result, _ = BatchGet(&KVKeys{"backupKey1", "backupKey2"})
+	assert.Equal(t, 0, len(*result))
+
+	assert.Nil(t, RestoreBadger(&buf, true))
+	result, err = BatchGet(&KVKeys{"backupKey1", "backupKey2"})
+	assert.Nil(t, err)
+	assert.Equal(t, kvs, *result)
+}
+
+func Test_BackupBadger_NotBadgerBackend(t *testing.T) {
+	CloseKvStore()
+	SetKvStore(NewMemoryKVStore())
+	defer CloseKvStore()
+
+	_, err := BackupBadger(&bytes.Buffer{}, 0)
+	assert.Equal(t, ErrNotBadgerBackend, err)
+	assert.Equal(t, ErrNotBadgerBackend, RestoreBadger(&bytes.Buffer{}, false))
+}
+
+func Test_CreateBadgerBackup_PrunesOldBackups(t *testing.T) {
+	InitKvStore()
+	defer CloseKvStore()
+
+	dir, err := ioutil.TempDir("", "badgerBackupForUnitTest")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	// an unrelated file must survive pruning
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "notABackup"), []byte{}, 0600))
+	for i := 0; i < 3; i++ {
+		name := badgerBackupFileName(time.Now().Add(-time.Duration(i+1)*time.Hour), 0)
+		assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, name), []byte{}, 0600))
+	}
+
+	retentionCount := Env.BadgerBackupRetentionCount
+	Env.BadgerBackupRetentionCount = 2
+	defer func() { Env.BadgerBackupRetentionCount = retentionCount }()
+
+	backup, err := CreateBadgerBackup(dir, 0)
+	assert.Nil(t, err)
+
+	files, err := ioutil.ReadDir(dir)
+	assert.Nil(t, err)
+	var names []string
+	for _, file := range files {
+		names = append(names, file.Name())
+	}
+	assert.Equal(t, 3, len(names))
+	assert.Contains(t, names, "notABackup")
+	assert.Contains(t, names, filepath.Base(backup.Path))
+
+	assert.Nil(t, RestoreBadgerFromFile(backup.Path, false))
+}
+
+func Test_CreateIncrementalBadgerBackup(t *testing.T) {
+	InitKvStore()
+	defer CloseKvStore()
+
+	dir, err := ioutil.TempDir("", "badgerBackupForUnitTest")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	assert.Nil(t, BatchSet(&KVPairs{"incrementalKey1": "value1"}, TestValueTimeToLive))
+	full, err := CreateIncrementalBadgerBackup(dir, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), full.Since)
+	assert.Equal(t, 1, full.ChainLength)
+
+	assert.Nil(t, BatchSet(&KVPairs{"incrementalKey2": "value2"}, TestValueTimeToLive))
+	incremental, err := CreateIncrementalBadgerBackup(dir, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, full.Version, incremental.Since)
+	assert.Equal(t, 2, incremental.ChainLength)
+
+	// the full backup and the incremental one together have everything
+	assert.Nil(t, RestoreBadgerFromFile(full.Path, true))
+	result, _ := BatchGet(&KVKeys{"incrementalKey1", "incrementalKey2"})
+	assert.Equal(t, KVPairs{"incrementalKey1": "value1"}, *result)
+	assert.Nil(t, RestoreBadgerFromFile(incremental.Path, false))
+	result, _ = BatchGet(&KVKeys{"incrementalKey1", "incrementalKey2"})
+	assert.Equal(t, KVPairs{"incrementalKey1": "value1", "incrementalKey2": "value2"}, *result)
+
+	// the chain is as long as allowed, so the next one starts over
+	next, err := CreateIncrementalBadgerBackup(dir, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), next.Since)
+	assert.Equal(t, 1, next.ChainLength)
+}
@@ -0,0 +1,334 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/dgraph-io/badger"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+/*ErrNotBadgerBackend is returned by badger specific operations when the K:V store is not backed by badger*/
+var ErrNotBadgerBackend = errors.New("K:V store is not backed by badger")
+
+type badgerKVStore struct {
+	db *badger.DB
+}
+
+/*NewBadgerKVStore opens a badger db in dir*/
+func NewBadgerKVStore(dir string) (KVStore, error) {
+	opts := badger.DefaultOptions(dir).WithTruncate(true)
+
+	db, err := badger.Open(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &badgerKVStore{db: db}, nil
+}
+
+/*GetBadgerDb returns the underlying badger database, or nil if the K:V store is not backed by badger*/
+func GetBadgerDb() *badger.DB {
+	if store, ok := kvStore.(*badgerKVStore); ok {
+		return store.db
+	}
+	return nil
+}
+
+func (b *badgerKVStore) Get(key string) (value string, expirationTime time.Time, err error) {
+	expirationTime = time.Now()
+	err = b.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(key))
+		if err != nil {
+			return err
+		}
+
+		valBytes, err := item.ValueCopy(nil)
+		if err != nil {
+			return err
+		}
+
+		value = string(valBytes)
+		expirationTime = time.Unix(int64(item.ExpiresAt()), 0)
+		return nil
+	})
+	return value, expirationTime, convertBadgerError(err)
+}
+
+func (b *badgerKVStore) BatchGet(ks *KVKeys) (*KVPairs, error) {
+	kvs := &KVPairs{}
+	err := b.db.View(func(txn *badger.Txn) error {
+		for _, k := range *ks {
+			// Skip any empty keys.
+			if k == "" {
+				continue
+			}
+
+			item, err := txn.Get([]byte(k))
+			if err == badger.ErrKeyNotFound {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			valBytes, err := item.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+
+			// Mutate KV map
+			(*kvs)[k] = string(valBytes)
+		}
+
+		return nil
+	})
+	return kvs, err
+}
+
+func (b *badgerKVStore) BatchSet(kvs *KVPairs, ttl time.Duration) error {
+	var err error
+	txn := b.db.NewTransaction(true)
+	for k, v := range *kvs {
+		e := txn.SetEntry(newBadgerEntry(k, v, ttl))
+		if e == nil {
+			continue
+		}
+
+		if e == badger.ErrTxnTooBig {
+			e = nil
+			if commitErr := txn.Commit(); commitErr != nil {
+				e = commitErr
+			} else {
+				txn = b.db.NewTransaction(true)
+				e = txn.SetEntry(newBadgerEntry(k, v, ttl))
+			}
+		}
+
+		if e != nil {
+			err = e
+			break
+		}
+	}
+
+	defer txn.Discard()
+	if err == nil {
+		err = txn.Commit()
+	}
+	return err
+}
+
+func (b *badgerKVStore) BatchDelete(ks *KVKeys) error {
+	var err error
+	txn := b.db.NewTransaction(true)
+	for _, key := range *ks {
+		e := txn.Delete([]byte(key))
+		if e == nil {
+			continue
+		}
+
+		if e == badger.ErrTxnTooBig {
+			e = nil
+			if commitErr := txn.Commit(); commitErr != nil {
+				e = commitErr
+			} else {
+				txn = b.db.NewTransaction(true)
+				e = txn.Delete([]byte(key))
+			}
+		}
+
+		if e != nil {
+			err = e
+			break
+		}
+	}
+
+	defer txn.Discard()
+	if err == nil {
+		err = txn.Commit()
+	}
+	return err
+}
+
+func (b *badgerKVStore) SetIfNotExists(key string, value string, ttl time.Duration) (bool, error) {
+	err := b.db.Update(func(txn *badger.Txn) error {
+		_, err := txn.Get([]byte(key))
+		if err == nil {
+			return errKeyAlreadyExists
+		}
+		if err != badger.ErrKeyNotFound {
+			return err
+		}
+		return txn.SetEntry(newBadgerEntry(key, value, ttl))
+	})
+
+	// ErrConflict means a concurrent transaction wrote the same key first
+	if err == errKeyAlreadyExists || err == badger.ErrConflict {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (b *badgerKVStore) CompareAndSwap(key string, oldValue string, newValue string, ttl time.Duration) (bool, error) {
+	err := b.db.Update(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(key))
+		if err == badger.ErrKeyNotFound {
+			return errValueChanged
+		}
+		if err != nil {
+			return err
+		}
+		value, err := item.ValueCopy(nil)
+		if err != nil {
+			return err
+		}
+		if string(value) != oldValue {
+			return errValueChanged
+		}
+		return txn.SetEntry(newBadgerEntry(key, newValue, ttl))
+	})
+
+	// ErrConflict means a concurrent transaction changed the key first
+	if err == errValueChanged || err == badger.ErrConflict {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (b *badgerKVStore) Iterate(fn func(key string, value string, expirationTime time.Time) error) error {
+	return b.db.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			valBytes, err := item.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+
+			expirationTime := time.Time{}
+			if item.ExpiresAt() != 0 {
+				expirationTime = time.Unix(int64(item.ExpiresAt()), 0)
+			}
+			if err := fn(string(item.KeyCopy(nil)), string(valBytes), expirationTime); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (b *badgerKVStore) DropAll() error {
+	return b.db.DropAll()
+}
+
+func (b *badgerKVStore) Close() error {
+	return b.db.Close()
+}
+
+func newBadgerEntry(key string, value string, ttl time.Duration) *badger.Entry {
+	entry := badger.NewEntry([]byte(key), []byte(value))
+	if ttl == 0 {
+		return entry
+	}
+	return entry.WithTTL(ttl)
+}
+
+func convertBadgerError(err error) error {
+	if err == badger.ErrKeyNotFound {
+		return ErrKeyNotFound
+	}
+	return err
+}
+
+// upper bound on value log files rewritten in one RunBadgerValueLogGC call, so it can't hog the disk
+const maxBadgerValueLogGCRewrites = 100
+
+// how many goroutines badger may use to flatten the LSM tree
+const badgerFlattenWorkers = 2
+
+/*BadgerStats describes the size of the badger db*/
+type BadgerStats struct {
+	LSMSize        int64
+	VlogSize       int64
+	KeyCount       uint64
+	TablesPerLevel map[int]int
+}
+
+/*GetBadgerStats reports the size of the badger db and exports it to the Prometheus metrics*/
+func GetBadgerStats() (BadgerStats, error) {
+	db := GetBadgerDb()
+	if db == nil {
+		return BadgerStats{}, ErrNotBadgerBackend
+	}
+
+	stats := BadgerStats{TablesPerLevel: make(map[int]int)}
+	stats.LSMSize, stats.VlogSize = db.Size()
+	for _, table := range db.Tables(true) {
+		stats.KeyCount += table.KeyCount
+		stats.TablesPerLevel[table.Level]++
+	}
+
+	Metrics_Badger_LSM_Size_Bytes.Set(float64(stats.LSMSize))
+	Metrics_Badger_Vlog_Size_Bytes.Set(float64(stats.VlogSize))
+	Metrics_Badger_Key_Count.Set(float64(stats.KeyCount))
+	Metrics_Badger_Tables.Reset()
+	for level, count := range stats.TablesPerLevel {
+		Metrics_Badger_Tables.With(prometheus.Labels{"level": strconv.Itoa(level)}).Set(float64(count))
+	}
+	return stats, nil
+}
+
+/*RunBadgerValueLogGC rewrites value log files until none has at least discardRatio of garbage left.
+Returns the number of files rewritten.*/
+func RunBadgerValueLogGC(discardRatio float64) (int, error) {
+	db := GetBadgerDb()
+	if db == nil {
+		return 0, ErrNotBadgerBackend
+	}
+	if discardRatio <= 0 || discardRatio >= 1 {
+		return 0, fmt.Errorf("discard ratio must be between 0 and 1, got %v", discardRatio)
+	}
+
+	rewrites := 0
+	for rewrites < maxBadgerValueLogGCRewrites {
+		err := db.RunValueLogGC(discardRatio)
+		if err == badger.ErrNoRewrite || err == badger.ErrRejected {
+			// ErrRejected means another GC is already running, which is as good as done for us
+			Metrics_Badger_Value_Log_GC_Counter.With(prometheus.Labels{"result": "no_rewrite"}).Inc()
+			return rewrites, nil
+		}
+		if err != nil {
+			Metrics_Badger_Value_Log_GC_Counter.With(prometheus.Labels{"result": "error"}).Inc()
+			return rewrites, err
+		}
+		Metrics_Badger_Value_Log_GC_Counter.With(prometheus.Labels{"result": "rewrite"}).Inc()
+		rewrites++
+	}
+	return rewrites, nil
+}
+
+/*FlattenBadgerIfNeeded flattens the LSM tree when more than levelThreshold levels hold tables.  Returns
+whether it flattened.*/
+func FlattenBadgerIfNeeded(levelThreshold int) (bool, error) {
+	db := GetBadgerDb()
+	if db == nil {
+		return false, ErrNotBadgerBackend
+	}
+
+	levels := make(map[int]bool)
+	for _, table := range db.Tables(false) {
+		levels[table.Level] = true
+	}
+	if len(levels) <= levelThreshold {
+		return false, nil
+	}
+
+	if err := db.Flatten(badgerFlattenWorkers); err != nil {
+		return false, err
+	}
+	Metrics_Badger_Flatten_Counter.Inc()
+	return true, nil
+}
@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GetBadgerStats(t *testing.T) {
+	SetTesting("../.env")
+	InitKvStore()
+	defer CloseKvStore()
+
+	stats, err := GetBadgerStats()
+	assert.Nil(t, err)
+	assert.NotNil(t, stats.TablesPerLevel)
+}
+
+func Test_RunBadgerValueLogGC(t *testing.T) {
+	InitKvStore()
+	defer CloseKvStore()
+
+	_, err := RunBadgerValueLogGC(1.5)
+	assert.NotNil(t, err)
+
+	counter := Metrics_Badger_Value_Log_GC_Counter.WithLabelValues("no_rewrite")
+	before := GetMetricCounter(counter)
+	rewrites, err := RunBadgerValueLogGC(0.5)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, rewrites)
+	assert.Equal(t, before+1, GetMetricCounter(counter))
+}
+
+func Test_FlattenBadgerIfNeeded(t *testing.T) {
+	InitKvStore()
+	defer CloseKvStore()
+
+	flattened, err := FlattenBadgerIfNeeded(100)
+	assert.Nil(t, err)
+	assert.False(t, flattened)
+}
+
+func Test_BadgerMaintenance_NotBadgerBackend(t *testing.T) {
+	CloseKvStore()
+	SetKvStore(NewMemoryKVStore())
+	defer CloseKvStore()
+
+	_, err := GetBadgerStats()
+	assert.Equal(t, ErrNotBadgerBackend, err)
+	_, err = RunBadgerValueLogGC(0.5)
+	assert.Equal(t, ErrNotBadgerBackend, err)
+	_, err = FlattenBadgerIfNeeded(0)
+	assert.Equal(t, ErrNotBadgerBackend, err)
+}
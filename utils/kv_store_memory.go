@@ -0,0 +1,140 @@
+package utils
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+type memoryKVEntry struct {
+	value     string
+	expiresAt time.Time
+}
+
+type memoryKVStore struct {
+	mutex   sync.RWMutex
+	entries map[string]memoryKVEntry
+}
+
+/*NewMemoryKVStore returns an empty K:V store that lives in memory*/
+func NewMemoryKVStore() KVStore {
+	return &memoryKVStore{entries: make(map[string]memoryKVEntry)}
+}
+
+func (m *memoryKVStore) Get(key string) (string, time.Time, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	entry, ok := m.getUnexpired(key)
+	if !ok {
+		return "", time.Now(), ErrKeyNotFound
+	}
+	return entry.value, time.Unix(entry.expiresAt.Unix(), 0), nil
+}
+
+func (m *memoryKVStore) BatchGet(ks *KVKeys) (*KVPairs, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	kvs := &KVPairs{}
+	for _, k := range *ks {
+		if entry, ok := m.getUnexpired(k); ok {
+			(*kvs)[k] = entry.value
+		}
+	}
+	return kvs, nil
+}
+
+func (m *memoryKVStore) BatchSet(kvs *KVPairs, ttl time.Duration) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for k, v := range *kvs {
+		m.entries[k] = newMemoryKVEntry(v, ttl)
+	}
+	return nil
+}
+
+func (m *memoryKVStore) BatchDelete(ks *KVKeys) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for _, k := range *ks {
+		delete(m.entries, k)
+	}
+	return nil
+}
+
+func (m *memoryKVStore) SetIfNotExists(key string, value string, ttl time.Duration) (bool, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if _, ok := m.getUnexpired(key); ok {
+		return false, nil
+	}
+	m.entries[key] = newMemoryKVEntry(value, ttl)
+	return true, nil
+}
+
+func (m *memoryKVStore) CompareAndSwap(key string, oldValue string, newValue string, ttl time.Duration) (bool, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if entry, ok := m.getUnexpired(key); !ok || entry.value != oldValue {
+		return false, nil
+	}
+	m.entries[key] = newMemoryKVEntry(newValue, ttl)
+	return true, nil
+}
+
+func (m *memoryKVStore) Iterate(fn func(key string, value string, expirationTime time.Time) error) error {
+	m.mutex.RLock()
+	keys := make([]string, 0, len(m.entries))
+	for k := range m.entries {
+		keys = append(keys, k)
+	}
+	m.mutex.RUnlock()
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		m.mutex.RLock()
+		entry, ok := m.getUnexpired(k)
+		m.mutex.RUnlock()
+		if !ok {
+			continue
+		}
+		if err := fn(k, entry.value, entry.expiresAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *memoryKVStore) DropAll() error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.entries = make(map[string]memoryKVEntry)
+	return nil
+}
+
+func (m *memoryKVStore) Close() error {
+	return nil
+}
+
+// caller must hold the mutex
+func (m *memoryKVStore) getUnexpired(key string) (memoryKVEntry, bool) {
+	entry, ok := m.entries[key]
+	if !ok || (!entry.expiresAt.IsZero() && !entry.expiresAt.After(time.Now())) {
+		return memoryKVEntry{}, false
+	}
+	return entry, true
+}
+
+func newMemoryKVEntry(value string, ttl time.Duration) memoryKVEntry {
+	entry := memoryKVEntry{value: value}
+	if ttl != 0 {
+		entry.expiresAt = time.Now().Add(ttl)
+	}
+	return entry
+}
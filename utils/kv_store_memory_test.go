@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_MemoryKVStore_BatchSetGetDelete(t *testing.T) {
+	store := NewMemoryKVStore()
+
+	kvs := KVPairs{"key1": "value1", "key2": "value2"}
+	assert.Nil(t, store.BatchSet(&kvs, time.Minute))
+
+	result, err := store.BatchGet(&KVKeys{"key1", "key2", "key3"})
+	assert.Nil(t, err)
+	assert.Equal(t, KVPairs{"key1": "value1", "key2": "value2"}, *result)
+
+	assert.Nil(t, store.BatchDelete(&KVKeys{"key1"}))
+	_, _, err = store.Get("key1")
+	assert.Equal(t, ErrKeyNotFound, err)
+}
+
+func Test_MemoryKVStore_Expiration(t *testing.T) {
+	store := NewMemoryKVStore()
+
+	kvs := KVPairs{"expired": "value"}
+	assert.Nil(t, store.BatchSet(&kvs, -time.Minute))
+	_, _, err := store.Get("expired")
+	assert.Equal(t, ErrKeyNotFound, err)
+
+	set, err := store.SetIfNotExists("expired", "value", time.Minute)
+	assert.Nil(t, err)
+	assert.True(t, set)
+
+	set, err = store.SetIfNotExists("expired", "value", time.Minute)
+	assert.Nil(t, err)
+	assert.False(t, set)
+}
+
+func Test_CopyKvStore(t *testing.T) {
+	from := NewMemoryKVStore()
+	to := NewMemoryKVStore()
+
+	pairs := getKvPairs(2500)
+	assert.Nil(t, from.BatchSet(pairs, time.Hour))
+	neverExpires := KVPairs{"neverExpires": "value"}
+	assert.Nil(t, from.BatchSet(&neverExpires, 0))
+	expired := KVPairs{"expired": "value"}
+	assert.Nil(t, from.BatchSet(&expired, -time.Minute))
+
+	copied, err := CopyKvStore(from, to)
+	assert.Nil(t, err)
+	assert.Equal(t, 2501, copied)
+
+	value, expirationTime, err := to.Get(strconv.Itoa(1234))
+	assert.Nil(t, err)
+	assert.Equal(t, "1234", value)
+	assert.True(t, expirationTime.After(time.Now().Add(59*time.Minute)))
+
+	_, _, err = to.Get("neverExpires")
+	assert.Nil(t, err)
+	_, _, err = to.Get("expired")
+	assert.Equal(t, ErrKeyNotFound, err)
+}
@@ -77,6 +77,55 @@ func Test_KVStoreBatchGet_WithMissingKey(t *testing.T) {
 	assert.Equal(t, "opacity", (*kvs)["key"])
 }
 
+func Test_KVStoreSetIfNotExists(t *testing.T) {
+	InitKvStore()
+	defer CloseKvStore()
+
+	key := "setIfNotExistsKey"
+	BatchDelete(&KVKeys{key})
+
+	set, err := SetIfNotExists(key, "opacity", TestValueTimeToLive)
+	assert.Nil(t, err)
+	assert.True(t, set)
+
+	set, err = SetIfNotExists(key, "opacity2", TestValueTimeToLive)
+	assert.Nil(t, err)
+	assert.False(t, set)
+
+	value, _, err := GetValueFromKV(key)
+	assert.Nil(t, err)
+	assert.Equal(t, "opacity", value)
+}
+
+func Test_KVStoreCompareAndSwap(t *testing.T) {
+	InitKvStore()
+	defer CloseKvStore()
+
+	key := "compareAndSwapKey"
+	BatchDelete(&KVKeys{key})
+
+	swapped, err := CompareAndSwap(key, "", "opacity", TestValueTimeToLive)
+	assert.Nil(t, err)
+	assert.False(t, swapped)
+
+	assert.Nil(t, BatchSet(&KVPairs{key: "opacity"}, TestValueTimeToLive))
+	swapped, err = CompareAndSwap(key, "opacity2", "opacity3", TestValueTimeToLive)
+	assert.Nil(t, err)
+	assert.False(t, swapped)
+
+	swapped, err = CompareAndSwap(key, "opacity", "opacity2", TestValueTimeToLive)
+	assert.Nil(t, err)
+	assert.True(t, swapped)
+
+	swapped, err = CompareAndSwap(key, "opacity2", "opacity2", TestValueTimeToLive)
+	assert.Nil(t, err)
+	assert.True(t, swapped)
+
+	value, _, err := GetValueFromKV(key)
+	assert.Nil(t, err)
+	assert.Equal(t, "opacity2", value)
+}
+
 func Test_KVStore_MassBatchGet(t *testing.T) {
 	InitKvStore()
 	defer CloseKvStore()
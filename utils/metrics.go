@@ -23,8 +23,10 @@ var (
 	}, []string{"response_code"})
 	Metrics_200_Response_Counter = Metrics_Http_Response_Counter.With(prometheus.Labels{"response_code": "200"})
 	Metrics_400_Response_Counter = Metrics_Http_Response_Counter.With(prometheus.Labels{"response_code": "400"})
+	Metrics_401_Response_Counter = Metrics_Http_Response_Counter.With(prometheus.Labels{"response_code": "401"})
 	Metrics_403_Response_Counter = Metrics_Http_Response_Counter.With(prometheus.Labels{"response_code": "403"})
 	Metrics_404_Response_Counter = Metrics_Http_Response_Counter.With(prometheus.Labels{"response_code": "404"})
+	Metrics_429_Response_Counter = Metrics_Http_Response_Counter.With(prometheus.Labels{"response_code": "429"})
 	Metrics_500_Response_Counter = Metrics_Http_Response_Counter.With(prometheus.Labels{"response_code": "500"})
 	Metrics_503_Response_Counter = Metrics_Http_Response_Counter.With(prometheus.Labels{"response_code": "503"})
 
@@ -86,6 +88,67 @@ var (
 		Help: "Totals all the file sizes of rows in completed_files table in SQL, as MB",
 	})
 
+	Metrics_Replayed_Request_Counter = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "storagenode_replayed_request_counter",
+		Help: "Total number of signed requests rejected because they were replayed or outside the timestamp window",
+	})
+
+	Metrics_Rate_Limited_Request_Counter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "storagenode_rate_limited_request_counter",
+		Help: "The total number of requests throttled, by route group and by whether the client IP or the account was over its limit",
+	}, []string{"group", "limited_by"})
+
+	Metrics_Untimestamped_Request_Counter = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "storagenode_untimestamped_request_counter",
+		Help: "Total number of signed requests received without a timestamp",
+	})
+
+	Metrics_Badger_LSM_Size_Bytes = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "storagenode_badger_lsm_size_bytes",
+		Help: "Size of the badger LSM tree on disk, in bytes",
+	})
+
+	Metrics_Badger_Vlog_Size_Bytes = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "storagenode_badger_vlog_size_bytes",
+		Help: "Size of the badger value log on disk, in bytes",
+	})
+
+	Metrics_Badger_Key_Count = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "storagenode_badger_key_count",
+		Help: "Number of keys in the badger LSM tables, counting every version that has not been compacted away",
+	})
+
+	Metrics_Badger_Tables = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "storagenode_badger_tables",
+		Help: "Number of badger LSM tables per level",
+	}, []string{"level"})
+
+	// result is one of rewrite, no_rewrite, or error
+	Metrics_Badger_Value_Log_GC_Counter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "storagenode_badger_value_log_gc_counter",
+		Help: "Total number of badger value log GC attempts, by result",
+	}, []string{"result"})
+
+	Metrics_Badger_Flatten_Counter = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "storagenode_badger_flatten_counter",
+		Help: "Total number of times the badger LSM tree was flattened",
+	})
+
+	Metrics_Pending_Expiration_Extensions = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "storagenode_pending_expiration_extensions",
+		Help: "Number of accounts whose metadata and file expirations are still being extended",
+	})
+
+	Metrics_Expiration_Extension_Items_Counter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "storagenode_expiration_extension_items_counter",
+		Help: "Total number of metadatas and completed files whose expiration was extended, by kind",
+	}, []string{"kind"})
+
+	Metrics_Pending_Key_Rotations = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "storagenode_pending_key_rotations",
+		Help: "Number of accounts whose metadata permission hashes and file modifier hashes are still being re-keyed",
+	})
+
 	// TODO:  use AWS cloudwatch to get these last two metrics
 	// https://docs.aws.amazon.com/sdk-for-go/api/service/cloudwatch/#CloudWatch.GetMetricStatistics
 	//Metrics_Files_Count_S3 = promauto.NewGauge(prometheus.GaugeOpts{
@@ -104,13 +167,17 @@ func createPlanMetrics() {
 	Metrics_Total_Paid_Accounts_Map[TotalLbl] = Metrics_Total_Paid_Accounts.With(prometheus.Labels{"plan_type": TotalLbl})
 	Metrics_Total_Stripe_Paid_Accounts_Map[TotalLbl] = Metrics_Total_Stripe_Paid_Accounts.With(prometheus.Labels{"plan_type": TotalLbl})
 	for _, plan := range Env.Plans {
-		name := plan.Name
-		Metrics_Percent_Of_Space_Used_Map[name] = Metrics_Percent_Of_Space_Used.With(prometheus.Labels{"plan_type": name})
-		Metrics_Total_Paid_Accounts_Map[name] = Metrics_Total_Paid_Accounts.With(prometheus.Labels{"plan_type": name})
-		Metrics_Total_Stripe_Paid_Accounts_Map[name] = Metrics_Total_Stripe_Paid_Accounts.With(prometheus.Labels{"plan_type": name})
+		AddPlanMetrics(plan.Name)
 	}
 }
 
+/*AddPlanMetrics adds the per plan metrics for a plan, which can be added to the plan catalog while running*/
+func AddPlanMetrics(name string) {
+	Metrics_Percent_Of_Space_Used_Map[name] = Metrics_Percent_Of_Space_Used.With(prometheus.Labels{"plan_type": name})
+	Metrics_Total_Paid_Accounts_Map[name] = Metrics_Total_Paid_Accounts.With(prometheus.Labels{"plan_type": name})
+	Metrics_Total_Stripe_Paid_Accounts_Map[name] = Metrics_Total_Stripe_Paid_Accounts.With(prometheus.Labels{"plan_type": name})
+}
+
 func GetMetricCounter(m prometheus.Counter) float64 {
 	pb := &dto.Metric{}
 	m.Write(pb)
@@ -0,0 +1,124 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+const (
+	// the number of months a plan's Cost and CostInUSD pay for
+	monthsPerPricingTerm = 12
+
+	// OPCT invoices are rounded to this many decimals, USD invoices to the cent
+	opctPriceDecimals = 6
+	usdPriceDecimals  = 2
+)
+
+/*SubscriptionDiscounts maps a subscription length in months to the percent taken off subscriptions at least
+that long, e.g. {"24": 10, "36": 15}*/
+type SubscriptionDiscounts map[int]float64
+
+/*SubscriptionTooShortError is returned for subscriptions shorter than their plan allows*/
+type SubscriptionTooShortError struct {
+	Plan      string
+	MinMonths int
+}
+
+func (err SubscriptionTooShortError) Error() string {
+	return fmt.Sprintf("the %s plan must be bought for at least %d months", err.Plan, err.MinMonths)
+}
+
+/*MinMonths returns the shortest subscription the plan can be bought for*/
+func (plan PlanInfo) MinMonths() int {
+	if plan.MinMonthsInSubscription > 0 {
+		return plan.MinMonthsInSubscription
+	}
+	if Env.MinMonthsInSubscription > 0 {
+		return Env.MinMonthsInSubscription
+	}
+	return 1
+}
+
+/*CheckSubscriptionMonths returns a SubscriptionTooShortError if the plan can't be bought for that many months*/
+func CheckSubscriptionMonths(plan PlanInfo, months int) error {
+	if months < plan.MinMonths() {
+		return SubscriptionTooShortError{Plan: plan.Name, MinMonths: plan.MinMonths()}
+	}
+	return nil
+}
+
+/*SubscriptionDiscountPercent returns the percent taken off a subscription of that many months, which is the
+largest discount for a length it reaches*/
+func SubscriptionDiscountPercent(months int) float64 {
+	discount := 0.0
+	for minMonths, percent := range Env.SubscriptionDiscounts {
+		if months >= minMonths && percent > discount {
+			discount = percent
+		}
+	}
+	return math.Min(discount, 100)
+}
+
+/*SubscriptionCost returns the cost in OPCT of buying plan for that many months*/
+func SubscriptionCost(plan PlanInfo, months int) float64 {
+	return subscriptionPrice(plan.Cost, months, opctPriceDecimals)
+}
+
+/*SubscriptionCostInUSD returns the cost in USD of buying plan for that many months*/
+func SubscriptionCostInUSD(plan PlanInfo, months int) float64 {
+	return subscriptionPrice(plan.CostInUSD, months, usdPriceDecimals)
+}
+
+/*subscriptionPrice prorates the price of a pricing term to months, takes off the discount for that length and
+rounds to decimals*/
+func subscriptionPrice(pricePerTerm float64, months int, decimals int) float64 {
+	if months <= 0 || pricePerTerm <= 0 {
+		return 0
+	}
+	price := pricePerTerm * float64(months) / monthsPerPricingTerm
+	price *= 1 - SubscriptionDiscountPercent(months)/100
+	return roundPrice(price, decimals)
+}
+
+func roundPrice(price float64, decimals int) float64 {
+	scale := math.Pow(10, float64(decimals))
+	return math.Round(price*scale) / scale
+}
+
+func parseSubscriptionDiscounts(discountsJSON string) (SubscriptionDiscounts, error) {
+	discounts := make(SubscriptionDiscounts)
+	if discountsJSON == "" {
+		return discounts, nil
+	}
+	if err := json.Unmarshal([]byte(discountsJSON), &discounts); err != nil {
+		return discounts, err
+	}
+	for months, percent := range discounts {
+		if months <= 0 || percent < 0 || percent > 100 {
+			return discounts, fmt.Errorf("discount of %g%% for %d months is not allowed", percent, months)
+		}
+	}
+	return discounts, nil
+}
+
+/*RoundCost rounds an amount of OPCT the way invoices are rounded*/
+func RoundCost(cost float64) float64 {
+	return roundPrice(cost, opctPriceDecimals)
+}
+
+/*OverageOffered returns whether accounts can opt in to storing more than their plan, which they can while
+there is a price for it*/
+func OverageOffered() bool {
+	return Env.OveragePricePerGBMonth > 0 || Env.OveragePricePerGBMonthInUSD > 0
+}
+
+/*OverageCost returns the cost in OPCT of storing that many GB-months over a plan*/
+func OverageCost(gbMonths float64) float64 {
+	return roundPrice(math.Max(gbMonths, 0)*Env.OveragePricePerGBMonth, opctPriceDecimals)
+}
+
+/*OverageCostInUSD returns the cost in USD of storing that many GB-months over a plan*/
+func OverageCostInUSD(gbMonths float64) float64 {
+	return roundPrice(math.Max(gbMonths, 0)*Env.OveragePricePerGBMonthInUSD, usdPriceDecimals)
+}
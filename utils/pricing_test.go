@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Init_Pricing(t *testing.T) {
+	SetTesting("../.env")
+}
+
+func Test_SubscriptionCost(t *testing.T) {
+	defer func() { Env.SubscriptionDiscounts = SubscriptionDiscounts{} }()
+	plan := PlanInfo{Name: "Basic", Cost: 2, CostInUSD: 39.99}
+
+	tests := []struct {
+		name      string
+		discounts SubscriptionDiscounts
+		months    int
+		cost      float64
+		costInUSD float64
+	}{
+		{"no months", nil, 0, 0, 0},
+		{"one month", nil, 1, 0.166667, 3.33},
+		{"six months", nil, 6, 1, 20},
+		{"one year", nil, 12, 2, 39.99},
+		{"eighteen months", nil, 18, 3, 59.99},
+		{"three years", nil, 36, 6, 119.97},
+		{"too short for a discount", SubscriptionDiscounts{24: 10}, 23, 3.833333, 76.65},
+		{"two year discount", SubscriptionDiscounts{24: 10, 36: 15}, 24, 3.6, 71.98},
+		{"largest discount reached", SubscriptionDiscounts{24: 10, 36: 15}, 48, 6.8, 135.97},
+	}
+
+	for _, tt := range tests {
+		Env.SubscriptionDiscounts = tt.discounts
+		assert.Equal(t, tt.cost, SubscriptionCost(plan, tt.months), tt.name)
+		assert.Equal(t, tt.costInUSD, SubscriptionCostInUSD(plan, tt.months), tt.name)
+	}
+}
+
+func Test_CheckSubscriptionMonths(t *testing.T) {
+	minMonths := Env.MinMonthsInSubscription
+	defer func() { Env.MinMonthsInSubscription = minMonths }()
+	Env.MinMonthsInSubscription = 3
+
+	tests := []struct {
+		name      string
+		plan      PlanInfo
+		months    int
+		minMonths int
+	}{
+		{"env minimum met", PlanInfo{Name: "Basic"}, 3, 0},
+		{"env minimum not met", PlanInfo{Name: "Basic"}, 2, 3},
+		{"plan minimum met", PlanInfo{Name: "Business", MinMonthsInSubscription: 12}, 12, 0},
+		{"plan minimum not met", PlanInfo{Name: "Business", MinMonthsInSubscription: 12}, 6, 12},
+	}
+
+	for _, tt := range tests {
+		err := CheckSubscriptionMonths(tt.plan, tt.months)
+		if tt.minMonths == 0 {
+			assert.Nil(t, err, tt.name)
+			continue
+		}
+		assert.Equal(t, SubscriptionTooShortError{Plan: tt.plan.Name, MinMonths: tt.minMonths}, err, tt.name)
+	}
+}
+
+func Test_ParseSubscriptionDiscounts(t *testing.T) {
+	tests := []struct {
+		json      string
+		discounts SubscriptionDiscounts
+		valid     bool
+	}{
+		{"", SubscriptionDiscounts{}, true},
+		{`{"24": 10, "36": 15.5}`, SubscriptionDiscounts{24: 10, 36: 15.5}, true},
+		{`{"24": 110}`, nil, false},
+		{`{"0": 10}`, nil, false},
+		{`{"two years": 10}`, nil, false},
+	}
+
+	for _, tt := range tests {
+		discounts, err := parseSubscriptionDiscounts(tt.json)
+		if !tt.valid {
+			assert.NotNil(t, err, tt.json)
+			continue
+		}
+		assert.Nil(t, err, tt.json)
+		assert.Equal(t, tt.discounts, discounts, tt.json)
+	}
+}
+
+func Test_OverageCost(t *testing.T) {
+	defer func() { Env.OveragePricePerGBMonth, Env.OveragePricePerGBMonthInUSD = 0, 0 }()
+	Env.OveragePricePerGBMonth, Env.OveragePricePerGBMonthInUSD = 0, 0
+	assert.False(t, OverageOffered())
+	assert.Equal(t, 0.0, OverageCost(10))
+
+	Env.OveragePricePerGBMonthInUSD = 0.02
+	assert.True(t, OverageOffered())
+	assert.Equal(t, 0.2, OverageCostInUSD(10))
+	assert.Equal(t, 0.01, OverageCostInUSD(0.333))
+	assert.Equal(t, 0.0, OverageCostInUSD(-1))
+
+	Env.OveragePricePerGBMonth = 0.001
+	assert.Equal(t, 0.000333, OverageCost(0.333))
+}
@@ -0,0 +1,174 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	/*RateLimitBackendMemory keeps rate limit buckets in the memory of each node*/
+	RateLimitBackendMemory = "memory"
+
+	/*RateLimitBackendKV keeps rate limit buckets in the K:V store, so nodes sharing a sql K:V store share limits*/
+	RateLimitBackendKV = "kv"
+
+	/*RateLimitBackendOff turns rate limiting off*/
+	RateLimitBackendOff = "off"
+)
+
+const (
+	rateLimitKeyPrefix = "ratelimit_"
+
+	// a bucket left alone this long has refilled completely, so it can be forgotten
+	rateLimitRefillTime = time.Minute
+
+	// how often a bucket is retried when other requests keep changing it first
+	maxRateLimitSwapAttempts = 5
+)
+
+/*RateLimit is a token bucket that holds up to PerMinute tokens and refills at PerMinute tokens a minute.  Each
+request takes a token.*/
+type RateLimit struct {
+	PerMinute int
+}
+
+/*RateLimiter takes a token from the bucket for key.  It returns whether there was one and, if there wasn't, how
+long until there will be.*/
+type RateLimiter interface {
+	Allow(key string, limit RateLimit) (bool, time.Duration, error)
+}
+
+type tokenBucket struct {
+	tokens    float64
+	updatedAt time.Time
+}
+
+// Singleton limiter, nil when rate limiting is off
+var rateLimiter RateLimiter
+
+/*InitRateLimiter sets up the rate limiter for the configured backend*/
+func InitRateLimiter() {
+	switch Env.RateLimitBackend {
+	case RateLimitBackendOff:
+		rateLimiter = nil
+	case RateLimitBackendKV:
+		rateLimiter = NewKVRateLimiter()
+	default:
+		rateLimiter = NewMemoryRateLimiter()
+	}
+}
+
+/*AllowRequest takes a token from the bucket for key, and always allows the request if rate limiting is off*/
+func AllowRequest(key string, limit RateLimit) (bool, time.Duration, error) {
+	if rateLimiter == nil || limit.PerMinute <= 0 {
+		return true, 0, nil
+	}
+	return rateLimiter.Allow(key, limit)
+}
+
+func newTokenBucket(limit RateLimit, now time.Time) tokenBucket {
+	return tokenBucket{tokens: float64(limit.PerMinute), updatedAt: now}
+}
+
+/*take refills the bucket for the time since it was last updated, then takes a token if there is one*/
+func (b tokenBucket) take(limit RateLimit, now time.Time) (tokenBucket, bool, time.Duration) {
+	capacity := float64(limit.PerMinute)
+	perSecond := capacity / rateLimitRefillTime.Seconds()
+	tokens := math.Min(capacity, b.tokens+math.Max(0, now.Sub(b.updatedAt).Seconds())*perSecond)
+
+	if tokens < 1 {
+		wait := time.Duration((1 - tokens) / perSecond * float64(time.Second))
+		return tokenBucket{tokens: tokens, updatedAt: now}, false, wait
+	}
+	return tokenBucket{tokens: tokens - 1, updatedAt: now}, true, 0
+}
+
+func (b tokenBucket) String() string {
+	return fmt.Sprintf("%g,%d", b.tokens, b.updatedAt.UnixNano())
+}
+
+func parseTokenBucket(value string) (tokenBucket, error) {
+	var tokens float64
+	var updatedAt int64
+	if _, err := fmt.Sscanf(value, "%g,%d", &tokens, &updatedAt); err != nil {
+		return tokenBucket{}, err
+	}
+	return tokenBucket{tokens: tokens, updatedAt: time.Unix(0, updatedAt)}, nil
+}
+
+type memoryRateLimiter struct {
+	mutex      sync.Mutex
+	buckets    map[string]tokenBucket
+	lastPruned time.Time
+}
+
+/*NewMemoryRateLimiter returns a rate limiter that keeps its buckets in memory*/
+func NewMemoryRateLimiter() RateLimiter {
+	return &memoryRateLimiter{buckets: make(map[string]tokenBucket), lastPruned: time.Now()}
+}
+
+func (m *memoryRateLimiter) Allow(key string, limit RateLimit) (bool, time.Duration, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	now := time.Now()
+	bucket, ok := m.buckets[key]
+	if !ok {
+		bucket = newTokenBucket(limit, now)
+	}
+	bucket, allowed, wait := bucket.take(limit, now)
+	m.buckets[key] = bucket
+
+	if now.Sub(m.lastPruned) > rateLimitRefillTime {
+		for k, b := range m.buckets {
+			if now.Sub(b.updatedAt) > rateLimitRefillTime {
+				delete(m.buckets, k)
+			}
+		}
+		m.lastPruned = now
+	}
+	return allowed, wait, nil
+}
+
+type kvRateLimiter struct{}
+
+/*NewKVRateLimiter returns a rate limiter that keeps its buckets in the K:V store*/
+func NewKVRateLimiter() RateLimiter {
+	return kvRateLimiter{}
+}
+
+func (kvRateLimiter) Allow(key string, limit RateLimit) (bool, time.Duration, error) {
+	key = rateLimitKeyPrefix + key
+	for attempt := 0; attempt < maxRateLimitSwapAttempts; attempt++ {
+		now := time.Now()
+		value, _, err := GetValueFromKV(key)
+		if err != nil && err != ErrKeyNotFound {
+			return false, 0, err
+		}
+
+		if err == ErrKeyNotFound {
+			bucket, allowed, wait := newTokenBucket(limit, now).take(limit, now)
+			set, err := SetIfNotExists(key, bucket.String(), rateLimitRefillTime)
+			if err != nil || set {
+				return allowed, wait, err
+			}
+			continue
+		}
+
+		// a bucket that can't be parsed is replaced by a full one
+		bucket, err := parseTokenBucket(value)
+		if err != nil {
+			bucket = newTokenBucket(limit, now)
+		}
+		bucket, allowed, wait := bucket.take(limit, now)
+		swapped, err := CompareAndSwap(key, value, bucket.String(), rateLimitRefillTime)
+		if err != nil || swapped {
+			return allowed, wait, err
+		}
+	}
+
+	// the bucket is too busy to get a token from, which only happens when it is being hammered
+	return false, time.Second, nil
+}
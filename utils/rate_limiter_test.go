@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Init_Rate_Limiter(t *testing.T) {
+	SetTesting("../.env")
+}
+
+func Test_TokenBucket_Take_And_Refill(t *testing.T) {
+	limit := RateLimit{PerMinute: 2}
+	now := time.Now()
+
+	bucket, allowed, _ := newTokenBucket(limit, now).take(limit, now)
+	assert.True(t, allowed)
+	bucket, allowed, _ = bucket.take(limit, now)
+	assert.True(t, allowed)
+	bucket, allowed, wait := bucket.take(limit, now)
+	assert.False(t, allowed)
+	assert.Equal(t, 30*time.Second, wait)
+
+	// a token comes back every 30 seconds, and the bucket never holds more than PerMinute
+	_, allowed, _ = bucket.take(limit, now.Add(30*time.Second))
+	assert.True(t, allowed)
+	bucket, _, _ = bucket.take(limit, now.Add(time.Hour))
+	assert.Equal(t, float64(1), bucket.tokens)
+}
+
+func Test_TokenBucket_String_Round_Trips(t *testing.T) {
+	bucket := tokenBucket{tokens: 1.5, updatedAt: time.Unix(0, time.Now().UnixNano())}
+	parsed, err := parseTokenBucket(bucket.String())
+	assert.Nil(t, err)
+	assert.Equal(t, bucket.tokens, parsed.tokens)
+	assert.True(t, bucket.updatedAt.Equal(parsed.updatedAt))
+
+	_, err = parseTokenBucket("not a bucket")
+	assert.NotNil(t, err)
+}
+
+func Test_RateLimiters_Allow(t *testing.T) {
+	InitKvStore()
+	defer CloseKvStore()
+
+	limit := RateLimit{PerMinute: 3}
+	for name, limiter := range map[string]RateLimiter{
+		RateLimitBackendMemory: NewMemoryRateLimiter(),
+		RateLimitBackendKV:     NewKVRateLimiter(),
+	} {
+		key := RandSeqFromRunes(16, []rune("abcdef01234567890"))
+		for i := 0; i < limit.PerMinute; i++ {
+			allowed, _, err := limiter.Allow(key, limit)
+			assert.Nil(t, err, name)
+			assert.True(t, allowed, name)
+		}
+		allowed, wait, err := limiter.Allow(key, limit)
+		assert.Nil(t, err, name)
+		assert.False(t, allowed, name)
+		assert.True(t, wait > 0, name)
+
+		// other keys have their own buckets
+		allowed, _, err = limiter.Allow(key+"other", limit)
+		assert.Nil(t, err, name)
+		assert.True(t, allowed, name)
+	}
+}
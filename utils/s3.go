@@ -3,6 +3,8 @@ package utils
 import (
 	"bytes"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"strings"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -132,6 +134,27 @@ func setObject(bucketName string, objectKey string, data string) error {
 	return err
 }
 
+func setObjectFromReader(bucketName string, objectKey string, body io.ReadSeeker) error {
+	cachedData.Remove(getKey(bucketName, objectKey))
+
+	input := &s3.PutObjectInput{
+		Body:   aws.ReadSeekCloser(body),
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(objectKey),
+	}
+
+	return svc.PutObject(input)
+}
+
+func getObjectReader(bucketName string, objectKey string) (io.ReadCloser, error) {
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(objectKey),
+	}
+
+	return svc.GetObjectReader(input)
+}
+
 func deleteObject(bucketName string, objectKey string) error {
 	cachedData.Remove(getKey(bucketName, objectKey))
 
@@ -288,6 +311,16 @@ func SetDefaultBucketObject(objectKey string, data string) error {
 	return setObject(Env.BucketName, objectKey, data)
 }
 
+// Set Object operation on defaultBucketName, streaming the body instead of holding it in memory
+func SetDefaultBucketObjectFromReader(objectKey string, body io.ReadSeeker) error {
+	return setObjectFromReader(Env.BucketName, objectKey, body)
+}
+
+// Get Object operation on defaultBucketName, caller must close the reader
+func GetDefaultBucketObjectReader(objectKey string) (io.ReadCloser, error) {
+	return getObjectReader(Env.BucketName, objectKey)
+}
+
 // Delete Object operation on defaultBucketName with particular prefix
 func DeleteDefaultBucketObject(objectKey string) error {
 	return deleteObject(Env.BucketName, objectKey)
@@ -403,6 +436,18 @@ func (svc *s3Wrapper) GetObjectAsString(input *s3.GetObjectInput) (string, error
 	return buf.String(), nil
 }
 
+func (svc *s3Wrapper) GetObjectReader(input *s3.GetObjectInput) (io.ReadCloser, error) {
+	if svc.s3 == nil {
+		return ioutil.NopCloser(strings.NewReader("")), nil
+	}
+
+	output, err := svc.s3.GetObject(input)
+	if err != nil {
+		return nil, err
+	}
+	return output.Body, nil
+}
+
 func (svc *s3Wrapper) ListObjectPages(input *s3.ListObjectsV2Input, it ObjectIterator) error {
 	if svc.s3 == nil {
 		it(nil)
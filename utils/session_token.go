@@ -0,0 +1,125 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+/*ErrInvalidSessionToken is returned for session tokens that are malformed or were not issued by this node*/
+var ErrInvalidSessionToken = errors.New("invalid session token")
+
+/*ErrExpiredSessionToken is returned for session tokens past their expiration*/
+var ErrExpiredSessionToken = errors.New("session token has expired")
+
+const sessionTokenIDLengthInBytes = 16
+
+/*SessionClaims is what a session token vouches for*/
+type SessionClaims struct {
+	TokenID   string   `json:"jti"`
+	AccountID string   `json:"accountID"`
+	PublicKey string   `json:"publicKey"`
+	Scopes    []string `json:"scopes,omitempty"`
+	// when the signed login that started the session happened, refreshing a token keeps it
+	LoginAt   int64 `json:"loginAt"`
+	IssuedAt  int64 `json:"iat"`
+	ExpiresAt int64 `json:"exp"`
+}
+
+/*ExpirationTime returns when the token expires*/
+func (claims SessionClaims) ExpirationTime() time.Time {
+	return time.Unix(claims.ExpiresAt, 0)
+}
+
+/*HasScope returns whether the token allows scope.  A token without scopes allows everything.*/
+func (claims SessionClaims) HasScope(scope string) bool {
+	if len(claims.Scopes) == 0 {
+		return true
+	}
+	for _, s := range claims.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+/*NewSessionClaims returns the claims for a new session token that expires after Env.SessionTokenTTLInSeconds.
+Pass a zero loginAt to start a new session.*/
+func NewSessionClaims(accountID string, publicKey string, scopes []string, loginAt time.Time) (SessionClaims, error) {
+	tokenID := make([]byte, sessionTokenIDLengthInBytes)
+	if _, err := rand.Read(tokenID); err != nil {
+		return SessionClaims{}, err
+	}
+	now := time.Now()
+	if loginAt.IsZero() {
+		loginAt = now
+	}
+	return SessionClaims{
+		TokenID:   hex.EncodeToString(tokenID),
+		AccountID: accountID,
+		PublicKey: publicKey,
+		Scopes:    scopes,
+		LoginAt:   loginAt.Unix(),
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(time.Duration(Env.SessionTokenTTLInSeconds) * time.Second).Unix(),
+	}, nil
+}
+
+/*CreateSessionToken encodes and signs claims.  The token is the base64url claims JSON and the base64url
+HMAC-SHA256 of it, joined by a dot, so verifying it takes no database or K:V lookups.*/
+func CreateSessionToken(claims SessionClaims) (string, error) {
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(claimsJSON)
+	return payload + "." + base64.RawURLEncoding.EncodeToString(signSessionPayload(payload)), nil
+}
+
+/*ParseSessionToken checks a token's signature and expiration and returns its claims*/
+func ParseSessionToken(token string) (SessionClaims, error) {
+	claims := SessionClaims{}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return claims, ErrInvalidSessionToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || !hmac.Equal(signature, signSessionPayload(parts[0])) {
+		return claims, ErrInvalidSessionToken
+	}
+
+	claimsJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return claims, ErrInvalidSessionToken
+	}
+	if err := json.Unmarshal(claimsJSON, &claims); err != nil {
+		return claims, ErrInvalidSessionToken
+	}
+
+	if !time.Now().Before(claims.ExpirationTime()) {
+		return claims, ErrExpiredSessionToken
+	}
+	return claims, nil
+}
+
+func signSessionPayload(payload string) []byte {
+	mac := hmac.New(sha256.New, sessionTokenSecret())
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
+
+// falls back to a key derived from the encryption key, so nodes don't need another secret to issue tokens
+func sessionTokenSecret() []byte {
+	if Env.SessionTokenSecret != "" {
+		return []byte(Env.SessionTokenSecret)
+	}
+	return Hash([]byte("session-token"), []byte(Env.EncryptionKey))
+}
@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Init_Session_Token(t *testing.T) {
+	SetTesting("../.env")
+}
+
+func Test_SessionToken_Round_Trip(t *testing.T) {
+	claims, err := NewSessionClaims("accountID", "publicKey", []string{"metadata:read"}, time.Time{})
+	assert.Nil(t, err)
+	assert.Equal(t, claims.IssuedAt, claims.LoginAt)
+	assert.Equal(t, claims.IssuedAt+int64(Env.SessionTokenTTLInSeconds), claims.ExpiresAt)
+
+	token, err := CreateSessionToken(claims)
+	assert.Nil(t, err)
+
+	parsed, err := ParseSessionToken(token)
+	assert.Nil(t, err)
+	assert.Equal(t, claims, parsed)
+}
+
+func Test_SessionToken_Keeps_LoginAt(t *testing.T) {
+	loginAt := time.Now().Add(-time.Hour)
+	claims, err := NewSessionClaims("accountID", "publicKey", nil, loginAt)
+	assert.Nil(t, err)
+	assert.Equal(t, loginAt.Unix(), claims.LoginAt)
+	assert.True(t, claims.IssuedAt > claims.LoginAt)
+}
+
+func Test_SessionToken_Rejects_Tampering(t *testing.T) {
+	claims, err := NewSessionClaims("accountID", "publicKey", []string{"metadata:read"}, time.Time{})
+	assert.Nil(t, err)
+	token, err := CreateSessionToken(claims)
+	assert.Nil(t, err)
+
+	claims.Scopes = nil
+	otherToken, err := CreateSessionToken(claims)
+	assert.Nil(t, err)
+	// the widened claims with the original signature
+	tampered := strings.Split(otherToken, ".")[0] + "." + strings.Split(token, ".")[1]
+
+	_, err = ParseSessionToken(tampered)
+	assert.Equal(t, ErrInvalidSessionToken, err)
+
+	_, err = ParseSessionToken("not a token")
+	assert.Equal(t, ErrInvalidSessionToken, err)
+
+	secret := Env.SessionTokenSecret
+	Env.SessionTokenSecret = "some other node's secret"
+	defer func() { Env.SessionTokenSecret = secret }()
+	_, err = ParseSessionToken(token)
+	assert.Equal(t, ErrInvalidSessionToken, err)
+}
+
+func Test_SessionToken_Expires(t *testing.T) {
+	claims, err := NewSessionClaims("accountID", "publicKey", nil, time.Time{})
+	assert.Nil(t, err)
+	claims.ExpiresAt = time.Now().Add(-time.Second).Unix()
+
+	token, err := CreateSessionToken(claims)
+	assert.Nil(t, err)
+
+	_, err = ParseSessionToken(token)
+	assert.Equal(t, ErrExpiredSessionToken, err)
+}
+
+func Test_SessionClaims_HasScope(t *testing.T) {
+	assert.True(t, SessionClaims{}.HasScope("files:delete"))
+
+	claims := SessionClaims{Scopes: []string{"metadata:read", "files:download"}}
+	assert.True(t, claims.HasScope("files:download"))
+	assert.False(t, claims.HasScope("files:delete"))
+}